@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"custodian-killer/aws"
+	"custodian-killer/reports"
+	"custodian-killer/storage"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Account is an AWS account policies can run in, reached by assuming a role
+type Account struct {
+	ID         string            `yaml:"id" json:"id"`
+	Name       string            `yaml:"name" json:"name"`
+	RoleARN    string            `yaml:"role_arn,omitempty" json:"role_arn,omitempty"` // empty uses the current credentials as they are
+	ExternalID string            `yaml:"external_id,omitempty" json:"external_id,omitempty"`
+	Regions    []string          `yaml:"regions,omitempty" json:"regions,omitempty"` // used when --regions isn't given
+	Tags       map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// accountsFile is the layout of accounts.yaml
+type accountsFile struct {
+	Accounts []Account `yaml:"accounts"`
+}
+
+var (
+	accountID = regexp.MustCompile(`^[0-9]{12}$`)
+	roleARN   = regexp.MustCompile(`^arn:aws[a-z-]*:iam::([0-9]{12}):role/.+$`)
+)
+
+// accountsFilePath is --accounts-file, or accounts.yaml next to the policies
+func accountsFilePath(policyStorage storage.PolicyStorage) (string, error) {
+	if accountsFileFlag != "" {
+		return accountsFileFlag, nil
+	}
+	fileStorage, ok := policyStorage.(*storage.FileStorage)
+	if !ok {
+		return "", fmt.Errorf("accounts need file storage or --accounts-file")
+	}
+	return filepath.Join(fileStorage.BaseDir(), "accounts.yaml"), nil
+}
+
+// LoadAccounts reads and checks an accounts file
+func LoadAccounts(filename string) ([]Account, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounts file: %v", err)
+	}
+
+	var file accountsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse accounts file %s: %v", filename, err)
+	}
+	if len(file.Accounts) == 0 {
+		return nil, fmt.Errorf("no accounts found in %s (expected a top-level 'accounts:' list)", filename)
+	}
+
+	ids := make(map[string]bool)
+	names := make(map[string]bool)
+	for i, account := range file.Accounts {
+		field := fmt.Sprintf("%s: accounts[%d]", filename, i)
+		if !accountID.MatchString(account.ID) {
+			return nil, fmt.Errorf("%s: id '%s' is not a 12-digit account ID", field, account.ID)
+		}
+		if account.Name == "" {
+			return nil, fmt.Errorf("%s: account %s has no name", field, account.ID)
+		}
+		if ids[account.ID] || names[account.Name] {
+			return nil, fmt.Errorf("%s: account %s (%s) is listed twice", field, account.Name, account.ID)
+		}
+		ids[account.ID], names[account.Name] = true, true
+
+		if account.RoleARN != "" {
+			match := roleARN.FindStringSubmatch(account.RoleARN)
+			if match == nil {
+				return nil, fmt.Errorf("%s: role_arn '%s' is not an IAM role ARN", field, account.RoleARN)
+			}
+			if match[1] != account.ID {
+				return nil, fmt.Errorf("%s: role_arn is in account %s, not %s", field, match[1], account.ID)
+			}
+		}
+		for _, region := range account.Regions {
+			if !aws.IsRegionName(region) {
+				return nil, fmt.Errorf("%s: '%s' is not an AWS region name", field, region)
+			}
+		}
+	}
+	return file.Accounts, nil
+}
+
+// SelectAccounts picks the accounts matching any of the selectors. A selector
+// is a glob on the account name or ID such as prod-*, or a tag as key=value.
+func SelectAccounts(accounts []Account, selectors []string) ([]Account, error) {
+	var selected []Account
+	for _, account := range accounts {
+		for _, selector := range selectors {
+			if account.matches(selector) {
+				selected = append(selected, account)
+				break
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no accounts match %s", strings.Join(selectors, ", "))
+	}
+	return selected, nil
+}
+
+func (a Account) matches(selector string) bool {
+	if key, value, isTag := strings.Cut(selector, "="); isTag {
+		tag, ok := a.Tags[key]
+		return ok && tag == value
+	}
+	for _, candidate := range []string{a.Name, a.ID} {
+		if matched, _ := path.Match(selector, candidate); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// String names the account for output, e.g. prod-web (111122223333)
+func (a Account) String() string {
+	return fmt.Sprintf("%s (%s)", a.Name, a.ID)
+}
+
+// accountsFlag reads --accounts and loads the accounts it selects. It returns
+// nil when the flag isn't set.
+func accountsFlag(cmd *cobra.Command) []Account {
+	spec, _ := cmd.Flags().GetString("accounts")
+	if spec == "" {
+		return nil
+	}
+	if replayDir != "" && recordDir != "" {
+		fmt.Println("❌ --record and --replay can't be used together")
+		os.Exit(1)
+	}
+
+	filename, err := accountsFilePath(policyStorage)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	accounts, err := LoadAccounts(filename)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	var selectors []string
+	for _, selector := range strings.Split(spec, ",") {
+		if selector = strings.TrimSpace(selector); selector != "" {
+			selectors = append(selectors, selector)
+		}
+	}
+	selected, err := SelectAccounts(accounts, selectors)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	return selected
+}
+
+// initializeAccountClient connects to an account through its role and checks
+// that the credentials really are for that account
+func initializeAccountClient(ctx context.Context, account Account, forceDryRun bool) (*aws.CustodianClient, error) {
+	client, err := newAWSClient(forceDryRun, &account)
+	if err != nil {
+		return nil, err
+	}
+
+	caller, err := client.GetCallerIdentity(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to confirm the account: %v", err)
+	}
+	if caller.Account != account.ID {
+		client.Close()
+		return nil, fmt.Errorf("credentials are for account %s, not %s", caller.Account, account.ID)
+	}
+
+	client.Account = account.ID
+	return client, nil
+}
+
+// accountRegions is where to run in an account: --regions when given,
+// otherwise the account's own list. Nil means the client's default region.
+func accountRegions(ctx context.Context, client *aws.CustodianClient, account Account, regionsSpec string) ([]string, error) {
+	if regionsSpec != "" {
+		return resolveRegions(ctx, client, regionsSpec)
+	}
+	return account.Regions, nil
+}
+
+// forEachAccount connects to each account in turn and calls fn with a client
+// for it and the regions to cover. Accounts that can't be reached are noted
+// in the report, when there is one, and left out.
+func forEachAccount(
+	ctx context.Context,
+	accounts []Account,
+	regionsSpec string,
+	forceDryRun bool,
+	report *reports.OrgReport,
+	fn func(account Account, client *aws.CustodianClient, regions []string),
+) {
+	for _, account := range accounts {
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Printf("\n🏢 Account %s\n", account)
+
+		client, err := initializeAccountClient(ctx, account, forceDryRun)
+		var regions []string
+		if err == nil {
+			regions, err = accountRegions(ctx, client, account, regionsSpec)
+			if err != nil {
+				client.Close()
+			}
+		}
+		if err != nil {
+			fmt.Printf("❌ Skipping account %s: %v\n", account, err)
+			if report != nil {
+				report.AddAccount(account.ID, account.Name, nil, err)
+			}
+			continue
+		}
+
+		if report != nil {
+			report.AddAccount(account.ID, account.Name, regionsOrDefault(client, regions), nil)
+		}
+		fn(account, client, regions)
+		client.Close()
+	}
+}
+
+// regionsOrDefault is the regions a run covers, which is the client's own
+// region when none were chosen
+func regionsOrDefault(client *aws.CustodianClient, regions []string) []string {
+	if len(regions) == 0 {
+		return []string{client.Region}
+	}
+	return regions
+}
+
+// runAccountsScan scans one policy, or every active policy, in each account
+// and rolls the results up into an organization report
+func runAccountsScan(ctx context.Context, accounts []Account, policyName string, regionsSpec string, outputFormat string) {
+	jsonOutput := outputFormat == "json"
+	policies := scanPolicies(policyName)
+	if len(policies) == 0 {
+		return
+	}
+	fmt.Printf("🏢 Scanning %d policies in %d accounts\n", len(policies), len(accounts))
+
+	report := reports.NewOrgReport("scan")
+	forEachAccount(ctx, accounts, regionsSpec, true, report, func(account Account, client *aws.CustodianClient, regions []string) {
+		if !jsonOutput {
+			printRegionSkips(policies, regionsOrDefault(client, regions))
+		}
+
+		scans := scanRegions(ctx, client, policies, regions)
+		for _, scan := range scans {
+			run := reports.OrgPolicyRun{
+				Account:     account.ID,
+				AccountName: account.Name,
+				Region:      scan.Region,
+				PolicyName:  scan.Policy,
+			}
+			if scan.Err != nil {
+				run.Error = scan.Err.Error()
+				if !jsonOutput {
+					fmt.Printf("❌ Failed to scan %s in %s: %v\n", scan.Policy, scan.Region, scan.Err)
+				}
+			} else {
+				run.ResourceType = scan.Result.ResourceType
+				run.Success = len(scan.Result.Errors) == 0
+				run.ResourcesFound = scan.Result.Summary.TotalScanned
+				run.ResourcesMatched = scan.Result.Summary.MatchedResources
+				run.EstimatedMonthlySavings = scan.Result.Summary.CostSavings
+				if !run.Success {
+					run.Error = scan.Result.Errors[len(scan.Result.Errors)-1]
+				}
+				if !jsonOutput {
+					displayScanResult(scan.Result)
+				}
+			}
+			report.Add(run)
+		}
+		if !jsonOutput {
+			printRegionalScanSummary(scans)
+		}
+	})
+	exitIfInterrupted(ctx)
+
+	finishOrgReport(report, jsonOutput)
+}
+
+// runAccountExecutions runs policies in each account, one account at a time,
+// and rolls the results up into an organization report
+func runAccountExecutions(cmd *cobra.Command, accounts []Account, policyNames []string, force bool) {
+	ctx := cmd.Context()
+	regionsSpec, _ := cmd.Flags().GetString("regions")
+	fmt.Printf("🏢 Executing %d policies in %d accounts\n", len(policyNames), len(accounts))
+
+	report := reports.NewOrgReport("execution")
+	forEachAccount(ctx, accounts, regionsSpec, false, report, func(account Account, client *aws.CustodianClient, regions []string) {
+		executor := newCommandExecutor(cmd, client, force)
+		for _, result := range executor.ExecutePoliciesInRegions(ctx, policyNames, regions) {
+			run := reports.OrgPolicyRun{
+				Account:                 account.ID,
+				AccountName:             account.Name,
+				Region:                  result.Region,
+				PolicyName:              result.PolicyName,
+				ResourceType:            result.ResourceType,
+				Success:                 result.Success && result.Stopped == "",
+				ResourcesFound:          result.ResourcesFound,
+				ResourcesMatched:        result.ResourcesMatched,
+				SuccessfulActions:       result.Summary.SuccessfulActions,
+				FailedActions:           result.Summary.FailedActions,
+				EstimatedMonthlySavings: result.Summary.EstimatedMonthlySavings,
+			}
+			if result.Stopped != "" {
+				run.Error = result.Stopped
+			} else if !result.Success && len(result.Errors) > 0 {
+				run.Error = result.Errors[len(result.Errors)-1]
+			}
+			report.Add(run)
+		}
+	})
+
+	finishOrgReport(report, false)
+}
+
+// finishOrgReport prints or saves an organization report, then exits with an
+// error if any account or run failed
+func finishOrgReport(report *reports.OrgReport, jsonOutput bool) {
+	report.Sort()
+	if jsonOutput {
+		printJSON(report)
+	} else {
+		printOrgSummary(report)
+		saveOrgReport(report)
+	}
+
+	if report.Summary.AccountsFailed > 0 || report.Summary.RunsFailed > 0 {
+		fmt.Printf("❌ %d of %d accounts couldn't be reached, %d of %d runs failed\n",
+			report.Summary.AccountsFailed, report.Summary.Accounts, report.Summary.RunsFailed, report.Summary.Runs)
+		os.Exit(1)
+	}
+}
+
+// printOrgSummary lists each account's totals
+func printOrgSummary(report *reports.OrgReport) {
+	fmt.Println("\n🏢 Organization Summary:")
+	fmt.Println("═══════════════════════════════════════")
+
+	for _, account := range report.Accounts {
+		name := fmt.Sprintf("%s (%s)", account.Name, account.ID)
+		if account.Error != "" {
+			fmt.Printf("   ❌ %-40s unreachable: %s\n", name, account.Error)
+			continue
+		}
+		status := "✅"
+		if account.Totals.RunsFailed > 0 {
+			status = "⚠️ "
+		}
+		fmt.Printf("   %s %-40s %d runs, %d/%d matched", status, name,
+			account.Totals.Runs, account.Totals.ResourcesMatched, account.Totals.ResourcesFound)
+		if report.Kind == "execution" {
+			fmt.Printf(", %d actions ok, %d failed", account.Totals.SuccessfulActions, account.Totals.FailedActions)
+		}
+		fmt.Printf(", $%.2f/month\n", account.Totals.EstimatedMonthlySavings)
+	}
+
+	totals := report.Summary
+	fmt.Printf("🎯 %d matched resources in %d runs across %d accounts", totals.ResourcesMatched, totals.Runs, totals.Accounts)
+	if report.Kind == "execution" {
+		fmt.Printf(", %d actions ok, %d failed", totals.SuccessfulActions, totals.FailedActions)
+	}
+	fmt.Printf("\n💰 Estimated savings: $%.2f/month\n", totals.EstimatedMonthlySavings)
+}
+
+// saveOrgReport writes an organization report to ./reports as JSON and CSV
+func saveOrgReport(report *reports.OrgReport) {
+	timestamp := report.GeneratedAt.Format("2006-01-02_15-04-05")
+	base := fmt.Sprintf("org_%s_%s", report.Kind, timestamp)
+
+	jsonGen := reports.NewJSONReportGenerator("./reports")
+	if err := jsonGen.SaveJSONReport(report, base+".json"); err != nil {
+		fmt.Printf("❌ Failed to save organization report: %v\n", err)
+	}
+	csvGen := reports.NewCSVReportGenerator("./reports")
+	if err := csvGen.GenerateOrgReport(report, base+".csv"); err != nil {
+		fmt.Printf("❌ Failed to save organization report: %v\n", err)
+	}
+}
+
+// accountResources gathers a report's resources from every account, each
+// across its regions
+func accountResources(cmd *cobra.Command, accounts []Account, regionsSpec string) ([]aws.EC2Instance, []aws.S3Bucket) {
+	var ec2Instances []aws.EC2Instance
+	var s3Buckets []aws.S3Bucket
+
+	forEachAccount(cmd.Context(), accounts, regionsSpec, true, nil, func(account Account, client *aws.CustodianClient, regions []string) {
+		if len(regions) > 0 {
+			fmt.Printf("🌍 Gathering resources from %d regions: %s\n", len(regions), strings.Join(regions, ", "))
+		}
+		instances, buckets := gatherRegions(cmd.Context(), client, regions)
+		ec2Instances = append(ec2Instances, instances...)
+		s3Buckets = append(s3Buckets, buckets...)
+	})
+	exitIfInterrupted(cmd.Context())
+	return ec2Instances, s3Buckets
+}
+
+// lookupAccount finds an account in the accounts file by ID
+func lookupAccount(id string) (Account, error) {
+	filename, err := accountsFilePath(policyStorage)
+	if err != nil {
+		return Account{}, err
+	}
+	accounts, err := LoadAccounts(filename)
+	if err != nil {
+		return Account{}, err
+	}
+	for _, account := range accounts {
+		if account.ID == id {
+			return account, nil
+		}
+	}
+	return Account{}, fmt.Errorf("account %s isn't in %s", id, filename)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"custodian-killer/scanner"
+	"custodian-killer/storage"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ApprovalConfig is who may approve high-impact plans and how many of them
+// must, kept in approvals.json next to the policies
+type ApprovalConfig struct {
+	Required  int               `json:"required"`           // 0 turns the gate off
+	Accounts  []string          `json:"accounts,omitempty"` // only gate these accounts; empty gates all
+	Approvers []TrustedApprover `json:"approvers"`
+}
+
+// TrustedApprover is a named approver and the key their approvals verify with
+type TrustedApprover struct {
+	Name      string    `json:"name"`
+	PublicKey string    `json:"public_key"` // base64 ed25519
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// PlanApproval is one approver's signature over a plan
+type PlanApproval struct {
+	Approver  string    `json:"approver"`
+	SignedAt  time.Time `json:"signed_at"`
+	Signature string    `json:"signature"` // base64 ed25519 over approvalMessage
+}
+
+// approvalConfigPath is where the approval config lives for the given storage
+func approvalConfigPath(policyStorage storage.PolicyStorage) (string, error) {
+	fileStorage, ok := policyStorage.(*storage.FileStorage)
+	if !ok {
+		return "", fmt.Errorf("approvals need file storage")
+	}
+	return filepath.Join(fileStorage.BaseDir(), "approvals.json"), nil
+}
+
+// LoadApprovalConfig reads the approval config; a missing file means no gate
+func LoadApprovalConfig(path string) (*ApprovalConfig, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ApprovalConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval config: %v", err)
+	}
+
+	var config ApprovalConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse approval config %s: %v", path, err)
+	}
+	return &config, nil
+}
+
+// Save writes the approval config
+func (c *ApprovalConfig) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode approval config: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write approval config: %v", err)
+	}
+	return nil
+}
+
+// Approver returns the trusted approver with the given name
+func (c *ApprovalConfig) Approver(name string) *TrustedApprover {
+	for i := range c.Approvers {
+		if c.Approvers[i].Name == name {
+			return &c.Approvers[i]
+		}
+	}
+	return nil
+}
+
+// AppliesTo reports whether the account needs approvals. An unknown account
+// is gated whenever any account is.
+func (c *ApprovalConfig) AppliesTo(account string) bool {
+	if c.Required <= 0 {
+		return false
+	}
+	return len(c.Accounts) == 0 || account == "" || containsString(c.Accounts, account)
+}
+
+// highImpactActions lists the distinct high-impact action types in a plan.
+// Impact is worked out from each action again, not read from the plan file.
+func highImpactActions(plan *ExecutionPlan) []string {
+	seen := make(map[string]bool)
+	var actions []string
+	for _, resource := range plan.Scan.MatchedResources {
+		for _, action := range resource.Actions {
+			stored := storage.StoredAction{Type: action.Type, Settings: action.Settings}
+			if scanner.PlanAction(stored, resource.ID).Impact == "high" && !seen[action.Type] {
+				seen[action.Type] = true
+				actions = append(actions, action.Type)
+			}
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// policyHighImpactActions lists the distinct high-impact action types a policy runs
+func policyHighImpactActions(policy *storage.StoredPolicy) []string {
+	seen := make(map[string]bool)
+	var actions []string
+	for _, action := range policy.Actions {
+		if scanner.PlanAction(action, "").Impact == "high" && !seen[action.Type] {
+			seen[action.Type] = true
+			actions = append(actions, action.Type)
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// planDigest fingerprints everything in a plan except its approvals, so an
+// approval covers the exact resources and actions it was given for
+func planDigest(plan *ExecutionPlan) (string, error) {
+	unsigned := *plan
+	unsigned.Approvals = nil
+
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode plan: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// approvalMessage is what an approver signs
+func approvalMessage(digest, approver string, signedAt time.Time) []byte {
+	return []byte(strings.Join([]string{
+		"custodian-killer plan approval",
+		digest,
+		approver,
+		signedAt.UTC().Format(time.RFC3339),
+	}, "\n"))
+}
+
+// GenerateApproverKey creates an ed25519 key pair and writes the private key
+// to path, readable only by the owner. It returns the base64 public key.
+func GenerateApproverKey(path string) (string, error) {
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("key %s already exists", path)
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate key: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create keys directory: %v", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(privateKey.Seed())
+	if err := os.WriteFile(path, []byte(encoded+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write key: %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+// loadApproverKey reads a private key written by GenerateApproverKey
+func loadApproverKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %v", err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s is not an approver key", path)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// parsePublicKey decodes a base64 ed25519 public key
+func parsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("not a base64 ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// SignPlan adds the approver's signature to the plan, replacing an earlier
+// approval by the same name
+func SignPlan(plan *ExecutionPlan, approver string, key ed25519.PrivateKey) error {
+	digest, err := planDigest(plan)
+	if err != nil {
+		return err
+	}
+
+	signedAt := time.Now().UTC().Truncate(time.Second)
+	approval := PlanApproval{
+		Approver:  approver,
+		SignedAt:  signedAt,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, approvalMessage(digest, approver, signedAt))),
+	}
+
+	var kept []PlanApproval
+	for _, existing := range plan.Approvals {
+		if existing.Approver != approver {
+			kept = append(kept, existing)
+		}
+	}
+	plan.Approvals = append(kept, approval)
+	return nil
+}
+
+// VerifyApprovals checks a plan's approvals against the trusted approvers. It
+// returns the distinct approvers whose signatures hold, and why the others don't.
+func VerifyApprovals(plan *ExecutionPlan, config *ApprovalConfig) ([]string, []string) {
+	digest, err := planDigest(plan)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+
+	var valid, problems []string
+	seenNames := make(map[string]bool)
+	seenKeys := make(map[string]string)
+
+	for _, approval := range plan.Approvals {
+		trusted := config.Approver(approval.Approver)
+		if trusted == nil {
+			problems = append(problems, fmt.Sprintf("%s is not a trusted approver", approval.Approver))
+			continue
+		}
+		publicKey, err := parsePublicKey(trusted.PublicKey)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s's trusted key is invalid: %v", approval.Approver, err))
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(approval.Signature)
+		if err != nil || !ed25519.Verify(publicKey, approvalMessage(digest, approval.Approver, approval.SignedAt), signature) {
+			problems = append(problems, fmt.Sprintf("%s's signature doesn't match this plan", approval.Approver))
+			continue
+		}
+		if seenNames[approval.Approver] {
+			problems = append(problems, fmt.Sprintf("%s approved more than once", approval.Approver))
+			continue
+		}
+		if other, shared := seenKeys[trusted.PublicKey]; shared {
+			problems = append(problems, fmt.Sprintf("%s shares a key with %s", approval.Approver, other))
+			continue
+		}
+
+		seenNames[approval.Approver] = true
+		seenKeys[trusted.PublicKey] = approval.Approver
+		valid = append(valid, approval.Approver)
+	}
+
+	return valid, problems
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"custodian-killer/scanner"
+	"custodian-killer/storage"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPlan builds a plan that terminates two instances
+func testPlan() *ExecutionPlan {
+	policy := &storage.StoredPolicy{
+		ResourceType: "ec2",
+		Actions:      []storage.StoredAction{{Type: "terminate"}},
+	}
+	var resources []scanner.MatchedResource
+	for _, id := range []string{"i-0aaa", "i-0bbb"} {
+		resources = append(resources, scanner.MatchedResource{
+			ID:    id,
+			Type:  "ec2",
+			State: "running",
+			Tags:  map[string]string{"Env": "dev"},
+			Properties: map[string]interface{}{
+				"instance_type": "t3.micro",
+				"cpu_percent":   1.5,
+				"volumes":       []interface{}{"vol-1", "vol-2"},
+			},
+			Actions: []scanner.PlannedAction{scanner.PlanAction(policy.Actions[0], id)},
+		})
+	}
+
+	return &ExecutionPlan{
+		FormatVersion: planFormatVersion,
+		CreatedAt:     time.Date(2025, 1, 2, 3, 4, 5, 678, time.UTC),
+		Region:        "us-east-1",
+		Account:       "123456789012",
+		PolicyName:    "kill-dev",
+		PolicyVersion: 3,
+		PolicyHash:    PolicyHash(policy),
+		Scan: &scanner.ScanResult{
+			PolicyName:       "kill-dev",
+			ResourceType:     "ec2",
+			MatchedResources: resources,
+		},
+	}
+}
+
+// testApprover returns a trusted approver and their private key
+func testApprover(t *testing.T, name string) (TrustedApprover, ed25519.PrivateKey) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return TrustedApprover{Name: name, PublicKey: base64.StdEncoding.EncodeToString(publicKey)}, privateKey
+}
+
+func TestPlanDigest(t *testing.T) {
+	base, err := planDigest(testPlan())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		edit     func(plan *ExecutionPlan)
+		wantSame bool
+	}{
+		{name: "unchanged", edit: func(plan *ExecutionPlan) {}, wantSame: true},
+		{
+			name: "approvals are left out",
+			edit: func(plan *ExecutionPlan) {
+				plan.Approvals = []PlanApproval{{Approver: "alice", Signature: "x"}}
+			},
+			wantSame: true,
+		},
+		{name: "resource ID", edit: func(plan *ExecutionPlan) { plan.Scan.MatchedResources[1].ID = "i-0ccc" }},
+		{name: "extra resource", edit: func(plan *ExecutionPlan) {
+			plan.Scan.MatchedResources = append(plan.Scan.MatchedResources, plan.Scan.MatchedResources[0])
+		}},
+		{name: "action", edit: func(plan *ExecutionPlan) { plan.Scan.MatchedResources[0].Actions[0].Type = "stop" }},
+		{name: "region", edit: func(plan *ExecutionPlan) { plan.Region = "eu-west-1" }},
+		{name: "account", edit: func(plan *ExecutionPlan) { plan.Account = "210987654321" }},
+		{name: "policy hash", edit: func(plan *ExecutionPlan) { plan.PolicyHash = "sha256:00" }},
+		{name: "creation time", edit: func(plan *ExecutionPlan) { plan.CreatedAt = plan.CreatedAt.Add(time.Nanosecond) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := testPlan()
+			test.edit(plan)
+			digest, err := planDigest(plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if same := digest == base; same != test.wantSame {
+				t.Errorf("digest unchanged = %v, want %v", same, test.wantSame)
+			}
+		})
+	}
+}
+
+func TestPlanDigestSurvivesSaveAndLoad(t *testing.T) {
+	plan := testPlan()
+	before, err := planDigest(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := SavePlan(plan, path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := planDigest(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before != after {
+		t.Errorf("digest changed from %s to %s across save and load", before, after)
+	}
+}
+
+func TestVerifyApprovals(t *testing.T) {
+	alice, aliceKey := testApprover(t, "alice")
+	bob, bobKey := testApprover(t, "bob")
+	mallory, malloryKey := testApprover(t, "mallory")
+	aliasOfAlice := TrustedApprover{Name: "alice2", PublicKey: alice.PublicKey}
+	broken := TrustedApprover{Name: "broken", PublicKey: "not a key"}
+
+	tests := []struct {
+		name         string
+		trusted      []TrustedApprover
+		sign         func(t *testing.T, plan *ExecutionPlan)
+		wantValid    []string
+		wantProblems []string // substrings, one per problem
+	}{
+		{
+			name:    "two distinct approvers",
+			trusted: []TrustedApprover{alice, bob},
+			sign: func(t *testing.T, plan *ExecutionPlan) {
+				mustSign(t, plan, "alice", aliceKey)
+				mustSign(t, plan, "bob", bobKey)
+			},
+			wantValid: []string{"alice", "bob"},
+		},
+		{
+			name:    "signing again replaces the earlier approval",
+			trusted: []TrustedApprover{alice},
+			sign: func(t *testing.T, plan *ExecutionPlan) {
+				mustSign(t, plan, "alice", aliceKey)
+				mustSign(t, plan, "alice", aliceKey)
+				if len(plan.Approvals) != 1 {
+					t.Fatalf("%d approvals after signing twice, want 1", len(plan.Approvals))
+				}
+			},
+			wantValid: []string{"alice"},
+		},
+		{
+			name:    "duplicated approval counts once",
+			trusted: []TrustedApprover{alice},
+			sign: func(t *testing.T, plan *ExecutionPlan) {
+				mustSign(t, plan, "alice", aliceKey)
+				plan.Approvals = append(plan.Approvals, plan.Approvals[0])
+			},
+			wantValid:    []string{"alice"},
+			wantProblems: []string{"alice approved more than once"},
+		},
+		{
+			name:         "untrusted approver",
+			trusted:      []TrustedApprover{alice},
+			sign:         func(t *testing.T, plan *ExecutionPlan) { mustSign(t, plan, "mallory", malloryKey) },
+			wantProblems: []string{"mallory is not a trusted approver"},
+		},
+		{
+			name:         "signed with someone else's key",
+			trusted:      []TrustedApprover{alice, mallory},
+			sign:         func(t *testing.T, plan *ExecutionPlan) { mustSign(t, plan, "alice", malloryKey) },
+			wantProblems: []string{"alice's signature doesn't match"},
+		},
+		{
+			name:    "two names sharing a key",
+			trusted: []TrustedApprover{alice, aliasOfAlice},
+			sign: func(t *testing.T, plan *ExecutionPlan) {
+				mustSign(t, plan, "alice", aliceKey)
+				mustSign(t, plan, "alice2", aliceKey)
+			},
+			wantValid:    []string{"alice"},
+			wantProblems: []string{"alice2 shares a key with alice"},
+		},
+		{
+			name:         "invalid trusted key",
+			trusted:      []TrustedApprover{broken},
+			sign:         func(t *testing.T, plan *ExecutionPlan) { mustSign(t, plan, "broken", aliceKey) },
+			wantProblems: []string{"broken's trusted key is invalid"},
+		},
+		{
+			name:    "plan edited after signing",
+			trusted: []TrustedApprover{alice, bob},
+			sign: func(t *testing.T, plan *ExecutionPlan) {
+				mustSign(t, plan, "alice", aliceKey)
+				mustSign(t, plan, "bob", bobKey)
+				plan.Scan.MatchedResources[0].ID = "i-0prod"
+			},
+			wantProblems: []string{"alice's signature doesn't match", "bob's signature doesn't match"},
+		},
+		{
+			name:    "signing time edited",
+			trusted: []TrustedApprover{alice},
+			sign: func(t *testing.T, plan *ExecutionPlan) {
+				mustSign(t, plan, "alice", aliceKey)
+				plan.Approvals[0].SignedAt = plan.Approvals[0].SignedAt.Add(time.Hour)
+			},
+			wantProblems: []string{"alice's signature doesn't match"},
+		},
+		{
+			name:    "approval copied from another plan",
+			trusted: []TrustedApprover{alice},
+			sign: func(t *testing.T, plan *ExecutionPlan) {
+				other := testPlan()
+				other.Region = "eu-west-1"
+				mustSign(t, other, "alice", aliceKey)
+				plan.Approvals = other.Approvals
+			},
+			wantProblems: []string{"alice's signature doesn't match"},
+		},
+		{
+			name:    "signature that isn't base64",
+			trusted: []TrustedApprover{alice},
+			sign: func(t *testing.T, plan *ExecutionPlan) {
+				plan.Approvals = []PlanApproval{{Approver: "alice", SignedAt: time.Now(), Signature: "!!"}}
+			},
+			wantProblems: []string{"alice's signature doesn't match"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := testPlan()
+			test.sign(t, plan)
+
+			valid, problems := VerifyApprovals(plan, &ApprovalConfig{Required: 2, Approvers: test.trusted})
+			if strings.Join(valid, ",") != strings.Join(test.wantValid, ",") {
+				t.Errorf("valid = %v, want %v", valid, test.wantValid)
+			}
+			if len(problems) != len(test.wantProblems) {
+				t.Fatalf("problems = %q, want %q", problems, test.wantProblems)
+			}
+			for i, want := range test.wantProblems {
+				if !strings.Contains(problems[i], want) {
+					t.Errorf("problem %d = %q, want it to mention %q", i, problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func mustSign(t *testing.T, plan *ExecutionPlan, approver string, key ed25519.PrivateKey) {
+	t.Helper()
+	if err := SignPlan(plan, approver, key); err != nil {
+		t.Fatalf("SignPlan: %v", err)
+	}
+}
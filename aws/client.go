@@ -5,29 +5,40 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
 // CustodianClient holds all AWS service clients
 type CustodianClient struct {
 	Config  aws.Config
-	EC2     *ec2.Client
-	S3      *s3.Client
-	RDS     *rds.Client
-	Lambda  *lambda.Client
-	IAM     *iam.Client
+	EC2     EC2API
+	S3      S3API
+	RDS     RDSAPI
+	Lambda  LambdaAPI
+	STS     STSAPI
+	SQS     SQSAPI
+	IAM     *iam.Client // nil when built from ServiceAPIs
 	Region  string
 	Profile string
+	Account string // the account ID the client was set up for, when known
 	DryRun  bool
+
+	newAPIs         APIFactory // rebuilds the service clients on SwitchRegion
+	regionalBuckets bool       // list only the S3 buckets located in Region
 }
 
 // ClientConfig for initializing the AWS client
@@ -37,9 +48,14 @@ type ClientConfig struct {
 	AccessKeyID     string
 	SecretAccessKey string
 	SessionToken    string
-	AssumeRoleARN   string
+	AssumeRoleARN   string // role to assume with the credentials above
+	ExternalID      string // passed when assuming AssumeRoleARN, if the role requires one
 	DryRun          bool
 	Timeout         time.Duration
+	Retry           RetryConfig // pacing and retries shared by every call the client makes
+
+	// WrapAPIs, when set, wraps each region's service clients, e.g. to record traffic
+	WrapAPIs func(region string, apis ServiceAPIs) ServiceAPIs
 }
 
 // NewCustodianClient creates a new AWS client for Custodian Killer
@@ -58,6 +74,9 @@ func NewCustodianClient(cfg ClientConfig) (*CustodianClient, error) {
 
 	ctx := context.Background()
 
+	// The throttle retries calls itself, so the SDK's own retries would multiply them
+	noRetries := config.WithRetryer(func() aws.Retryer { return aws.NopRetryer{} })
+
 	// Load AWS configuration
 	var awsConfig aws.Config
 	var err error
@@ -68,6 +87,7 @@ func NewCustodianClient(cfg ClientConfig) (*CustodianClient, error) {
 		fmt.Println("🔑 Using provided AWS credentials")
 		awsConfig, err = config.LoadDefaultConfig(ctx,
 			config.WithRegion(cfg.Region),
+			noRetries,
 			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
 				cfg.AccessKeyID,
 				cfg.SecretAccessKey,
@@ -79,6 +99,7 @@ func NewCustodianClient(cfg ClientConfig) (*CustodianClient, error) {
 		fmt.Printf("👤 Using AWS profile: %s\n", cfg.Profile)
 		awsConfig, err = config.LoadDefaultConfig(ctx,
 			config.WithRegion(cfg.Region),
+			noRetries,
 			config.WithSharedConfigProfile(cfg.Profile),
 		)
 	} else {
@@ -86,6 +107,7 @@ func NewCustodianClient(cfg ClientConfig) (*CustodianClient, error) {
 		fmt.Println("🔧 Using default AWS credential chain")
 		awsConfig, err = config.LoadDefaultConfig(ctx,
 			config.WithRegion(cfg.Region),
+			noRetries,
 		)
 	}
 
@@ -93,18 +115,29 @@ func NewCustodianClient(cfg ClientConfig) (*CustodianClient, error) {
 		return nil, fmt.Errorf("🚨 failed to load AWS configuration: %v", err)
 	}
 
+	// Every call then runs as the role, with credentials refreshed before they expire
+	if cfg.AssumeRoleARN != "" {
+		fmt.Printf("🎭 Assuming role: %s\n", cfg.AssumeRoleARN)
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsConfig), cfg.AssumeRoleARN,
+			func(o *stscreds.AssumeRoleOptions) {
+				o.RoleSessionName = "custodian-killer"
+				if cfg.ExternalID != "" {
+					o.ExternalID = aws.String(cfg.ExternalID)
+				}
+			})
+		awsConfig.Credentials = aws.NewCredentialsCache(provider)
+	}
+
 	// Create service clients
 	client := &CustodianClient{
 		Config:  awsConfig,
-		EC2:     ec2.NewFromConfig(awsConfig),
-		S3:      s3.NewFromConfig(awsConfig),
-		RDS:     rds.NewFromConfig(awsConfig),
-		Lambda:  lambda.NewFromConfig(awsConfig),
 		IAM:     iam.NewFromConfig(awsConfig),
 		Region:  cfg.Region,
 		Profile: cfg.Profile,
 		DryRun:  cfg.DryRun,
 	}
+	client.newAPIs = wrapFactory(wrapFactory(client.sdkAPIs, NewThrottle(cfg.Retry).Wrap), cfg.WrapAPIs)
+	client.setAPIs(client.newAPIs(cfg.Region))
 
 	// Test connection
 	if err := client.TestConnection(); err != nil {
@@ -115,6 +148,78 @@ func NewCustodianClient(cfg ClientConfig) (*CustodianClient, error) {
 	return client, nil
 }
 
+// NewCustodianClientWithAPIs creates a client on top of the given service clients,
+// such as an in-memory fake cloud, instead of the AWS SDK. Credentials and
+// AssumeRoleARN are not used; the factory decides which account is reached.
+func NewCustodianClientWithAPIs(factory APIFactory, cfg ClientConfig) (*CustodianClient, error) {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	client := &CustodianClient{
+		Config:  aws.Config{Region: cfg.Region},
+		Region:  cfg.Region,
+		Profile: cfg.Profile,
+		DryRun:  cfg.DryRun,
+		newAPIs: wrapFactory(wrapFactory(factory, NewThrottle(cfg.Retry).Wrap), cfg.WrapAPIs),
+	}
+	client.setAPIs(client.newAPIs(cfg.Region))
+
+	if err := client.TestConnection(); err != nil {
+		return nil, fmt.Errorf("🚨 connection test failed: %v", err)
+	}
+
+	return client, nil
+}
+
+// sdkAPIs creates AWS SDK clients for a region
+func (c *CustodianClient) sdkAPIs(region string) ServiceAPIs {
+	cfg := c.Config
+	cfg.Region = region
+	return ServiceAPIs{
+		EC2:    ec2.NewFromConfig(cfg),
+		S3:     s3.NewFromConfig(cfg),
+		RDS:    rds.NewFromConfig(cfg),
+		Lambda: lambda.NewFromConfig(cfg),
+		STS:    sts.NewFromConfig(cfg),
+		SQS:    sqs.NewFromConfig(cfg),
+	}
+}
+
+// wrapFactory applies an optional wrapper to every set of clients a factory builds
+func wrapFactory(factory APIFactory, wrap func(string, ServiceAPIs) ServiceAPIs) APIFactory {
+	if wrap == nil {
+		return factory
+	}
+	return func(region string) ServiceAPIs {
+		return wrap(region, factory(region))
+	}
+}
+
+func (c *CustodianClient) setAPIs(apis ServiceAPIs) {
+	c.EC2 = apis.EC2
+	c.S3 = apis.S3
+	c.RDS = apis.RDS
+	c.Lambda = apis.Lambda
+	c.STS = apis.STS
+	c.SQS = apis.SQS
+}
+
+// ForRegion returns a client for one region of a multi-region run. It shares
+// this client's credentials and rate limits, and lists only the S3 buckets
+// located in that region, so each bucket is handled once across regions.
+func (c *CustodianClient) ForRegion(region string) *CustodianClient {
+	regional := *c
+	regional.Config.Region = region
+	regional.Region = region
+	regional.regionalBuckets = true
+	regional.setAPIs(c.newAPIs(region))
+	if c.IAM != nil {
+		regional.IAM = iam.NewFromConfig(regional.Config)
+	}
+	return &regional
+}
+
 // TestConnection verifies AWS connectivity
 func (c *CustodianClient) TestConnection() error {
 	fmt.Println("🧪 Testing AWS connection...")
@@ -143,12 +248,20 @@ func (c *CustodianClient) TestConnection() error {
 }
 
 // GetCallerIdentity returns information about the AWS credentials being used
-func (c *CustodianClient) GetCallerIdentity() (*CallerInfo, error) {
-	// We'd need to add STS client for this, but for now return basic info
+func (c *CustodianClient) GetCallerIdentity(ctx context.Context) (*CallerInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	c.LogAWSCall("STS", "GetCallerIdentity", false)
+	result, err := c.STS.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caller identity: %v", err)
+	}
+
 	return &CallerInfo{
-		Account: "unknown",
-		UserID:  "unknown",
-		Arn:     "unknown",
+		Account: aws.ToString(result.Account),
+		UserID:  aws.ToString(result.UserId),
+		Arn:     aws.ToString(result.Arn),
 		Region:  c.Region,
 		Profile: c.Profile,
 	}, nil
@@ -156,11 +269,11 @@ func (c *CustodianClient) GetCallerIdentity() (*CallerInfo, error) {
 
 // CallerInfo contains information about the current AWS caller
 type CallerInfo struct {
-	Account string
-	UserID  string
-	Arn     string
-	Region  string
-	Profile string
+	Account string `json:"account"`
+	UserID  string `json:"user_id"`
+	Arn     string `json:"arn"`
+	Region  string `json:"region"`
+	Profile string `json:"profile,omitempty"`
 }
 
 // SetDryRun enables or disables dry-run mode
@@ -174,8 +287,7 @@ func (c *CustodianClient) SetDryRun(dryRun bool) {
 }
 
 // GetRegions returns list of available AWS regions
-func (c *CustodianClient) GetRegions() ([]string, error) {
-	ctx := context.Background()
+func (c *CustodianClient) GetRegions(ctx context.Context) ([]string, error) {
 	result, err := c.EC2.DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
 	if err != nil {
 		return nil, err
@@ -191,6 +303,14 @@ func (c *CustodianClient) GetRegions() ([]string, error) {
 	return regions, nil
 }
 
+// regionName matches names like us-east-1, eu-central-2 and us-gov-west-1
+var regionName = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-[0-9]+$`)
+
+// IsRegionName reports whether name looks like an AWS region
+func IsRegionName(name string) bool {
+	return regionName.MatchString(name)
+}
+
 // SwitchRegion changes the active region for all clients
 func (c *CustodianClient) SwitchRegion(region string) error {
 	fmt.Printf("🌍 Switching to region: %s\n", region)
@@ -200,11 +320,10 @@ func (c *CustodianClient) SwitchRegion(region string) error {
 	c.Region = region
 
 	// Recreate clients with new region
-	c.EC2 = ec2.NewFromConfig(c.Config)
-	c.S3 = s3.NewFromConfig(c.Config)
-	c.RDS = rds.NewFromConfig(c.Config)
-	c.Lambda = lambda.NewFromConfig(c.Config)
-	c.IAM = iam.NewFromConfig(c.Config)
+	c.setAPIs(c.newAPIs(region))
+	if c.IAM != nil {
+		c.IAM = iam.NewFromConfig(c.Config)
+	}
 
 	// Test new connection
 	if err := c.TestConnection(); err != nil {
@@ -217,8 +336,8 @@ func (c *CustodianClient) SwitchRegion(region string) error {
 
 // Helper function to check if error is unauthorized (for dry-run detection)
 func isUnauthorizedError(err error) bool {
-	return err != nil && (fmt.Sprintf("%v", err) == "UnauthorizedOperation" ||
-		fmt.Sprintf("%v", err) == "DryRunOperation")
+	return err != nil && (strings.Contains(err.Error(), "UnauthorizedOperation") ||
+		strings.Contains(err.Error(), "DryRunOperation"))
 }
 
 // LogAWSCall logs AWS API calls for debugging
@@ -0,0 +1,165 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// EBSVolume represents an EBS volume and its attachment state
+type EBSVolume struct {
+	VolumeID         string            `json:"volume_id"`
+	Name             string            `json:"name"`
+	VolumeType       string            `json:"volume_type"`
+	State            string            `json:"state"` // creating, available, in-use, etc.
+	SizeGB           int               `json:"size_gb"`
+	IOPS             int               `json:"iops,omitempty"`
+	Encrypted        bool              `json:"encrypted"`
+	KMSKeyID         string            `json:"kms_key_id,omitempty"`
+	AvailabilityZone string            `json:"availability_zone"`
+	CreateTime       time.Time         `json:"create_time"`
+	AgeDays          int               `json:"age_days"`
+	SnapshotID       string            `json:"snapshot_id,omitempty"`
+	AttachmentState  string            `json:"attachment_state"` // attached, detached
+	AttachedTo       []string          `json:"attached_to,omitempty"`
+	Tags             map[string]string `json:"tags"`
+	MonthlyCost      float64           `json:"estimated_monthly_cost"`
+}
+
+// EBSFilter represents filtering criteria for EBS volumes
+type EBSFilter struct {
+	VolumeIDs   []string          // specific volume IDs
+	States      []string          // available, in-use, etc.
+	VolumeTypes []string          // gp2, gp3, io1, etc.
+	Tags        map[string]string // tag filters
+}
+
+// GetEBSVolumes retrieves EBS volumes based on filters
+func (c *CustodianClient) GetEBSVolumes(ctx context.Context, filters EBSFilter) ([]EBSVolume, error) {
+	c.LogAWSCall("EC2", "DescribeVolumes", c.DryRun)
+
+	fmt.Println("💾 Scanning EBS volumes...")
+
+	var ec2Filters []types.Filter
+
+	if len(filters.States) > 0 {
+		ec2Filters = append(ec2Filters, types.Filter{
+			Name:   aws.String("status"),
+			Values: filters.States,
+		})
+	}
+
+	if len(filters.VolumeTypes) > 0 {
+		ec2Filters = append(ec2Filters, types.Filter{
+			Name:   aws.String("volume-type"),
+			Values: filters.VolumeTypes,
+		})
+	}
+
+	for key, value := range filters.Tags {
+		if value == "*" || value == "" {
+			ec2Filters = append(ec2Filters, types.Filter{
+				Name:   aws.String("tag-key"),
+				Values: []string{key},
+			})
+		} else {
+			ec2Filters = append(ec2Filters, types.Filter{
+				Name:   aws.String(fmt.Sprintf("tag:%s", key)),
+				Values: []string{value},
+			})
+		}
+	}
+
+	input := &ec2.DescribeVolumesInput{
+		Filters: ec2Filters,
+	}
+
+	if len(filters.VolumeIDs) > 0 {
+		input.VolumeIds = filters.VolumeIDs
+	}
+
+	var volumes []EBSVolume
+	paginator := ec2.NewDescribeVolumesPaginator(c.EC2, input)
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe volumes: %v", err)
+		}
+
+		for _, volume := range page.Volumes {
+			volumes = append(volumes, c.convertToEBSVolume(volume))
+		}
+	}
+
+	fmt.Printf("✅ Found %d EBS volumes matching criteria\n", len(volumes))
+	return volumes, nil
+}
+
+// convertToEBSVolume converts AWS SDK volume to our struct
+func (c *CustodianClient) convertToEBSVolume(volume types.Volume) EBSVolume {
+	ebsVolume := EBSVolume{
+		VolumeID:         aws.ToString(volume.VolumeId),
+		VolumeType:       string(volume.VolumeType),
+		State:            string(volume.State),
+		SizeGB:           int(aws.ToInt32(volume.Size)),
+		IOPS:             int(aws.ToInt32(volume.Iops)),
+		Encrypted:        aws.ToBool(volume.Encrypted),
+		KMSKeyID:         aws.ToString(volume.KmsKeyId),
+		AvailabilityZone: aws.ToString(volume.AvailabilityZone),
+		CreateTime:       aws.ToTime(volume.CreateTime),
+		SnapshotID:       aws.ToString(volume.SnapshotId),
+		AttachmentState:  "detached",
+		Tags:             make(map[string]string),
+	}
+
+	for _, tag := range volume.Tags {
+		key := aws.ToString(tag.Key)
+		ebsVolume.Tags[key] = aws.ToString(tag.Value)
+		if key == "Name" {
+			ebsVolume.Name = aws.ToString(tag.Value)
+		}
+	}
+
+	if ebsVolume.Name == "" {
+		ebsVolume.Name = ebsVolume.VolumeID
+	}
+
+	for _, attachment := range volume.Attachments {
+		ebsVolume.AttachedTo = append(ebsVolume.AttachedTo, aws.ToString(attachment.InstanceId))
+		ebsVolume.AttachmentState = "attached"
+	}
+
+	if !ebsVolume.CreateTime.IsZero() {
+		ebsVolume.AgeDays = int(time.Since(ebsVolume.CreateTime).Hours() / 24)
+	}
+
+	ebsVolume.MonthlyCost = c.estimateEBSCost(ebsVolume.VolumeType, ebsVolume.SizeGB)
+
+	return ebsVolume
+}
+
+// estimateEBSCost provides rough monthly cost estimates for volumes
+func (c *CustodianClient) estimateEBSCost(volumeType string, sizeGB int) float64 {
+	// EBS pricing per GB per month (simplified, US East 1)
+	pricing := map[string]float64{
+		"gp2":      0.10,
+		"gp3":      0.08,
+		"io1":      0.125,
+		"io2":      0.125,
+		"st1":      0.045,
+		"sc1":      0.015,
+		"standard": 0.05,
+	}
+
+	price, exists := pricing[volumeType]
+	if !exists {
+		price = 0.10
+	}
+
+	return float64(sizeGB) * price
+}
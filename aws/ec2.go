@@ -15,6 +15,8 @@ import (
 type EC2Instance struct {
 	InstanceID     string            `json:"instance_id"`
 	Name           string            `json:"name"`
+	Region         string            `json:"region,omitempty"`
+	Account        string            `json:"account,omitempty"`
 	InstanceType   string            `json:"instance_type"`
 	State          string            `json:"state"`
 	LaunchTime     time.Time         `json:"launch_time"`
@@ -46,15 +48,15 @@ type EC2Filter struct {
 	LaunchTimeBefore *time.Time        // instances launched before this time
 	CPUThreshold     *float64          // CPU utilization threshold
 	RunningDaysMin   *int              // minimum running days
+	IncludeMetrics   bool              // fetch CPU metrics even without a threshold
 }
 
 // GetEC2Instances retrieves EC2 instances based on filters
-func (c *CustodianClient) GetEC2Instances(filters EC2Filter) ([]EC2Instance, error) {
+func (c *CustodianClient) GetEC2Instances(ctx context.Context, filters EC2Filter) ([]EC2Instance, error) {
 	c.LogAWSCall("EC2", "DescribeInstances", c.DryRun)
 
 	fmt.Println("🔍 Scanning EC2 instances...")
 
-	ctx := context.Background()
 	var ec2Filters []types.Filter
 
 	// Build AWS filters from our custom filter
@@ -126,7 +128,7 @@ func (c *CustodianClient) GetEC2Instances(filters EC2Filter) ([]EC2Instance, err
 	}
 
 	// Enhance instances with CloudWatch metrics if CPU threshold is specified
-	if filters.CPUThreshold != nil {
+	if filters.CPUThreshold != nil || filters.IncludeMetrics {
 		fmt.Println("📊 Fetching CPU utilization metrics...")
 		instances = c.enhanceWithCloudWatchMetrics(instances)
 	}
@@ -139,6 +141,8 @@ func (c *CustodianClient) GetEC2Instances(filters EC2Filter) ([]EC2Instance, err
 func (c *CustodianClient) convertToEC2Instance(instance types.Instance) EC2Instance {
 	ec2Instance := EC2Instance{
 		InstanceID:     aws.ToString(instance.InstanceId),
+		Region:         c.Region,
+		Account:        c.Account,
 		InstanceType:   string(instance.InstanceType),
 		State:          string(instance.State.Name),
 		LaunchTime:     aws.ToTime(instance.LaunchTime),
@@ -275,6 +279,7 @@ func (c *CustodianClient) estimateInstanceCost(instanceType, state string) float
 
 // StopInstances stops EC2 instances
 func (c *CustodianClient) StopInstances(
+	ctx context.Context,
 	instanceIDs []string,
 	force bool,
 ) (*EC2ActionResult, error) {
@@ -286,7 +291,6 @@ func (c *CustodianClient) StopInstances(
 
 	fmt.Printf("⏹️  Stopping %d instances...\n", len(instanceIDs))
 
-	ctx := context.Background()
 	input := &ec2.StopInstancesInput{
 		InstanceIds: instanceIDs,
 		Force:       aws.Bool(force),
@@ -328,7 +332,7 @@ func (c *CustodianClient) StopInstances(
 }
 
 // TerminateInstances terminates EC2 instances
-func (c *CustodianClient) TerminateInstances(instanceIDs []string) (*EC2ActionResult, error) {
+func (c *CustodianClient) TerminateInstances(ctx context.Context, instanceIDs []string) (*EC2ActionResult, error) {
 	c.LogAWSCall("EC2", "TerminateInstances", c.DryRun)
 
 	if len(instanceIDs) == 0 {
@@ -340,7 +344,6 @@ func (c *CustodianClient) TerminateInstances(instanceIDs []string) (*EC2ActionRe
 		fmt.Println("⚠️  WARNING: This action is IRREVERSIBLE!")
 	}
 
-	ctx := context.Background()
 	input := &ec2.TerminateInstancesInput{
 		InstanceIds: instanceIDs,
 	}
@@ -381,7 +384,7 @@ func (c *CustodianClient) TerminateInstances(instanceIDs []string) (*EC2ActionRe
 }
 
 // StartInstances starts stopped EC2 instances
-func (c *CustodianClient) StartInstances(instanceIDs []string) (*EC2ActionResult, error) {
+func (c *CustodianClient) StartInstances(ctx context.Context, instanceIDs []string) (*EC2ActionResult, error) {
 	c.LogAWSCall("EC2", "StartInstances", c.DryRun)
 
 	if len(instanceIDs) == 0 {
@@ -390,7 +393,6 @@ func (c *CustodianClient) StartInstances(instanceIDs []string) (*EC2ActionResult
 
 	fmt.Printf("▶️  Starting %d instances...\n", len(instanceIDs))
 
-	ctx := context.Background()
 	input := &ec2.StartInstancesInput{
 		InstanceIds: instanceIDs,
 	}
@@ -430,6 +432,7 @@ func (c *CustodianClient) StartInstances(instanceIDs []string) (*EC2ActionResult
 
 // TagInstances adds tags to EC2 instances
 func (c *CustodianClient) TagInstances(
+	ctx context.Context,
 	instanceIDs []string,
 	tags map[string]string,
 ) (*EC2ActionResult, error) {
@@ -441,7 +444,6 @@ func (c *CustodianClient) TagInstances(
 
 	fmt.Printf("🏷️  Adding %d tags to %d instances...\n", len(tags), len(instanceIDs))
 
-	ctx := context.Background()
 	var ec2Tags []types.Tag
 
 	for key, value := range tags {
@@ -482,6 +484,59 @@ func (c *CustodianClient) TagInstances(
 	return actionResult, nil
 }
 
+// UntagInstances removes tag keys from EC2 instances
+func (c *CustodianClient) UntagInstances(
+	ctx context.Context,
+	instanceIDs []string,
+	keys []string,
+) (*EC2ActionResult, error) {
+	c.LogAWSCall("EC2", "DeleteTags", c.DryRun)
+
+	if len(instanceIDs) == 0 || len(keys) == 0 {
+		return &EC2ActionResult{}, nil
+	}
+
+	fmt.Printf("🏷️  Removing %d tags from %d instances...\n", len(keys), len(instanceIDs))
+
+	var ec2Tags []types.Tag
+	for _, key := range keys {
+		ec2Tags = append(ec2Tags, types.Tag{Key: aws.String(key)})
+	}
+
+	input := &ec2.DeleteTagsInput{
+		Resources: instanceIDs,
+		Tags:      ec2Tags,
+	}
+
+	if c.DryRun {
+		input.DryRun = aws.Bool(true)
+	}
+
+	_, err := c.EC2.DeleteTags(ctx, input)
+	if err != nil {
+		if c.DryRun && strings.Contains(err.Error(), "DryRunOperation") {
+			fmt.Println("🧪 Dry-run successful - tags would be removed")
+			return &EC2ActionResult{
+				Action:      "untag",
+				InstanceIDs: instanceIDs,
+				Success:     true,
+				DryRun:      true,
+				Timestamp:   time.Now(),
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to delete tags: %v", err)
+	}
+
+	fmt.Printf("✅ Tags removed from %d instances\n", len(instanceIDs))
+	return &EC2ActionResult{
+		Action:      "untag",
+		InstanceIDs: instanceIDs,
+		Success:     true,
+		DryRun:      c.DryRun,
+		Timestamp:   time.Now(),
+	}, nil
+}
+
 // EC2ActionResult represents the result of an EC2 action
 type EC2ActionResult struct {
 	Action       string                `json:"action"`
@@ -549,17 +604,18 @@ func (c *CustodianClient) createTagDryRunResult(
 
 // WaitForInstanceState waits for instances to reach a specific state
 func (c *CustodianClient) WaitForInstanceState(
+	ctx context.Context,
 	instanceIDs []string,
 	targetState string,
 	timeout time.Duration,
 ) error {
 	fmt.Printf("⏳ Waiting for %d instances to reach state: %s\n", len(instanceIDs), targetState)
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	return c.WaitForCompletion(ctx, func() (bool, error) {
-		instances, err := c.GetEC2Instances(EC2Filter{InstanceIDs: instanceIDs})
+		instances, err := c.GetEC2Instances(ctx, EC2Filter{InstanceIDs: instanceIDs})
 		if err != nil {
 			return false, err
 		}
@@ -0,0 +1,159 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// LambdaFunction represents a Lambda function and its configuration
+type LambdaFunction struct {
+	FunctionName    string            `json:"function_name"`
+	ARN             string            `json:"arn"`
+	Runtime         string            `json:"runtime"`
+	Handler         string            `json:"handler,omitempty"`
+	Description     string            `json:"description,omitempty"`
+	MemorySizeMB    int               `json:"memory_size_mb"`
+	TimeoutSeconds  int               `json:"timeout_seconds"`
+	CodeSizeBytes   int64             `json:"code_size_bytes"`
+	LastModified    time.Time         `json:"last_modified"`
+	DaysSinceUpdate int               `json:"days_since_update"`
+	Role            string            `json:"role,omitempty"`
+	Environment     map[string]string `json:"environment,omitempty"` // variable names only; values are RedactedValue
+	Tags            map[string]string `json:"tags"`
+}
+
+// LambdaFilter represents filtering criteria for Lambda functions
+type LambdaFilter struct {
+	FunctionNames []string          // specific function names
+	Runtimes      []string          // python3.12, nodejs20.x, etc.
+	Tags          map[string]string // tag filters
+}
+
+// lambdaTimeLayout is the timestamp format Lambda uses for LastModified
+const lambdaTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// GetLambdaFunctions retrieves Lambda functions based on filters
+func (c *CustodianClient) GetLambdaFunctions(ctx context.Context, filters LambdaFilter) ([]LambdaFunction, error) {
+	c.LogAWSCall("Lambda", "ListFunctions", c.DryRun)
+
+	fmt.Println("⚡ Scanning Lambda functions...")
+
+	var functions []LambdaFunction
+	paginator := lambda.NewListFunctionsPaginator(c.Lambda, &lambda.ListFunctionsInput{})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list functions: %v", err)
+		}
+
+		for _, configuration := range page.Functions {
+			function := c.convertToLambdaFunction(configuration)
+
+			// Apply cheap filters before fetching tags
+			if len(filters.FunctionNames) > 0 &&
+				!contains(filters.FunctionNames, function.FunctionName) {
+				continue
+			}
+			if len(filters.Runtimes) > 0 && !contains(filters.Runtimes, function.Runtime) {
+				continue
+			}
+
+			// Tag filters on a function whose tags we couldn't read would match wrongly
+			function.Tags, err = c.getFunctionTags(ctx, function.ARN)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tags of %s: %v", function.FunctionName, err)
+			}
+
+			if c.matchesLambdaTags(function, filters.Tags) {
+				functions = append(functions, function)
+			}
+		}
+	}
+
+	fmt.Printf("✅ Found %d Lambda functions matching criteria\n", len(functions))
+	return functions, nil
+}
+
+// convertToLambdaFunction converts AWS SDK function configuration to our struct
+func (c *CustodianClient) convertToLambdaFunction(
+	configuration types.FunctionConfiguration,
+) LambdaFunction {
+	function := LambdaFunction{
+		FunctionName:   aws.ToString(configuration.FunctionName),
+		ARN:            aws.ToString(configuration.FunctionArn),
+		Runtime:        string(configuration.Runtime),
+		Handler:        aws.ToString(configuration.Handler),
+		Description:    aws.ToString(configuration.Description),
+		MemorySizeMB:   int(aws.ToInt32(configuration.MemorySize)),
+		TimeoutSeconds: int(aws.ToInt32(configuration.Timeout)),
+		CodeSizeBytes:  configuration.CodeSize,
+		Role:           aws.ToString(configuration.Role),
+		Tags:           make(map[string]string),
+	}
+
+	if lastModified, err := time.Parse(
+		lambdaTimeLayout,
+		aws.ToString(configuration.LastModified),
+	); err == nil {
+		function.LastModified = lastModified
+		function.DaysSinceUpdate = int(time.Since(lastModified).Hours() / 24)
+	}
+
+	if configuration.Environment != nil {
+		function.Environment = RedactValues(configuration.Environment.Variables)
+	}
+
+	return function
+}
+
+// RedactedValue replaces values that may hold secrets, such as Lambda
+// environment variables, so they never reach scans, plans or snapshots
+const RedactedValue = "[redacted]"
+
+// RedactValues copies a map with every value replaced by RedactedValue
+func RedactValues(values map[string]string) map[string]string {
+	redacted := make(map[string]string, len(values))
+	for key := range values {
+		redacted[key] = RedactedValue
+	}
+	return redacted
+}
+
+// getFunctionTags retrieves function tags
+func (c *CustodianClient) getFunctionTags(ctx context.Context, functionARN string) (map[string]string, error) {
+	tags := make(map[string]string)
+
+	result, err := c.Lambda.ListTags(ctx, &lambda.ListTagsInput{
+		Resource: aws.String(functionARN),
+	})
+	if lookupFailed(ctx, err) {
+		return nil, err
+	}
+	if err != nil {
+		// No tags or access denied
+		return tags, nil
+	}
+
+	for key, value := range result.Tags {
+		tags[key] = value
+	}
+
+	return tags, nil
+}
+
+// matchesLambdaTags checks if a function carries the requested tags
+func (c *CustodianClient) matchesLambdaTags(function LambdaFunction, tags map[string]string) bool {
+	for key, value := range tags {
+		tagValue, exists := function.Tags[key]
+		if !exists || (value != "*" && tagValue != value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+// RDSInstance represents an RDS database instance
+type RDSInstance struct {
+	DBInstanceID          string            `json:"db_instance_id"`
+	ARN                   string            `json:"arn"`
+	InstanceClass         string            `json:"instance_class"`
+	Engine                string            `json:"engine"`
+	EngineVersion         string            `json:"engine_version"`
+	Status                string            `json:"status"`
+	AllocatedStorageGB    int               `json:"allocated_storage_gb"`
+	BackupRetentionPeriod int               `json:"backup_retention_period"`
+	MultiAZ               bool              `json:"multi_az"`
+	Encrypted             bool              `json:"encrypted"`
+	PubliclyAccessible    bool              `json:"publicly_accessible"`
+	CreatedAt             time.Time         `json:"created_at"`
+	AvailabilityZone      string            `json:"availability_zone,omitempty"`
+	Endpoint              string            `json:"endpoint,omitempty"`
+	Tags                  map[string]string `json:"tags"`
+	MonthlyCost           float64           `json:"estimated_monthly_cost"`
+}
+
+// RDSFilter represents filtering criteria for RDS instances
+type RDSFilter struct {
+	DBInstanceIDs []string          // specific instance identifiers
+	Engines       []string          // mysql, postgres, aurora, etc.
+	Statuses      []string          // available, stopped, etc.
+	Tags          map[string]string // tag filters
+}
+
+// GetRDSInstances retrieves RDS instances based on filters
+func (c *CustodianClient) GetRDSInstances(ctx context.Context, filters RDSFilter) ([]RDSInstance, error) {
+	c.LogAWSCall("RDS", "DescribeDBInstances", c.DryRun)
+
+	fmt.Println("🗄️  Scanning RDS instances...")
+
+	var instances []RDSInstance
+	paginator := rds.NewDescribeDBInstancesPaginator(c.RDS, &rds.DescribeDBInstancesInput{})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe DB instances: %v", err)
+		}
+
+		for _, dbInstance := range page.DBInstances {
+			instance := c.convertToRDSInstance(dbInstance)
+			if c.matchesRDSFilters(instance, filters) {
+				instances = append(instances, instance)
+			}
+		}
+	}
+
+	fmt.Printf("✅ Found %d RDS instances matching criteria\n", len(instances))
+	return instances, nil
+}
+
+// convertToRDSInstance converts AWS SDK DB instance to our struct
+func (c *CustodianClient) convertToRDSInstance(dbInstance types.DBInstance) RDSInstance {
+	instance := RDSInstance{
+		DBInstanceID:          aws.ToString(dbInstance.DBInstanceIdentifier),
+		ARN:                   aws.ToString(dbInstance.DBInstanceArn),
+		InstanceClass:         aws.ToString(dbInstance.DBInstanceClass),
+		Engine:                aws.ToString(dbInstance.Engine),
+		EngineVersion:         aws.ToString(dbInstance.EngineVersion),
+		Status:                aws.ToString(dbInstance.DBInstanceStatus),
+		AllocatedStorageGB:    int(aws.ToInt32(dbInstance.AllocatedStorage)),
+		BackupRetentionPeriod: int(aws.ToInt32(dbInstance.BackupRetentionPeriod)),
+		MultiAZ:               aws.ToBool(dbInstance.MultiAZ),
+		Encrypted:             aws.ToBool(dbInstance.StorageEncrypted),
+		PubliclyAccessible:    aws.ToBool(dbInstance.PubliclyAccessible),
+		CreatedAt:             aws.ToTime(dbInstance.InstanceCreateTime),
+		AvailabilityZone:      aws.ToString(dbInstance.AvailabilityZone),
+		Tags:                  make(map[string]string),
+	}
+
+	if dbInstance.Endpoint != nil {
+		instance.Endpoint = aws.ToString(dbInstance.Endpoint.Address)
+	}
+
+	for _, tag := range dbInstance.TagList {
+		instance.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	instance.MonthlyCost = c.estimateRDSCost(instance)
+
+	return instance
+}
+
+// matchesRDSFilters checks if a DB instance matches the specified filters
+func (c *CustodianClient) matchesRDSFilters(instance RDSInstance, filters RDSFilter) bool {
+	if len(filters.DBInstanceIDs) > 0 && !contains(filters.DBInstanceIDs, instance.DBInstanceID) {
+		return false
+	}
+	if len(filters.Engines) > 0 && !contains(filters.Engines, instance.Engine) {
+		return false
+	}
+	if len(filters.Statuses) > 0 && !contains(filters.Statuses, instance.Status) {
+		return false
+	}
+
+	for key, value := range filters.Tags {
+		tagValue, exists := instance.Tags[key]
+		if !exists || (value != "*" && tagValue != value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// estimateRDSCost provides rough monthly cost estimates for DB instances
+func (c *CustodianClient) estimateRDSCost(instance RDSInstance) float64 {
+	if instance.Status == "stopped" {
+		// Stopped instances still pay for storage
+		return float64(instance.AllocatedStorageGB) * 0.115
+	}
+
+	// Rough monthly costs for common instance classes (US East 1, single-AZ)
+	costs := map[string]float64{
+		"db.t3.micro":   12.41,
+		"db.t3.small":   24.82,
+		"db.t3.medium":  49.64,
+		"db.t3.large":   99.28,
+		"db.m5.large":   124.83,
+		"db.m5.xlarge":  249.66,
+		"db.m5.2xlarge": 499.32,
+		"db.r5.large":   175.20,
+		"db.r5.xlarge":  350.40,
+	}
+
+	cost, exists := costs[instance.InstanceClass]
+	if !exists {
+		cost = 100.0 // Default estimate for unknown classes
+	}
+
+	if instance.MultiAZ {
+		cost *= 2
+	}
+
+	return cost + float64(instance.AllocatedStorageGB)*0.115
+}
@@ -15,6 +15,7 @@ import (
 type S3Bucket struct {
 	Name                  string            `json:"name"`
 	Region                string            `json:"region"`
+	Account               string            `json:"account,omitempty"`
 	CreationDate          time.Time         `json:"creation_date"`
 	Tags                  map[string]string `json:"tags"`
 	PublicReadACL         bool              `json:"public_read_acl"`
@@ -43,6 +44,14 @@ type S3Encryption struct {
 	BucketKeyEnabled bool   `json:"bucket_key_enabled"`
 }
 
+// PublicAccessBlock is a bucket's public access block settings
+type PublicAccessBlock struct {
+	BlockPublicACLs       bool `json:"block_public_acls"`
+	BlockPublicPolicy     bool `json:"block_public_policy"`
+	IgnorePublicACLs      bool `json:"ignore_public_acls"`
+	RestrictPublicBuckets bool `json:"restrict_public_buckets"`
+}
+
 // S3Filter represents filtering criteria for S3 buckets
 type S3Filter struct {
 	BucketNames        []string          // specific bucket names
@@ -56,13 +65,11 @@ type S3Filter struct {
 }
 
 // GetS3Buckets retrieves S3 buckets based on filters
-func (c *CustodianClient) GetS3Buckets(filters S3Filter) ([]S3Bucket, error) {
+func (c *CustodianClient) GetS3Buckets(ctx context.Context, filters S3Filter) ([]S3Bucket, error) {
 	c.LogAWSCall("S3", "ListBuckets", c.DryRun)
 
 	fmt.Println("🪣 Scanning S3 buckets...")
 
-	ctx := context.Background()
-
 	// First, list all buckets
 	listResult, err := c.S3.ListBuckets(ctx, &s3.ListBucketsInput{})
 	if err != nil {
@@ -79,14 +86,29 @@ func (c *CustodianClient) GetS3Buckets(filters S3Filter) ([]S3Bucket, error) {
 			continue
 		}
 
-		fmt.Printf("📊 Analyzing bucket: %s\n", bucketName)
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("bucket scan stopped: %v", err)
+		}
 
-		s3Bucket, err := c.analyzeBucket(bucketName, aws.ToTime(bucket.CreationDate))
+		// A bucket with settings we couldn't read would look unprotected, so
+		// fail the scan rather than report it or leave it out
+		region, err := c.getBucketRegion(ctx, bucketName)
 		if err != nil {
-			fmt.Printf("⚠️  Warning: Failed to analyze bucket %s: %v\n", bucketName, err)
+			return nil, fmt.Errorf("failed to analyze bucket %s: %v", bucketName, err)
+		}
+
+		// Buckets elsewhere are left to the client for their own region
+		if c.regionalBuckets && region != c.Region {
 			continue
 		}
 
+		fmt.Printf("📊 Analyzing bucket: %s\n", bucketName)
+
+		s3Bucket, err := c.analyzeBucket(ctx, bucketName, region, aws.ToTime(bucket.CreationDate))
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze bucket %s: %v", bucketName, err)
+		}
+
 		// Apply filters
 		if c.matchesS3Filters(s3Bucket, filters) {
 			buckets = append(buckets, s3Bucket)
@@ -99,47 +121,46 @@ func (c *CustodianClient) GetS3Buckets(filters S3Filter) ([]S3Bucket, error) {
 
 // analyzeBucket performs deep analysis of a single bucket
 func (c *CustodianClient) analyzeBucket(
+	ctx context.Context,
 	bucketName string,
+	region string,
 	creationDate time.Time,
 ) (S3Bucket, error) {
-	ctx := context.Background()
-
 	bucket := S3Bucket{
 		Name:             bucketName,
+		Region:           region,
+		Account:          c.Account,
 		CreationDate:     creationDate,
 		Tags:             make(map[string]string),
 		StorageClass:     make(map[string]int64),
 		ComplianceIssues: make([]string, 0),
 	}
 
-	// Get bucket location
-	locationResult, err := c.S3.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
-		Bucket: aws.String(bucketName),
-	})
-	if err != nil {
-		bucket.Region = "us-east-1" // Default
-	} else {
-		if locationResult.LocationConstraint == "" {
-			bucket.Region = "us-east-1"
-		} else {
-			bucket.Region = string(locationResult.LocationConstraint)
-		}
-	}
-
 	// Get bucket tags
-	bucket.Tags = c.getBucketTags(bucketName)
+	var err error
+	if bucket.Tags, err = c.getBucketTags(ctx, bucketName); err != nil {
+		return bucket, err
+	}
 
 	// Analyze public access
-	c.analyzeBucketPublicAccess(&bucket)
+	if err := c.analyzeBucketPublicAccess(ctx, &bucket); err != nil {
+		return bucket, err
+	}
 
 	// Get public access block configuration
-	c.getBucketPublicAccessBlock(&bucket)
+	if err := c.getBucketPublicAccessBlock(ctx, &bucket); err != nil {
+		return bucket, err
+	}
 
 	// Get versioning status
-	c.getBucketVersioning(&bucket)
+	if err := c.getBucketVersioning(ctx, &bucket); err != nil {
+		return bucket, err
+	}
 
 	// Get encryption configuration
-	c.getBucketEncryption(&bucket)
+	if err := c.getBucketEncryption(ctx, &bucket); err != nil {
+		return bucket, err
+	}
 
 	// Get size and object count (this would be expensive for real buckets)
 	c.estimateBucketSize(&bucket)
@@ -153,36 +174,53 @@ func (c *CustodianClient) analyzeBucket(
 	return bucket, nil
 }
 
+// getBucketRegion returns the region a bucket is located in
+func (c *CustodianClient) getBucketRegion(ctx context.Context, bucketName string) (string, error) {
+	locationResult, err := c.S3.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
+		Bucket: aws.String(bucketName),
+	})
+	if lookupFailed(ctx, err) {
+		return "", fmt.Errorf("failed to get location: %v", err)
+	}
+	if err != nil || locationResult.LocationConstraint == "" {
+		return "us-east-1", nil // Default
+	}
+	return string(locationResult.LocationConstraint), nil
+}
+
 // getBucketTags retrieves bucket tags
-func (c *CustodianClient) getBucketTags(bucketName string) map[string]string {
-	ctx := context.Background()
+func (c *CustodianClient) getBucketTags(ctx context.Context, bucketName string) (map[string]string, error) {
 	tags := make(map[string]string)
 
 	result, err := c.S3.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{
 		Bucket: aws.String(bucketName),
 	})
+	if lookupFailed(ctx, err) {
+		return nil, fmt.Errorf("failed to get tags: %v", err)
+	}
 	if err != nil {
 		// No tags or access denied
-		return tags
+		return tags, nil
 	}
 
 	for _, tag := range result.TagSet {
 		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
 	}
 
-	return tags
+	return tags, nil
 }
 
 // analyzeBucketPublicAccess checks for public access via ACL
-func (c *CustodianClient) analyzeBucketPublicAccess(bucket *S3Bucket) {
-	ctx := context.Background()
-
+func (c *CustodianClient) analyzeBucketPublicAccess(ctx context.Context, bucket *S3Bucket) error {
 	// Check bucket ACL
 	aclResult, err := c.S3.GetBucketAcl(ctx, &s3.GetBucketAclInput{
 		Bucket: aws.String(bucket.Name),
 	})
+	if lookupFailed(ctx, err) {
+		return fmt.Errorf("failed to get ACL: %v", err)
+	}
 	if err != nil {
-		return
+		return nil
 	}
 
 	// Check for public access grants
@@ -202,22 +240,24 @@ func (c *CustodianClient) analyzeBucketPublicAccess(bucket *S3Bucket) {
 			}
 		}
 	}
+	return nil
 }
 
 // getBucketPublicAccessBlock gets the public access block configuration
-func (c *CustodianClient) getBucketPublicAccessBlock(bucket *S3Bucket) {
-	ctx := context.Background()
-
+func (c *CustodianClient) getBucketPublicAccessBlock(ctx context.Context, bucket *S3Bucket) error {
 	result, err := c.S3.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{
 		Bucket: aws.String(bucket.Name),
 	})
+	if lookupFailed(ctx, err) {
+		return fmt.Errorf("failed to get public access block: %v", err)
+	}
 	if err != nil {
 		// No public access block configured (more permissive)
 		bucket.BlockPublicACLs = false
 		bucket.BlockPublicPolicy = false
 		bucket.IgnorePublicACLs = false
 		bucket.RestrictPublicBuckets = false
-		return
+		return nil
 	}
 
 	config := result.PublicAccessBlockConfiguration
@@ -225,18 +265,20 @@ func (c *CustodianClient) getBucketPublicAccessBlock(bucket *S3Bucket) {
 	bucket.BlockPublicPolicy = aws.ToBool(config.BlockPublicPolicy)
 	bucket.IgnorePublicACLs = aws.ToBool(config.IgnorePublicAcls)
 	bucket.RestrictPublicBuckets = aws.ToBool(config.RestrictPublicBuckets)
+	return nil
 }
 
 // getBucketVersioning gets versioning configuration
-func (c *CustodianClient) getBucketVersioning(bucket *S3Bucket) {
-	ctx := context.Background()
-
+func (c *CustodianClient) getBucketVersioning(ctx context.Context, bucket *S3Bucket) error {
 	result, err := c.S3.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{
 		Bucket: aws.String(bucket.Name),
 	})
+	if lookupFailed(ctx, err) {
+		return fmt.Errorf("failed to get versioning: %v", err)
+	}
 	if err != nil {
 		bucket.Versioning = "Disabled"
-		return
+		return nil
 	}
 
 	if result.Status == "" {
@@ -244,18 +286,20 @@ func (c *CustodianClient) getBucketVersioning(bucket *S3Bucket) {
 	} else {
 		bucket.Versioning = string(result.Status)
 	}
+	return nil
 }
 
 // getBucketEncryption gets encryption configuration
-func (c *CustodianClient) getBucketEncryption(bucket *S3Bucket) {
-	ctx := context.Background()
-
+func (c *CustodianClient) getBucketEncryption(ctx context.Context, bucket *S3Bucket) error {
 	result, err := c.S3.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{
 		Bucket: aws.String(bucket.Name),
 	})
+	if lookupFailed(ctx, err) {
+		return fmt.Errorf("failed to get encryption: %v", err)
+	}
 	if err != nil {
 		bucket.Encryption = S3Encryption{Enabled: false}
-		return
+		return nil
 	}
 
 	if len(result.ServerSideEncryptionConfiguration.Rules) > 0 {
@@ -279,6 +323,7 @@ func (c *CustodianClient) getBucketEncryption(bucket *S3Bucket) {
 	} else {
 		bucket.Encryption = S3Encryption{Enabled: false}
 	}
+	return nil
 }
 
 // estimateBucketSize estimates bucket size and object count
@@ -465,7 +510,7 @@ func (c *CustodianClient) matchesS3Filters(bucket S3Bucket, filters S3Filter) bo
 }
 
 // BlockPublicAccess blocks public access on S3 buckets
-func (c *CustodianClient) BlockPublicAccess(bucketNames []string) (*S3ActionResult, error) {
+func (c *CustodianClient) BlockPublicAccess(ctx context.Context, bucketNames []string) (*S3ActionResult, error) {
 	c.LogAWSCall("S3", "PutPublicAccessBlock", c.DryRun)
 
 	if len(bucketNames) == 0 {
@@ -474,7 +519,6 @@ func (c *CustodianClient) BlockPublicAccess(bucketNames []string) (*S3ActionResu
 
 	fmt.Printf("🔒 Blocking public access on %d buckets...\n", len(bucketNames))
 
-	ctx := context.Background()
 	result := &S3ActionResult{
 		Action:      "block-public-access",
 		BucketNames: bucketNames,
@@ -513,8 +557,51 @@ func (c *CustodianClient) BlockPublicAccess(bucketNames []string) (*S3ActionResu
 	return result, nil
 }
 
+// SetPublicAccessBlock applies the given public access block settings to a
+// bucket, e.g. to restore the ones it had before BlockPublicAccess
+func (c *CustodianClient) SetPublicAccessBlock(
+	ctx context.Context,
+	bucketName string,
+	settings PublicAccessBlock,
+) (*S3ActionResult, error) {
+	c.LogAWSCall("S3", "PutPublicAccessBlock", c.DryRun)
+
+	result := &S3ActionResult{
+		Action:      "set-public-access-block",
+		BucketNames: []string{bucketName},
+		Success:     true,
+		DryRun:      c.DryRun,
+		Timestamp:   time.Now(),
+		Results:     make(map[string]string),
+	}
+
+	if c.DryRun {
+		result.Results[bucketName] = "would restore public access block"
+		return result, nil
+	}
+
+	_, err := c.S3.PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{
+		Bucket: aws.String(bucketName),
+		PublicAccessBlockConfiguration: &types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(settings.BlockPublicACLs),
+			BlockPublicPolicy:     aws.Bool(settings.BlockPublicPolicy),
+			IgnorePublicAcls:      aws.Bool(settings.IgnorePublicACLs),
+			RestrictPublicBuckets: aws.Bool(settings.RestrictPublicBuckets),
+		},
+	})
+	if err != nil {
+		result.Results[bucketName] = fmt.Sprintf("failed: %v", err)
+		result.Success = false
+	} else {
+		result.Results[bucketName] = "public access block restored"
+	}
+
+	return result, nil
+}
+
 // EnableEncryption enables server-side encryption on S3 buckets
 func (c *CustodianClient) EnableEncryption(
+	ctx context.Context,
 	bucketNames []string,
 	kmsKeyID string,
 ) (*S3ActionResult, error) {
@@ -526,7 +613,6 @@ func (c *CustodianClient) EnableEncryption(
 
 	fmt.Printf("🔐 Enabling encryption on %d buckets...\n", len(bucketNames))
 
-	ctx := context.Background()
 	result := &S3ActionResult{
 		Action:      "enable-encryption",
 		BucketNames: bucketNames,
@@ -585,7 +671,7 @@ func (c *CustodianClient) EnableEncryption(
 }
 
 // EnableVersioning enables versioning on S3 buckets
-func (c *CustodianClient) EnableVersioning(bucketNames []string) (*S3ActionResult, error) {
+func (c *CustodianClient) EnableVersioning(ctx context.Context, bucketNames []string) (*S3ActionResult, error) {
 	c.LogAWSCall("S3", "PutBucketVersioning", c.DryRun)
 
 	if len(bucketNames) == 0 {
@@ -594,7 +680,6 @@ func (c *CustodianClient) EnableVersioning(bucketNames []string) (*S3ActionResul
 
 	fmt.Printf("📚 Enabling versioning on %d buckets...\n", len(bucketNames))
 
-	ctx := context.Background()
 	result := &S3ActionResult{
 		Action:      "enable-versioning",
 		BucketNames: bucketNames,
@@ -630,8 +715,118 @@ func (c *CustodianClient) EnableVersioning(bucketNames []string) (*S3ActionResul
 	return result, nil
 }
 
+// SuspendVersioning suspends versioning on S3 buckets. A bucket that has
+// had versioning enabled can't go back to unversioned; suspended is as close
+// as S3 allows.
+func (c *CustodianClient) SuspendVersioning(ctx context.Context, bucketNames []string) (*S3ActionResult, error) {
+	c.LogAWSCall("S3", "PutBucketVersioning", c.DryRun)
+
+	if len(bucketNames) == 0 {
+		return &S3ActionResult{}, nil
+	}
+
+	fmt.Printf("📚 Suspending versioning on %d buckets...\n", len(bucketNames))
+
+	result := &S3ActionResult{
+		Action:      "suspend-versioning",
+		BucketNames: bucketNames,
+		Success:     true,
+		DryRun:      c.DryRun,
+		Timestamp:   time.Now(),
+		Results:     make(map[string]string),
+	}
+
+	for _, bucketName := range bucketNames {
+		if c.DryRun {
+			result.Results[bucketName] = "would suspend versioning"
+			continue
+		}
+
+		_, err := c.S3.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+			Bucket: aws.String(bucketName),
+			VersioningConfiguration: &types.VersioningConfiguration{
+				Status: types.BucketVersioningStatusSuspended,
+			},
+		})
+		if err != nil {
+			result.Results[bucketName] = fmt.Sprintf("failed: %v", err)
+			result.Success = false
+		} else {
+			result.Results[bucketName] = "versioning suspended"
+		}
+	}
+
+	fmt.Printf("✅ Versioning operation completed\n")
+	return result, nil
+}
+
+// RestoreBucketTags sets tag values and removes tag keys on a bucket, keeping
+// its other tags. With no tags left the tag set is deleted.
+func (c *CustodianClient) RestoreBucketTags(
+	ctx context.Context,
+	bucketName string,
+	set map[string]string,
+	remove []string,
+) (*S3ActionResult, error) {
+	c.LogAWSCall("S3", "PutBucketTagging", c.DryRun)
+
+	result := &S3ActionResult{
+		Action:      "restore-tags",
+		BucketNames: []string{bucketName},
+		Success:     true,
+		DryRun:      c.DryRun,
+		Timestamp:   time.Now(),
+		Results:     make(map[string]string),
+		Tags:        set,
+	}
+
+	if c.DryRun {
+		result.Results[bucketName] = fmt.Sprintf("would restore %d tags and remove %d", len(set), len(remove))
+		return result, nil
+	}
+
+	// Writing a tag set over tags we couldn't read would lose them
+	tags, err := c.getBucketTags(ctx, bucketName)
+	if err != nil {
+		result.Results[bucketName] = fmt.Sprintf("failed: %v", err)
+		result.Success = false
+		return result, nil
+	}
+	for _, key := range remove {
+		delete(tags, key)
+	}
+	for key, value := range set {
+		tags[key] = value
+	}
+
+	if len(tags) == 0 {
+		_, err = c.S3.DeleteBucketTagging(ctx, &s3.DeleteBucketTaggingInput{
+			Bucket: aws.String(bucketName),
+		})
+	} else {
+		var s3Tags []types.Tag
+		for key, value := range tags {
+			s3Tags = append(s3Tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+		_, err = c.S3.PutBucketTagging(ctx, &s3.PutBucketTaggingInput{
+			Bucket:  aws.String(bucketName),
+			Tagging: &types.Tagging{TagSet: s3Tags},
+		})
+	}
+
+	if err != nil {
+		result.Results[bucketName] = fmt.Sprintf("failed: %v", err)
+		result.Success = false
+	} else {
+		result.Results[bucketName] = fmt.Sprintf("restored %d tags, removed %d", len(set), len(remove))
+	}
+
+	return result, nil
+}
+
 // TagBuckets adds tags to S3 buckets
 func (c *CustodianClient) TagBuckets(
+	ctx context.Context,
 	bucketNames []string,
 	tags map[string]string,
 ) (*S3ActionResult, error) {
@@ -643,7 +838,6 @@ func (c *CustodianClient) TagBuckets(
 
 	fmt.Printf("🏷️  Adding %d tags to %d buckets...\n", len(tags), len(bucketNames))
 
-	ctx := context.Background()
 	result := &S3ActionResult{
 		Action:      "tag",
 		BucketNames: bucketNames,
@@ -690,7 +884,7 @@ func (c *CustodianClient) TagBuckets(
 }
 
 // DeleteBuckets deletes S3 buckets (dangerous operation!)
-func (c *CustodianClient) DeleteBuckets(bucketNames []string, force bool) (*S3ActionResult, error) {
+func (c *CustodianClient) DeleteBuckets(ctx context.Context, bucketNames []string, force bool) (*S3ActionResult, error) {
 	c.LogAWSCall("S3", "DeleteBucket", c.DryRun)
 
 	if len(bucketNames) == 0 {
@@ -702,7 +896,6 @@ func (c *CustodianClient) DeleteBuckets(bucketNames []string, force bool) (*S3Ac
 		fmt.Println("⚠️  WARNING: This action is IRREVERSIBLE!")
 	}
 
-	ctx := context.Background()
 	result := &S3ActionResult{
 		Action:      "delete",
 		BucketNames: bucketNames,
@@ -720,7 +913,7 @@ func (c *CustodianClient) DeleteBuckets(bucketNames []string, force bool) (*S3Ac
 
 		// First, try to empty the bucket if force is enabled
 		if force {
-			if err := c.emptyBucket(bucketName); err != nil {
+			if err := c.emptyBucket(ctx, bucketName); err != nil {
 				result.Results[bucketName] = fmt.Sprintf("failed to empty: %v", err)
 				result.Success = false
 				continue
@@ -744,11 +937,9 @@ func (c *CustodianClient) DeleteBuckets(bucketNames []string, force bool) (*S3Ac
 }
 
 // emptyBucket removes all objects from a bucket
-func (c *CustodianClient) emptyBucket(bucketName string) error {
+func (c *CustodianClient) emptyBucket(ctx context.Context, bucketName string) error {
 	fmt.Printf("🗑️  Emptying bucket: %s\n", bucketName)
 
-	ctx := context.Background()
-
 	// List and delete objects in batches
 	paginator := s3.NewListObjectsV2Paginator(c.S3, &s3.ListObjectsV2Input{
 		Bucket: aws.String(bucketName),
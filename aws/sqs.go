@@ -0,0 +1,120 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// maxSQSWait is the longest SQS will hold a receive open
+const maxSQSWait = 20 * time.Second
+
+// QueueMessage is a message received from an SQS queue
+type QueueMessage struct {
+	MessageID     string `json:"message_id"`
+	ReceiptHandle string `json:"receipt_handle"`
+	Body          string `json:"body"`
+}
+
+// ReceiveMessages waits up to wait (at most 20s) for messages on a queue and
+// returns up to 10 of them. They stay hidden for the queue's visibility timeout
+// and come back unless deleted.
+func (c *CustodianClient) ReceiveMessages(ctx context.Context, queueURL string, wait time.Duration) ([]QueueMessage, error) {
+	if c.SQS == nil {
+		return nil, fmt.Errorf("SQS isn't available with this client")
+	}
+	if wait > maxSQSWait {
+		wait = maxSQSWait
+	}
+	c.LogAWSCall("SQS", "ReceiveMessage", false)
+
+	ctx, cancel := context.WithTimeout(ctx, wait+30*time.Second)
+	defer cancel()
+
+	output, err := c.SQS.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueURL),
+		MaxNumberOfMessages: 10,
+		WaitTimeSeconds:     int32(wait / time.Second),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive messages: %v", err)
+	}
+
+	messages := make([]QueueMessage, 0, len(output.Messages))
+	for _, message := range output.Messages {
+		messages = append(messages, QueueMessage{
+			MessageID:     aws.ToString(message.MessageId),
+			ReceiptHandle: aws.ToString(message.ReceiptHandle),
+			Body:          aws.ToString(message.Body),
+		})
+	}
+	return messages, nil
+}
+
+// DeleteMessages removes handled messages from a queue, ten at a time. In
+// dry-run mode nothing is deleted and the messages come back once the queue's
+// visibility timeout passes.
+func (c *CustodianClient) DeleteMessages(ctx context.Context, queueURL string, receiptHandles []string) error {
+	if c.SQS == nil {
+		return fmt.Errorf("SQS isn't available with this client")
+	}
+	if c.DryRun {
+		c.LogAWSCall("SQS", "DeleteMessageBatch", true)
+		return nil
+	}
+
+	var failures []string
+	for start := 0; start < len(receiptHandles); start += 10 {
+		end := start + 10
+		if end > len(receiptHandles) {
+			end = len(receiptHandles)
+		}
+
+		entries := make([]types.DeleteMessageBatchRequestEntry, 0, end-start)
+		for i, handle := range receiptHandles[start:end] {
+			entries = append(entries, types.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(start + i)),
+				ReceiptHandle: aws.String(handle),
+			})
+		}
+
+		c.LogAWSCall("SQS", "DeleteMessageBatch", false)
+		callCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		output, err := c.SQS.DeleteMessageBatch(callCtx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(queueURL),
+			Entries:  entries,
+		})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to delete messages: %v", err)
+		}
+		for _, failed := range output.Failed {
+			failures = append(failures, fmt.Sprintf("%s: %s", aws.ToString(failed.Code), aws.ToString(failed.Message)))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to delete %d messages: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// QueueRegion returns the region in an SQS queue URL such as
+// https://sqs.eu-west-1.amazonaws.com/123456789012/events, or "" for other hosts
+func QueueRegion(queueURL string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(queueURL, "https://"), "http://")
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	parts := strings.Split(host, ".")
+	if len(parts) >= 4 && parts[0] == "sqs" && parts[2] == "amazonaws" {
+		return parts[1]
+	}
+	return ""
+}
@@ -0,0 +1,371 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// DefaultRates are the calls per second each service is held to, kept well
+// under the API limits AWS applies per account and region
+var DefaultRates = map[string]float64{
+	"EC2":    20,
+	"S3":     50,
+	"RDS":    10,
+	"Lambda": 10,
+	"STS":    10,
+	"SQS":    50,
+}
+
+// ParseRates reads SERVICE=CALLS_PER_SECOND settings such as "s3=20". A rate
+// of 0 turns off the limit for that service.
+func ParseRates(specs []string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, spec := range specs {
+		name, value, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate '%s' should look like SERVICE=CALLS_PER_SECOND", spec)
+		}
+
+		service := ""
+		for known := range DefaultRates {
+			if strings.EqualFold(known, strings.TrimSpace(name)) {
+				service = known
+			}
+		}
+		if service == "" {
+			return nil, fmt.Errorf("unknown service '%s' in rate '%s'", name, spec)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid rate '%s' for %s", value, service)
+		}
+		rates[service] = rate
+	}
+	return rates, nil
+}
+
+// RetryConfig controls how calls to AWS are paced and retried
+type RetryConfig struct {
+	MaxAttempts int                // tries per call, counting the first; defaults to 8
+	BaseDelay   time.Duration      // first backoff, doubled on each retry; defaults to 250ms
+	MaxDelay    time.Duration      // longest backoff; defaults to 20s
+	Rates       map[string]float64 // calls per second by service, overriding DefaultRates
+	Disabled    bool               // send calls straight through, e.g. when replaying a recording
+}
+
+// ServiceStats counts the calls made to one service
+type ServiceStats struct {
+	Calls     int           `json:"calls"`            // requests sent, retries included
+	Retries   int           `json:"retries"`          // requests sent again after a failure
+	Throttled int           `json:"throttled"`        // throttling responses from AWS
+	GaveUp    int           `json:"gave_up"`          // calls that still failed after every attempt
+	Waited    time.Duration `json:"waited,omitempty"` // time spent held by the rate limit or backing off
+}
+
+// CallStats collects ServiceStats for a run. Attach it to a context with
+// WithCallStats; every call made with that context is counted in it.
+type CallStats struct {
+	mu       sync.Mutex
+	services map[string]*ServiceStats
+}
+
+// NewCallStats creates empty call stats
+func NewCallStats() *CallStats {
+	return &CallStats{services: make(map[string]*ServiceStats)}
+}
+
+type callStatsKey struct{}
+
+// WithCallStats returns a context whose AWS calls are counted in stats
+func WithCallStats(ctx context.Context, stats *CallStats) context.Context {
+	return context.WithValue(ctx, callStatsKey{}, stats)
+}
+
+// Snapshot returns the counts so far by service, or nil when nothing was called
+func (s *CallStats) Snapshot() map[string]ServiceStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.services) == 0 {
+		return nil
+	}
+	snapshot := make(map[string]ServiceStats, len(s.services))
+	for service, stats := range s.services {
+		snapshot[service] = *stats
+	}
+	return snapshot
+}
+
+func (s *CallStats) add(service string, update func(*ServiceStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.services[service]
+	if !ok {
+		stats = &ServiceStats{}
+		s.services[service] = stats
+	}
+	update(stats)
+}
+
+// TotalCalls adds up stats across services
+func TotalCalls(stats map[string]ServiceStats) ServiceStats {
+	var total ServiceStats
+	for _, s := range stats {
+		total.Calls += s.Calls
+		total.Retries += s.Retries
+		total.Throttled += s.Throttled
+		total.GaveUp += s.GaveUp
+		total.Waited += s.Waited
+	}
+	return total
+}
+
+// ServicesByName returns the services in stats in a stable order
+func ServicesByName(stats map[string]ServiceStats) []string {
+	services := make([]string, 0, len(stats))
+	for service := range stats {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Throttle paces and retries the calls of every client it wraps. One
+// throttle is shared by all regions of a CustodianClient, so concurrent
+// policies draw on the same budget for each service in each region.
+type Throttle struct {
+	config RetryConfig
+
+	mu      sync.Mutex
+	buckets map[string]map[string]*tokenBucket // by region, then service
+}
+
+// NewThrottle creates a throttle, filling in defaults for unset fields
+func NewThrottle(cfg RetryConfig) *Throttle {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = 250 * time.Millisecond
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = 20 * time.Second
+	}
+
+	return &Throttle{
+		config:  cfg,
+		buckets: make(map[string]map[string]*tokenBucket),
+	}
+}
+
+// regionBuckets returns the token buckets for a region's services, since AWS
+// applies its API limits per region
+func (t *Throttle) regionBuckets(region string) map[string]*tokenBucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	buckets, ok := t.buckets[region]
+	if !ok {
+		buckets = make(map[string]*tokenBucket, len(DefaultRates))
+		for service, rate := range DefaultRates {
+			if override, ok := t.config.Rates[service]; ok {
+				rate = override
+			}
+			buckets[service] = newTokenBucket(rate)
+		}
+		t.buckets[region] = buckets
+	}
+	return buckets
+}
+
+// Wrap puts the throttle in front of each of a region's service clients.
+// It matches ClientConfig.WrapAPIs.
+func (t *Throttle) Wrap(region string, apis ServiceAPIs) ServiceAPIs {
+	if t.config.Disabled {
+		return apis
+	}
+	buckets := t.regionBuckets(region)
+	return ServiceAPIs{
+		EC2:    &throttledEC2{throttle: t, bucket: buckets["EC2"], next: apis.EC2},
+		S3:     &throttledS3{throttle: t, bucket: buckets["S3"], next: apis.S3},
+		RDS:    &throttledRDS{throttle: t, bucket: buckets["RDS"], next: apis.RDS},
+		Lambda: &throttledLambda{throttle: t, bucket: buckets["Lambda"], next: apis.Lambda},
+		STS:    &throttledSTS{throttle: t, bucket: buckets["STS"], next: apis.STS},
+		SQS:    &throttledSQS{throttle: t, bucket: buckets["SQS"], next: apis.SQS},
+	}
+}
+
+// record counts an event in the stats of the run ctx belongs to, if any
+func record(ctx context.Context, service string, update func(*ServiceStats)) {
+	if stats, ok := ctx.Value(callStatsKey{}).(*CallStats); ok {
+		stats.add(service, update)
+	}
+}
+
+// backoff picks a delay between half and all of BaseDelay, doubled for each
+// earlier retry and capped at MaxDelay
+func (t *Throttle) backoff(retries int) time.Duration {
+	limit := float64(t.config.BaseDelay) * math.Pow(2, float64(retries-1))
+	if limit > float64(t.config.MaxDelay) {
+		limit = float64(t.config.MaxDelay)
+	}
+	return time.Duration(limit/2 + rand.Float64()*limit/2)
+}
+
+// throttled makes one call to a service, waiting for the service's rate limit
+// and retrying throttling and transient errors with backoff
+func throttled[Out any](
+	ctx context.Context,
+	t *Throttle,
+	bucket *tokenBucket,
+	service string,
+	call func() (*Out, error),
+) (*Out, error) {
+	for attempt := 1; ; attempt++ {
+		waited, err := bucket.take(ctx)
+		record(ctx, service, func(s *ServiceStats) { s.Waited += waited })
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := call()
+		throttle := IsThrottleError(err)
+		record(ctx, service, func(s *ServiceStats) {
+			s.Calls++
+			if throttle {
+				s.Throttled++
+			}
+		})
+		if err == nil {
+			bucket.speedUp()
+			return out, nil
+		}
+		if throttle {
+			bucket.slowDown()
+		}
+
+		if !throttle && !isTransientError(err) {
+			return nil, err
+		}
+		if attempt >= t.config.MaxAttempts || ctx.Err() != nil {
+			record(ctx, service, func(s *ServiceStats) { s.GaveUp++ })
+			return nil, err
+		}
+
+		delay := t.backoff(attempt)
+		record(ctx, service, func(s *ServiceStats) {
+			s.Retries++
+			s.Waited += delay
+		})
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
+	}
+}
+
+// IsThrottleError reports whether AWS turned a call away for going too fast
+func IsThrottleError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary
+}
+
+// isTransientError reports whether a call failed in a way worth trying again,
+// such as a 5xx response or a dropped connection
+func isTransientError(err error) bool {
+	return retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary
+}
+
+// lookupFailed reports whether a lookup error leaves the answer unknown, as
+// opposed to the thing not existing or not being readable with our permissions
+func lookupFailed(ctx context.Context, err error) bool {
+	return err != nil && (ctx.Err() != nil || IsThrottleError(err) || isTransientError(err))
+}
+
+// tokenBucket lets calls through at a steady rate. Throttling responses halve
+// the rate; each success wins back a little of it.
+type tokenBucket struct {
+	mu     sync.Mutex
+	limit  float64 // configured calls per second
+	rate   float64 // current calls per second
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	return &tokenBucket{limit: rate, rate: rate, tokens: burst(rate), last: time.Now()}
+}
+
+// burst is how many calls can go out back to back at a rate
+func burst(rate float64) float64 {
+	return math.Max(1, rate)
+}
+
+// take waits for a token and returns how long that took. A bucket with no
+// positive rate never waits.
+func (b *tokenBucket) take(ctx context.Context) (time.Duration, error) {
+	if b == nil || b.limit <= 0 {
+		return 0, nil
+	}
+
+	start := time.Now()
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = math.Min(burst(b.rate), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return time.Since(start), nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return time.Since(start), ctx.Err()
+		}
+	}
+}
+
+// slowDown halves the rate, down to a tenth of the configured limit
+func (b *tokenBucket) slowDown() {
+	if b == nil || b.limit <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rate = math.Max(b.limit/10, b.rate/2)
+	b.tokens = math.Min(b.tokens, 0)
+}
+
+// speedUp raises the rate back toward the configured limit
+func (b *tokenBucket) speedUp() {
+	if b == nil || b.limit <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rate = math.Min(b.limit, b.rate+b.limit/20)
+}
@@ -0,0 +1,222 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+type throttledEC2 struct {
+	throttle *Throttle
+	bucket   *tokenBucket
+	next     EC2API
+}
+
+func (c *throttledEC2) DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "EC2", func() (*ec2.DescribeRegionsOutput, error) {
+		return c.next.DescribeRegions(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "EC2", func() (*ec2.DescribeInstancesOutput, error) {
+		return c.next.DescribeInstances(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledEC2) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "EC2", func() (*ec2.DescribeVolumesOutput, error) {
+		return c.next.DescribeVolumes(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledEC2) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "EC2", func() (*ec2.StopInstancesOutput, error) {
+		return c.next.StopInstances(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledEC2) StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "EC2", func() (*ec2.StartInstancesOutput, error) {
+		return c.next.StartInstances(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledEC2) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "EC2", func() (*ec2.TerminateInstancesOutput, error) {
+		return c.next.TerminateInstances(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledEC2) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "EC2", func() (*ec2.CreateTagsOutput, error) {
+		return c.next.CreateTags(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledEC2) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "EC2", func() (*ec2.DeleteTagsOutput, error) {
+		return c.next.DeleteTags(ctx, params, optFns...)
+	})
+}
+
+type throttledS3 struct {
+	throttle *Throttle
+	bucket   *tokenBucket
+	next     S3API
+}
+
+func (c *throttledS3) ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.ListBucketsOutput, error) {
+		return c.next.ListBuckets(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) GetBucketLocation(ctx context.Context, params *s3.GetBucketLocationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.GetBucketLocationOutput, error) {
+		return c.next.GetBucketLocation(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) GetBucketTagging(ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.GetBucketTaggingOutput, error) {
+		return c.next.GetBucketTagging(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) GetBucketAcl(ctx context.Context, params *s3.GetBucketAclInput, optFns ...func(*s3.Options)) (*s3.GetBucketAclOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.GetBucketAclOutput, error) {
+		return c.next.GetBucketAcl(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) GetPublicAccessBlock(ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.GetPublicAccessBlockOutput, error) {
+		return c.next.GetPublicAccessBlock(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) GetBucketVersioning(ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.GetBucketVersioningOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.GetBucketVersioningOutput, error) {
+		return c.next.GetBucketVersioning(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) GetBucketEncryption(ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.GetBucketEncryptionOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.GetBucketEncryptionOutput, error) {
+		return c.next.GetBucketEncryption(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) PutPublicAccessBlock(ctx context.Context, params *s3.PutPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.PutPublicAccessBlockOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.PutPublicAccessBlockOutput, error) {
+		return c.next.PutPublicAccessBlock(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) PutBucketEncryption(ctx context.Context, params *s3.PutBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.PutBucketEncryptionOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.PutBucketEncryptionOutput, error) {
+		return c.next.PutBucketEncryption(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) PutBucketVersioning(ctx context.Context, params *s3.PutBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.PutBucketVersioningOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.PutBucketVersioningOutput, error) {
+		return c.next.PutBucketVersioning(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) PutBucketTagging(ctx context.Context, params *s3.PutBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.PutBucketTaggingOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.PutBucketTaggingOutput, error) {
+		return c.next.PutBucketTagging(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) DeleteBucketTagging(ctx context.Context, params *s3.DeleteBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketTaggingOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.DeleteBucketTaggingOutput, error) {
+		return c.next.DeleteBucketTagging(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) DeleteBucket(ctx context.Context, params *s3.DeleteBucketInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.DeleteBucketOutput, error) {
+		return c.next.DeleteBucket(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.ListObjectsV2Output, error) {
+		return c.next.ListObjectsV2(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledS3) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "S3", func() (*s3.DeleteObjectsOutput, error) {
+		return c.next.DeleteObjects(ctx, params, optFns...)
+	})
+}
+
+type throttledRDS struct {
+	throttle *Throttle
+	bucket   *tokenBucket
+	next     RDSAPI
+}
+
+func (c *throttledRDS) DescribeDBInstances(ctx context.Context, params *rds.DescribeDBInstancesInput, optFns ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "RDS", func() (*rds.DescribeDBInstancesOutput, error) {
+		return c.next.DescribeDBInstances(ctx, params, optFns...)
+	})
+}
+
+type throttledLambda struct {
+	throttle *Throttle
+	bucket   *tokenBucket
+	next     LambdaAPI
+}
+
+func (c *throttledLambda) ListFunctions(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "Lambda", func() (*lambda.ListFunctionsOutput, error) {
+		return c.next.ListFunctions(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledLambda) ListTags(ctx context.Context, params *lambda.ListTagsInput, optFns ...func(*lambda.Options)) (*lambda.ListTagsOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "Lambda", func() (*lambda.ListTagsOutput, error) {
+		return c.next.ListTags(ctx, params, optFns...)
+	})
+}
+
+type throttledSTS struct {
+	throttle *Throttle
+	bucket   *tokenBucket
+	next     STSAPI
+}
+
+func (c *throttledSTS) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "STS", func() (*sts.GetCallerIdentityOutput, error) {
+		return c.next.GetCallerIdentity(ctx, params, optFns...)
+	})
+}
+
+type throttledSQS struct {
+	throttle *Throttle
+	bucket   *tokenBucket
+	next     SQSAPI
+}
+
+func (c *throttledSQS) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "SQS", func() (*sqs.ReceiveMessageOutput, error) {
+		return c.next.ReceiveMessage(ctx, params, optFns...)
+	})
+}
+
+func (c *throttledSQS) DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	return throttled(ctx, c.throttle, c.bucket, "SQS", func() (*sqs.DeleteMessageBatchOutput, error) {
+		return c.next.DeleteMessageBatch(ctx, params, optFns...)
+	})
+}
@@ -0,0 +1,435 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// statusError is a failed HTTP response, as the SDK reports it
+type statusError struct{ code int }
+
+func (e statusError) Error() string       { return fmt.Sprintf("http %d", e.code) }
+func (e statusError) HTTPStatusCode() int { return e.code }
+
+func apiError(code string) error {
+	return &smithy.GenericAPIError{Code: code, Message: code}
+}
+
+func TestParseRates(t *testing.T) {
+	tests := []struct {
+		specs   []string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{specs: nil, want: map[string]float64{}},
+		{specs: []string{"s3=20", " ec2 = 5.5 "}, want: map[string]float64{"S3": 20, "EC2": 5.5}},
+		{specs: []string{"lambda=0"}, want: map[string]float64{"Lambda": 0}},
+		{specs: []string{"s3"}, wantErr: true},
+		{specs: []string{"dynamodb=5"}, wantErr: true},
+		{specs: []string{"s3=fast"}, wantErr: true},
+		{specs: []string{"s3=-1"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseRates(test.specs)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseRates(%q) error = %v, wantErr %v", test.specs, err, test.wantErr)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseRates(%q) = %v, want %v", test.specs, got, test.want)
+		}
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantThrottle  bool
+		wantTransient bool
+	}{
+		{name: "no error"},
+		{name: "Throttling", err: apiError("Throttling"), wantThrottle: true, wantTransient: true},
+		{name: "RequestLimitExceeded", err: apiError("RequestLimitExceeded"), wantThrottle: true, wantTransient: true},
+		{name: "SlowDown", err: apiError("SlowDown"), wantThrottle: true, wantTransient: true},
+		{name: "wrapped throttle", err: fmt.Errorf("describe: %w", apiError("ThrottlingException")), wantThrottle: true, wantTransient: true},
+		{name: "request timeout", err: apiError("RequestTimeout"), wantTransient: true},
+		{name: "503", err: statusError{503}, wantTransient: true},
+		{name: "404", err: statusError{404}},
+		{name: "access denied", err: apiError("AccessDenied")},
+		{name: "plain error", err: errors.New("boom")},
+		{name: "cancelled", err: context.Canceled},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsThrottleError(test.err); got != test.wantThrottle {
+				t.Errorf("IsThrottleError = %v, want %v", got, test.wantThrottle)
+			}
+			if test.err == nil {
+				return
+			}
+			if got := isTransientError(test.err); got != test.wantTransient {
+				t.Errorf("isTransientError = %v, want %v", got, test.wantTransient)
+			}
+		})
+	}
+}
+
+func TestLookupFailed(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "no error", ctx: context.Background()},
+		{name: "not found", ctx: context.Background(), err: apiError("NoSuchBucket")},
+		{name: "access denied", ctx: context.Background(), err: apiError("AccessDenied")},
+		{name: "throttled", ctx: context.Background(), err: apiError("Throttling"), want: true},
+		{name: "server error", ctx: context.Background(), err: statusError{500}, want: true},
+		{name: "cancelled", ctx: cancelled, err: errors.New("request cancelled"), want: true},
+	}
+
+	for _, test := range tests {
+		if got := lookupFailed(test.ctx, test.err); got != test.want {
+			t.Errorf("%s: lookupFailed = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	throttle := NewThrottle(RetryConfig{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond})
+
+	tests := []struct {
+		retries int
+		limit   time.Duration
+	}{
+		{retries: 1, limit: 10 * time.Millisecond},
+		{retries: 2, limit: 20 * time.Millisecond},
+		{retries: 3, limit: 40 * time.Millisecond},
+		{retries: 4, limit: 50 * time.Millisecond},
+		{retries: 10, limit: 50 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 50; i++ {
+			if delay := throttle.backoff(test.retries); delay < test.limit/2 || delay > test.limit {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", test.retries, delay, test.limit/2, test.limit)
+				break
+			}
+		}
+	}
+}
+
+func TestNewThrottleDefaults(t *testing.T) {
+	throttle := NewThrottle(RetryConfig{})
+	want := RetryConfig{MaxAttempts: 8, BaseDelay: 250 * time.Millisecond, MaxDelay: 20 * time.Second}
+	if !reflect.DeepEqual(throttle.config, want) {
+		t.Errorf("config = %+v, want %+v", throttle.config, want)
+	}
+}
+
+func TestTokenBucketTake(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     float64
+		takes    int
+		minWait  time.Duration // total wait across every take
+		maxWait  time.Duration
+		cancelAt int // cancel the context before this take; 0 never does
+	}{
+		{name: "burst goes straight through", rate: 50, takes: 50, maxWait: 10 * time.Millisecond},
+		{name: "past the burst waits for tokens", rate: 50, takes: 55, minWait: 80 * time.Millisecond, maxWait: 300 * time.Millisecond},
+		{name: "slow rates allow one call at a time", rate: 0.5, takes: 1, maxWait: 10 * time.Millisecond},
+		{name: "no limit never waits", rate: 0, takes: 1000, maxWait: 10 * time.Millisecond},
+		{name: "cancelled while waiting", rate: 1, takes: 2, cancelAt: 2, maxWait: 100 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bucket := newTokenBucket(test.rate)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var total time.Duration
+			for i := 1; i <= test.takes; i++ {
+				if i == test.cancelAt {
+					cancel()
+				}
+				waited, err := bucket.take(ctx)
+				total += waited
+				if wantErr := i == test.cancelAt; (err != nil) != wantErr {
+					t.Fatalf("take %d: error = %v, want error %v", i, err, wantErr)
+				}
+			}
+			if total < test.minWait || total > test.maxWait {
+				t.Errorf("waited %v, want between %v and %v", total, test.minWait, test.maxWait)
+			}
+		})
+	}
+}
+
+func TestTokenBucketAdapts(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps string // s slows down, f speeds up
+		want  float64
+	}{
+		{name: "throttling halves the rate", steps: "s", want: 50},
+		{name: "down to a tenth of the limit", steps: "ssssss", want: 10},
+		{name: "successes win the rate back", steps: "sff", want: 60},
+		{name: "never above the limit", steps: "sffffffffffffff", want: 100},
+		{name: "successes at the limit change nothing", steps: "ff", want: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bucket := newTokenBucket(100)
+			for _, step := range test.steps {
+				if step == 's' {
+					bucket.slowDown()
+				} else {
+					bucket.speedUp()
+				}
+			}
+			if bucket.rate != test.want {
+				t.Errorf("rate = %v, want %v", bucket.rate, test.want)
+			}
+		})
+	}
+
+	// Throttling also empties the bucket, so the next call waits
+	bucket := newTokenBucket(100)
+	bucket.slowDown()
+	if waited, _ := bucket.take(context.Background()); waited < 10*time.Millisecond {
+		t.Errorf("first take after throttling waited %v, want about 20ms", waited)
+	}
+
+	// A bucket without a limit ignores both
+	unlimited := newTokenBucket(0)
+	unlimited.slowDown()
+	unlimited.speedUp()
+	if unlimited.rate != 0 {
+		t.Errorf("unlimited rate = %v, want 0", unlimited.rate)
+	}
+}
+
+func TestThrottledRetries(t *testing.T) {
+	throttleErr := apiError("Throttling")
+	transientErr := statusError{503}
+	deniedErr := apiError("AccessDenied")
+
+	tests := []struct {
+		name      string
+		responses []error // one per attempt; the last repeats
+		wantErr   error
+		want      ServiceStats
+		slowed    bool // the bucket's rate was lowered
+	}{
+		{
+			name:      "success",
+			responses: []error{nil},
+			want:      ServiceStats{Calls: 1},
+		},
+		{
+			name:      "throttled then success",
+			responses: []error{throttleErr, nil},
+			want:      ServiceStats{Calls: 2, Retries: 1, Throttled: 1},
+			slowed:    true,
+		},
+		{
+			name:      "transient errors are retried without slowing down",
+			responses: []error{transientErr, transientErr, nil},
+			want:      ServiceStats{Calls: 3, Retries: 2},
+		},
+		{
+			name:      "other errors are returned at once",
+			responses: []error{deniedErr},
+			wantErr:   deniedErr,
+			want:      ServiceStats{Calls: 1},
+		},
+		{
+			name:      "gives up after MaxAttempts",
+			responses: []error{throttleErr},
+			wantErr:   throttleErr,
+			want:      ServiceStats{Calls: 3, Retries: 2, Throttled: 3, GaveUp: 1},
+			slowed:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			throttle := NewThrottle(RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond})
+			bucket := newTokenBucket(1000)
+			stats := NewCallStats()
+			ctx := WithCallStats(context.Background(), stats)
+
+			attempts := 0
+			out, err := throttled(ctx, throttle, bucket, "EC2", func() (*string, error) {
+				response := test.responses[min(attempts, len(test.responses)-1)]
+				attempts++
+				if response != nil {
+					return nil, response
+				}
+				done := "done"
+				return &done, nil
+			})
+
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("error = %v, want %v", err, test.wantErr)
+			}
+			if err == nil && (out == nil || *out != "done") {
+				t.Errorf("out = %v, want the call's result", out)
+			}
+
+			got := stats.Snapshot()["EC2"]
+			if test.want.Retries > 0 && got.Waited <= 0 {
+				t.Errorf("waited = %v after %d retries", got.Waited, got.Retries)
+			}
+			got.Waited = 0
+			if got != test.want {
+				t.Errorf("stats = %+v, want %+v", got, test.want)
+			}
+			if slowed := bucket.rate < bucket.limit; slowed != test.slowed {
+				t.Errorf("rate = %v of %v, want slowed %v", bucket.rate, bucket.limit, test.slowed)
+			}
+		})
+	}
+}
+
+func TestThrottledStopsWhenCancelled(t *testing.T) {
+	throttle := NewThrottle(RetryConfig{MaxAttempts: 5, BaseDelay: time.Minute, MaxDelay: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := throttled(ctx, throttle, newTokenBucket(0), "S3", func() (*string, error) {
+		return nil, apiError("SlowDown")
+	})
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("returned %v after %v, want the throttling error soon after cancelling", err, time.Since(start))
+	}
+}
+
+func TestThrottleBuckets(t *testing.T) {
+	throttle := NewThrottle(RetryConfig{Rates: map[string]float64{"S3": 5, "EC2": 0}})
+
+	east := throttle.regionBuckets("us-east-1")
+	if again := throttle.regionBuckets("us-east-1"); !reflect.DeepEqual(east, again) || east["S3"] != again["S3"] {
+		t.Error("a region's buckets differ between clients")
+	}
+	if west := throttle.regionBuckets("us-west-2"); west["S3"] == east["S3"] {
+		t.Error("regions share a bucket")
+	}
+
+	limits := map[string]float64{}
+	for service, bucket := range east {
+		limits[service] = bucket.limit
+	}
+	want := map[string]float64{"EC2": 0, "S3": 5, "RDS": 10, "Lambda": 10, "STS": 10, "SQS": 50}
+	if !reflect.DeepEqual(limits, want) {
+		t.Errorf("limits = %v, want %v", limits, want)
+	}
+
+	// Wrapped clients draw on the region's buckets
+	wrapped := throttle.Wrap("us-east-1", ServiceAPIs{})
+	if s3 := wrapped.S3.(*throttledS3); s3.bucket != east["S3"] || s3.throttle != throttle {
+		t.Error("wrapped S3 client doesn't use the region's bucket")
+	}
+
+	// A disabled throttle hands the clients back untouched
+	apis := ServiceAPIs{}
+	if got := NewThrottle(RetryConfig{Disabled: true}).Wrap("us-east-1", apis); !reflect.DeepEqual(got, apis) {
+		t.Errorf("disabled throttle wrapped the clients: %+v", got)
+	}
+}
+
+// Concurrent policy runs share one budget per service and region; runs in
+// other regions don't slow them down
+func TestThrottleSharedBetweenRuns(t *testing.T) {
+	tests := []struct {
+		name    string
+		regions []string // one run per entry
+		calls   int      // each run makes this many calls
+		minTime time.Duration
+		maxTime time.Duration
+	}{
+		{
+			name:    "runs in one region wait for each other",
+			regions: []string{"us-east-1", "us-east-1", "us-east-1", "us-east-1"},
+			calls:   30, // 120 calls against a burst of 100 at 100 a second
+			minTime: 150 * time.Millisecond,
+			maxTime: 2 * time.Second,
+		},
+		{
+			name:    "runs in different regions don't",
+			regions: []string{"us-east-1", "us-west-2", "eu-west-1", "ap-south-1"},
+			calls:   30,
+			maxTime: 100 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			throttle := NewThrottle(RetryConfig{Rates: map[string]float64{"EC2": 100}})
+			stats := NewCallStats()
+			ctx := WithCallStats(context.Background(), stats)
+
+			start := time.Now()
+			var wg sync.WaitGroup
+			for _, region := range test.regions {
+				wg.Add(1)
+				go func(bucket *tokenBucket) {
+					defer wg.Done()
+					for i := 0; i < test.calls; i++ {
+						throttled(ctx, throttle, bucket, "EC2", func() (*string, error) { return nil, nil })
+					}
+				}(throttle.regionBuckets(region)["EC2"])
+			}
+			wg.Wait()
+			elapsed := time.Since(start)
+
+			if elapsed < test.minTime || elapsed > test.maxTime {
+				t.Errorf("took %v, want between %v and %v", elapsed, test.minTime, test.maxTime)
+			}
+			if calls := stats.Snapshot()["EC2"].Calls; calls != len(test.regions)*test.calls {
+				t.Errorf("counted %d calls, want %d", calls, len(test.regions)*test.calls)
+			}
+		})
+	}
+}
+
+func TestCallStats(t *testing.T) {
+	stats := NewCallStats()
+	if stats.Snapshot() != nil {
+		t.Error("empty stats have a snapshot")
+	}
+
+	// Calls without stats in their context aren't counted anywhere
+	record(context.Background(), "S3", func(s *ServiceStats) { s.Calls++ })
+
+	ctx := WithCallStats(context.Background(), stats)
+	record(ctx, "S3", func(s *ServiceStats) { s.Calls += 3; s.Throttled++ })
+	record(ctx, "EC2", func(s *ServiceStats) { s.Calls++; s.Retries++; s.Waited = time.Second })
+
+	snapshot := stats.Snapshot()
+	if got := ServicesByName(snapshot); !reflect.DeepEqual(got, []string{"EC2", "S3"}) {
+		t.Errorf("services = %v", got)
+	}
+	want := ServiceStats{Calls: 4, Retries: 1, Throttled: 1, Waited: time.Second}
+	if total := TotalCalls(snapshot); total != want {
+		t.Errorf("total = %+v, want %+v", total, want)
+	}
+}
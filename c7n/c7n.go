@@ -0,0 +1,211 @@
+package c7n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Note records something that couldn't be carried over exactly during a translation
+type Note struct {
+	Policy  string `json:"policy"`
+	Field   string `json:"field"` // e.g. filters[2], actions[0].hours
+	Message string `json:"message"`
+	Dropped bool   `json:"dropped"` // left out entirely, rather than approximated
+}
+
+// Report lists everything a translation dropped or approximated
+type Report struct {
+	Notes []Note `json:"notes"`
+}
+
+// Dropped returns the notes for things that were left out entirely
+func (r *Report) Dropped() []Note {
+	var dropped []Note
+	for _, note := range r.Notes {
+		if note.Dropped {
+			dropped = append(dropped, note)
+		}
+	}
+	return dropped
+}
+
+// DroppedFilters counts a policy's filters that were left out. A policy missing
+// filters matches more resources than the original did.
+func (r *Report) DroppedFilters(policy string) int {
+	count := 0
+	for _, note := range r.Notes {
+		if note.Dropped && note.Policy == policy && strings.HasPrefix(note.Field, "filters") {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *Report) drop(policy, field, format string, args ...interface{}) {
+	r.Notes = append(r.Notes, Note{Policy: policy, Field: field, Message: fmt.Sprintf(format, args...), Dropped: true})
+}
+
+func (r *Report) approximate(policy, field, format string, args ...interface{}) {
+	r.Notes = append(r.Notes, Note{Policy: policy, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// resourceAliases maps c7n resource names (without the aws. prefix) to ours
+var resourceAliases = map[string]string{
+	"ec2":          "ec2",
+	"s3":           "s3",
+	"rds":          "rds",
+	"lambda":       "lambda",
+	"ebs":          "ebs",
+	"ebs-snapshot": "ebs-snapshot",
+	"iam-user":     "iam",
+	"iam-role":     "iam",
+	"iam-policy":   "iam",
+	"vpc":          "vpc",
+	"elb":          "elb",
+	"app-elb":      "elb",
+}
+
+// exportResources maps our resource types to the c7n resource written on export
+var exportResources = map[string]string{
+	"ec2":          "aws.ec2",
+	"s3":           "aws.s3",
+	"rds":          "aws.rds",
+	"lambda":       "aws.lambda",
+	"ebs":          "aws.ebs",
+	"ebs-snapshot": "aws.ebs-snapshot",
+	"iam":          "aws.iam-user",
+	"vpc":          "aws.vpc",
+	"elb":          "aws.elb",
+}
+
+// keyAliases maps c7n value filter keys, which follow the AWS API shapes,
+// to our resource fields
+var keyAliases = map[string]map[string]string{
+	"ec2": {
+		"InstanceId":       "id",
+		"State.Name":       "state",
+		"InstanceType":     "instance_type",
+		"LaunchTime":       "launch_time",
+		"VpcId":            "vpc_id",
+		"SubnetId":         "subnet_id",
+		"Platform":         "platform",
+		"PrivateIpAddress": "private_ip",
+		"PublicIpAddress":  "public_ip",
+	},
+	"s3": {
+		"Name":         "name",
+		"CreationDate": "creation_date",
+	},
+	"rds": {
+		"DBInstanceIdentifier":  "id",
+		"DBInstanceStatus":      "state",
+		"Engine":                "engine",
+		"EngineVersion":         "engine_version",
+		"DBInstanceClass":       "instance_class",
+		"BackupRetentionPeriod": "backup_retention_period",
+		"MultiAZ":               "multi_az",
+		"PubliclyAccessible":    "publicly_accessible",
+		"StorageEncrypted":      "encrypted",
+		"AllocatedStorage":      "allocated_storage_gb",
+	},
+	"lambda": {
+		"FunctionName": "name",
+		"Runtime":      "runtime",
+		"MemorySize":   "memory_size",
+		"Timeout":      "timeout",
+		"CodeSize":     "code_size",
+		"Role":         "role",
+		"LastModified": "last_modified",
+	},
+	"ebs": {
+		"VolumeId":   "id",
+		"State":      "state",
+		"VolumeType": "volume_type",
+		"Size":       "size",
+		"Encrypted":  "encrypted",
+		"SnapshotId": "snapshot_id",
+		"CreateTime": "create_time",
+	},
+}
+
+// opAliases maps c7n value filter operators to ours
+var opAliases = map[string]string{
+	"eq":           "eq",
+	"equal":        "eq",
+	"ne":           "ne",
+	"not-equal":    "ne",
+	"gt":           "gt",
+	"greater-than": "gt",
+	"ge":           "gte",
+	"gte":          "gte",
+	"lt":           "lt",
+	"less-than":    "lt",
+	"le":           "lte",
+	"lte":          "lte",
+	"in":           "in",
+	"ni":           "not-in",
+	"not-in":       "not-in",
+	"contains":     "contains",
+}
+
+// actionAliases maps c7n actions to ours where only the name differs
+var actionAliases = map[string]string{
+	"stop":                  "stop",
+	"start":                 "start",
+	"terminate":             "terminate",
+	"delete":                "delete",
+	"snapshot":              "create-snapshot",
+	"set-public-block":      "block-public-access",
+	"set-bucket-encryption": "enable-encryption",
+}
+
+// exportActions maps our actions to c7n's where only the name differs
+var exportActions = map[string]string{
+	"stop":                "stop",
+	"start":               "start",
+	"terminate":           "terminate",
+	"delete":              "delete",
+	"create-snapshot":     "snapshot",
+	"block-public-access": "set-public-block",
+	"enable-encryption":   "set-bucket-encryption",
+}
+
+// eventModes are the c7n modes that run on AWS events rather than on a schedule
+var eventModes = []string{
+	"cloudtrail", "ec2-instance-state", "asg-instance-state", "guard-duty",
+	"config-rule", "config-poll-rule", "hub-finding", "hub-action", "phd",
+}
+
+// mapKey returns the c7n key that maps to one of our fields
+func mapKey(resource, field string) (string, bool) {
+	keys := make([]string, 0, len(keyAliases[resource]))
+	for key := range keyAliases[resource] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // deterministic when two keys map to the same field
+
+	for _, key := range keys {
+		if keyAliases[resource][key] == field {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// tagKey splits a c7n "tag:Name" key
+func tagKey(key string) (string, bool) {
+	if strings.HasPrefix(key, "tag:") {
+		return strings.TrimPrefix(key, "tag:"), true
+	}
+	return "", false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,356 @@
+package c7n
+
+import (
+	"custodian-killer/scanner"
+	"custodian-killer/storage"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// daysAgoPattern matches relative dates like "30 days ago", exported as value_type: age
+var daysAgoPattern = regexp.MustCompile(`^(\d+)\s+days?\s+ago$`)
+
+// Export writes policies as a c7n "policies:" document
+func Export(policies []storage.StoredPolicy) ([]byte, *Report, error) {
+	report := &Report{}
+
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, policy := range policies {
+		list.Content = append(list.Content, exportPolicy(policy, report))
+	}
+
+	document := mapping("policies", list)
+
+	var builder strings.Builder
+	encoder := yaml.NewEncoder(&builder)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return nil, nil, fmt.Errorf("failed to write c7n policy file: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to write c7n policy file: %v", err)
+	}
+
+	return []byte(builder.String()), report, nil
+}
+
+// exporter translates one policy, collecting notes as it goes
+type exporter struct {
+	policy   string
+	resource string
+	report   *Report
+}
+
+func exportPolicy(policy storage.StoredPolicy, report *Report) *yaml.Node {
+	ex := &exporter{policy: policy.Name, resource: policy.ResourceType, report: report}
+
+	resource, known := exportResources[policy.ResourceType]
+	if !known {
+		resource = "aws." + policy.ResourceType
+		report.approximate(policy.Name, "resource_type", "no known c7n resource for '%s'; wrote '%s'", policy.ResourceType, resource)
+	} else if policy.ResourceType == "iam" {
+		report.approximate(policy.Name, "resource_type", "iam exported as aws.iam-user")
+	}
+
+	node := mapping("name", policy.Name, "resource", resource)
+	if policy.Description != "" {
+		node = appendPairs(node, "description", policy.Description)
+	}
+	if mode := ex.exportMode(policy.Mode); mode != nil {
+		node = appendPairs(node, "mode", mode)
+	}
+
+	if len(policy.Regions) > 0 {
+		condition := mapping("type", "value", "key", "region", "op", "in", "value", policy.Regions)
+		node = appendPairs(node, "conditions", sequence(condition))
+	}
+
+	if filters := ex.exportFilters(policy.Filters, "filters"); len(filters.Content) > 0 {
+		node = appendPairs(node, "filters", filters)
+	}
+
+	actions := &yaml.Node{Kind: yaml.SequenceNode}
+	for i, action := range policy.Actions {
+		if exported := ex.exportAction(action, fmt.Sprintf("actions[%d]", i)); exported != nil {
+			actions.Content = append(actions.Content, exported)
+		}
+	}
+	if len(actions.Content) > 0 {
+		node = appendPairs(node, "actions", actions)
+	}
+
+	return node
+}
+
+func (ex *exporter) exportMode(mode storage.StoredPolicyMode) *yaml.Node {
+	switch mode.Type {
+	case "", "pull":
+		return nil
+
+	case "periodic":
+		expression, err := scheduleToC7n(mode.Schedule)
+		if err != nil {
+			ex.report.drop(ex.policy, "mode.schedule", "%v", err)
+			return nil
+		}
+		return mapping("type", "periodic", "schedule", expression)
+
+	case "event":
+		source := mode.Settings["source"]
+		if source == "" {
+			source = "cloudtrail"
+		}
+		node := mapping("type", source)
+		if events := mode.Settings["events"]; events != "" {
+			node = appendPairs(node, "events", strings.Split(events, ","))
+		}
+		ex.report.approximate(ex.policy, "mode", "event mode exported as '%s'; c7n also needs a role and event sources", source)
+		return node
+	}
+
+	ex.report.drop(ex.policy, "mode.type", "mode '%s' has no c7n equivalent; exported as pull", mode.Type)
+	return nil
+}
+
+func (ex *exporter) exportFilters(filters []storage.StoredFilter, path string) *yaml.Node {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for i, filter := range filters {
+		if exported := ex.exportFilter(filter, fmt.Sprintf("%s[%d]", path, i)); exported != nil {
+			list.Content = append(list.Content, exported)
+		}
+	}
+	return list
+}
+
+func (ex *exporter) exportFilter(filter storage.StoredFilter, field string) *yaml.Node {
+	node := ex.exportFilterBody(filter, field)
+	if node == nil || !filter.Negate {
+		return node
+	}
+
+	// c7n has no negate flag; wrap the filter in not, or unwrap a negated not
+	if filter.Type == "not" {
+		return mapping("and", node.Content[1])
+	}
+	return mapping("not", sequence(node))
+}
+
+func (ex *exporter) exportFilterBody(filter storage.StoredFilter, field string) *yaml.Node {
+	spec := scanner.FilterRegistry[filter.Type]
+
+	switch filter.Type {
+	case "and", "or", "not":
+		return mapping(filter.Type, ex.exportFilters(filter.Filters, field+".filters"))
+
+	case "tag":
+		switch {
+		case filter.Op == "exists" || filter.Op == "present" || (filter.Op == "" && isEmpty(filter.Value)):
+			return mapping("tag:"+filter.Key, "present")
+		case filter.Op == "missing" || filter.Op == "absent":
+			return mapping("tag:"+filter.Key, "absent")
+		case filter.Op == "" || filter.Op == "eq":
+			return mapping("tag:"+filter.Key, filter.Value)
+		}
+		return ex.valueFilter("tag:"+filter.Key, filter.Op, filter.Value, field)
+
+	case "tag-missing":
+		return mapping("tag:"+filter.Key, "absent")
+
+	case "marked-for-op":
+		node := mapping("type", "marked-for-op")
+		if filter.Key != "" {
+			node = appendPairs(node, "tag", filter.Key)
+		}
+		if !isEmpty(filter.Value) {
+			node = appendPairs(node, "op", filter.Value)
+		}
+		return node
+
+	case "value":
+		key := filter.Key
+		if mapped, ok := mapKey(ex.resource, filter.Key); ok {
+			key = mapped
+		} else {
+			ex.report.approximate(ex.policy, field+".key",
+				"no c7n key known for '%s'; exported as-is", filter.Key)
+		}
+		return ex.valueFilter(key, filter.Op, filter.Value, field)
+
+	case "state", "instance-state":
+		return ex.fieldFilter("state", filter, spec, field)
+	}
+
+	if spec.Path != "" {
+		return ex.fieldFilter(spec.Path, filter, spec, field)
+	}
+
+	ex.report.drop(ex.policy, field, "filter '%s' has no c7n equivalent", filter.Type)
+	return nil
+}
+
+// fieldFilter exports a named filter as a value filter on the matching c7n key
+func (ex *exporter) fieldFilter(
+	path string,
+	filter storage.StoredFilter,
+	spec scanner.FilterSpec,
+	field string,
+) *yaml.Node {
+	key, known := mapKey(ex.resource, path)
+	if !known {
+		ex.report.drop(ex.policy, field, "filter '%s' has no c7n equivalent", filter.Type)
+		return nil
+	}
+
+	op, value := filter.Op, filter.Value
+	if op == "" {
+		op = spec.DefaultOp
+	}
+	if value == nil {
+		value = spec.Default
+	}
+	ex.report.approximate(ex.policy, field, "'%s' exported as a value filter on %s", filter.Type, key)
+	return ex.valueFilter(key, op, value, field)
+}
+
+// valueFilter writes a c7n value filter, using value_type: age for relative dates
+func (ex *exporter) valueFilter(key, op string, value interface{}, field string) *yaml.Node {
+	switch op {
+	case "exists", "present":
+		return mapping("type", "value", "key", key, "value", "present")
+	case "missing", "absent":
+		return mapping("type", "value", "key", key, "value", "absent")
+	case "":
+		op = "eq"
+	case "not-in":
+		op = "ni"
+	}
+
+	if text, ok := value.(string); ok {
+		if match := daysAgoPattern.FindStringSubmatch(strings.TrimSpace(text)); match != nil {
+			inverted := map[string]string{"lt": "gt", "lte": "gte", "gt": "lt", "gte": "lte"}
+			if flipped, ok := inverted[op]; ok {
+				days, _ := strconv.Atoi(match[1])
+				return mapping("type", "value", "key", key, "value_type", "age", "op", flipped, "value", days)
+			}
+		}
+	}
+
+	if op == "in" || op == "ni" {
+		if text, ok := value.(string); ok {
+			// We accept comma separated lists; c7n wants a real list
+			items := strings.Split(text, ",")
+			for i := range items {
+				items[i] = strings.TrimSpace(items[i])
+			}
+			value = items
+		}
+	}
+
+	return mapping("type", "value", "key", key, "op", op, "value", value)
+}
+
+func (ex *exporter) exportAction(action storage.StoredAction, field string) *yaml.Node {
+	if action.DryRun {
+		ex.report.approximate(ex.policy, field+".dry_run", "c7n has no per-action dry run; use --dryrun when running it")
+	}
+
+	switch action.Type {
+	case "tag":
+		if key, ok := action.Settings["key"]; ok {
+			value := action.Settings["value"]
+			if value == nil {
+				ex.report.drop(ex.policy, field, "'tag' action for tag '%v' has no value", key)
+				return nil
+			}
+			return mapping("type", "tag", "key", key, "value", value)
+		}
+		return mapping("type", "tag", "tags", action.Settings)
+
+	case "mark-for-op":
+		node := mapping("type", "mark-for-op")
+		for _, key := range []string{"tag", "op", "days"} {
+			if value, exists := action.Settings[key]; exists {
+				node = appendPairs(node, key, value)
+			}
+		}
+		return node
+
+	case "enable-versioning":
+		return mapping("type", "toggle-versioning", "enabled", true)
+
+	case "encrypt":
+		if ex.resource == "s3" {
+			return mapping("type", "set-bucket-encryption")
+		}
+	}
+
+	name, known := exportActions[action.Type]
+	if !known {
+		ex.report.drop(ex.policy, field, "action '%s' has no c7n equivalent", action.Type)
+		return nil
+	}
+
+	if len(action.Settings) == 0 {
+		return scalar(name)
+	}
+
+	node := mapping("type", name)
+	var keys []string
+	for key := range action.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch {
+		case key == "force":
+			node = appendPairs(node, "force", action.Settings[key])
+		case key == "skip_final_snapshot" && action.Type == "delete":
+			node = appendPairs(node, "skip-snapshot", action.Settings[key])
+		default:
+			ex.report.drop(ex.policy, field+".settings."+key, "setting '%s' has no c7n equivalent", key)
+		}
+	}
+	return node
+}
+
+// mapping builds an ordered YAML mapping from key/value pairs
+func mapping(pairs ...interface{}) *yaml.Node {
+	return appendPairs(&yaml.Node{Kind: yaml.MappingNode}, pairs...)
+}
+
+// appendPairs adds key/value pairs to a mapping node
+func appendPairs(node *yaml.Node, pairs ...interface{}) *yaml.Node {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		node.Content = append(node.Content, toNode(pairs[i]), toNode(pairs[i+1]))
+	}
+	return node
+}
+
+func sequence(items ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Content: items}
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// toNode converts a plain value to a YAML node
+func toNode(value interface{}) *yaml.Node {
+	if node, ok := value.(*yaml.Node); ok {
+		return node
+	}
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return scalar(fmt.Sprint(value))
+	}
+	return &node
+}
+
+func isEmpty(value interface{}) bool {
+	return value == nil || value == ""
+}
@@ -0,0 +1,289 @@
+package c7n
+
+import (
+	"custodian-killer/storage"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exportOne exports a single policy and reads the c7n policy back as plain values
+func exportOne(t *testing.T, policy storage.StoredPolicy) (m, *Report) {
+	t.Helper()
+	data, report, err := Export([]storage.StoredPolicy{policy})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var document struct {
+		Policies []m `yaml:"policies"`
+	}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		t.Fatalf("exported invalid YAML: %v\n%s", err, data)
+	}
+	if len(document.Policies) != 1 {
+		t.Fatalf("exported %d policies, want 1", len(document.Policies))
+	}
+	return document.Policies[0], report
+}
+
+func TestExportFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		resource  string // defaults to ec2
+		filters   []storage.StoredFilter
+		want      l
+		wantNotes []string
+	}{
+		{
+			name:    "tag equals",
+			filters: []storage.StoredFilter{{Type: "tag", Key: "Env", Op: "eq", Value: "prod"}},
+			want:    l{m{"tag:Env": "prod"}},
+		},
+		{
+			name:    "tag exists",
+			filters: []storage.StoredFilter{{Type: "tag", Key: "Owner", Op: "exists"}},
+			want:    l{m{"tag:Owner": "present"}},
+		},
+		{
+			name:    "tag missing",
+			filters: []storage.StoredFilter{{Type: "tag-missing", Key: "Owner"}},
+			want:    l{m{"tag:Owner": "absent"}},
+		},
+		{
+			name:    "tag comparison",
+			filters: []storage.StoredFilter{{Type: "tag", Key: "Env", Op: "ne", Value: "prod"}},
+			want:    l{m{"type": "value", "key": "tag:Env", "op": "ne", "value": "prod"}},
+		},
+		{
+			name:    "value on a mapped field",
+			filters: []storage.StoredFilter{{Type: "value", Key: "state", Value: "running"}},
+			want:    l{m{"type": "value", "key": "State.Name", "op": "eq", "value": "running"}},
+		},
+		{
+			name:      "value on an unmapped field",
+			filters:   []storage.StoredFilter{{Type: "value", Key: "IamInstanceProfile.Arn", Op: "contains", Value: "admin"}},
+			want:      l{m{"type": "value", "key": "IamInstanceProfile.Arn", "op": "contains", "value": "admin"}},
+			wantNotes: []string{"filters[0].key approximated"},
+		},
+		{
+			name:    "comma separated lists",
+			filters: []storage.StoredFilter{{Type: "value", Key: "instance_type", Op: "not-in", Value: "t3.micro, t3.small"}},
+			want:    l{m{"type": "value", "key": "InstanceType", "op": "ni", "value": l{"t3.micro", "t3.small"}}},
+		},
+		{
+			name:      "named filter with a relative date",
+			filters:   []storage.StoredFilter{{Type: "launch-time", Value: "30 days ago"}},
+			want:      l{m{"type": "value", "key": "LaunchTime", "value_type": "age", "op": "gt", "value": 30}},
+			wantNotes: []string{"filters[0] approximated"},
+		},
+		{
+			name:      "named filter defaults",
+			resource:  "rds",
+			filters:   []storage.StoredFilter{{Type: "backup-retention"}},
+			want:      l{m{"type": "value", "key": "BackupRetentionPeriod", "op": "lt", "value": 7}},
+			wantNotes: []string{"filters[0] approximated"},
+		},
+		{
+			name:    "marked-for-op",
+			filters: []storage.StoredFilter{{Type: "marked-for-op", Key: "cleanup", Value: "stop"}},
+			want:    l{m{"type": "marked-for-op", "tag": "cleanup", "op": "stop"}},
+		},
+		{
+			name:    "negated filter",
+			filters: []storage.StoredFilter{{Type: "tag", Key: "Env", Value: "prod", Negate: true}},
+			want:    l{m{"not": l{m{"tag:Env": "prod"}}}},
+		},
+		{
+			name: "negated not",
+			filters: []storage.StoredFilter{{Type: "not", Negate: true, Filters: []storage.StoredFilter{
+				{Type: "tag-missing", Key: "Owner"},
+			}}},
+			want: l{m{"and": l{m{"tag:Owner": "absent"}}}},
+		},
+		{
+			name: "unsupported filters are dropped",
+			filters: []storage.StoredFilter{
+				{Type: "cpu-utilization", Value: 5},
+				{Type: "or", Filters: []storage.StoredFilter{
+					{Type: "running-days"},
+					{Type: "tag", Key: "Env", Value: "dev"},
+				}},
+			},
+			want:      l{m{"or": l{m{"tag:Env": "dev"}}}},
+			wantNotes: []string{"filters[0] dropped", "filters[1].filters[0] dropped"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resource := test.resource
+			if resource == "" {
+				resource = "ec2"
+			}
+			policy, report := exportOne(t, storage.StoredPolicy{Name: "p", ResourceType: resource, Filters: test.filters})
+
+			if !reflect.DeepEqual(policy["filters"], test.want) {
+				t.Errorf("filters = %#v, want %#v", policy["filters"], test.want)
+			}
+			if notes := noteFields(report); !reflect.DeepEqual(notes, test.wantNotes) {
+				t.Errorf("notes = %q, want %q", notes, test.wantNotes)
+			}
+			if got, want := report.DroppedFilters("p"), countDropped(test.wantNotes); got != want {
+				t.Errorf("DroppedFilters = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestExportActions(t *testing.T) {
+	tests := []struct {
+		name      string
+		resource  string // defaults to ec2
+		action    storage.StoredAction
+		want      interface{} // nil when the action is dropped
+		wantNotes []string
+	}{
+		{
+			name:   "plain name",
+			action: storage.StoredAction{Type: "stop"},
+			want:   "stop",
+		},
+		{
+			name:      "dry run",
+			action:    storage.StoredAction{Type: "stop", DryRun: true},
+			want:      "stop",
+			wantNotes: []string{"actions[0].dry_run approximated"},
+		},
+		{
+			name:   "renamed action",
+			action: storage.StoredAction{Type: "create-snapshot"},
+			want:   "snapshot",
+		},
+		{
+			name:   "tag map",
+			action: storage.StoredAction{Type: "tag", Settings: m{"Owner": "ops"}},
+			want:   m{"type": "tag", "tags": m{"Owner": "ops"}},
+		},
+		{
+			name:   "tag key and value",
+			action: storage.StoredAction{Type: "tag", Settings: m{"key": "Owner", "value": "ops"}},
+			want:   m{"type": "tag", "key": "Owner", "value": "ops"},
+		},
+		{
+			name:      "tag key without a value",
+			action:    storage.StoredAction{Type: "tag", Settings: m{"key": "Owner"}},
+			wantNotes: []string{"actions[0] dropped"},
+		},
+		{
+			name:   "mark-for-op",
+			action: storage.StoredAction{Type: "mark-for-op", Settings: m{"op": "stop", "days": 3}},
+			want:   m{"type": "mark-for-op", "op": "stop", "days": 3},
+		},
+		{
+			name:   "enable versioning",
+			action: storage.StoredAction{Type: "enable-versioning"},
+			want:   m{"type": "toggle-versioning", "enabled": true},
+		},
+		{
+			name:     "encrypt on s3",
+			resource: "s3",
+			action:   storage.StoredAction{Type: "encrypt"},
+			want:     m{"type": "set-bucket-encryption"},
+		},
+		{
+			name:      "delete settings",
+			resource:  "rds",
+			action:    storage.StoredAction{Type: "delete", Settings: m{"skip_final_snapshot": true, "reason": "old"}},
+			want:      m{"type": "delete", "skip-snapshot": true},
+			wantNotes: []string{"actions[0].settings.reason dropped"},
+		},
+		{
+			name:      "unknown action",
+			action:    storage.StoredAction{Type: "update-configuration"},
+			wantNotes: []string{"actions[0] dropped"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resource := test.resource
+			if resource == "" {
+				resource = "ec2"
+			}
+			policy, report := exportOne(t, storage.StoredPolicy{
+				Name: "p", ResourceType: resource, Actions: []storage.StoredAction{test.action},
+			})
+
+			var want interface{}
+			if test.want != nil {
+				want = l{test.want}
+			}
+			if !reflect.DeepEqual(policy["actions"], want) {
+				t.Errorf("actions = %#v, want %#v", policy["actions"], want)
+			}
+			if notes := noteFields(report); !reflect.DeepEqual(notes, test.wantNotes) {
+				t.Errorf("notes = %q, want %q", notes, test.wantNotes)
+			}
+		})
+	}
+}
+
+func TestExportPolicy(t *testing.T) {
+	policy, report := exportOne(t, storage.StoredPolicy{
+		Name:         "p",
+		ResourceType: "iam",
+		Description:  "old users",
+		Regions:      []string{"us-east-1"},
+		Mode:         storage.StoredPolicyMode{Type: "event", Settings: map[string]string{"events": "CreateUser"}},
+	})
+
+	want := m{
+		"name":        "p",
+		"resource":    "aws.iam-user",
+		"description": "old users",
+		"mode":        m{"type": "cloudtrail", "events": l{"CreateUser"}},
+		"conditions":  l{m{"type": "value", "key": "region", "op": "in", "value": l{"us-east-1"}}},
+	}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("policy = %#v, want %#v", policy, want)
+	}
+	wantNotes := []string{"resource_type approximated", "mode approximated"}
+	if notes := noteFields(report); !reflect.DeepEqual(notes, wantNotes) {
+		t.Errorf("notes = %q, want %q", notes, wantNotes)
+	}
+	if dropped := report.Dropped(); len(dropped) > 0 {
+		t.Errorf("dropped = %+v, want none", dropped)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	original := storage.StoredPolicy{
+		Name:         "stop-dev",
+		ResourceType: "ec2",
+		Filters: []storage.StoredFilter{
+			{Type: "tag", Key: "Env", Op: "eq", Value: "dev"},
+			{Type: "tag-missing", Key: "Owner"},
+			{Type: "value", Key: "state", Op: "eq", Value: "running"},
+		},
+		Actions: []storage.StoredAction{{Type: "stop"}},
+	}
+
+	data, report, err := Export([]storage.StoredPolicy{original})
+	if err != nil || len(report.Notes) > 0 {
+		t.Fatalf("Export: %v, notes %q", err, noteFields(report))
+	}
+	policies, report, err := Import(data)
+	if err != nil || len(report.Notes) > 0 {
+		t.Fatalf("Import: %v, notes %q", err, noteFields(report))
+	}
+
+	imported := policies[0]
+	if !reflect.DeepEqual(imported.Filters, original.Filters) {
+		t.Errorf("filters = %#v, want %#v", imported.Filters, original.Filters)
+	}
+	// Imported actions always start as dry runs
+	if want := []storage.StoredAction{{Type: "stop", DryRun: true}}; !reflect.DeepEqual(imported.Actions, want) {
+		t.Errorf("actions = %#v, want %#v", imported.Actions, want)
+	}
+}
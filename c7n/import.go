@@ -0,0 +1,484 @@
+package c7n
+
+import (
+	"custodian-killer/scanner"
+	"custodian-killer/storage"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// policyKeys are the c7n policy keys Import understands
+var policyKeys = []string{"name", "resource", "description", "comment", "comments", "mode", "conditions", "filters", "actions"}
+
+// Import translates a c7n "policies:" document. Policies are imported as drafts
+// with every action in dry run, so nothing changes until someone reviews them.
+func Import(data []byte) ([]storage.StoredPolicy, *Report, error) {
+	var document struct {
+		Policies []map[string]interface{} `yaml:"policies"`
+	}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse c7n policy file: %v", err)
+	}
+	if len(document.Policies) == 0 {
+		return nil, nil, fmt.Errorf("no policies found (expected a top-level 'policies:' list)")
+	}
+
+	report := &Report{}
+	var policies []storage.StoredPolicy
+	for i, raw := range document.Policies {
+		policies = append(policies, importPolicy(raw, i, report))
+	}
+
+	return policies, report, nil
+}
+
+// importer translates one policy, collecting notes as it goes
+type importer struct {
+	policy   string
+	resource string
+	report   *Report
+}
+
+func importPolicy(raw map[string]interface{}, index int, report *Report) storage.StoredPolicy {
+	name, _ := raw["name"].(string)
+	if name == "" {
+		name = fmt.Sprintf("c7n-policy-%d", index+1)
+		report.approximate(name, "name", "policy had no name; using '%s'", name)
+	}
+
+	im := &importer{policy: name, report: report}
+	policy := storage.StoredPolicy{
+		Name:     name,
+		Status:   "draft",
+		Source:   "import",
+		Metadata: map[string]interface{}{"imported_from": "c7n"},
+	}
+
+	resource, _ := raw["resource"].(string)
+	policy.ResourceType = im.importResource(resource)
+	im.resource = policy.ResourceType
+
+	policy.Description, _ = raw["description"].(string)
+	for _, key := range []string{"comment", "comments"} {
+		if comment, ok := raw[key].(string); ok && policy.Description == "" {
+			policy.Description = comment
+		}
+	}
+
+	policy.Mode = im.importMode(raw["mode"])
+	policy.Regions = im.importConditions(asList(raw["conditions"]))
+	policy.Filters = im.importFilters(asList(raw["filters"]), "filters")
+
+	for i, rawAction := range asList(raw["actions"]) {
+		if action, ok := im.importAction(rawAction, fmt.Sprintf("actions[%d]", i)); ok {
+			policy.Actions = append(policy.Actions, action)
+		}
+	}
+
+	var unknown []string
+	for key := range raw {
+		if !containsString(policyKeys, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		report.drop(name, key, "policy key '%s' has no equivalent", key)
+	}
+
+	return policy
+}
+
+func (im *importer) importResource(resource string) string {
+	if resource == "" {
+		im.report.drop(im.policy, "resource", "policy has no resource")
+		return ""
+	}
+
+	name := resource
+	if provider, rest, found := strings.Cut(resource, "."); found {
+		if provider != "aws" {
+			im.report.drop(im.policy, "resource", "only aws resources are supported, got '%s'", resource)
+			return rest
+		}
+		name = rest
+	}
+
+	ours, known := resourceAliases[name]
+	if !known {
+		im.report.drop(im.policy, "resource", "resource '%s' is not supported", resource)
+		return name
+	}
+	if ours != name {
+		im.report.approximate(im.policy, "resource", "'%s' imported as '%s'", resource, ours)
+	}
+	return ours
+}
+
+func (im *importer) importMode(raw interface{}) storage.StoredPolicyMode {
+	mode, _ := raw.(map[string]interface{})
+	modeType, _ := mode["type"].(string)
+
+	switch {
+	case modeType == "" || modeType == "pull":
+		return storage.StoredPolicyMode{Type: "pull"}
+
+	case modeType == "periodic":
+		expression, _ := mode["schedule"].(string)
+		converted, err := scheduleFromC7n(expression)
+		if err != nil {
+			im.report.drop(im.policy, "mode.schedule", "%v", err)
+			return storage.StoredPolicyMode{Type: "periodic"}
+		}
+		im.dropModeSettings(mode, "type", "schedule")
+		return storage.StoredPolicyMode{Type: "periodic", Schedule: converted}
+
+	case containsString(eventModes, modeType):
+		settings := map[string]string{"source": modeType}
+		var events []string
+		for _, event := range asList(mode["events"]) {
+			switch e := event.(type) {
+			case string:
+				events = append(events, e)
+			case map[string]interface{}:
+				if name, ok := e["event"].(string); ok {
+					events = append(events, name)
+				}
+			}
+		}
+		if len(events) > 0 {
+			settings["events"] = strings.Join(events, ",")
+		}
+		im.report.approximate(im.policy, "mode", "'%s' mode imported as an event mode", modeType)
+		im.dropModeSettings(mode, "type", "events")
+		return storage.StoredPolicyMode{Type: "event", Settings: settings}
+	}
+
+	im.report.drop(im.policy, "mode.type", "mode '%s' is not supported; imported as pull", modeType)
+	return storage.StoredPolicyMode{Type: "pull"}
+}
+
+// importConditions turns region conditions into the policy's region
+// allow-list; other conditions have no equivalent
+func (im *importer) importConditions(raw []interface{}) []string {
+	var regions []string
+	for i, rawCondition := range raw {
+		field := fmt.Sprintf("conditions[%d]", i)
+		condition, _ := rawCondition.(map[string]interface{})
+		key, _ := condition["key"].(string)
+		op, _ := condition["op"].(string)
+		if condition["type"] != "value" || key != "region" {
+			im.report.drop(im.policy, field, "only region conditions are supported")
+			continue
+		}
+
+		var values []string
+		switch op {
+		case "", "eq", "equal":
+			if value, ok := condition["value"].(string); ok {
+				values = []string{value}
+			}
+		case "in":
+			for _, value := range asList(condition["value"]) {
+				if region, ok := value.(string); ok {
+					values = append(values, region)
+				}
+			}
+		}
+		if len(values) == 0 {
+			im.report.drop(im.policy, field, "region condition with op '%s' is not supported", op)
+			continue
+		}
+
+		if regions == nil {
+			regions = values
+			continue
+		}
+		// Every condition must hold, so only regions in all of them remain
+		var both []string
+		for _, region := range regions {
+			if containsString(values, region) {
+				both = append(both, region)
+			}
+		}
+		regions = both
+		if len(regions) == 0 {
+			im.report.drop(im.policy, field, "region conditions have no region in common")
+		}
+	}
+	return regions
+}
+
+// dropModeSettings reports the Lambda deployment settings of a mode block
+func (im *importer) dropModeSettings(mode map[string]interface{}, handled ...string) {
+	var keys []string
+	for key := range mode {
+		if !containsString(handled, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		im.report.drop(im.policy, "mode."+key, "mode setting '%s' is not used", key)
+	}
+}
+
+func (im *importer) importFilters(raw []interface{}, path string) []storage.StoredFilter {
+	var filters []storage.StoredFilter
+	for i, rawFilter := range raw {
+		if filter, ok := im.importFilter(rawFilter, fmt.Sprintf("%s[%d]", path, i)); ok {
+			filters = append(filters, filter)
+		}
+	}
+	return filters
+}
+
+func (im *importer) importFilter(raw interface{}, field string) (storage.StoredFilter, bool) {
+	definition, ok := raw.(map[string]interface{})
+	if !ok {
+		im.report.drop(im.policy, field, "filter %v is not a mapping", raw)
+		return storage.StoredFilter{}, false
+	}
+
+	filterType, typed := definition["type"].(string)
+	if !typed {
+		if len(definition) != 1 {
+			im.report.drop(im.policy, field, "filter has no type")
+			return storage.StoredFilter{}, false
+		}
+		for key, value := range definition {
+			switch key {
+			case "and", "or", "not":
+				children := im.importFilters(asList(value), field+"."+key)
+				return storage.StoredFilter{Type: key, Filters: children}, len(children) > 0
+			default:
+				// {"tag:Owner": "absent"} and {"State.Name": "running"} are value filters
+				return im.importValueFilter(key, "eq", value, "", field)
+			}
+		}
+	}
+
+	switch filterType {
+	case "value":
+		key, _ := definition["key"].(string)
+		op, _ := definition["op"].(string)
+		valueType, _ := definition["value_type"].(string)
+		return im.importValueFilter(key, op, definition["value"], valueType, field)
+
+	case "marked-for-op":
+		filter := storage.StoredFilter{Type: "marked-for-op"}
+		if tag, ok := definition["tag"].(string); ok && tag != scanner.DefaultMarkTag {
+			filter.Key = tag
+		}
+		if op, ok := definition["op"].(string); ok {
+			filter.Value = op
+		}
+		if _, exists := definition["skew"]; exists {
+			im.report.drop(im.policy, field+".skew", "skew is not supported; the mark is due on its date")
+		}
+		return filter, true
+
+	case "and", "or", "not":
+		children := im.importFilters(asList(definition[filterType]), field+"."+filterType)
+		return storage.StoredFilter{Type: filterType, Filters: children}, len(children) > 0
+	}
+
+	im.report.drop(im.policy, field, "filter type '%s' has no equivalent", filterType)
+	return storage.StoredFilter{}, false
+}
+
+// importValueFilter translates a value filter, turning tag:Name keys into tag filters
+func (im *importer) importValueFilter(
+	key, op string,
+	value interface{},
+	valueType, field string,
+) (storage.StoredFilter, bool) {
+	if key == "" {
+		im.report.drop(im.policy, field, "value filter has no key")
+		return storage.StoredFilter{}, false
+	}
+
+	if op == "" {
+		op = "eq"
+	}
+	ours, known := opAliases[op]
+	if !known {
+		im.report.drop(im.policy, field+".op", "operator '%s' is not supported", op)
+		return storage.StoredFilter{}, false
+	}
+
+	filter := storage.StoredFilter{Type: "value", Key: key, Op: ours, Value: value}
+
+	// absent / present / empty are special values in c7n
+	switch value {
+	case "absent":
+		filter.Op, filter.Value = "missing", nil
+	case "present", "not-null":
+		filter.Op, filter.Value = "exists", nil
+	case "empty":
+		filter.Op, filter.Value = "missing", nil
+		im.report.approximate(im.policy, field+".value", "'empty' imported as missing; empty strings and lists won't match")
+	}
+
+	switch valueType {
+	case "":
+	case "age":
+		// value_type: age compares a timestamp's age in days
+		inverted := map[string]string{"gt": "lt", "gte": "lte", "lt": "gt", "lte": "gte"}
+		if flipped, ok := inverted[filter.Op]; ok {
+			filter.Op = flipped
+			filter.Value = fmt.Sprintf("%v days ago", value)
+		} else {
+			im.report.drop(im.policy, field+".value_type", "age with operator '%s' is not supported", op)
+			return storage.StoredFilter{}, false
+		}
+	case "integer", "date", "normalize":
+		im.report.approximate(im.policy, field+".value_type", "value_type '%s' ignored; values are compared by type automatically", valueType)
+	default:
+		im.report.drop(im.policy, field+".value_type", "value_type '%s' is not supported", valueType)
+		return storage.StoredFilter{}, false
+	}
+
+	if tag, isTag := tagKey(key); isTag {
+		filter.Type, filter.Key = "tag", tag
+		if filter.Op == "missing" {
+			filter.Type, filter.Op = "tag-missing", ""
+		}
+		return filter, true
+	}
+
+	if alias, mapped := keyAliases[im.resource][key]; mapped {
+		filter.Key = alias
+	} else if keyPattern.MatchString(key) {
+		im.report.approximate(im.policy, field+".key",
+			"key '%s' kept as-is; check it matches a custodian-killer resource field", key)
+	}
+
+	return filter, true
+}
+
+// keyPattern spots AWS API shaped keys, which our resources don't use
+var keyPattern = regexp.MustCompile(`[A-Z]`)
+
+func (im *importer) importAction(raw interface{}, field string) (storage.StoredAction, bool) {
+	// Every imported action starts in dry run
+	action := storage.StoredAction{DryRun: true, Settings: map[string]interface{}{}}
+
+	definition, _ := raw.(map[string]interface{})
+	actionType, _ := raw.(string)
+	if definition != nil {
+		actionType, _ = definition["type"].(string)
+	}
+
+	handled := []string{"type"}
+	switch actionType {
+	case "tag", "mark":
+		action.Type = "tag"
+		if tags, ok := definition["tags"].(map[string]interface{}); ok {
+			keys := make([]string, 0, len(tags))
+			for key := range tags {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if tags[key] == nil {
+					im.report.drop(im.policy, field+".tags."+key, "tag '%s' has no value", key)
+					continue
+				}
+				action.Settings[key] = fmt.Sprint(tags[key])
+			}
+			if len(action.Settings) == 0 {
+				im.report.drop(im.policy, field, "'%s' action has no tags with values", actionType)
+				return action, false
+			}
+		} else {
+			// Like c7n: key or tag names the tag, value or msg holds its value
+			key, _ := definition["key"].(string)
+			if key == "" {
+				key, _ = definition["tag"].(string)
+			}
+			if key == "" {
+				key = scanner.DefaultMarkTag
+				im.report.approximate(im.policy, field+".key", "no tag key given; using c7n's default '%s'", key)
+			}
+			value, exists := definition["value"]
+			if value == nil {
+				value, exists = definition["msg"]
+			}
+			if !exists || value == nil {
+				im.report.drop(im.policy, field, "'%s' action for tag '%s' has no value", actionType, key)
+				return action, false
+			}
+			action.Settings[key] = fmt.Sprint(value)
+		}
+		handled = append(handled, "tags", "key", "tag", "value", "msg")
+
+	case "mark-for-op":
+		action.Type = "mark-for-op"
+		action.Settings["op"] = definition["op"]
+		if days, exists := definition["days"]; exists {
+			action.Settings["days"] = days
+		}
+		if hours, ok := definition["hours"].(int); ok {
+			days := (hours + 23) / 24
+			action.Settings["days"] = days
+			im.report.approximate(im.policy, field+".hours", "%d hours rounded up to %d day(s)", hours, days)
+		}
+		if tag, ok := definition["tag"].(string); ok {
+			action.Settings["tag"] = tag
+		}
+		handled = append(handled, "op", "days", "hours", "tag")
+
+	case "toggle-versioning":
+		if enabled, ok := definition["enabled"].(bool); ok && !enabled {
+			im.report.drop(im.policy, field, "suspending versioning is not supported")
+			return action, false
+		}
+		action.Type = "enable-versioning"
+		handled = append(handled, "enabled")
+
+	case "stop", "terminate", "delete":
+		action.Type = actionType
+		if force, ok := definition["force"].(bool); ok {
+			action.Settings["force"] = force
+		}
+		if skip, ok := definition["skip-snapshot"].(bool); ok && actionType == "delete" {
+			action.Settings["skip_final_snapshot"] = skip
+		}
+		handled = append(handled, "force", "skip-snapshot")
+
+	default:
+		ours, known := actionAliases[actionType]
+		if !known {
+			im.report.drop(im.policy, field, "action '%s' has no equivalent", actionType)
+			return action, false
+		}
+		action.Type = ours
+	}
+
+	var extra []string
+	for key := range definition {
+		if !containsString(handled, key) {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		im.report.drop(im.policy, field+"."+key, "'%s' setting of '%s' is not supported", key, actionType)
+	}
+
+	if len(action.Settings) == 0 {
+		action.Settings = nil
+	}
+	return action, true
+}
+
+// asList returns a YAML sequence, or nil for anything else
+func asList(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
@@ -0,0 +1,389 @@
+package c7n
+
+import (
+	"custodian-killer/storage"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// importOne imports a single c7n policy given as a plain value
+func importOne(t *testing.T, policy map[string]interface{}) (storage.StoredPolicy, *Report) {
+	t.Helper()
+	data, err := yaml.Marshal(map[string]interface{}{"policies": []interface{}{policy}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies, report, err := Import(data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(policies) != 1 {
+		t.Fatalf("imported %d policies, want 1", len(policies))
+	}
+	return policies[0], report
+}
+
+// noteFields describes a report's notes as "field dropped" or "field approximated"
+func noteFields(report *Report) []string {
+	var fields []string
+	for _, note := range report.Notes {
+		kind := "approximated"
+		if note.Dropped {
+			kind = "dropped"
+		}
+		fields = append(fields, note.Field+" "+kind)
+	}
+	return fields
+}
+
+type m = map[string]interface{}
+type l = []interface{}
+
+func TestImportFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		resource  string // defaults to aws.ec2
+		filters   l
+		want      []storage.StoredFilter
+		wantNotes []string
+	}{
+		{
+			name:    "tag shorthand",
+			filters: l{m{"tag:Env": "prod"}},
+			want:    []storage.StoredFilter{{Type: "tag", Key: "Env", Op: "eq", Value: "prod"}},
+		},
+		{
+			name:    "absent tag",
+			filters: l{m{"tag:Owner": "absent"}},
+			want:    []storage.StoredFilter{{Type: "tag-missing", Key: "Owner"}},
+		},
+		{
+			name:    "present tag",
+			filters: l{m{"type": "value", "key": "tag:Owner", "value": "present"}},
+			want:    []storage.StoredFilter{{Type: "tag", Key: "Owner", Op: "exists"}},
+		},
+		{
+			name:    "API key mapped to our field",
+			filters: l{m{"type": "value", "key": "State.Name", "value": "running"}},
+			want:    []storage.StoredFilter{{Type: "value", Key: "state", Op: "eq", Value: "running"}},
+		},
+		{
+			name:     "keys map per resource",
+			resource: "aws.rds",
+			filters:  l{m{"type": "value", "key": "BackupRetentionPeriod", "op": "less-than", "value": 7}},
+			want:     []storage.StoredFilter{{Type: "value", Key: "backup_retention_period", Op: "lt", Value: 7}},
+		},
+		{
+			name:    "ni operator",
+			filters: l{m{"type": "value", "key": "InstanceType", "op": "ni", "value": l{"t3.micro", "t3.small"}}},
+			want:    []storage.StoredFilter{{Type: "value", Key: "instance_type", Op: "not-in", Value: l{"t3.micro", "t3.small"}}},
+		},
+		{
+			name:    "age becomes a relative date",
+			filters: l{m{"type": "value", "key": "LaunchTime", "value_type": "age", "op": "gt", "value": 30}},
+			want:    []storage.StoredFilter{{Type: "value", Key: "launch_time", Op: "lt", Value: "30 days ago"}},
+		},
+		{
+			name: "nested groups",
+			filters: l{m{"or": l{
+				m{"tag:Env": "dev"},
+				m{"not": l{m{"type": "value", "key": "State.Name", "value": "stopped"}}},
+			}}},
+			want: []storage.StoredFilter{{Type: "or", Filters: []storage.StoredFilter{
+				{Type: "tag", Key: "Env", Op: "eq", Value: "dev"},
+				{Type: "not", Filters: []storage.StoredFilter{{Type: "value", Key: "state", Op: "eq", Value: "stopped"}}},
+			}}},
+		},
+		{
+			name:      "marked-for-op",
+			filters:   l{m{"type": "marked-for-op", "tag": "cleanup", "op": "stop", "skew": 1}},
+			want:      []storage.StoredFilter{{Type: "marked-for-op", Key: "cleanup", Value: "stop"}},
+			wantNotes: []string{"filters[0].skew dropped"},
+		},
+		{
+			name:      "empty is approximated",
+			filters:   l{m{"type": "value", "key": "PublicIpAddress", "value": "empty"}},
+			want:      []storage.StoredFilter{{Type: "value", Key: "public_ip", Op: "missing"}},
+			wantNotes: []string{"filters[0].value approximated"},
+		},
+		{
+			name:      "unmapped API key kept as-is",
+			filters:   l{m{"type": "value", "key": "IamInstanceProfile.Arn", "op": "contains", "value": "admin"}},
+			want:      []storage.StoredFilter{{Type: "value", Key: "IamInstanceProfile.Arn", Op: "contains", Value: "admin"}},
+			wantNotes: []string{"filters[0].key approximated"},
+		},
+		{
+			name:      "ignored value_type",
+			filters:   l{m{"type": "value", "key": "tag:Size", "value_type": "integer", "op": "gt", "value": 3}},
+			want:      []storage.StoredFilter{{Type: "tag", Key: "Size", Op: "gt", Value: 3}},
+			wantNotes: []string{"filters[0].value_type approximated"},
+		},
+		{
+			name: "unsupported filters are dropped",
+			filters: l{
+				m{"type": "metrics", "name": "CPUUtilization"},
+				m{"type": "value", "key": "InstanceType", "op": "regex", "value": "^t3"},
+				m{"type": "value", "key": "LaunchTime", "value_type": "expiration", "value": 5},
+				m{"type": "value", "value": "x"},
+				"instance-age",
+				m{"tag:Env": "dev"},
+			},
+			want: []storage.StoredFilter{{Type: "tag", Key: "Env", Op: "eq", Value: "dev"}},
+			wantNotes: []string{
+				"filters[0] dropped",
+				"filters[1].op dropped",
+				"filters[2].value_type dropped",
+				"filters[3] dropped",
+				"filters[4] dropped",
+			},
+		},
+		{
+			name:      "a group whose children are all dropped is dropped",
+			filters:   l{m{"and": l{m{"type": "metrics"}}}},
+			wantNotes: []string{"filters[0].and[0] dropped"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resource := test.resource
+			if resource == "" {
+				resource = "aws.ec2"
+			}
+			policy, report := importOne(t, m{"name": "p", "resource": resource, "filters": test.filters})
+
+			if !reflect.DeepEqual(policy.Filters, test.want) {
+				t.Errorf("filters = %#v, want %#v", policy.Filters, test.want)
+			}
+			if notes := noteFields(report); !reflect.DeepEqual(notes, test.wantNotes) {
+				t.Errorf("notes = %q, want %q", notes, test.wantNotes)
+			}
+			if got, want := report.DroppedFilters("p"), countDropped(test.wantNotes); got != want {
+				t.Errorf("DroppedFilters = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func countDropped(notes []string) int {
+	count := 0
+	for _, note := range notes {
+		if strings.HasSuffix(note, " dropped") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestImportActions(t *testing.T) {
+	tests := []struct {
+		name      string
+		action    interface{}
+		want      []storage.StoredAction
+		wantNotes []string
+	}{
+		{
+			name:   "plain name",
+			action: "stop",
+			want:   []storage.StoredAction{{Type: "stop", DryRun: true}},
+		},
+		{
+			name:   "renamed action",
+			action: "snapshot",
+			want:   []storage.StoredAction{{Type: "create-snapshot", DryRun: true}},
+		},
+		{
+			name:   "delete settings",
+			action: m{"type": "delete", "force": true, "skip-snapshot": true},
+			want: []storage.StoredAction{{Type: "delete", DryRun: true, Settings: m{
+				"force": true, "skip_final_snapshot": true,
+			}}},
+		},
+		{
+			name:   "tag key and value",
+			action: m{"type": "tag", "key": "Owner", "value": "ops"},
+			want:   []storage.StoredAction{{Type: "tag", DryRun: true, Settings: m{"Owner": "ops"}}},
+		},
+		{
+			name:   "tag map",
+			action: m{"type": "tag", "tags": m{"Owner": "ops", "Cost": 42}},
+			want:   []storage.StoredAction{{Type: "tag", DryRun: true, Settings: m{"Owner": "ops", "Cost": "42"}}},
+		},
+		{
+			name:   "mark with tag and msg",
+			action: m{"type": "mark", "tag": "cleanup", "msg": "flagged"},
+			want:   []storage.StoredAction{{Type: "tag", DryRun: true, Settings: m{"cleanup": "flagged"}}},
+		},
+		{
+			name:      "mark without a key uses c7n's default",
+			action:    m{"type": "mark", "value": "flagged"},
+			want:      []storage.StoredAction{{Type: "tag", DryRun: true, Settings: m{"maid_status": "flagged"}}},
+			wantNotes: []string{"actions[0].key approximated"},
+		},
+		{
+			name:      "tag without a value",
+			action:    m{"type": "tag", "key": "Owner"},
+			wantNotes: []string{"actions[0] dropped"},
+		},
+		{
+			name:      "tag with a null value",
+			action:    m{"type": "tag", "key": "Owner", "value": nil},
+			wantNotes: []string{"actions[0] dropped"},
+		},
+		{
+			name:      "tag map with a null value",
+			action:    m{"type": "tag", "tags": m{"Owner": "ops", "Team": nil}},
+			want:      []storage.StoredAction{{Type: "tag", DryRun: true, Settings: m{"Owner": "ops"}}},
+			wantNotes: []string{"actions[0].tags.Team dropped"},
+		},
+		{
+			name:      "tag map with only null values",
+			action:    m{"type": "tag", "tags": m{"Team": nil}},
+			wantNotes: []string{"actions[0].tags.Team dropped", "actions[0] dropped"},
+		},
+		{
+			name:      "mark-for-op hours round up to days",
+			action:    m{"type": "mark-for-op", "op": "stop", "hours": 30, "tag": "cleanup"},
+			want:      []storage.StoredAction{{Type: "mark-for-op", DryRun: true, Settings: m{"op": "stop", "days": 2, "tag": "cleanup"}}},
+			wantNotes: []string{"actions[0].hours approximated"},
+		},
+		{
+			name:   "enable versioning",
+			action: m{"type": "toggle-versioning", "enabled": true},
+			want:   []storage.StoredAction{{Type: "enable-versioning", DryRun: true}},
+		},
+		{
+			name:      "suspend versioning",
+			action:    m{"type": "toggle-versioning", "enabled": false},
+			wantNotes: []string{"actions[0] dropped"},
+		},
+		{
+			name:      "unknown action",
+			action:    m{"type": "notify", "to": l{"ops@example.com"}},
+			wantNotes: []string{"actions[0] dropped"},
+		},
+		{
+			name:      "unsupported setting",
+			action:    m{"type": "stop", "hibernate": true},
+			want:      []storage.StoredAction{{Type: "stop", DryRun: true}},
+			wantNotes: []string{"actions[0].hibernate dropped"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, report := importOne(t, m{"name": "p", "resource": "aws.ec2", "actions": l{test.action}})
+
+			if !reflect.DeepEqual(policy.Actions, test.want) {
+				t.Errorf("actions = %#v, want %#v", policy.Actions, test.want)
+			}
+			if notes := noteFields(report); !reflect.DeepEqual(notes, test.wantNotes) {
+				t.Errorf("notes = %q, want %q", notes, test.wantNotes)
+			}
+			if got, want := len(report.Dropped()), countDropped(test.wantNotes); got != want {
+				t.Errorf("Dropped = %d notes, want %d", got, want)
+			}
+			if got := report.DroppedFilters("p"); got != 0 {
+				t.Errorf("DroppedFilters = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestImportPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    m
+		check     func(t *testing.T, policy storage.StoredPolicy)
+		wantNotes []string
+	}{
+		{
+			name:   "imported as a draft",
+			policy: m{"name": "p", "resource": "aws.ec2", "comment": "from c7n"},
+			check: func(t *testing.T, policy storage.StoredPolicy) {
+				if policy.Status != "draft" || policy.Description != "from c7n" || policy.Mode.Type != "pull" {
+					t.Errorf("policy = %+v", policy)
+				}
+			},
+		},
+		{
+			name:      "resource alias",
+			policy:    m{"name": "p", "resource": "aws.app-elb"},
+			check:     func(t *testing.T, policy storage.StoredPolicy) { assertEqual(t, policy.ResourceType, "elb") },
+			wantNotes: []string{"resource approximated"},
+		},
+		{
+			name:      "unsupported resource",
+			policy:    m{"name": "p", "resource": "aws.sagemaker-notebook"},
+			wantNotes: []string{"resource dropped"},
+		},
+		{
+			name:      "no name",
+			policy:    m{"resource": "aws.ec2"},
+			check:     func(t *testing.T, policy storage.StoredPolicy) { assertEqual(t, policy.Name, "c7n-policy-1") },
+			wantNotes: []string{"name approximated"},
+		},
+		{
+			name: "region conditions",
+			policy: m{"name": "p", "resource": "aws.ec2", "conditions": l{
+				m{"type": "value", "key": "region", "op": "in", "value": l{"us-east-1", "eu-west-1"}},
+				m{"type": "value", "key": "region", "value": "eu-west-1"},
+				m{"type": "value", "key": "account_id", "value": "123"},
+			}},
+			check: func(t *testing.T, policy storage.StoredPolicy) {
+				assertEqual(t, policy.Regions, []string{"eu-west-1"})
+			},
+			wantNotes: []string{"conditions[2] dropped"},
+		},
+		{
+			name: "periodic mode",
+			policy: m{"name": "p", "resource": "aws.ec2", "mode": m{
+				"type": "periodic", "schedule": "rate(1 day)", "role": "arn:aws:iam::123:role/c7n",
+			}},
+			check: func(t *testing.T, policy storage.StoredPolicy) {
+				assertEqual(t, policy.Mode.Type, "periodic")
+				if policy.Mode.Schedule == "" {
+					t.Error("schedule not imported")
+				}
+			},
+			wantNotes: []string{"mode.role dropped"},
+		},
+		{
+			name: "event mode",
+			policy: m{"name": "p", "resource": "aws.ec2", "mode": m{
+				"type": "cloudtrail", "events": l{"RunInstances", m{"event": "CreateTags", "source": "ec2.amazonaws.com"}},
+			}},
+			check: func(t *testing.T, policy storage.StoredPolicy) {
+				assertEqual(t, policy.Mode.Settings["events"], "RunInstances,CreateTags")
+			},
+			wantNotes: []string{"mode approximated"},
+		},
+		{
+			name:      "unknown policy keys",
+			policy:    m{"name": "p", "resource": "aws.ec2", "tags": l{"x"}, "max-resources": 5},
+			wantNotes: []string{"max-resources dropped", "tags dropped"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, report := importOne(t, test.policy)
+			if test.check != nil {
+				test.check(t, policy)
+			}
+			if notes := noteFields(report); !reflect.DeepEqual(notes, test.wantNotes) {
+				t.Errorf("notes = %q, want %q", notes, test.wantNotes)
+			}
+		})
+	}
+}
+
+func assertEqual(t *testing.T, got, want interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+package c7n
+
+import (
+	"custodian-killer/schedule"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ratePattern  = regexp.MustCompile(`^rate\(\s*(\d+)\s+(minutes?|hours?|days?)\s*\)$`)
+	cronPattern  = regexp.MustCompile(`^cron\((.*)\)$`)
+	digitPattern = regexp.MustCompile(`\d+`)
+)
+
+// scheduleFromC7n converts an EventBridge rate() or cron() expression to our cron syntax
+func scheduleFromC7n(expression string) (string, error) {
+	expression = strings.TrimSpace(expression)
+
+	if match := ratePattern.FindStringSubmatch(expression); match != nil {
+		amount, _ := strconv.Atoi(match[1])
+		var unit time.Duration
+		switch strings.TrimSuffix(match[2], "s") {
+		case "minute":
+			unit = time.Minute
+		case "hour":
+			unit = time.Hour
+		case "day":
+			unit = 24 * time.Hour
+		}
+		converted := "@every " + formatInterval(time.Duration(amount)*unit)
+		return converted, validSchedule(converted)
+	}
+
+	match := cronPattern.FindStringSubmatch(expression)
+	if match == nil {
+		return "", fmt.Errorf("unsupported schedule '%s' (expected rate() or cron())", expression)
+	}
+
+	fields := strings.Fields(match[1])
+	if len(fields) != 6 {
+		return "", fmt.Errorf("expected 6 fields in '%s', got %d", expression, len(fields))
+	}
+	if fields[5] != "*" {
+		return "", fmt.Errorf("year restrictions are not supported in '%s'", expression)
+	}
+
+	for i := range fields[:5] {
+		if strings.ContainsAny(fields[i], "LW#") {
+			return "", fmt.Errorf("'%s' uses L, W or # which standard cron doesn't support", expression)
+		}
+		if fields[i] == "?" {
+			fields[i] = "*"
+		}
+	}
+	// EventBridge numbers weekdays 1-7 from Sunday, cron 0-6
+	fields[4] = shiftWeekdays(fields[4], -1)
+
+	converted := strings.Join(fields[:5], " ")
+	return converted, validSchedule(converted)
+}
+
+// scheduleToC7n converts one of our schedules to an EventBridge expression
+func scheduleToC7n(expression string) (string, error) {
+	parsed, err := schedule.Parse(expression)
+	if err != nil {
+		return "", err
+	}
+
+	if every := parsed.Every(); every > 0 {
+		switch {
+		case every%(24*time.Hour) == 0:
+			return rate(int(every/(24*time.Hour)), "day"), nil
+		case every%time.Hour == 0:
+			return rate(int(every/time.Hour), "hour"), nil
+		case every%time.Minute == 0:
+			return rate(int(every/time.Minute), "minute"), nil
+		}
+		return "", fmt.Errorf("interval %s is not a whole number of minutes", every)
+	}
+
+	fields := parsed.Fields()
+	// EventBridge wants '?' in one of the two day fields
+	switch {
+	case fields[4] == "*":
+		fields[4] = "?"
+	case fields[2] == "*":
+		fields[2] = "?"
+	default:
+		return "", fmt.Errorf("'%s' restricts both day of month and day of week, which EventBridge can't express", expression)
+	}
+	if fields[4] != "?" {
+		fields[4] = shiftWeekdays(fields[4], 1)
+	}
+
+	return fmt.Sprintf("cron(%s *)", strings.Join(fields, " ")), nil
+}
+
+// shiftWeekdays renumbers the numeric weekdays in a day-of-week field
+func shiftWeekdays(field string, delta int) string {
+	parts := strings.Split(field, ",")
+	for i, part := range parts {
+		// A step like */2 is a count, not a weekday
+		base, step, hasStep := strings.Cut(part, "/")
+		base = digitPattern.ReplaceAllStringFunc(base, func(digits string) string {
+			day, _ := strconv.Atoi(digits)
+			day += delta
+			if day > 7 {
+				day = 1 // our 7 is Sunday too
+			}
+			return strconv.Itoa(day)
+		})
+		if hasStep {
+			base += "/" + step
+		}
+		parts[i] = base
+	}
+	return strings.Join(parts, ",")
+}
+
+func rate(amount int, unit string) string {
+	if amount != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("rate(%d %s)", amount, unit)
+}
+
+// formatInterval writes a duration the way @every expects, e.g. 24h or 90m
+func formatInterval(interval time.Duration) string {
+	if interval%time.Hour == 0 {
+		return fmt.Sprintf("%dh", interval/time.Hour)
+	}
+	return fmt.Sprintf("%dm", interval/time.Minute)
+}
+
+func validSchedule(expression string) error {
+	_, err := schedule.Parse(expression)
+	return err
+}
@@ -1,11 +1,22 @@
 package main
 
 import (
+	"context"
 	"custodian-killer/aws"
+	"custodian-killer/c7n"
+	"custodian-killer/events"
+	"custodian-killer/inventory"
 	"custodian-killer/reports"
+	"custodian-killer/scanner"
+	"custodian-killer/schedule"
 	"custodian-killer/storage"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -55,22 +66,57 @@ var deletePolicyCmd = &cobra.Command{
 
 var exportPolicyCmd = &cobra.Command{
 	Use:   "export [policy-name] [output-file]",
-	Short: "Export a policy to file",
+	Short: "Export a policy to file (.json, .yaml or .yml)",
 	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		reportFile, _ := cmd.Flags().GetString("report")
+		if format == "c7n" {
+			exportC7nPolicy(args[0], args[1], reportFile)
+			return
+		}
 		exportPolicy(args[0], args[1])
 	},
 }
 
 var importPolicyCmd = &cobra.Command{
 	Use:   "import [input-file]",
-	Short: "Import a policy from file",
+	Short: "Import a policy from a JSON or YAML file",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		reportFile, _ := cmd.Flags().GetString("report")
+		if format == "c7n" {
+			allowPartial, _ := cmd.Flags().GetBool("allow-partial")
+			importC7nPolicies(args[0], reportFile, allowPartial)
+			return
+		}
 		importPolicy(args[0])
 	},
 }
 
+var lintPolicyCmd = &cobra.Command{
+	Use:   "lint [policy-name|file]",
+	Short: "Check policies for risky patterns",
+	Long:  "Lint one policy (by name or file) or every stored policy for best-practice problems",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runLintCommand(cmd, args)
+	},
+}
+
+var testPolicyCmd = &cobra.Command{
+	Use:   "test [test-file|dir]...",
+	Short: "Run policy tests against resource fixtures",
+	Long: `Run policy test suites (*.test.yaml, *.test.yml, *.test.json) offline.
+Each case loads resource fixtures, runs the policy's filters and actions
+without touching AWS, and compares the matches with what the case expects.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPolicyTestCommand(cmd, args)
+	},
+}
+
 // Scan command
 var scanCmd = &cobra.Command{
 	Use:   "scan",
@@ -122,6 +168,168 @@ var inventoryReportCmd = &cobra.Command{
 	},
 }
 
+// Inventory command
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Collect and manage offline resource snapshots",
+	Long:  "Snapshot every supported resource in the account, then scan policies against the snapshot with 'scan --from-snapshot'",
+}
+
+var inventoryCollectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Snapshot every supported resource type",
+	Run: func(cmd *cobra.Command, args []string) {
+		runInventoryCollect(cmd)
+	},
+}
+
+var inventoryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List collected snapshots",
+	Run: func(cmd *cobra.Command, args []string) {
+		runInventoryList()
+	},
+}
+
+var inventoryDiffCmd = &cobra.Command{
+	Use:   "diff [old] [new]",
+	Short: "Show resources added, removed or changed between two snapshots",
+	Long: `Compare two snapshots and report the resources added, removed or changed
+(tags, state, instance type, public access, encryption), and which changes
+made resources start or stop violating active policies.
+
+With no arguments the two latest snapshots are compared; with one, that
+snapshot is compared to the latest. Snapshots are paths, names from
+'inventory list', or 'latest'. --live collects a fresh snapshot to compare against.`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runInventoryDiff(cmd, args)
+	},
+}
+
+// Runs command
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "Browse the journal of policy executions",
+	Long:  "Every policy execution is journaled with its run ID, policy version, caller identity, region and per-action results",
+}
+
+var runsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List journaled runs, newest first",
+	Run: func(cmd *cobra.Command, args []string) {
+		runRunsList(cmd)
+	},
+}
+
+var runsShowCmd = &cobra.Command{
+	Use:   "show [run-id]",
+	Short: "Show one run with its per-action results",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRunsShow(cmd, args[0])
+	},
+}
+
+var runsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export journaled runs for audits",
+	Run: func(cmd *cobra.Command, args []string) {
+		runRunsExport(cmd)
+	},
+}
+
+// Rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [run-id]",
+	Short: "Undo the reversible actions of a journaled run",
+	Long:  "Restore what a run's stop, tag, mark-for-op, block-public-access and enable-versioning actions changed, using the state recorded before they ran",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRollback(cmd, args[0])
+	},
+}
+
+// Approve command
+var approveCmd = &cobra.Command{
+	Use:   "approve [plan-file]",
+	Short: "Sign a saved plan as one of its approvers",
+	Long:  "Add your signed approval to a plan file from 'scan --out'. Plans with high-impact actions need the number of approvals set with 'approvers require' before 'execute --plan' runs them.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runApprove(cmd, args[0])
+	},
+}
+
+// Approvers command
+var approversCmd = &cobra.Command{
+	Use:   "approvers",
+	Short: "Manage who can approve high-impact plans",
+	Long:  "Trusted approvers and the number of approvals high-impact plans need are kept in approvals.json next to the policies",
+}
+
+var approversKeygenCmd = &cobra.Command{
+	Use:   "keygen [name]",
+	Short: "Create a signing key for an approver",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runApproversKeygen(args[0])
+	},
+}
+
+var approversAddCmd = &cobra.Command{
+	Use:   "add [name] [public-key]",
+	Short: "Trust an approver's public key",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runApproversAdd(args[0], args[1])
+	},
+}
+
+var approversRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "Stop trusting an approver",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runApproversRemove(args[0])
+	},
+}
+
+var approversListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show trusted approvers and how many approvals are required",
+	Run: func(cmd *cobra.Command, args []string) {
+		runApproversList()
+	},
+}
+
+var approversRequireCmd = &cobra.Command{
+	Use:   "require [count]",
+	Short: "Set how many approvals high-impact plans need (0 turns the gate off)",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runApproversRequire(cmd, args[0])
+	},
+}
+
+// Daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run periodic policies on their schedules and event policies on CloudTrail events",
+	Long:  "Run every active policy in periodic mode on its cron schedule, journaling each run. Missed runs are caught up according to the policy's mode.settings.catch_up (skip, once or all). With --events-dir or --events-queue, event-mode policies run on the CloudTrail events in their mode.settings.events, against just the resources each event names.",
+	Run: func(cmd *cobra.Command, args []string) {
+		runDaemon(cmd)
+	},
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show scheduled and event policies with their last and next runs",
+	Run: func(cmd *cobra.Command, args []string) {
+		runDaemonStatus()
+	},
+}
+
 // Config command
 var configCmd = &cobra.Command{
 	Use:   "config",
@@ -141,7 +349,7 @@ var configTestCmd = &cobra.Command{
 	Use:   "test",
 	Short: "Test AWS connection",
 	Run: func(cmd *cobra.Command, args []string) {
-		testAWSConnection()
+		testAWSConnection(cmd.Context())
 	},
 }
 
@@ -153,12 +361,75 @@ func init() {
 	policyCmd.AddCommand(deletePolicyCmd)
 	policyCmd.AddCommand(exportPolicyCmd)
 	policyCmd.AddCommand(importPolicyCmd)
+	policyCmd.AddCommand(lintPolicyCmd)
+	policyCmd.AddCommand(testPolicyCmd)
+
+	testPolicyCmd.Flags().StringP("output", "o", "table", "Output format (table, json)")
+
+	importPolicyCmd.Flags().String("format", "native", "Policy format (native, c7n)")
+	importPolicyCmd.Flags().String("report", "", "Write the c7n translation report to this JSON file")
+	importPolicyCmd.Flags().Bool("allow-partial", false, "Import c7n policies even when some filters couldn't be translated")
+	exportPolicyCmd.Flags().String("format", "native", "Policy format (native, c7n)")
+	exportPolicyCmd.Flags().String("report", "", "Write the c7n translation report to this JSON file")
+
+	lintPolicyCmd.Flags().StringP("output", "o", "table", "Output format (table, json)")
+	lintPolicyCmd.Flags().String("fail-on", SeverityError, "Exit non-zero at this severity or above (info, warning, error, none)")
 
 	// Add subcommands to report command
 	reportCmd.AddCommand(complianceReportCmd)
 	reportCmd.AddCommand(costReportCmd)
 	reportCmd.AddCommand(inventoryReportCmd)
 
+	// Add subcommands to inventory command
+	inventoryCmd.AddCommand(inventoryCollectCmd)
+	inventoryCmd.AddCommand(inventoryListCmd)
+	inventoryCmd.AddCommand(inventoryDiffCmd)
+
+	inventoryCollectCmd.Flags().StringSlice("types", scanner.ScannableTypes, "Resource types to collect")
+	inventoryCollectCmd.Flags().StringP("region", "r", "", "AWS region to collect")
+	inventoryCollectCmd.Flags().String("dir", "", "Directory to write the snapshot to (default ~/.custodian-killer/inventory)")
+	inventoryDiffCmd.Flags().StringP("output", "o", "table", "Output format (table, json, html)")
+	inventoryDiffCmd.Flags().StringP("file", "f", "", "Output file path for json or html")
+	inventoryDiffCmd.Flags().Bool("live", false, "Collect a fresh snapshot now and compare against it")
+	inventoryDiffCmd.Flags().String("dir", "", "Snapshot directory (default ~/.custodian-killer/inventory)")
+
+	// Add subcommands to runs command
+	runsCmd.AddCommand(runsListCmd)
+	runsCmd.AddCommand(runsShowCmd)
+	runsCmd.AddCommand(runsExportCmd)
+
+	runsListCmd.Flags().StringP("policy", "p", "", "Only runs of this policy")
+	runsListCmd.Flags().IntP("limit", "n", 20, "Show at most this many runs (0 for all)")
+	runsListCmd.Flags().StringP("output", "o", "table", "Output format (table, json)")
+	runsShowCmd.Flags().StringP("output", "o", "table", "Output format (table, json)")
+	runsExportCmd.Flags().StringP("output", "o", "json", "Output format (json, csv)")
+	runsExportCmd.Flags().StringP("file", "f", "", "Output file path (default stdout)")
+	runsExportCmd.Flags().StringP("policy", "p", "", "Only runs of this policy")
+	runsExportCmd.Flags().String("since", "", "Only runs started on or after this date (YYYY-MM-DD or RFC 3339)")
+
+	rollbackCmd.Flags().BoolP("dry-run", "d", false, "Show what would be restored without changing anything")
+	rollbackCmd.Flags().BoolP("force", "f", false, "Skip confirmation, and roll back a run that was already rolled back")
+
+	approveCmd.Flags().StringP("name", "n", "", "Your approver name (required)")
+	approveCmd.Flags().StringP("key", "k", "", "Your signing key (default: the key 'approvers keygen' made for --name)")
+	approveCmd.Flags().BoolP("force", "f", false, "Skip confirmation")
+	approveCmd.MarkFlagRequired("name")
+
+	approversCmd.AddCommand(approversKeygenCmd)
+	approversCmd.AddCommand(approversAddCmd)
+	approversCmd.AddCommand(approversRemoveCmd)
+	approversCmd.AddCommand(approversListCmd)
+	approversCmd.AddCommand(approversRequireCmd)
+	approversRequireCmd.Flags().StringSlice("account", nil, "Only require approvals in these accounts (default: all accounts)")
+
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.Flags().Duration("interval", 30*time.Second, "How often to check schedules")
+	daemonCmd.Flags().BoolP("dry-run", "d", false, "Run every policy in dry-run mode")
+	daemonCmd.Flags().Bool("once", false, "Check schedules once, wait for due runs, and exit")
+	daemonCmd.Flags().String("log-file", "", "Also append the daemon log to this file")
+	daemonCmd.Flags().String("events-dir", "", "Read CloudTrail log files (*.json.gz) from this directory")
+	daemonCmd.Flags().String("events-queue", "", "Receive CloudTrail events from this SQS queue URL")
+
 	// Add subcommands to config command
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configTestCmd)
@@ -168,25 +439,42 @@ func init() {
 	scanCmd.Flags().BoolP("verbose", "v", false, "Verbose output")
 	scanCmd.Flags().StringP("output", "o", "table", "Output format (table, json, csv)")
 	scanCmd.Flags().StringP("region", "r", "", "AWS region to scan")
+	scanCmd.Flags().String("regions", "", "Scan in several regions at once: 'all' or a list such as us-east-1,eu-west-1")
+	scanCmd.Flags().String("accounts", "", "Scan in the accounts from the accounts file matching these names, IDs or tags, e.g. prod-* or env=prod")
+	scanCmd.Flags().String("from-snapshot", "", "Scan an inventory snapshot file (or 'latest') instead of AWS")
+	scanCmd.Flags().String("out", "", "Save the scan as a plan file for 'execute --plan' (needs --policy)")
 
 	// Add flags to execute command
 	executeCmd.Flags().StringP("policy", "p", "", "Execute specific policy only")
 	executeCmd.Flags().BoolP("force", "f", false, "Force execution without confirmation")
 	executeCmd.Flags().BoolP("dry-run", "d", false, "Dry run mode (same as scan)")
 	executeCmd.Flags().StringP("region", "r", "", "AWS region to execute in")
+	executeCmd.Flags().String("regions", "", "Execute in several regions at once: 'all' or a list such as us-east-1,eu-west-1")
+	executeCmd.Flags().String("accounts", "", "Execute in the accounts from the accounts file matching these names, IDs or tags, e.g. prod-* or env=prod")
+	executeCmd.Flags().String("plan", "", "Apply a plan file saved by 'scan --out' instead of re-scanning")
+	executeCmd.Flags().Bool("all", false, "Execute every active policy")
+	executeCmd.Flags().Int("concurrency", 5, "How many policies and action batches run at once, all told")
+	executeCmd.Flags().Int("batch-size", 10, "How many resources each action call covers")
+	executeCmd.Flags().Duration("timeout", 30*time.Minute, "Stop starting new actions on a policy after this long")
 
 	// Add flags to report commands
 	complianceReportCmd.Flags().StringP("output", "o", "html", "Output format (html, json, csv)")
 	complianceReportCmd.Flags().StringP("file", "f", "", "Output file path")
 	complianceReportCmd.Flags().StringP("region", "r", "", "AWS region to analyze")
+	complianceReportCmd.Flags().String("regions", "", "Analyze several regions: 'all' or a list such as us-east-1,eu-west-1")
+	complianceReportCmd.Flags().String("accounts", "", "Analyze the accounts from the accounts file matching these names, IDs or tags, e.g. prod-* or env=prod")
 
 	costReportCmd.Flags().StringP("output", "o", "csv", "Output format (html, json, csv)")
 	costReportCmd.Flags().StringP("file", "f", "", "Output file path")
 	costReportCmd.Flags().StringP("region", "r", "", "AWS region to analyze")
+	costReportCmd.Flags().String("regions", "", "Analyze several regions: 'all' or a list such as us-east-1,eu-west-1")
+	costReportCmd.Flags().String("accounts", "", "Analyze the accounts from the accounts file matching these names, IDs or tags, e.g. prod-* or env=prod")
 
 	inventoryReportCmd.Flags().StringP("output", "o", "csv", "Output format (csv, json)")
 	inventoryReportCmd.Flags().StringP("file", "f", "", "Output file path")
 	inventoryReportCmd.Flags().StringP("region", "r", "", "AWS region to analyze")
+	inventoryReportCmd.Flags().String("regions", "", "Analyze several regions: 'all' or a list such as us-east-1,eu-west-1")
+	inventoryReportCmd.Flags().String("accounts", "", "Analyze the accounts from the accounts file matching these names, IDs or tags, e.g. prod-* or env=prod")
 }
 
 // Command implementations
@@ -207,9 +495,9 @@ func editPolicy(policyName string) {
 
 	fmt.Println("📝 Policy editing via interactive mode - coming soon!")
 	fmt.Println("💡 For now, you can:")
-	fmt.Printf("   1. Export: custodian-killer policy export %s policy.json\n", policyName)
-	fmt.Println("   2. Edit the JSON file manually")
-	fmt.Printf("   3. Import: custodian-killer policy import policy.json\n")
+	fmt.Printf("   1. Export: custodian-killer policy export %s policy.yaml\n", policyName)
+	fmt.Println("   2. Edit the YAML file manually (comments are kept)")
+	fmt.Printf("   3. Import: custodian-killer policy import policy.yaml\n")
 }
 
 func deletePolicy(policyName string) {
@@ -287,6 +575,18 @@ func importPolicy(inputFile string) {
 		return
 	}
 
+	// Validate before anything is written
+	policy, err := storage.LoadPolicyFile(inputFile)
+	if err != nil {
+		fmt.Printf("❌ Failed to import policy: %v\n", err)
+		return
+	}
+	if errs := ValidateStoredPolicy(policy); len(errs) > 0 {
+		printValidationErrors(errs)
+		fmt.Println("❌ Policy not imported")
+		return
+	}
+
 	// Import the policy
 	if fileStorage, ok := policyStorage.(*storage.FileStorage); ok {
 		if err := fileStorage.ImportPolicy(inputFile); err != nil {
@@ -301,26 +601,340 @@ func importPolicy(inputFile string) {
 	fmt.Printf("✅ Policy imported successfully\n")
 }
 
-func runScanCommand(cmd *cobra.Command) {
-	fmt.Println("🔍 Running policy scan...")
+// importC7nPolicies translates a Cloud Custodian policy file and saves every
+// policy that passes validation. Policies that lost filters in translation would
+// match more than the original, so they're skipped unless allowPartial is set.
+func importC7nPolicies(inputFile, reportFile string, allowPartial bool) {
+	fmt.Printf("📥 Importing Cloud Custodian policies from: %s\n", inputFile)
+
+	if policyStorage == nil {
+		fmt.Println("❌ Storage not initialized!")
+		return
+	}
+
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		fmt.Printf("❌ Failed to read file: %v\n", err)
+		return
+	}
+
+	policies, report, err := c7n.Import(data)
+	if err != nil {
+		fmt.Printf("❌ Failed to import policies: %v\n", err)
+		return
+	}
+
+	imported := 0
+	for i := range policies {
+		policy := &policies[i]
+		if dropped := report.DroppedFilters(policy.Name); dropped > 0 && !allowPartial {
+			fmt.Printf("❌ Policy '%s' not imported: %d filter(s) couldn't be translated, so it would match more resources\n", policy.Name, dropped)
+			fmt.Println("💡 Use --allow-partial to import it anyway")
+			continue
+		}
+		if errs := ValidateStoredPolicy(policy); len(errs) > 0 {
+			fmt.Printf("\n📋 %s\n", policy.Name)
+			printValidationErrors(errs)
+			fmt.Printf("❌ Policy '%s' not imported\n", policy.Name)
+			continue
+		}
+		if err := policyStorage.SavePolicy(*policy); err != nil {
+			fmt.Printf("❌ Failed to save policy '%s': %v\n", policy.Name, err)
+			continue
+		}
+		imported++
+	}
+
+	printC7nReport(report, reportFile)
+	fmt.Printf("✅ Imported %d of %d policies as drafts with every action in dry run\n", imported, len(policies))
+}
+
+// exportC7nPolicy writes a policy as a Cloud Custodian policy file
+func exportC7nPolicy(policyName, outputFile, reportFile string) {
+	fmt.Printf("📤 Exporting policy '%s' for Cloud Custodian to: %s\n", policyName, outputFile)
+
+	if policyStorage == nil {
+		fmt.Println("❌ Storage not initialized!")
+		return
+	}
+
+	policy, err := policyStorage.GetPolicy(policyName)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	data, report, err := c7n.Export([]storage.StoredPolicy{*policy})
+	if err != nil {
+		fmt.Printf("❌ Failed to export policy: %v\n", err)
+		return
+	}
+
+	if err := os.WriteFile(outputFile, data, 0644); err != nil {
+		fmt.Printf("❌ Failed to write export file: %v\n", err)
+		return
+	}
+
+	printC7nReport(report, reportFile)
+	fmt.Printf("✅ Policy exported successfully\n")
+}
+
+// printC7nReport lists what a c7n translation dropped or approximated
+func printC7nReport(report *c7n.Report, reportFile string) {
+	if len(report.Notes) > 0 {
+		fmt.Printf("\n📝 Translation report (%d note(s), %d dropped):\n", len(report.Notes), len(report.Dropped()))
+		for _, note := range report.Notes {
+			icon := "⚠️ "
+			if note.Dropped {
+				icon = "❌"
+			}
+			fmt.Printf("   %s %s %s: %s\n", icon, note.Policy, note.Field, note.Message)
+		}
+		fmt.Println()
+	}
+
+	if reportFile == "" {
+		return
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Printf("❌ Failed to encode translation report: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(reportFile, data, 0644); err != nil {
+		fmt.Printf("❌ Failed to write translation report: %v\n", err)
+		return
+	}
+	fmt.Printf("📝 Translation report written to: %s\n", reportFile)
+}
+
+func runPolicyTestCommand(cmd *cobra.Command, args []string) {
+	outputFormat, _ := cmd.Flags().GetString("output")
+
+	suites, err := FindPolicyTests(args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if len(suites) == 0 {
+		fmt.Println("❌ No policy tests found (looking for *.test.yaml, *.test.yml, *.test.json)")
+		os.Exit(1)
+	}
+
+	results := []PolicyTestResult{}
+	failed := false
+	for _, suite := range suites {
+		suiteResults, err := RunPolicyTestSuite(suite)
+		if err != nil {
+			// A suite that can't load counts as one failed case
+			suiteResults = []PolicyTestResult{{Suite: suite, Case: "load", Failures: []string{err.Error()}}}
+		}
+		for _, result := range suiteResults {
+			failed = failed || !result.Passed
+		}
+		results = append(results, suiteResults...)
+	}
+
+	if outputFormat == "json" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Failed to encode test results: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	} else {
+		printPolicyTestResults(results)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// printPolicyTestResults shows a PASS / FAIL line per case
+func printPolicyTestResults(results []PolicyTestResult) {
+	passed := 0
+	currentSuite := ""
+	for _, result := range results {
+		if result.Suite != currentSuite {
+			currentSuite = result.Suite
+			fmt.Printf("\n🧪 %s", result.Suite)
+			if result.Policy != "" {
+				fmt.Printf(" (policy: %s)", result.Policy)
+			}
+			fmt.Println()
+		}
+
+		if result.Passed {
+			passed++
+			fmt.Printf("   ✅ PASS %s\n", result.Case)
+			continue
+		}
+		fmt.Printf("   ❌ FAIL %s\n", result.Case)
+		for _, failure := range result.Failures {
+			fmt.Printf("      • %s\n", failure)
+		}
+	}
+
+	fmt.Printf("\n📊 %d passed, %d failed\n", passed, len(results)-passed)
+}
+
+func runLintCommand(cmd *cobra.Command, args []string) {
+	outputFormat, _ := cmd.Flags().GetString("output")
+	failOn, _ := cmd.Flags().GetString("fail-on")
+
+	if _, known := severityRank[failOn]; !known && failOn != "none" {
+		fmt.Printf("❌ Unknown --fail-on severity: %s (expected info, warning, error or none)\n", failOn)
+		os.Exit(1)
+	}
+
+	policies, err := loadPoliciesToLint(args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	findings := []LintFinding{}
+	for i := range policies {
+		findings = append(findings, LintPolicy(&policies[i])...)
+	}
+
+	switch outputFormat {
+	case "json":
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Failed to encode lint results: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	default:
+		printLintFindings(findings, len(policies))
+	}
+
+	for _, finding := range findings {
+		if severityAtLeast(finding.Severity, failOn) {
+			os.Exit(1)
+		}
+	}
+}
+
+// loadPoliciesToLint resolves the lint argument to a policy file, a stored policy
+// or, with no argument, every stored policy
+func loadPoliciesToLint(args []string) ([]storage.StoredPolicy, error) {
+	if len(args) == 1 {
+		if info, err := os.Stat(args[0]); err == nil && !info.IsDir() {
+			policy, err := storage.LoadPolicyFile(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return []storage.StoredPolicy{*policy}, nil
+		}
+	}
+
+	if policyStorage == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	if len(args) == 1 {
+		policy, err := policyStorage.GetPolicy(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("policy '%s' not found: %v", args[0], err)
+		}
+		return []storage.StoredPolicy{*policy}, nil
+	}
 
+	return policyStorage.ListPolicies()
+}
+
+// printLintFindings shows lint results grouped by policy
+func printLintFindings(findings []LintFinding, policyCount int) {
+	if len(findings) == 0 {
+		fmt.Printf("✅ %d policy(ies) linted - no problems found\n", policyCount)
+		return
+	}
+
+	icons := map[string]string{
+		SeverityError:   "❌",
+		SeverityWarning: "⚠️ ",
+		SeverityInfo:    "💡",
+	}
+
+	counts := make(map[string]int)
+	currentPolicy := ""
+	for _, finding := range findings {
+		if finding.Policy != currentPolicy || currentPolicy == "" {
+			currentPolicy = finding.Policy
+			fmt.Printf("\n📋 %s\n", finding.Policy)
+		}
+		fmt.Printf("   %s [%s] %s: %s\n", icons[finding.Severity], finding.Rule, finding.Field, finding.Message)
+		counts[finding.Severity]++
+	}
+
+	fmt.Printf(
+		"\n🔎 %d policy(ies) linted: %d error(s), %d warning(s), %d info\n",
+		policyCount,
+		counts[SeverityError],
+		counts[SeverityWarning],
+		counts[SeverityInfo],
+	)
+}
+
+func runScanCommand(cmd *cobra.Command) {
 	// Get flags
 	specificPolicy, _ := cmd.Flags().GetString("policy")
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	outputFormat, _ := cmd.Flags().GetString("output")
 	region, _ := cmd.Flags().GetString("region")
+	fromSnapshot, _ := cmd.Flags().GetString("from-snapshot")
+	planFile, _ := cmd.Flags().GetString("out")
+	regions := regionsFlag(cmd)
+	accounts := accountsFlag(cmd)
+
+	if planFile != "" && specificPolicy == "" {
+		fmt.Println("❌ --out saves one policy's plan; choose it with --policy")
+		os.Exit(1)
+	}
+	if planFile != "" && fromSnapshot != "" {
+		fmt.Println("❌ Plans are made from live resources; --out can't be used with --from-snapshot")
+		os.Exit(1)
+	}
+	if regions != "" && (planFile != "" || fromSnapshot != "") {
+		fmt.Println("❌ --regions scans live resources in each region; it can't be used with --out or --from-snapshot")
+		os.Exit(1)
+	}
+	if accounts != nil && (planFile != "" || fromSnapshot != "") {
+		fmt.Println("❌ --accounts scans live resources in each account; it can't be used with --out or --from-snapshot")
+		os.Exit(1)
+	}
+
+	// Keep JSON output machine-readable
+	jsonOutput := specificPolicy != "" && outputFormat == "json"
+	if !jsonOutput {
+		fmt.Println("🔍 Running policy scan...")
+	}
 
 	// Set region if provided
 	if region != "" {
 		os.Setenv("AWS_REGION", region)
 	}
 
+	if accounts != nil {
+		runAccountsScan(cmd.Context(), accounts, specificPolicy, regions, outputFormat)
+		return
+	}
+	if regions != "" {
+		runMultiRegionScan(cmd.Context(), specificPolicy, regions, outputFormat)
+		return
+	}
+
 	if specificPolicy != "" {
-		fmt.Printf("🎯 Scanning specific policy: %s\n", specificPolicy)
-		runSpecificPolicyScan(specificPolicy, verbose, outputFormat)
+		if !jsonOutput {
+			fmt.Printf("🎯 Scanning specific policy: %s\n", specificPolicy)
+		}
+		runSpecificPolicyScan(cmd.Context(), specificPolicy, verbose, outputFormat, fromSnapshot, planFile)
 	} else {
 		fmt.Println("🚀 Scanning all active policies")
-		runScan() // Use the interactive function
+		runScanFrom(cmd.Context(), fromSnapshot) // Use the interactive function
 	}
 }
 
@@ -332,12 +946,42 @@ func runExecuteCommand(cmd *cobra.Command) {
 	force, _ := cmd.Flags().GetBool("force")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	region, _ := cmd.Flags().GetString("region")
+	planFile, _ := cmd.Flags().GetString("plan")
+	all, _ := cmd.Flags().GetBool("all")
+	regions := regionsFlag(cmd)
+	accounts := accountsFlag(cmd)
+
+	if all && specificPolicy != "" {
+		fmt.Println("❌ --all and --policy can't be used together")
+		os.Exit(1)
+	}
+	if regions != "" && planFile != "" {
+		fmt.Println("❌ A plan is for the region it was made in; --regions can't be used with --plan")
+		os.Exit(1)
+	}
+	if regions != "" && !dryRun && !all && specificPolicy == "" {
+		fmt.Println("❌ --regions needs --policy or --all")
+		os.Exit(1)
+	}
+	if accounts != nil && planFile != "" {
+		fmt.Println("❌ A plan is for the account it was made in; --accounts can't be used with --plan")
+		os.Exit(1)
+	}
+	if accounts != nil && !dryRun && !all && specificPolicy == "" {
+		fmt.Println("❌ --accounts needs --policy or --all")
+		os.Exit(1)
+	}
 
 	// Set region if provided
 	if region != "" {
 		os.Setenv("AWS_REGION", region)
 	}
 
+	if planFile != "" {
+		runPlanExecution(cmd.Context(), planFile, specificPolicy, dryRun, force)
+		return
+	}
+
 	if dryRun {
 		fmt.Println("🧪 Dry-run mode enabled - no changes will be made")
 		runScanCommand(cmd)
@@ -357,10 +1001,28 @@ func runExecuteCommand(cmd *cobra.Command) {
 
 	if specificPolicy != "" {
 		fmt.Printf("🎯 Executing specific policy: %s\n", specificPolicy)
-		runSpecificPolicyExecution(specificPolicy)
+		runPolicyExecutions(cmd, []string{specificPolicy}, force)
+	} else if all {
+		policies, err := policyStorage.ListPolicies()
+		if err != nil {
+			fmt.Printf("❌ Failed to list policies: %v\n", err)
+			os.Exit(1)
+		}
+		var names []string
+		for _, policy := range policies {
+			if policy.Status == "active" {
+				names = append(names, policy.Name)
+			}
+		}
+		if len(names) == 0 {
+			fmt.Println("📋 No active policies found!")
+			return
+		}
+		fmt.Printf("🚀 Executing %d active policies\n", len(names))
+		runPolicyExecutions(cmd, names, force)
 	} else {
 		fmt.Println("🚀 Executing policies")
-		executePolicy() // Use the interactive function
+		executePolicy(cmd.Context()) // Use the interactive function
 	}
 }
 
@@ -370,23 +1032,18 @@ func generateComplianceReportCmd(cmd *cobra.Command) {
 	outputFormat, _ := cmd.Flags().GetString("output")
 	outputFile, _ := cmd.Flags().GetString("file")
 	region, _ := cmd.Flags().GetString("region")
+	regions := regionsFlag(cmd)
 
 	// Set region if provided
 	if region != "" {
 		os.Setenv("AWS_REGION", region)
 	}
 
-	// Initialize AWS client
-	awsClient, err := initializeAWSClient(true)
-	if err != nil {
-		fmt.Printf("❌ Failed to initialize AWS client: %v\n", err)
+	// Get resources
+	ec2Instances, s3Buckets, ok := reportResources(cmd, regions)
+	if !ok {
 		return
 	}
-	defer awsClient.Close()
-
-	// Get resources
-	ec2Instances, _ := awsClient.GetEC2Instances(aws.EC2Filter{})
-	s3Buckets, _ := awsClient.GetS3Buckets(aws.S3Filter{})
 
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
 
@@ -418,23 +1075,18 @@ func generateCostReportCmd(cmd *cobra.Command) {
 	outputFormat, _ := cmd.Flags().GetString("output")
 	outputFile, _ := cmd.Flags().GetString("file")
 	region, _ := cmd.Flags().GetString("region")
+	regions := regionsFlag(cmd)
 
 	// Set region if provided
 	if region != "" {
 		os.Setenv("AWS_REGION", region)
 	}
 
-	// Initialize AWS client
-	awsClient, err := initializeAWSClient(true)
-	if err != nil {
-		fmt.Printf("❌ Failed to initialize AWS client: %v\n", err)
+	// Get resources
+	ec2Instances, s3Buckets, ok := reportResources(cmd, regions)
+	if !ok {
 		return
 	}
-	defer awsClient.Close()
-
-	// Get resources
-	ec2Instances, _ := awsClient.GetEC2Instances(aws.EC2Filter{})
-	s3Buckets, _ := awsClient.GetS3Buckets(aws.S3Filter{})
 
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
 
@@ -451,23 +1103,18 @@ func generateInventoryReportCmd(cmd *cobra.Command) {
 	outputFormat, _ := cmd.Flags().GetString("output")
 	outputFile, _ := cmd.Flags().GetString("file")
 	region, _ := cmd.Flags().GetString("region")
+	regions := regionsFlag(cmd)
 
 	// Set region if provided
 	if region != "" {
 		os.Setenv("AWS_REGION", region)
 	}
 
-	// Initialize AWS client
-	awsClient, err := initializeAWSClient(true)
-	if err != nil {
-		fmt.Printf("❌ Failed to initialize AWS client: %v\n", err)
+	// Get resources
+	ec2Instances, s3Buckets, ok := reportResources(cmd, regions)
+	if !ok {
 		return
 	}
-	defer awsClient.Close()
-
-	// Get resources
-	ec2Instances, _ := awsClient.GetEC2Instances(aws.EC2Filter{})
-	s3Buckets, _ := awsClient.GetS3Buckets(aws.S3Filter{})
 
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
 
@@ -583,7 +1230,7 @@ func showConfig() {
 	}
 }
 
-func testAWSConnection() {
+func testAWSConnection(ctx context.Context) {
 	fmt.Println("🧪 Testing AWS connection...")
 
 	awsClient, err := initializeAWSClient(true)
@@ -603,7 +1250,7 @@ func testAWSConnection() {
 	// Test basic API calls
 	fmt.Println("\n🔍 Testing API access...")
 
-	regions, err := awsClient.GetRegions()
+	regions, err := awsClient.GetRegions(ctx)
 	if err != nil {
 		fmt.Printf("⚠️  Failed to list regions: %v\n", err)
 	} else {
@@ -618,16 +1265,1240 @@ func testAWSConnection() {
 }
 
 // Helper functions
-func runSpecificPolicyScan(policyName string, verbose bool, outputFormat string) {
-	// Implementation for scanning specific policy
-	fmt.Printf("🎯 Scanning policy: %s\n", policyName)
-	fmt.Println("💡 Use the main 'scan' command for now")
+func runSpecificPolicyScan(ctx context.Context, policyName string, verbose bool, outputFormat string, snapshotFile string, planFile string) {
+	if policyStorage == nil {
+		fmt.Println("❌ Storage not initialized!")
+		os.Exit(1)
+	}
+
+	// A plan also records the client's region and account
+	var (
+		provider      scanner.ResourceProvider
+		closeProvider func()
+		awsClient     *aws.CustodianClient
+		err           error
+	)
+	if planFile != "" {
+		awsClient, err = initializeAWSClient(true)
+		if err == nil {
+			provider, closeProvider = scanner.NewAWSResourceProvider(awsClient), func() { awsClient.Close() }
+		}
+	} else {
+		provider, closeProvider, err = newScanProvider(snapshotFile)
+	}
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize resource source: %v\n", err)
+		os.Exit(1)
+	}
+	defer closeProvider()
+
+	jsonOutput := outputFormat == "json"
+	if snapshot, ok := provider.(*inventory.Snapshot); ok && !jsonOutput {
+		printSnapshotSource(snapshot)
+	}
+
+	policyScanner := scanner.NewPolicyScanner(policyStorage, provider, scanner.ScannerConfig{
+		AWSRegion:     providerRegion(provider),
+		DryRunDefault: true,
+		MaxResources:  1000,
+		Timeout:       300,
+		Quiet:         jsonOutput,
+	})
+
+	result, err := policyScanner.ScanPolicy(ctx, policyName)
+	if err != nil {
+		fmt.Printf("❌ Failed to scan policy: %v\n", err)
+		os.Exit(1)
+	}
+
+	if planFile != "" {
+		if err := savePlanFile(ctx, planFile, awsClient, result); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Failed to encode scan result: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	displayScanResult(result)
+
+	if planFile != "" {
+		fmt.Printf("\n📝 Plan saved to %s - apply it with: custodian-killer execute --plan %s\n", planFile, planFile)
+	}
+}
+
+// savePlanFile writes a scan result as a plan, refusing scans that had errors
+// since their matches may be incomplete
+func savePlanFile(ctx context.Context, path string, awsClient *aws.CustodianClient, result *scanner.ScanResult) error {
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("scan had errors, not saving a plan: %s", strings.Join(result.Errors, "; "))
+	}
+
+	policy, err := policyStorage.GetPolicy(result.PolicyName)
+	if err != nil {
+		return fmt.Errorf("failed to load policy: %v", err)
+	}
+
+	plan := &ExecutionPlan{
+		FormatVersion: planFormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		Region:        awsClient.Region,
+		PolicyName:    policy.Name,
+		PolicyVersion: policy.Version,
+		PolicyHash:    PolicyHash(policy),
+		Scan:          result,
+	}
+	if caller, err := awsClient.GetCallerIdentity(ctx); err == nil {
+		plan.Account = caller.Account
+	}
+
+	return SavePlan(plan, path)
+}
+
+// runPlanExecution applies a plan file through the executor
+func runPlanExecution(ctx context.Context, planFile string, policyName string, dryRun bool, force bool) {
+	plan, err := LoadPlan(planFile)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if policyName != "" && policyName != plan.PolicyName {
+		fmt.Printf("❌ Plan is for policy '%s', not '%s'\n", plan.PolicyName, policyName)
+		os.Exit(1)
+	}
+
+	actions := 0
+	for _, resource := range plan.Scan.MatchedResources {
+		actions += len(resource.Actions)
+	}
+	fmt.Printf("📝 Plan: %s - %d actions on %d resources in %s\n",
+		plan.PolicyName, actions, len(plan.Scan.MatchedResources), plan.Region)
+
+	if len(plan.Scan.MatchedResources) == 0 {
+		fmt.Println("✅ Plan has no resources - nothing to do!")
+		return
+	}
+
+	if dryRun {
+		fmt.Println("🧪 Dry-run mode enabled - no changes will be made")
+	} else if !force {
+		fmt.Print("⚠️  This will make real changes to AWS resources. Continue? (y/N): ")
+		var confirm string
+		fmt.Scanln(&confirm)
+
+		if strings.ToLower(confirm) != "y" && strings.ToLower(confirm) != "yes" {
+			fmt.Println("❌ Execution cancelled")
+			return
+		}
+	}
+
+	awsClient, err := initializeAWSClient(dryRun)
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize AWS client: %v\n", err)
+		os.Exit(1)
+	}
+	defer awsClient.Close()
+
+	if awsClient.Region != plan.Region {
+		if err := awsClient.SwitchRegion(plan.Region); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	executor := NewPolicyExecutor(awsClient, policyStorage)
+	if _, err := executor.ExecutePlan(ctx, plan); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runInventoryCollect(cmd *cobra.Command) {
+	resourceTypes, _ := cmd.Flags().GetStringSlice("types")
+	region, _ := cmd.Flags().GetString("region")
+	dir, _ := cmd.Flags().GetString("dir")
+
+	if region != "" {
+		os.Setenv("AWS_REGION", region)
+	}
+
+	snapshot, path, err := collectSnapshot(cmd.Context(), resourceTypes, dir)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Snapshot of %d resources saved to: %s\n", snapshot.Count(), path)
+	fmt.Println("💡 Scan it with: custodian-killer scan --from-snapshot latest")
+}
+
+// collectSnapshot snapshots the account through the AWS client and saves it to dir
+func collectSnapshot(ctx context.Context, resourceTypes []string, dir string) (*inventory.Snapshot, string, error) {
+	if dir == "" {
+		var err error
+		if dir, err = inventory.DefaultDir(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	fmt.Println("📸 Collecting inventory snapshot...")
+
+	awsClient, err := initializeAWSClient(true)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to initialize AWS client: %v", err)
+	}
+	defer awsClient.Close()
+
+	snapshot := inventory.Collect(ctx, scanner.NewAWSResourceProvider(awsClient), resourceTypes, awsClient.Region)
+	snapshot.Profile = awsClient.Profile
+	if ctx.Err() != nil {
+		return nil, "", fmt.Errorf("interrupted, snapshot not saved")
+	}
+
+	fmt.Println("\n📦 Collected:")
+	for _, resourceType := range snapshot.Types {
+		fmt.Printf("   • %s: %d\n", strings.ToUpper(resourceType), len(snapshot.Resources[resourceType]))
+	}
+	for _, resourceType := range resourceTypes {
+		if reason, failed := snapshot.Errors[resourceType]; failed {
+			fmt.Printf("   ⚠️  %s: %s\n", strings.ToUpper(resourceType), reason)
+		}
+	}
+
+	if len(snapshot.Types) == 0 {
+		return nil, "", fmt.Errorf("nothing was collected")
+	}
+
+	path, err := snapshot.Save(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	return snapshot, path, nil
+}
+
+func runInventoryList() {
+	dir, err := inventory.DefaultDir()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	files, err := inventory.List(dir)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(files) == 0 {
+		fmt.Println("📋 No snapshots found!")
+		fmt.Println("💡 Collect one with 'custodian-killer inventory collect'")
+		return
+	}
+
+	fmt.Printf("📸 Snapshots (%d):\n", len(files))
+	for _, file := range files {
+		snapshot, err := inventory.Load(file)
+		if err != nil {
+			fmt.Printf("   ⚠️  %s: %v\n", file, err)
+			continue
+		}
+		fmt.Printf("   • %s  %s  %s  %d resources (%s)\n",
+			filepath.Base(file),
+			snapshot.CollectedAt.Local().Format("2006-01-02 15:04:05"),
+			snapshot.Region,
+			snapshot.Count(),
+			strings.Join(snapshot.Types, ", "),
+		)
+	}
+}
+
+func runInventoryDiff(cmd *cobra.Command, args []string) {
+	outputFormat, _ := cmd.Flags().GetString("output")
+	outputFile, _ := cmd.Flags().GetString("file")
+	live, _ := cmd.Flags().GetBool("live")
+	dir, _ := cmd.Flags().GetString("dir")
+
+	if outputFormat != "table" && outputFormat != "json" && outputFormat != "html" {
+		fmt.Printf("❌ Unsupported output format: %s\n", outputFormat)
+		os.Exit(1)
+	}
+
+	if dir == "" {
+		var err error
+		if dir, err = inventory.DefaultDir(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fromPath, toPath, err := diffSnapshotPaths(args, live, dir)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if live {
+		if _, toPath, err = collectSnapshot(cmd.Context(), scanner.ScannableTypes, dir); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println()
+	}
+
+	from, err := inventory.Load(fromPath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	to, err := inventory.Load(toPath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	diff := inventory.Compare(from, to)
+	diff.From.Path = fromPath
+	diff.To.Path = toPath
+
+	policies, err := policyStorage.ListPolicies()
+	if err != nil {
+		fmt.Printf("⚠️  Skipping policy violations: %v\n", err)
+	} else {
+		var activePolicies []storage.StoredPolicy
+		for _, policy := range policies {
+			if policy.Status == "active" {
+				activePolicies = append(activePolicies, policy)
+			}
+		}
+		inventory.CompareViolations(diff, activePolicies, from, to)
+	}
+
+	switch outputFormat {
+	case "json":
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Failed to encode diff: %v\n", err)
+			os.Exit(1)
+		}
+		if outputFile == "" {
+			fmt.Println(string(data))
+			return
+		}
+		if err := os.WriteFile(outputFile, data, 0644); err != nil {
+			fmt.Printf("❌ Failed to write diff: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Drift report saved: %s\n", outputFile)
+	case "html":
+		if outputFile == "" {
+			outputFile = fmt.Sprintf("drift_report_%s.html", time.Now().Format("2006-01-02_15-04-05"))
+		}
+		htmlGen := reports.NewHTMLReportGenerator(filepath.Dir(outputFile))
+		if err := htmlGen.SaveDriftReport(diff, filepath.Base(outputFile)); err != nil {
+			fmt.Printf("❌ Failed to generate HTML report: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		displayInventoryDiff(diff)
+	}
+}
+
+// diffSnapshotPaths picks the snapshots to compare from the arguments. With
+// --live the newer side is collected afterwards, so only the older is returned.
+func diffSnapshotPaths(args []string, live bool, dir string) (string, string, error) {
+	if len(args) == 2 && live {
+		return "", "", fmt.Errorf("--live compares one snapshot against the account; pass at most one")
+	}
+
+	if len(args) == 0 {
+		files, err := inventory.List(dir)
+		if err != nil {
+			return "", "", err
+		}
+		switch {
+		case live && len(files) > 0:
+			return files[len(files)-1], "", nil
+		case !live && len(files) >= 2:
+			return files[len(files)-2], files[len(files)-1], nil
+		case live:
+			return "", "", fmt.Errorf("no snapshots in %s; run 'inventory collect' first", dir)
+		default:
+			return "", "", fmt.Errorf("need two snapshots in %s to compare (found %d); run 'inventory collect' or use --live", dir, len(files))
+		}
+	}
+
+	fromPath, err := inventory.Resolve(args[0], dir)
+	if err != nil {
+		return "", "", err
+	}
+	if live {
+		return fromPath, "", nil
+	}
+
+	toArg := "latest"
+	if len(args) == 2 {
+		toArg = args[1]
+	}
+	toPath, err := inventory.Resolve(toArg, dir)
+	if err != nil {
+		return "", "", err
+	}
+	return fromPath, toPath, nil
+}
+
+// displayInventoryDiff prints a diff as a readable summary
+func displayInventoryDiff(diff *inventory.Diff) {
+	fmt.Println("🔍 Resource drift")
+	fmt.Printf("   From: %s (%s)\n", filepath.Base(diff.From.Path), diff.From.CollectedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("   To:   %s (%s)\n", filepath.Base(diff.To.Path), diff.To.CollectedAt.Local().Format("2006-01-02 15:04:05"))
+	if diff.From.Region != diff.To.Region {
+		fmt.Printf("   ⚠️  Snapshots are from different regions (%s, %s)\n", diff.From.Region, diff.To.Region)
+	}
+	if len(diff.Skipped) > 0 {
+		fmt.Printf("   ⚠️  Not in both snapshots, skipped: %s\n", strings.Join(diff.Skipped, ", "))
+	}
+
+	if !diff.HasChanges() && len(diff.Violations) == 0 {
+		fmt.Println("\n✅ No drift - nothing changed")
+		return
+	}
+
+	if len(diff.Added) > 0 {
+		fmt.Printf("\n➕ Added (%d):\n", len(diff.Added))
+		for _, resource := range diff.Added {
+			fmt.Printf("   • %s %s (%s)\n", strings.ToUpper(resource.ResourceType), resource.ID, resource.Name)
+			for _, issue := range resource.Issues {
+				fmt.Printf("       ⚠️  %s\n", issue)
+			}
+		}
+	}
+
+	if len(diff.Removed) > 0 {
+		fmt.Printf("\n➖ Removed (%d):\n", len(diff.Removed))
+		for _, resource := range diff.Removed {
+			fmt.Printf("   • %s %s (%s)\n", strings.ToUpper(resource.ResourceType), resource.ID, resource.Name)
+		}
+	}
+
+	if len(diff.Changed) > 0 {
+		fmt.Printf("\n✏️  Changed (%d):\n", len(diff.Changed))
+		for _, resource := range diff.Changed {
+			fmt.Printf("   • %s %s (%s)\n", strings.ToUpper(resource.ResourceType), resource.ID, resource.Name)
+			for _, change := range resource.Changes {
+				fmt.Printf("       %s: %s → %s\n", change.Field, diffValue(change.Before), diffValue(change.After))
+			}
+			for _, issue := range resource.NewIssues {
+				fmt.Printf("       🔴 %s\n", issue)
+			}
+			for _, issue := range resource.ResolvedIssues {
+				fmt.Printf("       🟢 %s\n", issue)
+			}
+		}
+	}
+
+	if introduced := diff.NewViolations(); len(introduced) > 0 {
+		fmt.Printf("\n🚨 New violations (%d):\n", len(introduced))
+		for _, violation := range introduced {
+			cause := "changed"
+			if violation.Added {
+				cause = "added"
+			}
+			fmt.Printf("   • %s: %s (%s, %s)\n", violation.Policy, violation.Resource.ID, violation.Resource.Name, cause)
+		}
+	}
+
+	if resolved := len(diff.Violations) - len(diff.NewViolations()); resolved > 0 {
+		fmt.Printf("\n🟢 Resolved violations (%d):\n", resolved)
+		for _, violation := range diff.Violations {
+			if violation.Status == "resolved" {
+				fmt.Printf("   • %s: %s (%s)\n", violation.Policy, violation.Resource.ID, violation.Resource.Name)
+			}
+		}
+	}
+
+	for _, failure := range diff.Errors {
+		fmt.Printf("\n⚠️  Couldn't compare policy %s\n", failure)
+	}
+}
+
+// diffValue formats one side of a field change
+func diffValue(value interface{}) string {
+	if value == nil {
+		return "—"
+	}
+	return fmt.Sprint(value)
+}
+
+func runRunsList(cmd *cobra.Command) {
+	policyName, _ := cmd.Flags().GetString("policy")
+	limit, _ := cmd.Flags().GetInt("limit")
+	outputFormat, _ := cmd.Flags().GetString("output")
+
+	records := loadRuns(policyName, time.Time{})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+
+	if outputFormat == "json" {
+		printJSON(records)
+		return
+	}
+
+	if len(records) == 0 {
+		fmt.Println("📋 No runs journaled yet!")
+		fmt.Println("💡 Runs are recorded whenever a policy is executed")
+		return
+	}
+
+	fmt.Printf("🧾 Runs (%d):\n", len(records))
+	for _, record := range records {
+		status := "✅"
+		if record.Result.Stopped != "" {
+			status = "⏹️ "
+		} else if !record.Result.Success {
+			status = "❌"
+		}
+		mode := "live"
+		if record.Result.DryRun {
+			mode = "dry-run"
+		}
+		if record.Result.Stopped != "" {
+			mode += ", " + record.Result.Stopped
+		}
+		if record.RollbackOf != "" {
+			mode += ", rollback of " + record.RollbackOf
+		}
+		fmt.Printf("   %s %s  %s  %s v%d  %s  %s  %d/%d actions ok\n",
+			status,
+			record.ID,
+			record.Result.StartTime.Local().Format("2006-01-02 15:04:05"),
+			record.PolicyName,
+			record.PolicyVersion,
+			runLocation(record),
+			mode,
+			record.Result.Summary.SuccessfulActions,
+			record.Result.Summary.TotalActions,
+		)
+	}
+}
+
+// runLocation is where a run happened: its region, prefixed by the account
+// for runs made with --accounts
+func runLocation(record RunRecord) string {
+	if record.Result.Account != "" {
+		return record.Result.Account + "/" + record.Region
+	}
+	return record.Region
+}
+
+func runRunsShow(cmd *cobra.Command, runID string) {
+	outputFormat, _ := cmd.Flags().GetString("output")
+
+	journal, err := openRunJournal(policyStorage)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	record, err := journal.Get(runID)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if outputFormat == "json" {
+		printJSON(record)
+		return
+	}
+
+	fmt.Printf("🧾 Run %s\n", record.ID)
+	fmt.Printf("📋 Policy: %s (version %d)\n", record.PolicyName, record.PolicyVersion)
+	fmt.Printf("🌍 Region: %s\n", record.Region)
+	if record.Profile != "" {
+		fmt.Printf("👤 Profile: %s\n", record.Profile)
+	}
+	if record.Caller != nil {
+		fmt.Printf("🔑 Caller: %s (account %s)\n", record.Caller.Arn, record.Caller.Account)
+	} else if record.CallerError != "" {
+		fmt.Printf("🔑 Caller: unknown (%s)\n", record.CallerError)
+	}
+	fmt.Printf("🕐 Started: %s\n", record.Result.StartTime.Local().Format("2006-01-02 15:04:05"))
+	if record.RollbackOf != "" {
+		fmt.Printf("↩️  Rollback of: %s\n", record.RollbackOf)
+	}
+	if len(record.Result.Approvers) > 0 {
+		fmt.Printf("✍️  Approved by: %s\n", strings.Join(record.Result.Approvers, ", "))
+	}
+	if record.Result.Trigger != "" {
+		fmt.Printf("📡 Triggered by: %s\n", record.Result.Trigger)
+	}
+
+	displayExecutionResult(record.Result)
+}
+
+func runRunsExport(cmd *cobra.Command) {
+	outputFormat, _ := cmd.Flags().GetString("output")
+	outputFile, _ := cmd.Flags().GetString("file")
+	policyName, _ := cmd.Flags().GetString("policy")
+	sinceFlag, _ := cmd.Flags().GetString("since")
+
+	var since time.Time
+	if sinceFlag != "" {
+		var err error
+		if since, err = time.ParseInLocation("2006-01-02", sinceFlag, time.Local); err != nil {
+			if since, err = time.Parse(time.RFC3339, sinceFlag); err != nil {
+				fmt.Printf("❌ Invalid --since %q: use YYYY-MM-DD or RFC 3339\n", sinceFlag)
+				os.Exit(1)
+			}
+		}
+	}
+
+	records := loadRuns(policyName, since)
+
+	out := os.Stdout
+	if outputFile != "" {
+		file, err := os.Create(outputFile)
+		if err != nil {
+			fmt.Printf("❌ Failed to create file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch outputFormat {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(records); err != nil {
+			fmt.Printf("❌ Failed to export runs: %v\n", err)
+			os.Exit(1)
+		}
+	case "csv":
+		if err := writeRunsCSV(out, records); err != nil {
+			fmt.Printf("❌ Failed to export runs: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("❌ Unsupported output format: %s\n", outputFormat)
+		os.Exit(1)
+	}
+
+	if outputFile != "" {
+		fmt.Printf("✅ Exported %d runs to: %s\n", len(records), outputFile)
+	}
+}
+
+func runRollback(cmd *cobra.Command, runID string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+
+	journal, err := openRunJournal(policyStorage)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	record, err := journal.Get(runID)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if record.RollbackOf != "" {
+		fmt.Printf("❌ Run %s is itself a rollback (of %s)\n", record.ID, record.RollbackOf)
+		os.Exit(1)
+	}
+	if record.Result.DryRun {
+		fmt.Printf("✅ Run %s was a dry run - nothing to roll back\n", record.ID)
+		return
+	}
+
+	if !force {
+		records, err := journal.List()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		for _, other := range records {
+			if other.RollbackOf == record.ID && !other.Result.DryRun {
+				fmt.Printf("❌ Run %s was already rolled back by %s (use --force to roll back again)\n", record.ID, other.ID)
+				os.Exit(1)
+			}
+		}
+	}
+
+	reversible, irreversible := 0, 0
+	for _, action := range record.Result.ActionResults {
+		if !action.Success || action.DryRun {
+			continue
+		}
+		if action.Prior != nil {
+			reversible++
+		} else {
+			irreversible++
+		}
+	}
+
+	fmt.Printf("↩️  Rolling back run %s (%s, %s)\n", record.ID, record.PolicyName, record.Region)
+	fmt.Printf("   • %d actions can be rolled back\n", reversible)
+	if irreversible > 0 {
+		fmt.Printf("   • ⚠️  %d actions can't be rolled back (no prior state recorded)\n", irreversible)
+	}
+	if reversible == 0 {
+		fmt.Println("❌ Nothing to roll back")
+		os.Exit(1)
+	}
+
+	if !dryRun && !force {
+		fmt.Print("⚠️  This will make real changes to AWS resources. Continue? (y/N): ")
+		var confirm string
+		fmt.Scanln(&confirm)
+
+		if strings.ToLower(confirm) != "y" && strings.ToLower(confirm) != "yes" {
+			fmt.Println("❌ Rollback cancelled")
+			return
+		}
+	}
+
+	var awsClient *aws.CustodianClient
+	if record.Result.Account != "" {
+		// Runs made with --accounts are undone through the same account's role
+		account, lookupErr := lookupAccount(record.Result.Account)
+		if lookupErr != nil {
+			fmt.Printf("❌ %v\n", lookupErr)
+			os.Exit(1)
+		}
+		awsClient, err = initializeAccountClient(cmd.Context(), account, dryRun)
+	} else {
+		awsClient, err = initializeAWSClient(dryRun)
+	}
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize AWS client: %v\n", err)
+		os.Exit(1)
+	}
+	defer awsClient.Close()
+
+	if record.Region != "" && awsClient.Region != record.Region {
+		if err := awsClient.SwitchRegion(record.Region); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	result := RollbackRun(cmd.Context(), awsClient, record)
+	displayExecutionResult(result)
+
+	rollback := &RunRecord{
+		ID:            result.RunID,
+		PolicyName:    record.PolicyName,
+		PolicyVersion: record.PolicyVersion,
+		Region:        awsClient.Region,
+		Profile:       awsClient.Profile,
+		RollbackOf:    record.ID,
+		Result:        result,
+	}
+	// Journal the rollback even when it was interrupted
+	if rollback.Caller, err = awsClient.GetCallerIdentity(context.WithoutCancel(cmd.Context())); err != nil {
+		rollback.CallerError = err.Error()
+	}
+	if err := journal.Save(rollback); err != nil {
+		fmt.Printf("⚠️  Failed to journal the rollback: %v\n", err)
+	} else {
+		fmt.Printf("💾 Rollback saved as run %s\n", rollback.ID)
+	}
+
+	if !result.Success {
+		os.Exit(1)
+	}
+}
+
+func runApprove(cmd *cobra.Command, planFile string) {
+	name, _ := cmd.Flags().GetString("name")
+	keyPath, _ := cmd.Flags().GetString("key")
+	force, _ := cmd.Flags().GetBool("force")
+
+	plan, err := LoadPlan(planFile)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if keyPath == "" {
+		keyPath = approverKeyPath(name)
+	}
+	key, err := loadApproverKey(keyPath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	config := loadApprovalConfigOrExit()
+	if trusted := config.Approver(name); trusted == nil {
+		fmt.Printf("⚠️  %s isn't a trusted approver here, so this approval won't count until they're added\n", name)
+	} else if publicKey, err := parsePublicKey(trusted.PublicKey); err != nil || !publicKey.Equal(key.Public()) {
+		fmt.Printf("⚠️  %s doesn't match the key trusted for %s, so this approval won't count\n", keyPath, name)
+	}
+
+	fmt.Printf("📝 Plan: %s (policy v%d, %s", plan.PolicyName, plan.PolicyVersion, plan.Region)
+	if plan.Account != "" {
+		fmt.Printf(", account %s", plan.Account)
+	}
+	fmt.Printf(")\n🕐 Made: %s\n", plan.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("🎯 %d %s resources:\n", len(plan.Scan.MatchedResources), strings.ToUpper(plan.Scan.ResourceType))
+	for _, resource := range plan.Scan.MatchedResources {
+		var actions []string
+		for _, action := range resource.Actions {
+			actions = append(actions, action.Type)
+		}
+		fmt.Printf("   • %s: %s\n", resource.ID, strings.Join(actions, ", "))
+	}
+	if highImpact := highImpactActions(plan); len(highImpact) > 0 {
+		fmt.Printf("🔥 High-impact actions: %s\n", strings.Join(highImpact, ", "))
+	}
+
+	if !force {
+		fmt.Printf("✍️  Approve this plan as %s? (y/N): ", name)
+		var confirm string
+		fmt.Scanln(&confirm)
+
+		if strings.ToLower(confirm) != "y" && strings.ToLower(confirm) != "yes" {
+			fmt.Println("❌ Approval cancelled")
+			return
+		}
+	}
+
+	if err := SignPlan(plan, name, key); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if err := SavePlan(plan, planFile); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	valid, _ := VerifyApprovals(plan, config)
+	fmt.Printf("✅ Approved by %s; plan now has %d valid approvals", name, len(valid))
+	if config.Required > 0 {
+		fmt.Printf(" of %d required", config.Required)
+	}
+	fmt.Println()
+}
+
+func runApproversKeygen(name string) {
+	path := approverKeyPath(name)
+	publicKey, err := GenerateApproverKey(path)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("🔑 Signing key for %s saved to: %s\n", name, path)
+	fmt.Printf("📢 Public key: %s\n", publicKey)
+	fmt.Printf("💡 Whoever runs executions trusts it with: custodian-killer approvers add %s %s\n", name, publicKey)
+}
+
+func runApproversAdd(name string, publicKey string) {
+	if _, err := parsePublicKey(publicKey); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	config := loadApprovalConfigOrExit()
+	if trusted := config.Approver(name); trusted != nil {
+		trusted.PublicKey = publicKey
+		trusted.AddedAt = time.Now().UTC()
+	} else {
+		config.Approvers = append(config.Approvers, TrustedApprover{
+			Name:      name,
+			PublicKey: publicKey,
+			AddedAt:   time.Now().UTC(),
+		})
+	}
+	saveApprovalConfigOrExit(config)
+
+	fmt.Printf("✅ %s is now a trusted approver\n", name)
+}
+
+func runApproversRemove(name string) {
+	config := loadApprovalConfigOrExit()
+
+	var kept []TrustedApprover
+	for _, trusted := range config.Approvers {
+		if trusted.Name != name {
+			kept = append(kept, trusted)
+		}
+	}
+	if len(kept) == len(config.Approvers) {
+		fmt.Printf("❌ %s is not a trusted approver\n", name)
+		os.Exit(1)
+	}
+	config.Approvers = kept
+	saveApprovalConfigOrExit(config)
+
+	fmt.Printf("🗑️  %s is no longer a trusted approver\n", name)
+	if len(kept) < config.Required {
+		fmt.Printf("⚠️  Only %d approvers are left but %d approvals are required\n", len(kept), config.Required)
+	}
+}
+
+func runApproversList() {
+	config := loadApprovalConfigOrExit()
+
+	if config.Required > 0 {
+		scope := "in every account"
+		if len(config.Accounts) > 0 {
+			scope = "in accounts " + strings.Join(config.Accounts, ", ")
+		}
+		fmt.Printf("✍️  High-impact plans need %d approvals %s\n", config.Required, scope)
+	} else {
+		fmt.Println("✍️  No approvals required (set with 'approvers require')")
+	}
+
+	if len(config.Approvers) == 0 {
+		fmt.Println("👥 No trusted approvers")
+		return
+	}
+	fmt.Printf("👥 Trusted approvers (%d):\n", len(config.Approvers))
+	for _, trusted := range config.Approvers {
+		fmt.Printf("   • %s  %s  added %s\n", trusted.Name, trusted.PublicKey, trusted.AddedAt.Local().Format("2006-01-02"))
+	}
+}
+
+func runApproversRequire(cmd *cobra.Command, count string) {
+	accounts, _ := cmd.Flags().GetStringSlice("account")
+
+	required, err := strconv.Atoi(count)
+	if err != nil || required < 0 {
+		fmt.Printf("❌ Invalid approval count: %s\n", count)
+		os.Exit(1)
+	}
+
+	config := loadApprovalConfigOrExit()
+	config.Required = required
+	config.Accounts = accounts
+	saveApprovalConfigOrExit(config)
+
+	if required == 0 {
+		fmt.Println("✅ Approvals are no longer required")
+		return
+	}
+	fmt.Printf("✅ High-impact plans now need %d approvals from distinct approvers\n", required)
+	if len(config.Approvers) < required {
+		fmt.Printf("⚠️  Only %d approvers are trusted so far\n", len(config.Approvers))
+	}
+}
+
+// approverKeyPath is where 'approvers keygen' keeps a signing key
+func approverKeyPath(name string) string {
+	baseDir := ""
+	if fileStorage, ok := policyStorage.(*storage.FileStorage); ok {
+		baseDir = fileStorage.BaseDir()
+	}
+	return filepath.Join(baseDir, "keys", name+".key")
 }
 
-func runSpecificPolicyExecution(policyName string) {
-	// Implementation for executing specific policy
-	fmt.Printf("⚡ Executing policy: %s\n", policyName)
-	fmt.Println("💡 Use the main 'execute' command for now")
+// loadApprovalConfigOrExit reads the approval config, exiting if it can't
+func loadApprovalConfigOrExit() *ApprovalConfig {
+	path, err := approvalConfigPath(policyStorage)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	config, err := LoadApprovalConfig(path)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	return config
+}
+
+// saveApprovalConfigOrExit writes the approval config, exiting if it can't
+func saveApprovalConfigOrExit(config *ApprovalConfig) {
+	path, err := approvalConfigPath(policyStorage)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if err := config.Save(path); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runDaemon(cmd *cobra.Command) {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	once, _ := cmd.Flags().GetBool("once")
+	logFile, _ := cmd.Flags().GetString("log-file")
+	eventsDir, _ := cmd.Flags().GetString("events-dir")
+	eventsQueue, _ := cmd.Flags().GetString("events-queue")
+
+	if eventsDir != "" && eventsQueue != "" {
+		fmt.Println("❌ --events-dir and --events-queue can't be used together")
+		os.Exit(1)
+	}
+
+	if logFile != "" {
+		file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			fmt.Printf("❌ Failed to open log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		log.SetOutput(io.MultiWriter(os.Stderr, file))
+	}
+
+	// In Once mode, read what is there now rather than waiting for more
+	wait := interval
+	if once {
+		wait = 0
+	}
+
+	var source events.Source
+	switch {
+	case eventsDir != "":
+		fileStorage, ok := policyStorage.(*storage.FileStorage)
+		if !ok {
+			fmt.Println("❌ Reading events needs file storage")
+			os.Exit(1)
+		}
+		// A dry run doesn't record the files it handled
+		dirSource, err := events.NewDirSource(eventsDir, filepath.Join(fileStorage.BaseDir(), "events-handled.json"), wait, dryRun)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		source = dirSource
+	case eventsQueue != "":
+		// A dry-run client leaves handled messages on the queue
+		client, err := initializeAWSClient(dryRun)
+		if err != nil {
+			fmt.Printf("❌ Failed to initialize AWS client: %v\n", err)
+			os.Exit(1)
+		}
+		if region := aws.QueueRegion(eventsQueue); region != "" && region != client.Region {
+			if err := client.SwitchRegion(region); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+		}
+		source = events.NewSQSSource(client, eventsQueue, wait)
+	}
+
+	daemon, err := NewDaemon(policyStorage, DaemonConfig{
+		Interval: interval,
+		DryRun:   dryRun,
+		Once:     once,
+		Events:   source,
+	}, initializeAWSClient)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	mode := "live"
+	if dryRun {
+		mode = "dry-run"
+	}
+	log.Printf("🕰️  Daemon started (%s, checking every %s)", mode, interval)
+	daemon.Run(cmd.Context())
+}
+
+func runDaemonStatus() {
+	daemon, err := NewDaemon(policyStorage, DaemonConfig{}, initializeAWSClient)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	policies, err := policyStorage.ListPolicies()
+	if err != nil {
+		fmt.Printf("❌ Failed to list policies: %v\n", err)
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	count := 0
+	for _, policy := range policies {
+		if policy.Mode.Type == "event" {
+			count++
+			printEventPolicyStatus(policy, daemon.state.Policies[policy.Name])
+			continue
+		}
+		if policy.Mode.Type != "periodic" {
+			continue
+		}
+		count++
+
+		fmt.Printf("\n📅 %s  '%s'  catch_up: %s", policy.Name, policy.Mode.Schedule, catchUpMode(policy.Mode.Settings["catch_up"]))
+		if policy.Status != "active" {
+			fmt.Printf("  (%s - not scheduled)", policy.Status)
+		}
+		fmt.Println()
+
+		state := daemon.state.Policies[policy.Name]
+		if state != nil && state.LastRunID != "" {
+			fmt.Printf("   🧾 Last run: %s", state.LastRunID)
+			if state.LastError != "" {
+				fmt.Printf(" (failed: %s)", state.LastError)
+			}
+			fmt.Println()
+		}
+
+		sched, err := schedule.Parse(policy.Mode.Schedule)
+		if err != nil {
+			fmt.Printf("   ⚠️  Invalid schedule: %v\n", err)
+			continue
+		}
+		from := now
+		if state != nil && state.Schedule == policy.Mode.Schedule {
+			from = state.LastSlot
+		}
+		if next := sched.Next(from); next.Before(now) {
+			fmt.Printf("   ⏰ Overdue since %s\n", next.Local().Format("2006-01-02 15:04"))
+		} else {
+			fmt.Printf("   ⏰ Next run: %s\n", next.Local().Format("2006-01-02 15:04"))
+		}
+	}
+
+	if count == 0 {
+		fmt.Println("📭 No periodic or event policies")
+	}
+}
+
+// printEventPolicyStatus shows an event-mode policy's events and last run
+func printEventPolicyStatus(policy storage.StoredPolicy, state *policyScheduleState) {
+	names := eventNames(policy.Mode)
+	if len(names) == 0 {
+		fmt.Printf("\n📡 %s  no events - never runs", policy.Name)
+	} else {
+		fmt.Printf("\n📡 %s  on %s", policy.Name, strings.Join(names, ", "))
+	}
+	if policy.Status != "active" {
+		fmt.Printf("  (%s - not listening)", policy.Status)
+	}
+	fmt.Println()
+
+	if state != nil && state.LastRunID != "" {
+		fmt.Printf("   🧾 Last run: %s for %s", state.LastRunID, state.LastEvent)
+		if state.LastError != "" {
+			fmt.Printf(" (failed: %s)", state.LastError)
+		}
+		fmt.Println()
+	}
+}
+
+// loadRuns reads the journal, exiting if it can't be opened
+func loadRuns(policyName string, since time.Time) []RunRecord {
+	journal, err := openRunJournal(policyStorage)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	records, err := journal.List()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	return filterRuns(records, policyName, since)
+}
+
+// printJSON prints a value as indented JSON, exiting if it can't be encoded
+func printJSON(value interface{}) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		fmt.Printf("❌ Failed to encode JSON: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
+
+// newCommandExecutor creates an executor set up from the execute flags
+func newCommandExecutor(cmd *cobra.Command, awsClient *aws.CustodianClient, force bool) *PolicyExecutor {
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	executor := NewPolicyExecutor(awsClient, policyStorage)
+	config := executor.config
+	config.MaxConcurrency = concurrency
+	config.BatchSize = batchSize
+	config.TimeoutPerPolicy = timeout
+	config.ConfirmActions = !force
+	executor.SetConfig(config)
+	return executor
+}
+
+// runPolicyExecutions runs policies on the executor's worker pool, in each
+// region of --regions when it is set, and lists the outcomes in the order the
+// policies were given. With --accounts it runs them in each account instead.
+func runPolicyExecutions(cmd *cobra.Command, policyNames []string, force bool) {
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	regionsSpec, _ := cmd.Flags().GetString("regions")
+	if accounts := accountsFlag(cmd); accounts != nil {
+		runAccountExecutions(cmd, accounts, policyNames, force)
+		return
+	}
+
+	awsClient, err := initializeAWSClient(false)
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize AWS client: %v\n", err)
+		os.Exit(1)
+	}
+	defer awsClient.Close()
+
+	var regions []string
+	if regionsSpec != "" {
+		regions, err = resolveRegions(cmd.Context(), awsClient, regionsSpec)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("🌍 Executing in %d regions: %s\n", len(regions), strings.Join(regions, ", "))
+	}
+
+	executor := newCommandExecutor(cmd, awsClient, force)
+
+	start := time.Now()
+	results := executor.ExecutePoliciesInRegions(cmd.Context(), policyNames, regions)
+
+	runs := "policies"
+	if len(regions) > 0 {
+		runs = fmt.Sprintf("policy runs across %d regions", len(regions))
+	}
+	fmt.Printf("\n📊 Ran %d %s in %s (concurrency %d)\n",
+		len(results), runs, time.Since(start).Round(time.Second), concurrency)
+	failed := 0
+	for _, result := range results {
+		status := "✅"
+		if result.Stopped != "" {
+			status = "⏹️ "
+			failed++
+		} else if !result.Success {
+			status = "❌"
+			failed++
+		}
+		fmt.Printf("   %s %s  %s  %s  %d/%d actions ok\n", status, result.PolicyName, result.Region, result.RunID,
+			result.Summary.SuccessfulActions, result.Summary.TotalActions)
+		if !result.Success && len(result.Errors) > 0 {
+			fmt.Printf("      %s\n", result.Errors[len(result.Errors)-1])
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("❌ %d of %d %s failed\n", failed, len(results), runs)
+		os.Exit(1)
+	}
 }
 
 func generateComplianceReportHTML(
@@ -685,6 +2556,15 @@ func generateComplianceReportCSV(
 	fmt.Printf("✅ CSV compliance report saved: ./reports/%s\n", filename)
 }
 
+// exitIfInterrupted ends a command that was interrupted while it gathered
+// data, rather than writing output from a partial view
+func exitIfInterrupted(ctx context.Context) {
+	if ctx.Err() != nil {
+		fmt.Println("❌ Interrupted, nothing was written")
+		os.Exit(1)
+	}
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
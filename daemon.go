@@ -0,0 +1,472 @@
+package main
+
+import (
+	"context"
+	"custodian-killer/aws"
+	"custodian-killer/events"
+	"custodian-killer/schedule"
+	"custodian-killer/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// catchUpModes are the values of a periodic policy's mode.settings.catch_up:
+// what the daemon does with slots that came due while it wasn't running
+var catchUpModes = []string{"skip", "once", "all"}
+
+// maxCatchUpRuns bounds "all" catch-up after a long outage
+const maxCatchUpRuns = 24
+
+// DaemonConfig controls how the daemon runs policies
+type DaemonConfig struct {
+	Interval time.Duration // how often schedules are checked
+	DryRun   bool          // run every policy in dry run mode
+	Once     bool          // check schedules once, wait for the runs, and return
+	Events   events.Source // feeds event-mode policies; nil runs schedules only
+}
+
+// Daemon runs periodic policies on their cron schedules and event-mode
+// policies on the CloudTrail events they name
+type Daemon struct {
+	storage   storage.PolicyStorage
+	config    DaemonConfig
+	newClient func(dryRun bool) (*aws.CustodianClient, error)
+	startedAt time.Time
+
+	statePath string
+	mu        sync.Mutex
+	state     daemonState
+	running   map[string]bool // policies with a run in progress
+	wg        sync.WaitGroup
+
+	account string // the caller's account; events from others are ignored
+}
+
+// daemonState is what the daemon remembers between restarts
+type daemonState struct {
+	Policies map[string]*policyScheduleState `json:"policies"`
+}
+
+// policyScheduleState tracks one policy's schedule, or for event-mode
+// policies the last event it answered
+type policyScheduleState struct {
+	Schedule  string    `json:"schedule,omitempty"`
+	LastSlot  time.Time `json:"last_slot"` // newest slot handled, run or skipped
+	LastEvent string    `json:"last_event,omitempty"`
+	LastRunID string    `json:"last_run_id,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// NewDaemon creates a daemon that keeps its state next to the policies
+func NewDaemon(
+	policyStorage storage.PolicyStorage,
+	config DaemonConfig,
+	newClient func(dryRun bool) (*aws.CustodianClient, error),
+) (*Daemon, error) {
+	fileStorage, ok := policyStorage.(*storage.FileStorage)
+	if !ok {
+		return nil, fmt.Errorf("daemon needs file storage")
+	}
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+
+	d := &Daemon{
+		storage:   policyStorage,
+		config:    config,
+		newClient: newClient,
+		startedAt: time.Now(),
+		statePath: filepath.Join(fileStorage.BaseDir(), "daemon-state.json"),
+		state:     daemonState{Policies: make(map[string]*policyScheduleState)},
+		running:   make(map[string]bool),
+	}
+	if err := d.loadState(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Run checks schedules every interval and starts due policies, while events
+// are handled as they arrive. It returns when ctx is done, or in Once mode
+// after the started runs finish and the event source has nothing more.
+// Either way it waits for runs in progress, which stop early and are
+// journaled as interrupted when ctx is cancelled.
+func (d *Daemon) Run(ctx context.Context) {
+	if d.config.Events != nil {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.consumeEvents(ctx)
+		}()
+	} else if policies, err := d.storage.ListPolicies(); err == nil {
+		for _, policy := range policies {
+			if policy.Status == "active" && policy.Mode.Type == "event" {
+				log.Printf("⚠️  %s: event mode needs --events-dir or --events-queue to run", policy.Name)
+			}
+		}
+	}
+
+	for {
+		d.tick(ctx, time.Now())
+		if d.config.Once {
+			d.wg.Wait()
+			return
+		}
+
+		select {
+		case <-time.After(d.config.Interval):
+		case <-ctx.Done():
+			log.Printf("🛑 Stopping: waiting for runs in progress to wrap up")
+			d.wg.Wait()
+			return
+		}
+	}
+}
+
+// tick starts every periodic policy with a slot due at now
+func (d *Daemon) tick(ctx context.Context, now time.Time) {
+	policies, err := d.storage.ListPolicies()
+	if err != nil {
+		log.Printf("❌ Failed to list policies: %v", err)
+		return
+	}
+
+	for i := range policies {
+		policy := policies[i]
+		if policy.Status != "active" || policy.Mode.Type != "periodic" {
+			continue
+		}
+		d.checkPolicy(ctx, &policy, now)
+	}
+}
+
+// checkPolicy works out which of a policy's slots are due and starts them
+func (d *Daemon) checkPolicy(ctx context.Context, policy *storage.StoredPolicy, now time.Time) {
+	sched, err := schedule.Parse(policy.Mode.Schedule)
+	if err != nil {
+		log.Printf("⚠️  %s: invalid schedule '%s': %v", policy.Name, policy.Mode.Schedule, err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state := d.state.Policies[policy.Name]
+	if state == nil || state.Schedule != policy.Mode.Schedule {
+		// New or rescheduled: count from now rather than from old slots
+		d.state.Policies[policy.Name] = &policyScheduleState{Schedule: policy.Mode.Schedule, LastSlot: now}
+		d.saveStateLocked()
+		log.Printf("📅 %s: scheduled '%s', next run %s",
+			policy.Name, policy.Mode.Schedule, sched.Next(now).Local().Format("2006-01-02 15:04"))
+		return
+	}
+
+	var missed, due []time.Time
+	for slot := sched.Next(state.LastSlot); !slot.IsZero() && !slot.After(now); slot = sched.Next(slot) {
+		if slot.Before(d.startedAt) {
+			missed = append(missed, slot)
+		} else {
+			due = append(due, slot)
+		}
+		state.LastSlot = slot
+	}
+	if len(missed) == 0 && len(due) == 0 {
+		return
+	}
+
+	slots := append(catchUpSlots(policy.Mode.Settings["catch_up"], missed), due...)
+	if len(missed) > 0 {
+		log.Printf("⏪ %s: missed %d runs while the daemon was down; catching up %d (catch_up: %s)",
+			policy.Name, len(missed), len(slots)-len(due), catchUpMode(policy.Mode.Settings["catch_up"]))
+	}
+	d.saveStateLocked()
+
+	if len(slots) == 0 {
+		return
+	}
+	if d.running[policy.Name] {
+		log.Printf("⏭️  %s: previous run still in progress, skipping %d due runs", policy.Name, len(slots))
+		return
+	}
+
+	d.running[policy.Name] = true
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer d.finish(policy.Name)
+		for _, slot := range slots {
+			if ctx.Err() != nil {
+				return
+			}
+			d.runPolicy(ctx, policy.Name, slot)
+		}
+	}()
+}
+
+// catchUpSlots picks which missed slots to run for a catch_up setting
+func catchUpSlots(setting string, missed []time.Time) []time.Time {
+	if len(missed) == 0 {
+		return nil
+	}
+	switch catchUpMode(setting) {
+	case "once":
+		return missed[len(missed)-1:]
+	case "all":
+		if len(missed) > maxCatchUpRuns {
+			missed = missed[len(missed)-maxCatchUpRuns:]
+		}
+		return missed
+	default:
+		return nil
+	}
+}
+
+// catchUpMode returns the catch_up setting, defaulting to skip
+func catchUpMode(setting string) string {
+	if setting == "" {
+		return "skip"
+	}
+	return setting
+}
+
+// runPolicy executes one scheduled run and records its outcome
+func (d *Daemon) runPolicy(ctx context.Context, policyName string, slot time.Time) {
+	log.Printf("▶️  %s: running (scheduled %s)", policyName, slot.Local().Format("2006-01-02 15:04"))
+
+	runID, err := d.execute(ctx, policyName, nil, nil)
+	d.recordRun(policyName, runID, err)
+}
+
+// recordRun keeps a run's outcome in the state and logs it
+func (d *Daemon) recordRun(policyName, runID string, err error) {
+	d.mu.Lock()
+	if state := d.state.Policies[policyName]; state != nil {
+		state.LastRunID = runID
+		state.LastError = ""
+		if err != nil {
+			state.LastError = err.Error()
+		}
+		d.saveStateLocked()
+	}
+	d.mu.Unlock()
+
+	if err != nil {
+		log.Printf("❌ %s: run %s failed: %v", policyName, runID, err)
+		return
+	}
+	log.Printf("✅ %s: run %s finished", policyName, runID)
+}
+
+// consumeEvents hands each event from the source to the policies waiting
+// for it, until ctx is done. In Once mode it returns when the source has
+// nothing more.
+func (d *Daemon) consumeEvents(ctx context.Context) {
+	source := d.config.Events
+	log.Printf("📡 Listening for CloudTrail events from %s", source.Name())
+
+	if client, err := d.newClient(true); err != nil {
+		log.Printf("⚠️  Can't look up the account, events from every account will be used: %v", err)
+	} else {
+		if caller, err := client.GetCallerIdentity(ctx); err == nil {
+			d.account = caller.Account
+		}
+		client.Close()
+	}
+
+	for ctx.Err() == nil {
+		received, err := source.Receive(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("⚠️  %s: %v", source.Name(), err)
+		}
+		for _, event := range received {
+			d.handleEvent(ctx, event)
+		}
+		if ctx.Err() != nil {
+			// Not acked, so events cut short are delivered again next time
+			return
+		}
+		if ackErr := source.Ack(ctx); ackErr != nil {
+			log.Printf("⚠️  %s: %v", source.Name(), ackErr)
+		}
+
+		if len(received) == 0 {
+			if d.config.Once {
+				return
+			}
+			if err != nil {
+				// Don't spin on a source that keeps failing
+				select {
+				case <-time.After(d.config.Interval):
+				case <-ctx.Done():
+				}
+			}
+		}
+	}
+}
+
+// handleEvent runs every active event-mode policy that listens for the
+// event, against just the resources the event names
+func (d *Daemon) handleEvent(ctx context.Context, event events.Event) {
+	if d.account != "" && event.Account != "" && event.Account != d.account {
+		log.Printf("⏭️  Ignoring %s from account %s", event, event.Account)
+		return
+	}
+
+	policies, err := d.storage.ListPolicies()
+	if err != nil {
+		log.Printf("❌ Failed to list policies: %v", err)
+		return
+	}
+
+	for _, policy := range policies {
+		if ctx.Err() != nil {
+			return
+		}
+		if policy.Status != "active" || policy.Mode.Type != "event" ||
+			!containsString(eventNames(policy.Mode), event.Name) {
+			continue
+		}
+		if event.Region != "" && !policy.AllowsRegion(event.Region) {
+			continue
+		}
+		resourceIDs := event.ResourceIDs(policy.ResourceType)
+		if len(resourceIDs) == 0 {
+			continue
+		}
+
+		log.Printf("⚡ %s: %s touched %s", policy.Name, event, strings.Join(resourceIDs, ", "))
+		d.mu.Lock()
+		if d.state.Policies[policy.Name] == nil {
+			d.state.Policies[policy.Name] = &policyScheduleState{}
+		}
+		d.state.Policies[policy.Name].LastEvent = event.Name + " " + event.ID
+		d.mu.Unlock()
+
+		runID, err := d.execute(ctx, policy.Name, &event, resourceIDs)
+		d.recordRun(policy.Name, runID, err)
+	}
+}
+
+// eventNames lists the CloudTrail event names an event-mode policy reacts to
+func eventNames(mode storage.StoredPolicyMode) []string {
+	var names []string
+	for _, name := range strings.Split(mode.Settings["events"], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// execute runs a policy with its own client: in each of the policy's regions
+// on a schedule, or in the event's region limited to the resources it named.
+// Nobody is there to answer prompts, so destructive actions aren't confirmed;
+// approval gates still apply.
+func (d *Daemon) execute(
+	ctx context.Context,
+	policyName string,
+	event *events.Event,
+	resourceIDs []string,
+) (string, error) {
+	policy, err := d.storage.GetPolicy(policyName)
+	if err != nil {
+		return "", fmt.Errorf("failed to load policy: %v", err)
+	}
+
+	client, err := d.newClient(d.config.DryRun)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize AWS client: %v", err)
+	}
+	defer client.Close()
+
+	// An organization trail carries events from every region
+	if event != nil && event.Region != "" && event.Region != client.Region {
+		if err := client.SwitchRegion(event.Region); err != nil {
+			return "", err
+		}
+	}
+	if event != nil && !policy.AllowsRegion(client.Region) {
+		return "", fmt.Errorf("policy is limited to %s, not %s", strings.Join(policy.Regions, ", "), client.Region)
+	}
+
+	executor := NewPolicyExecutor(client, d.storage)
+	config := executor.config
+	config.ConfirmActions = false
+	executor.SetConfig(config)
+	if !d.config.DryRun {
+		log.Printf("⚠️  %s: running unattended, destructive actions won't ask for confirmation", policyName)
+	}
+
+	if event != nil {
+		result, err := executor.ExecutePolicyOn(ctx, policyName, resourceIDs, event.Name+" "+event.ID)
+		if result == nil {
+			return "", err
+		}
+		if err == nil && !result.Success {
+			err = fmt.Errorf("%d errors", len(result.Errors))
+		}
+		return result.RunID, err
+	}
+
+	// With no regions the policy runs in the client's region
+	results := executor.ExecutePoliciesInRegions(ctx, []string{policyName}, policy.Regions)
+	var runIDs, failures []string
+	for _, result := range results {
+		if result.RunID != "" {
+			runIDs = append(runIDs, result.RunID)
+		}
+		if !result.Success {
+			failures = append(failures, fmt.Sprintf("%s: %d errors", result.Region, len(result.Errors)))
+		}
+	}
+	if len(failures) > 0 {
+		err = errors.New(strings.Join(failures, "; "))
+	}
+	return strings.Join(runIDs, ", "), err
+}
+
+func (d *Daemon) finish(policyName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.running, policyName)
+}
+
+func (d *Daemon) loadState() error {
+	data, err := os.ReadFile(d.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read daemon state: %v", err)
+	}
+	if err := json.Unmarshal(data, &d.state); err != nil {
+		return fmt.Errorf("failed to parse daemon state %s: %v", d.statePath, err)
+	}
+	if d.state.Policies == nil {
+		d.state.Policies = make(map[string]*policyScheduleState)
+	}
+	return nil
+}
+
+// saveStateLocked writes the state; the caller holds d.mu. A dry run keeps
+// its progress in memory only, so a later live daemon still runs the slots
+// and events it only pretended to handle.
+func (d *Daemon) saveStateLocked() {
+	if d.config.DryRun {
+		return
+	}
+	data, err := json.MarshalIndent(d.state, "", "  ")
+	if err == nil {
+		err = os.WriteFile(d.statePath, data, 0644)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to save daemon state: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"custodian-killer/aws"
+	"custodian-killer/fakecloud"
+	"custodian-killer/storage"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDaemonDryRunKeepsState(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 0, 30, 0, time.UTC)
+	lastSlot := now.Add(-3 * time.Minute).Truncate(time.Minute)
+
+	tests := []struct {
+		name      string
+		dryRun    bool
+		wantSaved bool
+	}{
+		{name: "live run saves its progress", dryRun: false, wantSaved: true},
+		{name: "dry run leaves the state file alone", dryRun: true, wantSaved: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileStorage, err := storage.NewFileStorage(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			policies := []storage.StoredPolicy{
+				{
+					Name: "stop-dev", ResourceType: "ec2", Status: "active",
+					Mode:    storage.StoredPolicyMode{Type: "periodic", Schedule: "* * * * *"},
+					Filters: []storage.StoredFilter{{Type: "tag", Key: "Env", Value: "dev"}},
+					Actions: []storage.StoredAction{{Type: "stop"}},
+				},
+				{
+					Name: "new-policy", ResourceType: "ec2", Status: "active",
+					Mode: storage.StoredPolicyMode{Type: "periodic", Schedule: "0 * * * *"},
+				},
+			}
+			for _, policy := range policies {
+				if err := fileStorage.SavePolicy(policy); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			statePath := filepath.Join(fileStorage.BaseDir(), "daemon-state.json")
+			saved, err := json.Marshal(daemonState{Policies: map[string]*policyScheduleState{
+				"stop-dev": {Schedule: "* * * * *", LastSlot: lastSlot},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(statePath, saved, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cloud := fakecloud.New(fakecloud.Seed{Instances: []fakecloud.Instance{
+				{InstanceID: "i-dev", InstanceType: "t3.micro", State: "running", Tags: map[string]string{"Env": "dev"}},
+			}})
+			newClient := func(dryRun bool) (*aws.CustodianClient, error) {
+				return aws.NewCustodianClientWithAPIs(cloud.APIs, aws.ClientConfig{Region: "us-east-1", DryRun: dryRun})
+			}
+			d, err := NewDaemon(fileStorage, DaemonConfig{DryRun: test.dryRun}, newClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d.startedAt = lastSlot // the due slots aren't catch-up runs
+
+			for i := range policies {
+				d.checkPolicy(context.Background(), &policies[i], now)
+			}
+			d.wg.Wait()
+
+			// The daemon tracks progress either way, so it won't rerun the slots
+			state := d.state.Policies["stop-dev"]
+			if want := now.Truncate(time.Minute); !state.LastSlot.Equal(want) {
+				t.Errorf("in-memory last slot = %v, want %v", state.LastSlot, want)
+			}
+			if state.LastRunID == "" || state.LastError != "" {
+				t.Errorf("in-memory run = %q, error %q", state.LastRunID, state.LastError)
+			}
+			if d.state.Policies["new-policy"] == nil {
+				t.Error("new policy wasn't scheduled")
+			}
+
+			data, err := os.ReadFile(statePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed := !bytes.Equal(data, saved); changed != test.wantSaved {
+				t.Errorf("state file changed = %v, want %v:\n%s", changed, test.wantSaved, data)
+			}
+		})
+	}
+}
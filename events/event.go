@@ -0,0 +1,201 @@
+// Package events reads CloudTrail events and works out which resources each
+// one touched, so event-mode policies can run against just those resources.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event is one CloudTrail record, reduced to what policies need
+type Event struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`   // eventName, e.g. RunInstances
+	Source    string     `json:"source"` // eventSource, e.g. ec2.amazonaws.com
+	Time      time.Time  `json:"time"`
+	Region    string     `json:"region"`
+	Account   string     `json:"account,omitempty"`
+	Actor     string     `json:"actor,omitempty"` // ARN of the caller
+	Resources []Resource `json:"resources"`
+}
+
+// Resource is a resource an event touched
+type Resource struct {
+	Type string `json:"type"` // policy resource type: ec2 or s3
+	ID   string `json:"id"`
+}
+
+// ResourceIDs returns the IDs of the event's resources of one type
+func (e Event) ResourceIDs(resourceType string) []string {
+	var ids []string
+	for _, resource := range e.Resources {
+		if resource.Type == resourceType {
+			ids = append(ids, resource.ID)
+		}
+	}
+	return ids
+}
+
+// String describes the event for logs, e.g. "RunInstances (1f3c...) by arn:..."
+func (e Event) String() string {
+	description := fmt.Sprintf("%s (%s)", e.Name, e.ID)
+	if e.Actor != "" {
+		description += " by " + e.Actor
+	}
+	return description
+}
+
+// record is the part of a CloudTrail record we read
+type record struct {
+	EventID            string    `json:"eventID"`
+	EventName          string    `json:"eventName"`
+	EventSource        string    `json:"eventSource"`
+	EventTime          time.Time `json:"eventTime"`
+	AWSRegion          string    `json:"awsRegion"`
+	RecipientAccountID string    `json:"recipientAccountId"`
+	ErrorCode          string    `json:"errorCode"`
+	UserIdentity       struct {
+		ARN string `json:"arn"`
+	} `json:"userIdentity"`
+	RequestParameters map[string]interface{} `json:"requestParameters"`
+	ResponseElements  map[string]interface{} `json:"responseElements"`
+	Resources         []struct {
+		ARN  string `json:"ARN"`
+		Type string `json:"type"`
+	} `json:"resources"`
+}
+
+// Parse reads CloudTrail events from a log file or a queue message. It accepts
+// a CloudTrail log ({"Records": [...]}), a single record, an EventBridge event
+// wrapping a record in "detail", and any of those inside an SNS notification.
+// Calls that failed are dropped: they changed nothing.
+func Parse(data []byte) ([]Event, error) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("not JSON: %v", err)
+	}
+
+	switch {
+	case envelope["Records"] != nil:
+		var records []record
+		if err := json.Unmarshal(envelope["Records"], &records); err != nil {
+			return nil, fmt.Errorf("failed to parse CloudTrail records: %v", err)
+		}
+		var events []Event
+		for _, r := range records {
+			if r.ErrorCode == "" {
+				events = append(events, r.event())
+			}
+		}
+		return events, nil
+
+	case envelope["detail"] != nil:
+		return Parse(envelope["detail"])
+
+	case envelope["Message"] != nil && envelope["Type"] != nil:
+		var message string
+		if err := json.Unmarshal(envelope["Message"], &message); err != nil {
+			return nil, fmt.Errorf("failed to parse SNS message: %v", err)
+		}
+		return Parse([]byte(message))
+
+	case envelope["eventName"] != nil:
+		var r record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("failed to parse CloudTrail record: %v", err)
+		}
+		if r.ErrorCode != "" {
+			return nil, nil
+		}
+		return []Event{r.event()}, nil
+
+	default:
+		return nil, fmt.Errorf("not a CloudTrail event")
+	}
+}
+
+func (r record) event() Event {
+	return Event{
+		ID:        r.EventID,
+		Name:      r.EventName,
+		Source:    r.EventSource,
+		Time:      r.EventTime,
+		Region:    r.AWSRegion,
+		Account:   r.RecipientAccountID,
+		Actor:     r.UserIdentity.ARN,
+		Resources: r.resources(),
+	}
+}
+
+// resources finds the instances and buckets a record names, in its
+// parameters, its response, and its resources list
+func (r record) resources() []Resource {
+	seen := make(map[Resource]bool)
+	add := func(resourceType, id string) {
+		if id != "" {
+			seen[Resource{Type: resourceType, ID: id}] = true
+		}
+	}
+
+	switch r.EventSource {
+	case "ec2.amazonaws.com":
+		// instancesSet.items[].instanceId, resourcesSet.items[].resourceId
+		// for CreateTags, or a bare instanceId, depending on the call
+		for _, tree := range []interface{}{r.RequestParameters, r.ResponseElements} {
+			walkStrings(tree, func(key, value string) {
+				if (key == "instanceId" || key == "resourceId") && strings.HasPrefix(value, "i-") {
+					add("ec2", value)
+				}
+			})
+		}
+	case "s3.amazonaws.com":
+		if bucket, ok := r.RequestParameters["bucketName"].(string); ok {
+			add("s3", bucket)
+		}
+	}
+
+	for _, resource := range r.Resources {
+		switch resource.Type {
+		case "AWS::EC2::Instance":
+			if i := strings.LastIndex(resource.ARN, "instance/"); i >= 0 {
+				add("ec2", resource.ARN[i+len("instance/"):])
+			}
+		case "AWS::S3::Bucket":
+			add("s3", strings.TrimPrefix(resource.ARN, "arn:aws:s3:::"))
+		}
+	}
+
+	resources := make([]Resource, 0, len(seen))
+	for resource := range seen {
+		resources = append(resources, resource)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Type != resources[j].Type {
+			return resources[i].Type < resources[j].Type
+		}
+		return resources[i].ID < resources[j].ID
+	})
+	return resources
+}
+
+// walkStrings calls fn for every string value in a decoded JSON tree, with
+// the key it is stored under
+func walkStrings(node interface{}, fn func(key, value string)) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, child := range n {
+			if value, ok := child.(string); ok {
+				fn(key, value)
+				continue
+			}
+			walkStrings(child, fn)
+		}
+	case []interface{}:
+		for _, child := range n {
+			walkStrings(child, fn)
+		}
+	}
+}
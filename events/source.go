@@ -0,0 +1,257 @@
+package events
+
+import (
+	"compress/gzip"
+	"context"
+	"custodian-killer/aws"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Source delivers CloudTrail events to the daemon
+type Source interface {
+	// Name describes the source for logs
+	Name() string
+	// Receive returns the next events, waiting up to the source's wait when
+	// there are none, or until ctx is done. Events it returns alongside an
+	// error are still good: the error is about input that had to be skipped.
+	Receive(ctx context.Context) ([]Event, error)
+	// Ack marks everything the last Receive returned as handled, so it
+	// isn't delivered again
+	Ack(ctx context.Context) error
+}
+
+// maxFilesPerReceive keeps one receive from reading a whole trail at once
+const maxFilesPerReceive = 20
+
+// DirSource reads CloudTrail log files (*.json.gz or *.json) from a
+// directory tree, such as a synced CloudTrail bucket. Each file is read once;
+// the files already handled are kept in a state file.
+type DirSource struct {
+	dir       string
+	statePath string
+	wait      time.Duration
+	dryRun    bool            // keep handled files in memory only
+	handled   map[string]bool // absolute paths
+	pending   []string        // read by the last Receive, not yet acked
+}
+
+// NewDirSource opens a directory of CloudTrail logs. Files listed in the
+// state file are skipped; the first run reads everything in the directory.
+// A dry-run source reads the state file but never writes it, so a later live
+// run still acts on the files it saw.
+func NewDirSource(dir, statePath string, wait time.Duration, dryRun bool) (*DirSource, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid events directory: %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("events directory %s doesn't exist", dir)
+	}
+
+	source := &DirSource{dir: dir, statePath: statePath, wait: wait, dryRun: dryRun, handled: make(map[string]bool)}
+	data, err := os.ReadFile(statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read events state: %v", err)
+	}
+	if err == nil {
+		var handled []string
+		if err := json.Unmarshal(data, &handled); err != nil {
+			return nil, fmt.Errorf("failed to parse events state %s: %v", statePath, err)
+		}
+		for _, path := range handled {
+			// Forget files that were cleaned up so the state doesn't grow forever
+			if _, err := os.Stat(path); err == nil {
+				source.handled[path] = true
+			}
+		}
+	}
+	return source, nil
+}
+
+// Name describes the source
+func (s *DirSource) Name() string {
+	return s.dir
+}
+
+// Receive reads the oldest unhandled files, by path. CloudTrail puts the date
+// and time in both, so that is roughly the order the events happened in.
+func (s *DirSource) Receive(ctx context.Context) ([]Event, error) {
+	var files []string
+	err := filepath.WalkDir(s.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == "CloudTrail-Digest" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if (strings.HasSuffix(path, ".json.gz") || strings.HasSuffix(path, ".json")) && !s.handled[path] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %v", s.dir, err)
+	}
+
+	if len(files) == 0 {
+		select {
+		case <-time.After(s.wait):
+		case <-ctx.Done():
+		}
+		return nil, nil
+	}
+	sort.Strings(files)
+	if len(files) > maxFilesPerReceive {
+		files = files[:maxFilesPerReceive]
+	}
+
+	var events []Event
+	var problems []string
+	for _, path := range files {
+		fileEvents, err := readLogFile(path)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", filepath.Base(path), err))
+		}
+		events = append(events, fileEvents...)
+	}
+	// Unreadable files are acked too: reading them again won't help
+	s.pending = files
+
+	if len(problems) > 0 {
+		return events, fmt.Errorf("skipped %d files: %s", len(problems), strings.Join(problems, "; "))
+	}
+	return events, nil
+}
+
+// Ack records the files from the last Receive as handled. A dry-run source
+// only skips them for the rest of the run.
+func (s *DirSource) Ack(ctx context.Context) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	for _, path := range s.pending {
+		s.handled[path] = true
+	}
+	s.pending = nil
+	if s.dryRun {
+		return nil
+	}
+
+	handled := make([]string, 0, len(s.handled))
+	for path := range s.handled {
+		handled = append(handled, path)
+	}
+	sort.Strings(handled)
+
+	data, err := json.MarshalIndent(handled, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode events state: %v", err)
+	}
+	if err := os.WriteFile(s.statePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write events state: %v", err)
+	}
+	return nil
+}
+
+// readLogFile reads one CloudTrail log file, gzipped or not
+func readLogFile(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// SQSSource receives CloudTrail events from an SQS queue, fed by an
+// EventBridge rule, an SNS topic, or anything else that sends the records
+type SQSSource struct {
+	client   *aws.CustodianClient
+	queueURL string
+	wait     time.Duration
+	pending  []aws.QueueMessage // messages from the last Receive
+	// left holds the IDs of messages a dry run left on the queue, so they are
+	// skipped when the queue delivers them again
+	left map[string]bool
+}
+
+// NewSQSSource reads events from a queue with the given client
+func NewSQSSource(client *aws.CustodianClient, queueURL string, wait time.Duration) *SQSSource {
+	return &SQSSource{client: client, queueURL: queueURL, wait: wait, left: make(map[string]bool)}
+}
+
+// Name describes the source
+func (s *SQSSource) Name() string {
+	return s.queueURL
+}
+
+// Receive long-polls the queue for the next messages
+func (s *SQSSource) Receive(ctx context.Context) ([]Event, error) {
+	messages, err := s.client.ReceiveMessages(ctx, s.queueURL, s.wait)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var problems []string
+	for _, message := range messages {
+		if s.left[message.MessageID] {
+			continue
+		}
+		messageEvents, err := Parse([]byte(message.Body))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("message %s: %v", message.MessageID, err))
+		}
+		events = append(events, messageEvents...)
+		// Messages that don't parse are deleted too, or they would come back forever
+		s.pending = append(s.pending, message)
+	}
+
+	if len(problems) > 0 {
+		return events, fmt.Errorf("skipped %d messages: %s", len(problems), strings.Join(problems, "; "))
+	}
+	return events, nil
+}
+
+// Ack deletes the messages from the last Receive. A dry-run client leaves
+// them on the queue instead, and they are skipped from then on.
+func (s *SQSSource) Ack(ctx context.Context) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	handles := make([]string, 0, len(s.pending))
+	for _, message := range s.pending {
+		handles = append(handles, message.ReceiptHandle)
+		if s.client.DryRun {
+			s.left[message.MessageID] = true
+		}
+	}
+	s.pending = nil
+	return s.client.DeleteMessages(ctx, s.queueURL, handles)
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const stopInstancesLog = `{"Records": [{
+  "eventID": "e-1",
+  "eventName": "StopInstances",
+  "eventSource": "ec2.amazonaws.com",
+  "eventTime": "2025-01-02T03:04:05Z",
+  "awsRegion": "us-east-1",
+  "requestParameters": {"instancesSet": {"items": [{"instanceId": "i-0abc"}]}}
+}]}`
+
+func TestDirSourceState(t *testing.T) {
+	tests := []struct {
+		name      string
+		dryRun    bool
+		wantState bool
+	}{
+		{name: "live runs record handled files", wantState: true},
+		{name: "dry runs leave the state alone", dryRun: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			logs := filepath.Join(dir, "logs")
+			if err := os.MkdirAll(logs, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(logs, "trail.json"), []byte(stopInstancesLog), 0644); err != nil {
+				t.Fatal(err)
+			}
+			statePath := filepath.Join(dir, "events-handled.json")
+			ctx := context.Background()
+
+			source, err := NewDirSource(logs, statePath, 0, test.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			received, err := source.Receive(ctx)
+			if err != nil || len(received) != 1 {
+				t.Fatalf("Receive = %d events, %v; want 1 event", len(received), err)
+			}
+			if err := source.Ack(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			// Either way the same source doesn't deliver the file twice
+			if again, _ := source.Receive(ctx); len(again) != 0 {
+				t.Errorf("file delivered again after Ack: %v", again)
+			}
+
+			if _, err := os.Stat(statePath); (err == nil) != test.wantState {
+				t.Errorf("state file written = %v, want %v", err == nil, test.wantState)
+			}
+
+			// A live source opened afterwards sees the file only if it wasn't recorded
+			live, err := NewDirSource(logs, statePath, 0, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			received, _ = live.Receive(ctx)
+			if delivered := len(received) > 0; delivered == test.wantState {
+				t.Errorf("next live run received the file = %v, want %v", delivered, !test.wantState)
+			}
+		})
+	}
+}
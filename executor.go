@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"custodian-killer/aws"
+	"custodian-killer/scanner"
 	"custodian-killer/storage"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,6 +18,18 @@ type PolicyExecutor struct {
 	storage   storage.PolicyStorage
 	config    ExecutorConfig
 	dryRun    bool
+	journal   *RunJournal // nil when runs can't be journaled
+
+	callerOnce sync.Once
+	caller     *aws.CallerInfo // looked up once, on first use
+	callerErr  error
+
+	promptMu *sync.Mutex // concurrent runs ask for confirmation one at a time
+	statsMu  *sync.Mutex // runs of a policy in several regions update its stats in turn
+
+	// slots holds MaxConcurrency tokens shared by policy runs and their
+	// action batches, so no more than MaxConcurrency of them run at once
+	slots chan struct{}
 }
 
 // ExecutorConfig holds configuration for policy execution
@@ -29,20 +45,29 @@ type ExecutorConfig struct {
 
 // ExecutionResult represents the result of executing a policy
 type ExecutionResult struct {
-	PolicyName       string           `json:"policy_name"`
-	StartTime        time.Time        `json:"start_time"`
-	EndTime          time.Time        `json:"end_time"`
-	Duration         time.Duration    `json:"duration"`
-	ResourceType     string           `json:"resource_type"`
-	DryRun           bool             `json:"dry_run"`
-	Success          bool             `json:"success"`
-	ResourcesFound   int              `json:"resources_found"`
-	ResourcesMatched int              `json:"resources_matched"`
-	ActionsExecuted  int              `json:"actions_executed"`
-	ActionResults    []ActionResult   `json:"action_results"`
-	Errors           []string         `json:"errors"`
-	Summary          ExecutionSummary `json:"summary"`
-	CostImpact       CostImpact       `json:"cost_impact"`
+	RunID            string                      `json:"run_id,omitempty"`
+	PolicyName       string                      `json:"policy_name"`
+	StartTime        time.Time                   `json:"start_time"`
+	EndTime          time.Time                   `json:"end_time"`
+	Duration         time.Duration               `json:"duration"`
+	ResourceType     string                      `json:"resource_type"`
+	Region           string                      `json:"region,omitempty"`
+	Account          string                      `json:"account,omitempty"`
+	DryRun           bool                        `json:"dry_run"`
+	Success          bool                        `json:"success"`
+	ResourcesFound   int                         `json:"resources_found"`
+	ResourcesMatched int                         `json:"resources_matched"`
+	ActionsExecuted  int                         `json:"actions_executed"`
+	ActionResults    []ActionResult              `json:"action_results"`
+	Approvers        []string                    `json:"approvers,omitempty"` // verified plan approvals
+	Trigger          string                      `json:"trigger,omitempty"`   // the event an event-mode run answered
+	Stopped          string                      `json:"stopped,omitempty"`   // "interrupted" or "timed out" when the run ended early
+	APICalls         map[string]aws.ServiceStats `json:"api_calls,omitempty"` // AWS calls by service, with retries and throttling
+	Errors           []string                    `json:"errors"`
+	Summary          ExecutionSummary            `json:"summary"`
+	CostImpact       CostImpact                  `json:"cost_impact"`
+
+	scope []string // when set, the only resource IDs the run looks at
 }
 
 // ActionResult represents the result of a single action
@@ -54,6 +79,7 @@ type ActionResult struct {
 	DryRun        bool                   `json:"dry_run"`
 	Message       string                 `json:"message"`
 	Details       map[string]interface{} `json:"details,omitempty"`
+	Prior         *PriorState            `json:"prior_state,omitempty"` // for rollback
 	Timestamp     time.Time              `json:"timestamp"`
 	ExecutionTime time.Duration          `json:"execution_time"`
 }
@@ -82,7 +108,7 @@ func NewPolicyExecutor(
 	awsClient *aws.CustodianClient,
 	storage storage.PolicyStorage,
 ) *PolicyExecutor {
-	return &PolicyExecutor{
+	executor := &PolicyExecutor{
 		awsClient: awsClient,
 		storage:   storage,
 		config: ExecutorConfig{
@@ -93,27 +119,201 @@ func NewPolicyExecutor(
 			StopOnError:      false,
 			SaveResults:      true,
 		},
-		dryRun: awsClient.DryRun,
+		dryRun:   awsClient.DryRun,
+		promptMu: &sync.Mutex{},
+		statsMu:  &sync.Mutex{},
+	}
+	executor.slots = newSlots(executor.config.MaxConcurrency)
+
+	journal, err := openRunJournal(storage)
+	if err != nil {
+		fmt.Printf("⚠️  Runs won't be journaled: %v\n", err)
+	} else {
+		executor.journal = journal
 	}
+
+	return executor
 }
 
 // SetConfig updates executor configuration
 func (pe *PolicyExecutor) SetConfig(config ExecutorConfig) {
 	pe.config = config
+	pe.slots = newSlots(config.MaxConcurrency)
+}
+
+// newSlots makes the token pool for a MaxConcurrency setting
+func newSlots(maxConcurrency int) chan struct{} {
+	return make(chan struct{}, max(maxConcurrency, 1))
+}
+
+// takeSlot waits for a free slot and returns the function that gives it back.
+// When ctx is done it returns at once, since the run stops at its first check.
+func (pe *PolicyExecutor) takeSlot(ctx context.Context) func() {
+	select {
+	case pe.slots <- struct{}{}:
+		return func() { <-pe.slots }
+	case <-ctx.Done():
+		return func() {}
+	}
+}
+
+// ExecutePolicy executes a single policy. Cancelling ctx stops the run between
+// AWS calls; what finished is still journaled.
+func (pe *PolicyExecutor) ExecutePolicy(ctx context.Context, policyName string) (*ExecutionResult, error) {
+	return pe.executePolicy(ctx, policyName, nil, "")
+}
+
+// ExecutePolicyOn runs a policy against only the named resources, as an
+// event-mode policy does for the resources an event touched
+func (pe *PolicyExecutor) ExecutePolicyOn(
+	ctx context.Context,
+	policyName string,
+	resourceIDs []string,
+	trigger string,
+) (*ExecutionResult, error) {
+	return pe.executePolicy(ctx, policyName, resourceIDs, trigger)
+}
+
+// ExecutePolicies runs policies on up to MaxConcurrency workers. Results come
+// back in the order the policies were given, whichever finishes first; a
+// policy named twice runs once. Policies not started when ctx is cancelled
+// get an unsaved result saying so.
+func (pe *PolicyExecutor) ExecutePolicies(ctx context.Context, policyNames []string) []*ExecutionResult {
+	return pe.ExecutePoliciesInRegions(ctx, policyNames, nil)
+}
+
+// ExecutePoliciesInRegions runs each policy in each region, all on the same
+// pool of MaxConcurrency workers. Results are grouped by policy, then by
+// region in the order given. A policy is skipped in regions outside its
+// allow-list. With no regions, policies run in the executor's own region.
+func (pe *PolicyExecutor) ExecutePoliciesInRegions(
+	ctx context.Context,
+	policyNames []string,
+	regions []string,
+) []*ExecutionResult {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range policyNames {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	executors := map[string]*PolicyExecutor{"": pe}
+	for _, region := range regions {
+		executors[region] = pe.inRegion(region)
+	}
+
+	type job struct{ name, region string }
+	var queued []job
+	for _, name := range names {
+		if len(regions) == 0 {
+			queued = append(queued, job{name: name})
+			continue
+		}
+		// A policy that can't be loaded is queued anyway, so its run reports why
+		policy, err := pe.storage.GetPolicy(name)
+		for _, region := range regions {
+			if err == nil && !policy.AllowsRegion(region) {
+				fmt.Printf("⏭️  Skipping %s in %s: limited to %s\n", name, region, strings.Join(policy.Regions, ", "))
+				continue
+			}
+			queued = append(queued, job{name: name, region: region})
+		}
+	}
+
+	workers := pe.config.MaxConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(queued) {
+		workers = len(queued)
+	}
+
+	results := make([]*ExecutionResult, len(queued))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], _ = executors[queued[i].region].ExecutePolicy(ctx, queued[i].name)
+			}
+		}()
+	}
+queue:
+	for i := range queued {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break queue
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, result := range results {
+		if result == nil {
+			results[i] = &ExecutionResult{
+				PolicyName: queued[i].name,
+				Region:     executors[queued[i].region].awsClient.Region,
+				Account:    pe.awsClient.Account,
+				Stopped:    "interrupted",
+				Errors:     []string{"interrupted before it started"},
+			}
+		}
+	}
+
+	return results
+}
+
+// inRegion returns an executor for one region of a multi-region run. It
+// shares this executor's storage, settings, journal, confirmation prompt and
+// concurrency slots.
+func (pe *PolicyExecutor) inRegion(region string) *PolicyExecutor {
+	return &PolicyExecutor{
+		awsClient: pe.awsClient.ForRegion(region),
+		storage:   pe.storage,
+		config:    pe.config,
+		dryRun:    pe.dryRun,
+		journal:   pe.journal,
+		promptMu:  pe.promptMu,
+		statsMu:   pe.statsMu,
+		slots:     pe.slots,
+	}
 }
 
-// ExecutePolicy executes a single policy
-func (pe *PolicyExecutor) ExecutePolicy(policyName string) (*ExecutionResult, error) {
+// executePolicy runs a policy, limited to scope when it is set
+func (pe *PolicyExecutor) executePolicy(
+	ctx context.Context,
+	policyName string,
+	scope []string,
+	trigger string,
+) (*ExecutionResult, error) {
 	fmt.Printf("🚀 Executing policy: %s\n", policyName)
 
+	release := pe.takeSlot(ctx)
+	defer release()
+	ctx, cancel := pe.withPolicyTimeout(ctx)
+	defer cancel()
+	calls := aws.NewCallStats()
+	ctx = aws.WithCallStats(ctx, calls)
+
 	startTime := time.Now()
 	result := &ExecutionResult{
+		RunID:         newRunID(startTime),
 		PolicyName:    policyName,
 		StartTime:     startTime,
+		Region:        pe.awsClient.Region,
+		Account:       pe.awsClient.Account,
 		DryRun:        pe.dryRun,
+		Trigger:       trigger,
 		ActionResults: make([]ActionResult, 0),
 		Errors:        make([]string, 0),
 		CostImpact:    CostImpact{Currency: "USD"},
+		scope:         scope,
 	}
 
 	// Get policy from storage
@@ -126,20 +326,69 @@ func (pe *PolicyExecutor) ExecutePolicy(policyName string) (*ExecutionResult, er
 
 	result.ResourceType = policy.ResourceType
 
+	if !policy.AllowsRegion(result.Region) {
+		err = fmt.Errorf("policy '%s' is limited to %s and does not run in %s",
+			policy.Name, strings.Join(policy.Regions, ", "), result.Region)
+		result.Success = false
+		result.Errors = append(result.Errors, err.Error())
+		return result, err
+	}
+
 	fmt.Printf("📋 Policy: %s\n", policy.Description)
 	fmt.Printf("🎯 Resource Type: %s\n", strings.ToUpper(policy.ResourceType))
+	fmt.Printf("🌍 Region: %s\n", result.Region)
 	fmt.Printf("🔍 Filters: %d | ⚡ Actions: %d\n", len(policy.Filters), len(policy.Actions))
 
 	if pe.dryRun {
 		fmt.Println("🧪 DRY RUN MODE - No actual changes will be made")
 	}
+	if scope != nil {
+		fmt.Printf("📡 Triggered by %s, limited to: %s\n", trigger, strings.Join(scope, ", "))
+	}
+
+	// Strict validation: a typo in a filter type must never widen the match
+	if policy.AllowUnknownFilters {
+		for _, issue := range scanner.ValidateFilters(policy.ResourceType, policy.Filters) {
+			if issue.Unknown {
+				fmt.Printf("🧪 Experimental filter allowed by policy: %s\n", issue.Error())
+			}
+		}
+	}
+	if errs := ValidateStoredPolicy(policy); len(errs) > 0 {
+		printValidationErrors(errs)
+		for _, validationErr := range errs {
+			result.Errors = append(result.Errors, validationErr.Error())
+		}
+		err = fmt.Errorf("policy '%s' failed validation with %d errors", policy.Name, len(errs))
+		result.Success = false
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		if pe.config.SaveResults {
+			pe.saveExecutionResult(ctx, policy, result)
+		}
+		return result, err
+	}
+
+	// High-impact actions in gated accounts only run from approved plans
+	if !pe.dryRun {
+		if err := pe.requireApprovedPlan(ctx, policy); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			result.Success = false
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			if pe.config.SaveResults {
+				pe.saveExecutionResult(ctx, policy, result)
+			}
+			return result, err
+		}
+	}
 
 	// Execute based on resource type
 	switch policy.ResourceType {
 	case "ec2":
-		err = pe.executeEC2Policy(policy, result)
+		err = pe.executeEC2Policy(ctx, policy, result)
 	case "s3":
-		err = pe.executeS3Policy(policy, result)
+		err = pe.executeS3Policy(ctx, policy, result)
 	case "rds":
 		err = pe.executeRDSPolicy(policy, result)
 	case "lambda":
@@ -148,29 +397,350 @@ func (pe *PolicyExecutor) ExecutePolicy(policyName string) (*ExecutionResult, er
 		err = fmt.Errorf("unsupported resource type: %s", policy.ResourceType)
 		result.Errors = append(result.Errors, err.Error())
 	}
+	if noteStop(ctx, result) {
+		err = fmt.Errorf("policy '%s' %s", policyName, pe.stopDescription(result))
+	}
 
 	// Finalize result
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 	result.Success = err == nil
 	result.Summary = pe.calculateSummary(result)
+	result.APICalls = calls.Snapshot()
+
+	// Save results if configured, before the stats update bumps the version
+	if pe.config.SaveResults {
+		pe.saveExecutionResult(ctx, policy, result)
+	}
 
 	// Update policy run statistics
 	pe.updatePolicyStats(policy, result)
 
-	// Save results if configured
+	// Print summary
+	pe.printExecutionSummary(result)
+
+	return result, err
+}
+
+// ExecutePlan applies a plan saved by 'scan --out'. Nothing is changed if the
+// policy is invalid or was edited since the plan was made, or a planned
+// resource drifted.
+func (pe *PolicyExecutor) ExecutePlan(ctx context.Context, plan *ExecutionPlan) (*ExecutionResult, error) {
+	fmt.Printf("🚀 Applying plan for policy: %s\n", plan.PolicyName)
+
+	release := pe.takeSlot(ctx)
+	defer release()
+	ctx, cancel := pe.withPolicyTimeout(ctx)
+	defer cancel()
+	calls := aws.NewCallStats()
+	ctx = aws.WithCallStats(ctx, calls)
+
+	startTime := time.Now()
+	result := &ExecutionResult{
+		RunID:         newRunID(startTime),
+		PolicyName:    plan.PolicyName,
+		StartTime:     startTime,
+		Region:        plan.Region,
+		Account:       plan.Account,
+		ResourceType:  plan.Scan.ResourceType,
+		DryRun:        pe.dryRun,
+		ActionResults: make([]ActionResult, 0),
+		Errors:        make([]string, 0),
+		CostImpact:    CostImpact{Currency: "USD"},
+	}
+
+	policy, err := pe.storage.GetPolicy(plan.PolicyName)
+	if err != nil {
+		result.Success = false
+		result.Errors = append(result.Errors, fmt.Sprintf("Failed to load policy: %v", err))
+		return result, err
+	}
+
+	fmt.Printf("📋 Plan made: %s (policy v%d)\n", plan.CreatedAt.Local().Format("2006-01-02 15:04:05"), plan.PolicyVersion)
+	fmt.Printf("🎯 Resource Type: %s | Planned resources: %d\n",
+		strings.ToUpper(plan.Scan.ResourceType), len(plan.Scan.MatchedResources))
+
+	if pe.dryRun {
+		fmt.Println("🧪 DRY RUN MODE - No actual changes will be made")
+	}
+
+	// The policy may have been saved invalid, or the rules may have tightened,
+	// since the plan was made
+	if errs := ValidateStoredPolicy(policy); len(errs) > 0 {
+		printValidationErrors(errs)
+		for _, validationErr := range errs {
+			result.Errors = append(result.Errors, validationErr.Error())
+		}
+		err = fmt.Errorf("policy '%s' failed validation with %d errors", policy.Name, len(errs))
+	} else {
+		err = pe.checkPlan(ctx, plan, policy, result)
+	}
+	if err == nil {
+		switch plan.Scan.ResourceType {
+		case "ec2":
+			err = pe.applyEC2Plan(ctx, plan, policy, result)
+		case "s3":
+			err = pe.applyS3Plan(ctx, plan, policy, result)
+		default:
+			err = fmt.Errorf("plans for %s resources can't be applied yet", plan.Scan.ResourceType)
+		}
+	}
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	if noteStop(ctx, result) {
+		err = fmt.Errorf("plan for '%s' %s", plan.PolicyName, pe.stopDescription(result))
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	result.Success = err == nil
+	result.Summary = pe.calculateSummary(result)
+	result.APICalls = calls.Snapshot()
+
 	if pe.config.SaveResults {
-		pe.saveExecutionResult(result)
+		pe.saveExecutionResult(ctx, policy, result)
+	}
+
+	// A refused plan didn't run the policy
+	if result.Summary.TotalActions > 0 {
+		pe.updatePolicyStats(policy, result)
 	}
 
-	// Print summary
 	pe.printExecutionSummary(result)
 
 	return result, err
 }
 
+// checkPlan refuses a plan made from a different policy, region or account, or
+// one missing the approvals its high-impact actions need
+func (pe *PolicyExecutor) checkPlan(ctx context.Context, plan *ExecutionPlan, policy *storage.StoredPolicy, result *ExecutionResult) error {
+	if hash := PolicyHash(policy); hash != plan.PolicyHash {
+		return fmt.Errorf("policy '%s' changed since the plan was made; run 'scan --out' again", policy.Name)
+	}
+	if err := checkPlannedActions(plan, policy); err != nil {
+		return err
+	}
+
+	if plan.Region != pe.awsClient.Region {
+		return fmt.Errorf("plan was made in %s but the client is in %s", plan.Region, pe.awsClient.Region)
+	}
+
+	if plan.Account != "" {
+		caller, err := pe.callerIdentity(ctx)
+		if err != nil {
+			return fmt.Errorf("can't confirm the plan's account %s: %v", plan.Account, err)
+		}
+		if caller.Account != plan.Account {
+			return fmt.Errorf("plan was made in account %s but the credentials are for %s", plan.Account, caller.Account)
+		}
+	}
+
+	return pe.checkApprovals(plan, policy, result)
+}
+
+// checkApprovals refuses a plan with high-impact actions until enough distinct
+// trusted approvers have signed it. Dry runs only report what's missing.
+func (pe *PolicyExecutor) checkApprovals(plan *ExecutionPlan, policy *storage.StoredPolicy, result *ExecutionResult) error {
+	highImpact := policyHighImpactActions(policy)
+	if len(highImpact) == 0 {
+		return nil
+	}
+
+	config, err := pe.approvalGate(plan.Account)
+	if err != nil || config == nil {
+		return err
+	}
+
+	approvers, problems := VerifyApprovals(plan, config)
+	result.Approvers = approvers
+	for _, problem := range problems {
+		fmt.Printf("   ⚠️  %s\n", problem)
+	}
+	fmt.Printf("✍️  High-impact actions (%s) need %d approvals: %d valid",
+		strings.Join(highImpact, ", "), config.Required, len(approvers))
+	if len(approvers) > 0 {
+		fmt.Printf(" (%s)", strings.Join(approvers, ", "))
+	}
+	fmt.Println()
+
+	if len(approvers) < config.Required {
+		err := fmt.Errorf("plan has %d of the %d approvals its high-impact actions need; approvers sign it with 'custodian-killer approve'",
+			len(approvers), config.Required)
+		if pe.dryRun {
+			fmt.Printf("🧪 A real run would be refused: %v\n", err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// requireApprovedPlan refuses to run a policy's high-impact actions directly
+// in an account where they need approvals
+func (pe *PolicyExecutor) requireApprovedPlan(ctx context.Context, policy *storage.StoredPolicy) error {
+	highImpact := policyHighImpactActions(policy)
+	if len(highImpact) == 0 {
+		return nil
+	}
+
+	account := ""
+	if caller, err := pe.callerIdentity(ctx); err == nil {
+		account = caller.Account
+	}
+
+	config, err := pe.approvalGate(account)
+	if err != nil || config == nil {
+		return err
+	}
+	return fmt.Errorf("high-impact actions (%s) need %d approvals in this account: save a plan with 'scan --out', have it approved, then run 'execute --plan'",
+		strings.Join(highImpact, ", "), config.Required)
+}
+
+// approvalGate returns the approval config if it gates the account, or nil
+func (pe *PolicyExecutor) approvalGate(account string) (*ApprovalConfig, error) {
+	path, err := approvalConfigPath(pe.storage)
+	if err != nil {
+		return nil, err
+	}
+	config, err := LoadApprovalConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if !config.AppliesTo(account) {
+		return nil, nil
+	}
+	return config, nil
+}
+
+// applyEC2Plan runs a plan's actions on its instances, if none has drifted
+func (pe *PolicyExecutor) applyEC2Plan(
+	ctx context.Context,
+	plan *ExecutionPlan,
+	policy *storage.StoredPolicy,
+	result *ExecutionResult,
+) error {
+	// Every state, so a planned instance that stopped shows up as changed, not gone
+	filter := aws.EC2Filter{IncludeMetrics: pe.convertToEC2Filter(policy.Filters).IncludeMetrics}
+	instances, err := pe.awsClient.GetEC2Instances(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to get EC2 instances: %v", err)
+	}
+
+	byID := make(map[string]aws.EC2Instance)
+	current := make(map[string]scanner.MatchedResource)
+	for _, instance := range instances {
+		byID[instance.InstanceID] = instance
+		current[instance.InstanceID] = scanner.EC2InstanceToResource(instance, pe.awsClient.Region)
+	}
+
+	return pe.applyPlannedActions(ctx, plan, policy, current, result, func(action storage.StoredAction, ids []string) error {
+		var selected []aws.EC2Instance
+		for _, id := range ids {
+			selected = append(selected, byID[id])
+		}
+		return pe.executeEC2Action(ctx, selected, action, result)
+	})
+}
+
+// applyS3Plan runs a plan's actions on its buckets, if none has drifted
+func (pe *PolicyExecutor) applyS3Plan(
+	ctx context.Context,
+	plan *ExecutionPlan,
+	policy *storage.StoredPolicy,
+	result *ExecutionResult,
+) error {
+	var names []string
+	for _, resource := range plan.Scan.MatchedResources {
+		names = append(names, resource.ID)
+	}
+
+	buckets, err := pe.awsClient.GetS3Buckets(ctx, aws.S3Filter{BucketNames: names})
+	if err != nil {
+		return fmt.Errorf("failed to get S3 buckets: %v", err)
+	}
+
+	byName := make(map[string]aws.S3Bucket)
+	current := make(map[string]scanner.MatchedResource)
+	for _, bucket := range buckets {
+		byName[bucket.Name] = bucket
+		current[bucket.Name] = scanner.S3BucketToResource(bucket)
+	}
+
+	return pe.applyPlannedActions(ctx, plan, policy, current, result, func(action storage.StoredAction, ids []string) error {
+		var selected []aws.S3Bucket
+		for _, id := range ids {
+			selected = append(selected, byName[id])
+		}
+		return pe.executeS3Action(ctx, selected, action, result)
+	})
+}
+
+// applyPlannedActions checks every planned resource against its current state,
+// then runs each of the policy's actions on exactly the planned resources
+func (pe *PolicyExecutor) applyPlannedActions(
+	ctx context.Context,
+	plan *ExecutionPlan,
+	policy *storage.StoredPolicy,
+	current map[string]scanner.MatchedResource,
+	result *ExecutionResult,
+	execute func(action storage.StoredAction, ids []string) error,
+) error {
+	planned := plan.Scan.MatchedResources
+	result.ResourcesFound = len(planned)
+
+	var drifted []string
+	for _, resource := range planned {
+		var now *scanner.MatchedResource
+		if found, exists := current[resource.ID]; exists {
+			now = &found
+		}
+		if reason := planDrift(resource, now, policy.Filters); reason != "" {
+			drifted = append(drifted, reason)
+		}
+	}
+	if len(drifted) > 0 {
+		for _, reason := range drifted {
+			fmt.Printf("   ⚠️  %s\n", reason)
+		}
+		result.Errors = append(result.Errors, drifted...)
+		return fmt.Errorf("plan is out of date: %d of %d resources changed since %s; run 'scan --out' again",
+			len(drifted), len(planned), plan.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	result.ResourcesMatched = len(planned)
+	fmt.Printf("✅ All %d planned resources still match the plan\n", len(planned))
+
+	// Each of the policy's actions runs in order, with its own settings, on
+	// every planned resource
+	ids := plannedResourceIDs(planned)
+	for _, action := range policy.Actions {
+		if noteStop(ctx, result) {
+			break
+		}
+		fmt.Printf("⚡ Executing action: %s on %d resources\n", action.Type, len(ids))
+
+		if !pe.dryRun && pe.config.ConfirmActions && pe.isDestructiveAction(action.Type) {
+			if !pe.confirmAction(ctx, action.Type, len(ids)) {
+				if noteStop(ctx, result) {
+					break
+				}
+				fmt.Println("❌ Action cancelled by user")
+				continue
+			}
+		}
+
+		if err := execute(action, ids); err != nil && pe.config.StopOnError {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // executeEC2Policy handles EC2-specific policy execution
 func (pe *PolicyExecutor) executeEC2Policy(
+	ctx context.Context,
 	policy *storage.StoredPolicy,
 	result *ExecutionResult,
 ) error {
@@ -178,14 +748,24 @@ func (pe *PolicyExecutor) executeEC2Policy(
 
 	// Convert filters to AWS format
 	filter := pe.convertToEC2Filter(policy.Filters)
+	if result.scope != nil {
+		filter.InstanceIDs = result.scope
+		// Instances named by an event may have just launched
+		if !hasStateFilter(policy.Filters) {
+			filter.States = append(filter.States, "pending")
+		}
+	}
 
 	// Get matching instances
-	instances, err := pe.awsClient.GetEC2Instances(filter)
+	instances, err := pe.awsClient.GetEC2Instances(ctx, filter)
 	if err != nil {
 		return fmt.Errorf("failed to get EC2 instances: %v", err)
 	}
 
 	result.ResourcesFound = len(instances)
+
+	// Evaluate every filter client-side so operators and negation are honored
+	instances = pe.filterEC2Instances(instances, policy.Filters)
 	result.ResourcesMatched = len(instances)
 
 	fmt.Printf("🎯 Found %d instances matching policy criteria\n", len(instances))
@@ -201,17 +781,23 @@ func (pe *PolicyExecutor) executeEC2Policy(
 
 	// Execute actions on matching instances
 	for _, action := range policy.Actions {
+		if noteStop(ctx, result) {
+			break
+		}
 		fmt.Printf("⚡ Executing action: %s\n", action.Type)
 
 		// Ask for confirmation if not dry-run and action is destructive
 		if !pe.dryRun && pe.config.ConfirmActions && pe.isDestructiveAction(action.Type) {
-			if !pe.confirmAction(action.Type, len(instances)) {
+			if !pe.confirmAction(ctx, action.Type, len(instances)) {
+				if noteStop(ctx, result) {
+					break
+				}
 				fmt.Println("❌ Action cancelled by user")
 				continue
 			}
 		}
 
-		err := pe.executeEC2Action(instances, action, result)
+		err := pe.executeEC2Action(ctx, instances, action, result)
 		if err != nil && pe.config.StopOnError {
 			return err
 		}
@@ -227,8 +813,21 @@ func (pe *PolicyExecutor) executeEC2Policy(
 	return nil
 }
 
-// executeEC2Action executes a specific action on EC2 instances
+// executeEC2Action executes a specific action on EC2 instances, in batches
 func (pe *PolicyExecutor) executeEC2Action(
+	ctx context.Context,
+	instances []aws.EC2Instance,
+	action storage.StoredAction,
+	result *ExecutionResult,
+) error {
+	return pe.runBatches(ctx, len(instances), result, func(start, end int, batch *ExecutionResult) error {
+		return pe.executeEC2ActionBatch(ctx, instances[start:end], action, batch)
+	})
+}
+
+// executeEC2ActionBatch executes an action on one batch of instances
+func (pe *PolicyExecutor) executeEC2ActionBatch(
+	ctx context.Context,
 	instances []aws.EC2Instance,
 	action storage.StoredAction,
 	result *ExecutionResult,
@@ -249,27 +848,39 @@ func (pe *PolicyExecutor) executeEC2Action(
 			}
 		}
 
-		awsResult, err := pe.awsClient.StopInstances(instanceIDs, force)
-		pe.processEC2ActionResult("stop", awsResult, err, actionStart, result)
+		priors := pe.capturePriorEC2(instances, "stop", nil)
+		awsResult, err := pe.awsClient.StopInstances(ctx, instanceIDs, force)
+		pe.processEC2ActionResult("stop", awsResult, err, actionStart, result, priors)
 
 	case "terminate":
-		awsResult, err := pe.awsClient.TerminateInstances(instanceIDs)
-		pe.processEC2ActionResult("terminate", awsResult, err, actionStart, result)
+		awsResult, err := pe.awsClient.TerminateInstances(ctx, instanceIDs)
+		pe.processEC2ActionResult("terminate", awsResult, err, actionStart, result, nil)
 
 	case "start":
-		awsResult, err := pe.awsClient.StartInstances(instanceIDs)
-		pe.processEC2ActionResult("start", awsResult, err, actionStart, result)
+		awsResult, err := pe.awsClient.StartInstances(ctx, instanceIDs)
+		pe.processEC2ActionResult("start", awsResult, err, actionStart, result, nil)
 
 	case "tag":
-		tags := make(map[string]string)
-		for key, value := range action.Settings {
-			if strValue, ok := value.(string); ok {
-				tags[key] = strValue
-			}
+		tags, err := TagsFromSettings(action.Settings)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			return err
+		}
+
+		priors := pe.capturePriorEC2(instances, "tag", tags)
+		awsResult, err := pe.awsClient.TagInstances(ctx, instanceIDs, tags)
+		pe.processEC2ActionResult("tag", awsResult, err, actionStart, result, priors)
+
+	case "mark-for-op":
+		tags, err := MarkFromSettings(action.Settings, time.Now())
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			return err
 		}
 
-		awsResult, err := pe.awsClient.TagInstances(instanceIDs, tags)
-		pe.processEC2ActionResult("tag", awsResult, err, actionStart, result)
+		priors := pe.capturePriorEC2(instances, "mark-for-op", tags)
+		awsResult, err := pe.awsClient.TagInstances(ctx, instanceIDs, tags)
+		pe.processEC2ActionResult("mark-for-op", awsResult, err, actionStart, result, priors)
 
 	default:
 		err := fmt.Errorf("unsupported EC2 action: %s", action.Type)
@@ -287,6 +898,7 @@ func (pe *PolicyExecutor) processEC2ActionResult(
 	err error,
 	startTime time.Time,
 	result *ExecutionResult,
+	priors map[string]*PriorState,
 ) {
 	executionTime := time.Since(startTime)
 
@@ -319,6 +931,7 @@ func (pe *PolicyExecutor) processEC2ActionResult(
 			Success:       true,
 			DryRun:        pe.dryRun,
 			Message:       message,
+			Prior:         priors[instanceID],
 			Timestamp:     time.Now(),
 			ExecutionTime: executionTime,
 		}
@@ -342,6 +955,7 @@ func (pe *PolicyExecutor) processEC2ActionResult(
 
 // executeS3Policy handles S3-specific policy execution
 func (pe *PolicyExecutor) executeS3Policy(
+	ctx context.Context,
 	policy *storage.StoredPolicy,
 	result *ExecutionResult,
 ) error {
@@ -349,14 +963,20 @@ func (pe *PolicyExecutor) executeS3Policy(
 
 	// Convert filters to AWS format
 	filter := pe.convertToS3Filter(policy.Filters)
+	if result.scope != nil {
+		filter.BucketNames = result.scope
+	}
 
 	// Get matching buckets
-	buckets, err := pe.awsClient.GetS3Buckets(filter)
+	buckets, err := pe.awsClient.GetS3Buckets(ctx, filter)
 	if err != nil {
 		return fmt.Errorf("failed to get S3 buckets: %v", err)
 	}
 
 	result.ResourcesFound = len(buckets)
+
+	// Evaluate every filter client-side so operators and negation are honored
+	buckets = pe.filterS3Buckets(buckets, policy.Filters)
 	result.ResourcesMatched = len(buckets)
 
 	fmt.Printf("🎯 Found %d buckets matching policy criteria\n", len(buckets))
@@ -372,16 +992,22 @@ func (pe *PolicyExecutor) executeS3Policy(
 
 	// Execute actions on matching buckets
 	for _, action := range policy.Actions {
+		if noteStop(ctx, result) {
+			break
+		}
 		fmt.Printf("⚡ Executing action: %s\n", action.Type)
 
 		if !pe.dryRun && pe.config.ConfirmActions && pe.isDestructiveAction(action.Type) {
-			if !pe.confirmAction(action.Type, len(buckets)) {
+			if !pe.confirmAction(ctx, action.Type, len(buckets)) {
+				if noteStop(ctx, result) {
+					break
+				}
 				fmt.Println("❌ Action cancelled by user")
 				continue
 			}
 		}
 
-		err := pe.executeS3Action(buckets, action, result)
+		err := pe.executeS3Action(ctx, buckets, action, result)
 		if err != nil && pe.config.StopOnError {
 			return err
 		}
@@ -390,8 +1016,21 @@ func (pe *PolicyExecutor) executeS3Policy(
 	return nil
 }
 
-// executeS3Action executes a specific action on S3 buckets
+// executeS3Action executes a specific action on S3 buckets, in batches
 func (pe *PolicyExecutor) executeS3Action(
+	ctx context.Context,
+	buckets []aws.S3Bucket,
+	action storage.StoredAction,
+	result *ExecutionResult,
+) error {
+	return pe.runBatches(ctx, len(buckets), result, func(start, end int, batch *ExecutionResult) error {
+		return pe.executeS3ActionBatch(ctx, buckets[start:end], action, batch)
+	})
+}
+
+// executeS3ActionBatch executes an action on one batch of buckets
+func (pe *PolicyExecutor) executeS3ActionBatch(
+	ctx context.Context,
 	buckets []aws.S3Bucket,
 	action storage.StoredAction,
 	result *ExecutionResult,
@@ -405,10 +1044,11 @@ func (pe *PolicyExecutor) executeS3Action(
 
 	switch action.Type {
 	case "block-public-access":
-		awsResult, err := pe.awsClient.BlockPublicAccess(bucketNames)
-		pe.processS3ActionResult("block-public-access", awsResult, err, actionStart, result)
+		priors := pe.capturePriorS3(buckets, "block-public-access", nil)
+		awsResult, err := pe.awsClient.BlockPublicAccess(ctx, bucketNames)
+		pe.processS3ActionResult("block-public-access", awsResult, err, actionStart, result, priors)
 
-	case "enable-encryption":
+	case "enable-encryption", "encrypt":
 		kmsKeyID := ""
 		if keyID, exists := action.Settings["kms_key_id"]; exists {
 			if keyStr, ok := keyID.(string); ok {
@@ -416,23 +1056,35 @@ func (pe *PolicyExecutor) executeS3Action(
 			}
 		}
 
-		awsResult, err := pe.awsClient.EnableEncryption(bucketNames, kmsKeyID)
-		pe.processS3ActionResult("enable-encryption", awsResult, err, actionStart, result)
+		awsResult, err := pe.awsClient.EnableEncryption(ctx, bucketNames, kmsKeyID)
+		pe.processS3ActionResult("enable-encryption", awsResult, err, actionStart, result, nil)
 
 	case "enable-versioning":
-		awsResult, err := pe.awsClient.EnableVersioning(bucketNames)
-		pe.processS3ActionResult("enable-versioning", awsResult, err, actionStart, result)
+		priors := pe.capturePriorS3(buckets, "enable-versioning", nil)
+		awsResult, err := pe.awsClient.EnableVersioning(ctx, bucketNames)
+		pe.processS3ActionResult("enable-versioning", awsResult, err, actionStart, result, priors)
 
 	case "tag":
-		tags := make(map[string]string)
-		for key, value := range action.Settings {
-			if strValue, ok := value.(string); ok {
-				tags[key] = strValue
-			}
+		tags, err := TagsFromSettings(action.Settings)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			return err
 		}
 
-		awsResult, err := pe.awsClient.TagBuckets(bucketNames, tags)
-		pe.processS3ActionResult("tag", awsResult, err, actionStart, result)
+		priors := pe.capturePriorS3(buckets, "tag", tags)
+		awsResult, err := pe.awsClient.TagBuckets(ctx, bucketNames, tags)
+		pe.processS3ActionResult("tag", awsResult, err, actionStart, result, priors)
+
+	case "mark-for-op":
+		tags, err := MarkFromSettings(action.Settings, time.Now())
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			return err
+		}
+
+		priors := pe.capturePriorS3(buckets, "mark-for-op", tags)
+		awsResult, err := pe.awsClient.TagBuckets(ctx, bucketNames, tags)
+		pe.processS3ActionResult("mark-for-op", awsResult, err, actionStart, result, priors)
 
 	case "delete":
 		force := false
@@ -442,8 +1094,8 @@ func (pe *PolicyExecutor) executeS3Action(
 			}
 		}
 
-		awsResult, err := pe.awsClient.DeleteBuckets(bucketNames, force)
-		pe.processS3ActionResult("delete", awsResult, err, actionStart, result)
+		awsResult, err := pe.awsClient.DeleteBuckets(ctx, bucketNames, force)
+		pe.processS3ActionResult("delete", awsResult, err, actionStart, result, nil)
 
 	default:
 		err := fmt.Errorf("unsupported S3 action: %s", action.Type)
@@ -461,6 +1113,7 @@ func (pe *PolicyExecutor) processS3ActionResult(
 	err error,
 	startTime time.Time,
 	result *ExecutionResult,
+	priors map[string]*PriorState,
 ) {
 	executionTime := time.Since(startTime)
 
@@ -497,6 +1150,9 @@ func (pe *PolicyExecutor) processS3ActionResult(
 			Timestamp:     time.Now(),
 			ExecutionTime: executionTime,
 		}
+		if success {
+			actionResult.Prior = priors[bucketName]
+		}
 
 		result.ActionResults = append(result.ActionResults, actionResult)
 	}
@@ -520,73 +1176,95 @@ func (pe *PolicyExecutor) executeLambdaPolicy(
 }
 
 // Helper functions for filter conversion
+//
+// Only filters whose meaning maps exactly onto the AWS-side filter are pushed down;
+// everything is evaluated again client-side by filterEC2Instances/filterS3Buckets.
 func (pe *PolicyExecutor) convertToEC2Filter(filters []storage.StoredFilter) aws.EC2Filter {
 	filter := aws.EC2Filter{
 		Tags: make(map[string]string),
 	}
+	scanner.WalkFilters(filters, func(f storage.StoredFilter) {
+		switch f.Type {
+		case "cpu-utilization", "cpu-utilization-avg":
+			filter.IncludeMetrics = true
+		}
+	})
 
-	for _, f := range filters {
+	for _, f := range pushableFilters(filters) {
 		switch f.Type {
 		case "instance-state", "state":
-			if strValue, ok := f.Value.(string); ok {
+			if strValue, ok := f.Value.(string); ok && isPlainEquality(f) {
 				filter.States = append(filter.States, strValue)
 			}
 		case "instance-type":
-			if strValue, ok := f.Value.(string); ok {
+			if strValue, ok := f.Value.(string); ok && isPlainEquality(f) {
 				filter.InstanceTypes = append(filter.InstanceTypes, strValue)
 			}
 		case "vpc-id":
-			if strValue, ok := f.Value.(string); ok {
+			if strValue, ok := f.Value.(string); ok && isPlainEquality(f) {
 				filter.VpcIDs = append(filter.VpcIDs, strValue)
 			}
-		case "tag", "tag-missing":
-			if f.Key != "" {
+		case "tag":
+			if f.Key != "" && !f.Negate && (f.Op == "" || f.Op == "exists" || f.Op == "eq") {
 				filter.Tags[f.Key] = "*" // Check for existence
 			}
-		case "cpu-utilization", "cpu-utilization-avg":
-			if floatValue, ok := f.Value.(float64); ok {
-				filter.CPUThreshold = &floatValue
-			}
 		case "running-days":
-			if intValue, ok := f.Value.(int); ok {
+			if days, ok := scanner.ToNumber(f.Value); ok && !f.Negate &&
+				(f.Op == "" || f.Op == "gte") {
+				intValue := int(days)
 				filter.RunningDaysMin = &intValue
 			}
 		}
 	}
 
 	// Default to running instances if no state specified
-	if len(filter.States) == 0 {
+	if !hasStateFilter(filters) {
 		filter.States = []string{"running"}
 	}
 
 	return filter
 }
 
+// hasStateFilter reports whether a policy checks instance state anywhere.
+// A state check inside an or / not group still means the policy cares about
+// non-running instances.
+func hasStateFilter(filters []storage.StoredFilter) bool {
+	found := false
+	scanner.WalkFilters(filters, func(f storage.StoredFilter) {
+		if f.Type == "instance-state" || f.Type == "state" {
+			found = true
+		}
+	})
+	return found
+}
+
 func (pe *PolicyExecutor) convertToS3Filter(filters []storage.StoredFilter) aws.S3Filter {
 	filter := aws.S3Filter{
 		Tags: make(map[string]string),
 	}
 
-	for _, f := range filters {
+	for _, f := range pushableFilters(filters) {
 		switch f.Type {
 		case "public-read", "public-access":
-			if boolValue, ok := f.Value.(bool); ok && boolValue {
+			if boolValue, ok := f.Value.(bool); ok && boolValue && isPlainEquality(f) {
 				filter.PublicAccessOnly = true
 			}
 		case "encryption":
-			if boolValue, ok := f.Value.(bool); ok && !boolValue {
+			if boolValue, ok := f.Value.(bool); ok && !boolValue && isPlainEquality(f) {
 				filter.UnencryptedOnly = true
 			}
-		case "tag", "tag-missing":
-			if f.Key != "" {
+		case "tag":
+			if f.Key != "" && !f.Negate && (f.Op == "" || f.Op == "exists" || f.Op == "eq") {
 				filter.Tags[f.Key] = "*"
 			}
 		case "size":
-			if intValue, ok := f.Value.(int64); ok {
+			if size, ok := scanner.ToNumber(f.Value); ok && !f.Negate && f.Op == "gte" {
+				intValue := int64(size)
 				filter.LargeSizeThreshold = &intValue
 			}
 		case "security-score":
-			if intValue, ok := f.Value.(int); ok {
+			if score, ok := scanner.ToNumber(f.Value); ok && !f.Negate && f.Op == "gte" {
+				intValue := int(score)
 				filter.MinSecurityScore = &intValue
 			}
 		}
@@ -595,26 +1273,218 @@ func (pe *PolicyExecutor) convertToS3Filter(filters []storage.StoredFilter) aws.
 	return filter
 }
 
-// Utility functions
-func (pe *PolicyExecutor) isDestructiveAction(actionType string) bool {
-	destructiveActions := []string{"terminate", "delete", "stop"}
-	for _, action := range destructiveActions {
-		if action == actionType {
-			return true
+// pushableFilters returns the leaf filters every match must satisfy: top-level
+// filters plus the children of non-negated and groups. Filters inside or / not
+// groups are only evaluated client-side.
+func pushableFilters(filters []storage.StoredFilter) []storage.StoredFilter {
+	var leaves []storage.StoredFilter
+	for _, f := range filters {
+		switch f.Type {
+		case "and":
+			if !f.Negate {
+				leaves = append(leaves, pushableFilters(f.Filters)...)
+			}
+		case "or", "not":
+			// Can't be expressed as AWS-side filters
+		default:
+			leaves = append(leaves, f)
+		}
+	}
+	return leaves
+}
+
+// isPlainEquality reports whether a filter is a non-negated equality check
+func isPlainEquality(f storage.StoredFilter) bool {
+	return !f.Negate && (f.Op == "" || f.Op == "eq")
+}
+
+// filterEC2Instances keeps the instances that match every policy filter
+func (pe *PolicyExecutor) filterEC2Instances(
+	instances []aws.EC2Instance,
+	filters []storage.StoredFilter,
+) []aws.EC2Instance {
+	var matched []aws.EC2Instance
+	for _, instance := range instances {
+		resource := scanner.EC2InstanceToResource(instance, pe.awsClient.Region)
+		if scanner.MatchesFilters(filters, resource) {
+			matched = append(matched, instance)
 		}
 	}
-	return false
+	return matched
 }
 
-func (pe *PolicyExecutor) confirmAction(actionType string, resourceCount int) bool {
+// filterS3Buckets keeps the buckets that match every policy filter
+func (pe *PolicyExecutor) filterS3Buckets(
+	buckets []aws.S3Bucket,
+	filters []storage.StoredFilter,
+) []aws.S3Bucket {
+	var matched []aws.S3Bucket
+	for _, bucket := range buckets {
+		if scanner.MatchesFilters(filters, scanner.S3BucketToResource(bucket)) {
+			matched = append(matched, bucket)
+		}
+	}
+	return matched
+}
+
+// Utility functions
+func (pe *PolicyExecutor) isDestructiveAction(actionType string) bool {
+	return isDestructive(actionType)
+}
+
+// confirmAction asks before a destructive action; an interrupt counts as no
+func (pe *PolicyExecutor) confirmAction(ctx context.Context, actionType string, resourceCount int) bool {
+	pe.promptMu.Lock()
+	defer pe.promptMu.Unlock()
+
+	if ctx.Err() != nil {
+		return false
+	}
 	fmt.Printf(
 		"⚠️  About to execute '%s' on %d resources. Continue? (y/N): ",
 		actionType,
 		resourceCount,
 	)
-	var response string
-	fmt.Scanln(&response)
-	return strings.ToLower(response) == "y" || strings.ToLower(response) == "yes"
+
+	answers := make(chan string, 1)
+	go func() {
+		var response string
+		fmt.Scanln(&response)
+		answers <- response
+	}()
+
+	select {
+	case response := <-answers:
+		return strings.ToLower(response) == "y" || strings.ToLower(response) == "yes"
+	case <-ctx.Done():
+		fmt.Println()
+		return false
+	}
+}
+
+// capturePriorEC2 records what an EC2 action will change; dry runs change nothing
+func (pe *PolicyExecutor) capturePriorEC2(
+	instances []aws.EC2Instance,
+	actionType string,
+	tags map[string]string,
+) map[string]*PriorState {
+	if pe.dryRun {
+		return nil
+	}
+	return captureEC2PriorState(instances, actionType, tags)
+}
+
+// capturePriorS3 records what an S3 action will change; dry runs change nothing
+func (pe *PolicyExecutor) capturePriorS3(
+	buckets []aws.S3Bucket,
+	actionType string,
+	tags map[string]string,
+) map[string]*PriorState {
+	if pe.dryRun {
+		return nil
+	}
+	return captureS3PriorState(buckets, actionType, tags)
+}
+
+// runBatches splits count resources into batches of BatchSize. A batch runs
+// on its own goroutine when it can take a free slot, and otherwise on the run's
+// own slot, so batches and policy runs together stay within MaxConcurrency.
+// Each batch writes to its own result; they are merged back in batch order, so
+// the outcome doesn't depend on which batch finished first. Batches not yet
+// started when ctx is done are skipped.
+func (pe *PolicyExecutor) runBatches(
+	ctx context.Context,
+	count int,
+	result *ExecutionResult,
+	run func(start, end int, batch *ExecutionResult) error,
+) error {
+	size := pe.config.BatchSize
+	if size <= 0 || count <= size {
+		return run(0, count, result)
+	}
+
+	type batchOutcome struct {
+		result ExecutionResult
+		err    error
+	}
+	var outcomes []*batchOutcome
+	var wg sync.WaitGroup
+
+	for start := 0; start < count; start += size {
+		if noteStop(ctx, result) {
+			break
+		}
+
+		outcome := &batchOutcome{}
+		outcomes = append(outcomes, outcome)
+		end := min(start+size, count)
+		select {
+		case pe.slots <- struct{}{}:
+			wg.Add(1)
+			go func(start, end int) {
+				defer wg.Done()
+				defer func() { <-pe.slots }()
+				outcome.err = run(start, end, &outcome.result)
+			}(start, end)
+		default:
+			outcome.err = run(start, end, &outcome.result)
+		}
+	}
+	wg.Wait()
+
+	var firstErr error
+	for _, outcome := range outcomes {
+		result.ActionResults = append(result.ActionResults, outcome.result.ActionResults...)
+		result.Errors = append(result.Errors, outcome.result.Errors...)
+		if outcome.err != nil && firstErr == nil {
+			firstErr = outcome.err
+		}
+	}
+	return firstErr
+}
+
+// withPolicyTimeout limits one run to TimeoutPerPolicy, when it is set
+func (pe *PolicyExecutor) withPolicyTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if pe.config.TimeoutPerPolicy > 0 {
+		return context.WithTimeout(ctx, pe.config.TimeoutPerPolicy)
+	}
+	return context.WithCancel(ctx)
+}
+
+// noteStop reports whether the run was interrupted or ran out of time,
+// recording why in the result the first time
+func noteStop(ctx context.Context, result *ExecutionResult) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+	if result.Stopped == "" {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.Stopped = "timed out"
+			result.Errors = append(result.Errors, "ran out of time: remaining actions were skipped")
+		} else {
+			result.Stopped = "interrupted"
+			result.Errors = append(result.Errors, "interrupted: remaining actions were skipped")
+		}
+	}
+	return true
+}
+
+// stopDescription explains a run that ended early, for its error
+func (pe *PolicyExecutor) stopDescription(result *ExecutionResult) string {
+	if result.Stopped == "timed out" {
+		return fmt.Sprintf("timed out after %s", pe.config.TimeoutPerPolicy)
+	}
+	return fmt.Sprintf("was interrupted after %d actions", len(result.ActionResults))
+}
+
+// callerIdentity looks the caller up once; concurrent runs share the answer.
+// The lookup isn't cancelled with ctx, so an interrupted run is still
+// journaled with its caller.
+func (pe *PolicyExecutor) callerIdentity(ctx context.Context) (*aws.CallerInfo, error) {
+	pe.callerOnce.Do(func() {
+		pe.caller, pe.callerErr = pe.awsClient.GetCallerIdentity(context.WithoutCancel(ctx))
+	})
+	return pe.caller, pe.callerErr
 }
 
 func (pe *PolicyExecutor) calculateSummary(result *ExecutionResult) ExecutionSummary {
@@ -647,6 +1517,14 @@ func (pe *PolicyExecutor) calculateSummary(result *ExecutionResult) ExecutionSum
 }
 
 func (pe *PolicyExecutor) updatePolicyStats(policy *storage.StoredPolicy, result *ExecutionResult) {
+	pe.statsMu.Lock()
+	defer pe.statsMu.Unlock()
+
+	// Count on top of the latest copy, which other regions' runs may have saved
+	if latest, err := pe.storage.GetPolicy(policy.Name); err == nil {
+		policy = latest
+	}
+
 	// Update policy statistics
 	now := time.Now()
 	policy.LastRun = &now
@@ -657,15 +1535,45 @@ func (pe *PolicyExecutor) updatePolicyStats(policy *storage.StoredPolicy, result
 	pe.storage.SavePolicy(*policy)
 }
 
-func (pe *PolicyExecutor) saveExecutionResult(result *ExecutionResult) {
-	// In a full implementation, this would save to a results database or file
-	fmt.Printf("💾 Execution result saved for policy: %s\n", result.PolicyName)
+// saveExecutionResult writes the run to the journal, with the policy version
+// and the identity it ran as
+func (pe *PolicyExecutor) saveExecutionResult(
+	ctx context.Context,
+	policy *storage.StoredPolicy,
+	result *ExecutionResult,
+) {
+	if pe.journal == nil {
+		return
+	}
+
+	caller, callerErr := pe.callerIdentity(ctx)
+	record := &RunRecord{
+		ID:            result.RunID,
+		PolicyName:    policy.Name,
+		PolicyVersion: policy.Version,
+		Region:        result.Region,
+		Profile:       pe.awsClient.Profile,
+		Caller:        caller,
+		Result:        result,
+	}
+	if callerErr != nil {
+		record.CallerError = callerErr.Error()
+	}
+
+	if err := pe.journal.Save(record); err != nil {
+		fmt.Printf("⚠️  Failed to save execution result: %v\n", err)
+		return
+	}
+	fmt.Printf("💾 Execution result saved as run %s\n", result.RunID)
 }
 
 func (pe *PolicyExecutor) printExecutionSummary(result *ExecutionResult) {
 	fmt.Println("\n📊 Execution Result Summary:")
 	fmt.Println("═══════════════════════════════════════════════════")
 	fmt.Printf("🎯 Policy: %s\n", result.PolicyName)
+	if result.RunID != "" {
+		fmt.Printf("🧾 Run ID: %s\n", result.RunID)
+	}
 	fmt.Printf("⏱️  Duration: %v\n", result.Duration.Round(time.Second))
 	fmt.Printf(
 		"📊 Resources: %d found, %d matched\n",
@@ -687,6 +1595,10 @@ func (pe *PolicyExecutor) printExecutionSummary(result *ExecutionResult) {
 		fmt.Printf("🔒 Security Improvements: %d\n", result.Summary.SecurityImprovements)
 	}
 
+	if len(result.APICalls) > 0 {
+		fmt.Printf("📡 AWS Calls: %s\n", describeAPICalls(result.APICalls))
+	}
+
 	if len(result.Errors) > 0 {
 		fmt.Printf("❌ Errors: %d\n", len(result.Errors))
 		for _, err := range result.Errors {
@@ -694,9 +1606,32 @@ func (pe *PolicyExecutor) printExecutionSummary(result *ExecutionResult) {
 		}
 	}
 
-	if result.Success {
+	if result.Stopped != "" {
+		fmt.Printf("⏹️  Policy execution %s: %d actions ran before it stopped\n",
+			result.Stopped, result.Summary.TotalActions)
+	} else if result.Success {
 		fmt.Println("✅ Policy execution completed successfully!")
 	} else {
 		fmt.Println("⚠️  Policy execution completed with errors")
 	}
 }
+
+// describeAPICalls sums up a run's AWS calls, e.g. "120 (EC2 20, S3 100), 4 retries, 3 throttled"
+func describeAPICalls(stats map[string]aws.ServiceStats) string {
+	total := aws.TotalCalls(stats)
+
+	var services []string
+	for _, service := range aws.ServicesByName(stats) {
+		services = append(services, fmt.Sprintf("%s %d", service, stats[service].Calls))
+	}
+
+	text := fmt.Sprintf("%d (%s), %d retries, %d throttled",
+		total.Calls, strings.Join(services, ", "), total.Retries, total.Throttled)
+	if total.GaveUp > 0 {
+		text += fmt.Sprintf(", %d gave up", total.GaveUp)
+	}
+	if total.Waited >= time.Second {
+		text += fmt.Sprintf(", %v spent waiting", total.Waited.Round(100*time.Millisecond))
+	}
+	return text
+}
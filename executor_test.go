@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"custodian-killer/aws"
+	"custodian-killer/fakecloud"
+	"custodian-killer/scanner"
+	"custodian-killer/storage"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testExecutor runs live, without prompts, against a fake cloud holding seed
+func testExecutor(t *testing.T, seed fakecloud.Seed) (*PolicyExecutor, *fakecloud.Cloud, *storage.FileStorage) {
+	t.Helper()
+	cloud := fakecloud.New(seed)
+	client, err := aws.NewCustodianClientWithAPIs(cloud.APIs, aws.ClientConfig{Region: "us-east-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileStorage, err := storage.NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pe := NewPolicyExecutor(client, fileStorage)
+	config := pe.config
+	config.ConfirmActions = false
+	pe.SetConfig(config)
+	return pe, cloud, fileStorage
+}
+
+func TestRunBatchesSharesSlots(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxConcurrency int
+		runs           int // policy runs batching at the same time
+		resources      int
+		batchSize      int
+	}{
+		{name: "one run spreads over free slots", maxConcurrency: 4, runs: 1, resources: 40, batchSize: 3},
+		{name: "full pool runs batches inline", maxConcurrency: 3, runs: 3, resources: 20, batchSize: 2},
+		{name: "more runs than slots", maxConcurrency: 2, runs: 5, resources: 10, batchSize: 1},
+		{name: "single slot", maxConcurrency: 1, runs: 2, resources: 7, batchSize: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pe := &PolicyExecutor{}
+			pe.SetConfig(ExecutorConfig{MaxConcurrency: test.maxConcurrency, BatchSize: test.batchSize})
+
+			var inFlight, peak int64
+			results := make([]*ExecutionResult, test.runs)
+			var wg sync.WaitGroup
+			for r := 0; r < test.runs; r++ {
+				wg.Add(1)
+				go func(r int) {
+					defer wg.Done()
+					ctx := context.Background()
+					release := pe.takeSlot(ctx)
+					defer release()
+
+					result := &ExecutionResult{}
+					err := pe.runBatches(ctx, test.resources, result, func(start, end int, batch *ExecutionResult) error {
+						now := atomic.AddInt64(&inFlight, 1)
+						for {
+							old := atomic.LoadInt64(&peak)
+							if now <= old || atomic.CompareAndSwapInt64(&peak, old, now) {
+								break
+							}
+						}
+						time.Sleep(time.Millisecond)
+						for i := start; i < end; i++ {
+							batch.ActionResults = append(batch.ActionResults, ActionResult{ResourceID: fmt.Sprint(i)})
+						}
+						atomic.AddInt64(&inFlight, -1)
+						return nil
+					})
+					if err != nil {
+						t.Errorf("runBatches: %v", err)
+					}
+					results[r] = result
+				}(r)
+			}
+			wg.Wait()
+
+			if peak > int64(test.maxConcurrency) {
+				t.Errorf("%d batches ran at once, want at most %d", peak, test.maxConcurrency)
+			}
+			for r, result := range results {
+				if len(result.ActionResults) != test.resources {
+					t.Fatalf("run %d: %d results, want %d", r, len(result.ActionResults), test.resources)
+				}
+				for i, action := range result.ActionResults {
+					if action.ResourceID != fmt.Sprint(i) {
+						t.Fatalf("run %d: result %d is for %s, want batch order", r, i, action.ResourceID)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestExecutePlanValidatesPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(policy *storage.StoredPolicy)
+		wantErr string // "" when the plan applies
+	}{
+		{name: "valid policy", edit: func(policy *storage.StoredPolicy) {}},
+		{
+			name: "action settings",
+			edit: func(policy *storage.StoredPolicy) {
+				policy.Actions = append(policy.Actions, storage.StoredAction{Type: "tag"})
+			},
+			wantErr: "failed validation",
+		},
+		{
+			name:    "action not offered",
+			edit:    func(policy *storage.StoredPolicy) { policy.Actions[0].Type = "enable-encryption" },
+			wantErr: "failed validation",
+		},
+		{
+			name:    "mode",
+			edit:    func(policy *storage.StoredPolicy) { policy.Mode.Type = "sometimes" },
+			wantErr: "failed validation",
+		},
+		{
+			name:    "regions",
+			edit:    func(policy *storage.StoredPolicy) { policy.Regions = []string{"us-east-1", "moon-1"} },
+			wantErr: "failed validation",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pe, cloud, fileStorage := testExecutor(t, fakecloud.Seed{Instances: []fakecloud.Instance{
+				{InstanceID: "i-0dev", InstanceType: "t3.micro", State: "running", Tags: map[string]string{"Env": "dev"}},
+			}})
+
+			policy := storage.StoredPolicy{
+				Name:         "stop-dev",
+				ResourceType: "ec2",
+				Filters:      []storage.StoredFilter{{Type: "tag", Key: "Env", Value: "dev"}},
+				Actions:      []storage.StoredAction{{Type: "stop"}},
+				Mode:         storage.StoredPolicyMode{Type: "pull"},
+			}
+			test.edit(&policy)
+			if err := fileStorage.SavePolicy(policy); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := fileStorage.GetPolicy(policy.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resource := scanner.MatchedResource{ID: "i-0dev", Type: "ec2", State: "running", Tags: map[string]string{"Env": "dev"}}
+			for _, action := range stored.Actions {
+				resource.Actions = append(resource.Actions, scanner.PlanAction(action, resource.ID))
+			}
+			plan := &ExecutionPlan{
+				FormatVersion: planFormatVersion,
+				CreatedAt:     time.Now().UTC(),
+				Region:        "us-east-1",
+				PolicyName:    stored.Name,
+				PolicyVersion: stored.Version,
+				PolicyHash:    PolicyHash(stored),
+				Scan: &scanner.ScanResult{
+					PolicyName:       stored.Name,
+					ResourceType:     "ec2",
+					MatchedResources: []scanner.MatchedResource{resource},
+				},
+			}
+
+			_, err = pe.ExecutePlan(context.Background(), plan)
+			state := cloud.State().Instances[0].State
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ExecutePlan: %v", err)
+				}
+				if state != "stopped" {
+					t.Errorf("instance is %s after the plan ran, want stopped", state)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("ExecutePlan error = %v, want one containing %q", err, test.wantErr)
+			}
+			if state != "running" {
+				t.Errorf("instance is %s after a refused plan, want running", state)
+			}
+		})
+	}
+}
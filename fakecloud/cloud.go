@@ -0,0 +1,307 @@
+// Package fakecloud is an in-memory AWS backend for running Custodian Killer
+// without an AWS account. It implements the aws service interfaces on top of
+// a JSON seed file and keeps the changes actions make.
+package fakecloud
+
+import (
+	"custodian-killer/aws"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRegion    = "us-east-1"
+	defaultAccountID = "123456789012"
+)
+
+// Seed is the state of the fake cloud, as read from and written to the seed file
+type Seed struct {
+	Region    string       `json:"region,omitempty"`     // given to resources that don't name one
+	AccountID string       `json:"account_id,omitempty"` // used in generated ARNs
+	Instances []Instance   `json:"ec2_instances,omitempty"`
+	Buckets   []Bucket     `json:"s3_buckets,omitempty"`
+	Databases []DBInstance `json:"rds_instances,omitempty"`
+	Functions []Function   `json:"lambda_functions,omitempty"`
+	Volumes   []Volume     `json:"ebs_volumes,omitempty"`
+	Queues    []Queue      `json:"sqs_queues,omitempty"`
+}
+
+// Instance is a fake EC2 instance
+type Instance struct {
+	InstanceID     string            `json:"instance_id"`
+	Region         string            `json:"region,omitempty"`
+	InstanceType   string            `json:"instance_type"`
+	State          string            `json:"state"`
+	LaunchTime     time.Time         `json:"launch_time"`
+	Platform       string            `json:"platform,omitempty"`
+	PublicIP       string            `json:"public_ip,omitempty"`
+	PrivateIP      string            `json:"private_ip,omitempty"`
+	VpcID          string            `json:"vpc_id,omitempty"`
+	SubnetID       string            `json:"subnet_id,omitempty"`
+	SecurityGroups []string          `json:"security_groups,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+// Bucket is a fake S3 bucket
+type Bucket struct {
+	Name              string             `json:"name"`
+	Region            string             `json:"region,omitempty"`
+	CreationDate      time.Time          `json:"creation_date"`
+	Tags              map[string]string  `json:"tags,omitempty"`
+	PublicReadACL     bool               `json:"public_read_acl,omitempty"`
+	PublicWriteACL    bool               `json:"public_write_acl,omitempty"`
+	PublicAccessBlock *PublicAccessBlock `json:"public_access_block,omitempty"` // nil when not configured
+	Versioning        string             `json:"versioning,omitempty"`          // Enabled, Suspended, or empty
+	Encryption        string             `json:"encryption,omitempty"`          // AES256, aws:kms, or empty
+	KMSKeyID          string             `json:"kms_key_id,omitempty"`
+	Objects           []Object           `json:"objects,omitempty"`
+}
+
+// PublicAccessBlock is a bucket's public access block configuration
+type PublicAccessBlock struct {
+	BlockPublicACLs       bool `json:"block_public_acls"`
+	BlockPublicPolicy     bool `json:"block_public_policy"`
+	IgnorePublicACLs      bool `json:"ignore_public_acls"`
+	RestrictPublicBuckets bool `json:"restrict_public_buckets"`
+}
+
+// Object is an object stored in a fake bucket
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// DBInstance is a fake RDS instance
+type DBInstance struct {
+	DBInstanceID          string            `json:"db_instance_id"`
+	Region                string            `json:"region,omitempty"`
+	InstanceClass         string            `json:"instance_class"`
+	Engine                string            `json:"engine"`
+	EngineVersion         string            `json:"engine_version,omitempty"`
+	Status                string            `json:"status"`
+	AllocatedStorageGB    int32             `json:"allocated_storage_gb"`
+	BackupRetentionPeriod int32             `json:"backup_retention_period"`
+	MultiAZ               bool              `json:"multi_az,omitempty"`
+	Encrypted             bool              `json:"encrypted,omitempty"`
+	PubliclyAccessible    bool              `json:"publicly_accessible,omitempty"`
+	CreatedAt             time.Time         `json:"created_at"`
+	AvailabilityZone      string            `json:"availability_zone,omitempty"`
+	Endpoint              string            `json:"endpoint,omitempty"`
+	Tags                  map[string]string `json:"tags,omitempty"`
+}
+
+// Function is a fake Lambda function
+type Function struct {
+	FunctionName   string            `json:"function_name"`
+	Region         string            `json:"region,omitempty"`
+	Runtime        string            `json:"runtime"`
+	Handler        string            `json:"handler,omitempty"`
+	Description    string            `json:"description,omitempty"`
+	MemorySizeMB   int32             `json:"memory_size_mb"`
+	TimeoutSeconds int32             `json:"timeout_seconds"`
+	CodeSizeBytes  int64             `json:"code_size_bytes"`
+	LastModified   time.Time         `json:"last_modified"`
+	Role           string            `json:"role,omitempty"`
+	Environment    map[string]string `json:"environment,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+// Volume is a fake EBS volume
+type Volume struct {
+	VolumeID         string            `json:"volume_id"`
+	Region           string            `json:"region,omitempty"`
+	VolumeType       string            `json:"volume_type"`
+	State            string            `json:"state"`
+	SizeGB           int32             `json:"size_gb"`
+	IOPS             int32             `json:"iops,omitempty"`
+	Encrypted        bool              `json:"encrypted,omitempty"`
+	KMSKeyID         string            `json:"kms_key_id,omitempty"`
+	AvailabilityZone string            `json:"availability_zone,omitempty"`
+	CreateTime       time.Time         `json:"create_time"`
+	SnapshotID       string            `json:"snapshot_id,omitempty"`
+	AttachedTo       []string          `json:"attached_to,omitempty"`
+	Tags             map[string]string `json:"tags,omitempty"`
+}
+
+// Queue is a fake SQS queue, named by the last part of its URL
+type Queue struct {
+	Name     string    `json:"name"`
+	Messages []Message `json:"messages,omitempty"`
+}
+
+// Message is a message waiting on a fake queue
+type Message struct {
+	MessageID string `json:"message_id,omitempty"` // generated when empty
+	Body      string `json:"body"`
+}
+
+// Cloud holds the fake cloud's state. Every service client shares it, so a
+// change made through one client is seen by the next describe call.
+type Cloud struct {
+	mu   sync.Mutex
+	seed Seed
+	path string // written back after every change when set
+}
+
+// New creates a fake cloud from a seed, kept in memory only
+func New(seed Seed) *Cloud {
+	if seed.Region == "" {
+		seed.Region = defaultRegion
+	}
+	if seed.AccountID == "" {
+		seed.AccountID = defaultAccountID
+	}
+	return &Cloud{seed: seed}
+}
+
+// Load creates a fake cloud from a seed file. Changes are saved back to the
+// file, so state carries over between runs.
+func Load(path string) (*Cloud, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fake cloud seed: %v", err)
+	}
+
+	var seed Seed
+	if err := json.Unmarshal(data, &seed); err != nil {
+		return nil, fmt.Errorf("failed to parse fake cloud seed %s: %v", path, err)
+	}
+
+	cloud := New(seed)
+	cloud.path = path
+	return cloud, nil
+}
+
+// APIs returns service clients that see the resources in one region.
+// S3 is global, as it is in AWS.
+func (c *Cloud) APIs(region string) aws.ServiceAPIs {
+	if region == "" {
+		region = c.seed.Region
+	}
+	return aws.ServiceAPIs{
+		EC2:    &EC2{cloud: c, region: region},
+		S3:     &S3{cloud: c},
+		RDS:    &RDS{cloud: c, region: region},
+		Lambda: &Lambda{cloud: c, region: region},
+		STS:    &STS{cloud: c},
+		SQS:    &SQS{cloud: c},
+	}
+}
+
+// State returns a copy of the current state
+func (c *Cloud) State() Seed {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A JSON round trip is the simplest deep copy
+	data, _ := json.Marshal(c.seed)
+	var state Seed
+	_ = json.Unmarshal(data, &state)
+	return state
+}
+
+// Regions lists every region that has resources, plus the default region
+func (c *Cloud) Regions() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seen := map[string]bool{c.seed.Region: true}
+	for _, instance := range c.seed.Instances {
+		seen[c.regionOf(instance.Region)] = true
+	}
+	for _, bucket := range c.seed.Buckets {
+		seen[c.regionOf(bucket.Region)] = true
+	}
+	for _, database := range c.seed.Databases {
+		seen[c.regionOf(database.Region)] = true
+	}
+	for _, function := range c.seed.Functions {
+		seen[c.regionOf(function.Region)] = true
+	}
+	for _, volume := range c.seed.Volumes {
+		seen[c.regionOf(volume.Region)] = true
+	}
+
+	regions := make([]string, 0, len(seen))
+	for region := range seen {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// regionOf resolves a resource's region; callers hold the lock
+func (c *Cloud) regionOf(region string) string {
+	if region == "" {
+		return c.seed.Region
+	}
+	return region
+}
+
+// save writes the state back to the seed file; callers hold the lock
+func (c *Cloud) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.seed, "", "  ")
+	if err != nil {
+		return apiError("InternalError", "failed to encode fake cloud state: %v", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return apiError("InternalError", "failed to save fake cloud state: %v", err)
+	}
+	return nil
+}
+
+// APIError is an error response from the fake cloud, shaped like the SDK's
+// "api error Code: message"
+type APIError struct {
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %s: %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the AWS error code, e.g. NoSuchBucket
+func (e *APIError) ErrorCode() string {
+	return e.Code
+}
+
+// ErrorMessage returns the error message without the code
+func (e *APIError) ErrorMessage() string {
+	return e.Message
+}
+
+func apiError(code, format string, args ...interface{}) error {
+	return &APIError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// dryRunError is what EC2 returns when a dry run would have succeeded
+func dryRunError() error {
+	return apiError("DryRunOperation", "Request would have succeeded, but DryRun flag is set.")
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	copied := make(map[string]string, len(tags))
+	for key, value := range tags {
+		copied[key] = value
+	}
+	return copied
+}
+
+func sortedKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
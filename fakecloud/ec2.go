@@ -0,0 +1,487 @@
+package fakecloud
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// instanceStateCodes are the numeric codes EC2 reports with each state name
+var instanceStateCodes = map[string]int32{
+	"pending":       0,
+	"running":       16,
+	"shutting-down": 32,
+	"terminated":    48,
+	"stopping":      64,
+	"stopped":       80,
+}
+
+// EC2 is a fake EC2 client for one region
+type EC2 struct {
+	cloud  *Cloud
+	region string
+}
+
+// DescribeRegions lists the regions the fake cloud has resources in
+func (e *EC2) DescribeRegions(
+	ctx context.Context,
+	params *ec2.DescribeRegionsInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.DescribeRegionsOutput, error) {
+	if params != nil && aws.ToBool(params.DryRun) {
+		return nil, dryRunError()
+	}
+
+	output := &ec2.DescribeRegionsOutput{}
+	for _, region := range e.cloud.Regions() {
+		output.Regions = append(output.Regions, types.Region{
+			RegionName:  aws.String(region),
+			Endpoint:    aws.String("ec2." + region + ".amazonaws.com"),
+			OptInStatus: aws.String("opt-in-not-required"),
+		})
+	}
+	return output, nil
+}
+
+// DescribeInstances returns the region's instances, one per reservation, in a single page
+func (e *EC2) DescribeInstances(
+	ctx context.Context,
+	params *ec2.DescribeInstancesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.DescribeInstancesOutput, error) {
+	if params == nil {
+		params = &ec2.DescribeInstancesInput{}
+	}
+	if aws.ToBool(params.DryRun) {
+		return nil, dryRunError()
+	}
+
+	e.cloud.mu.Lock()
+	defer e.cloud.mu.Unlock()
+
+	if err := e.checkInstanceIDs(params.InstanceIds); err != nil {
+		return nil, err
+	}
+
+	output := &ec2.DescribeInstancesOutput{}
+	for _, instance := range e.cloud.seed.Instances {
+		if e.cloud.regionOf(instance.Region) != e.region {
+			continue
+		}
+		if len(params.InstanceIds) > 0 && !containsString(params.InstanceIds, instance.InstanceID) {
+			continue
+		}
+
+		matched, err := matchesEC2Filters(params.Filters, map[string]string{
+			"instance-state-name": instance.State,
+			"instance-type":       instance.InstanceType,
+			"vpc-id":              instance.VpcID,
+			"subnet-id":           instance.SubnetID,
+		}, instance.Tags)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		output.Reservations = append(output.Reservations, types.Reservation{
+			ReservationId: aws.String("r-" + strings.TrimPrefix(instance.InstanceID, "i-")),
+			OwnerId:       aws.String(e.cloud.seed.AccountID),
+			Instances:     []types.Instance{toSDKInstance(instance)},
+		})
+	}
+
+	return output, nil
+}
+
+// DescribeVolumes returns the region's volumes in a single page
+func (e *EC2) DescribeVolumes(
+	ctx context.Context,
+	params *ec2.DescribeVolumesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.DescribeVolumesOutput, error) {
+	if params == nil {
+		params = &ec2.DescribeVolumesInput{}
+	}
+	if aws.ToBool(params.DryRun) {
+		return nil, dryRunError()
+	}
+
+	e.cloud.mu.Lock()
+	defer e.cloud.mu.Unlock()
+
+	for _, id := range params.VolumeIds {
+		if e.findVolume(id) == nil {
+			return nil, apiError("InvalidVolume.NotFound", "The volume '%s' does not exist.", id)
+		}
+	}
+
+	output := &ec2.DescribeVolumesOutput{}
+	for _, volume := range e.cloud.seed.Volumes {
+		if e.cloud.regionOf(volume.Region) != e.region {
+			continue
+		}
+		if len(params.VolumeIds) > 0 && !containsString(params.VolumeIds, volume.VolumeID) {
+			continue
+		}
+
+		matched, err := matchesEC2Filters(params.Filters, map[string]string{
+			"status":            volume.State,
+			"volume-type":       volume.VolumeType,
+			"availability-zone": volume.AvailabilityZone,
+		}, volume.Tags)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			output.Volumes = append(output.Volumes, toSDKVolume(volume))
+		}
+	}
+
+	return output, nil
+}
+
+// StopInstances moves instances straight to stopped
+func (e *EC2) StopInstances(
+	ctx context.Context,
+	params *ec2.StopInstancesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.StopInstancesOutput, error) {
+	changes, err := e.changeState(params.InstanceIds, aws.ToBool(params.DryRun), "stopped")
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.StopInstancesOutput{StoppingInstances: changes}, nil
+}
+
+// StartInstances moves instances straight to running
+func (e *EC2) StartInstances(
+	ctx context.Context,
+	params *ec2.StartInstancesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.StartInstancesOutput, error) {
+	changes, err := e.changeState(params.InstanceIds, aws.ToBool(params.DryRun), "running")
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.StartInstancesOutput{StartingInstances: changes}, nil
+}
+
+// TerminateInstances moves instances to terminated. Like EC2, terminated
+// instances stay visible to describe calls.
+func (e *EC2) TerminateInstances(
+	ctx context.Context,
+	params *ec2.TerminateInstancesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.TerminateInstancesOutput, error) {
+	changes, err := e.changeState(params.InstanceIds, aws.ToBool(params.DryRun), "terminated")
+	if err != nil {
+		return nil, err
+	}
+
+	// Terminating detaches the instance's volumes
+	e.cloud.mu.Lock()
+	defer e.cloud.mu.Unlock()
+	for i := range e.cloud.seed.Volumes {
+		volume := &e.cloud.seed.Volumes[i]
+		var attached []string
+		for _, instanceID := range volume.AttachedTo {
+			if !containsString(params.InstanceIds, instanceID) {
+				attached = append(attached, instanceID)
+			}
+		}
+		if len(attached) != len(volume.AttachedTo) {
+			volume.AttachedTo = attached
+			if len(attached) == 0 {
+				volume.State = "available"
+			}
+		}
+	}
+	if err := e.cloud.save(); err != nil {
+		return nil, err
+	}
+
+	return &ec2.TerminateInstancesOutput{TerminatingInstances: changes}, nil
+}
+
+// CreateTags adds or overwrites tags on instances and volumes
+func (e *EC2) CreateTags(
+	ctx context.Context,
+	params *ec2.CreateTagsInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.CreateTagsOutput, error) {
+	e.cloud.mu.Lock()
+	defer e.cloud.mu.Unlock()
+
+	var targets []*map[string]string
+	for _, id := range params.Resources {
+		if instance := e.findInstance(id); instance != nil {
+			targets = append(targets, &instance.Tags)
+		} else if volume := e.findVolume(id); volume != nil {
+			targets = append(targets, &volume.Tags)
+		} else {
+			return nil, apiError("InvalidID", "The ID '%s' is not valid", id)
+		}
+	}
+
+	if aws.ToBool(params.DryRun) {
+		return nil, dryRunError()
+	}
+
+	for _, tags := range targets {
+		if *tags == nil {
+			*tags = make(map[string]string)
+		}
+		for _, tag := range params.Tags {
+			(*tags)[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+
+	if err := e.cloud.save(); err != nil {
+		return nil, err
+	}
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+// DeleteTags removes tags from instances and volumes. A tag given with a
+// value is only removed when the value matches, as in EC2.
+func (e *EC2) DeleteTags(
+	ctx context.Context,
+	params *ec2.DeleteTagsInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.DeleteTagsOutput, error) {
+	e.cloud.mu.Lock()
+	defer e.cloud.mu.Unlock()
+
+	var targets []map[string]string
+	for _, id := range params.Resources {
+		if instance := e.findInstance(id); instance != nil {
+			targets = append(targets, instance.Tags)
+		} else if volume := e.findVolume(id); volume != nil {
+			targets = append(targets, volume.Tags)
+		} else {
+			return nil, apiError("InvalidID", "The ID '%s' is not valid", id)
+		}
+	}
+
+	if aws.ToBool(params.DryRun) {
+		return nil, dryRunError()
+	}
+
+	for _, tags := range targets {
+		for _, tag := range params.Tags {
+			key := aws.ToString(tag.Key)
+			if tag.Value != nil && tags[key] != aws.ToString(tag.Value) {
+				continue
+			}
+			delete(tags, key)
+		}
+	}
+
+	if err := e.cloud.save(); err != nil {
+		return nil, err
+	}
+	return &ec2.DeleteTagsOutput{}, nil
+}
+
+// changeState moves instances to a new state and reports the transitions
+func (e *EC2) changeState(
+	instanceIDs []string,
+	dryRun bool,
+	state string,
+) ([]types.InstanceStateChange, error) {
+	e.cloud.mu.Lock()
+	defer e.cloud.mu.Unlock()
+
+	if err := e.checkInstanceIDs(instanceIDs); err != nil {
+		return nil, err
+	}
+	for _, id := range instanceIDs {
+		if instance := e.findInstance(id); instance.State == "terminated" && state != "terminated" {
+			return nil, apiError("IncorrectInstanceState",
+				"The instance '%s' is not in a state from which it can be %s.", id, state)
+		}
+	}
+
+	if dryRun {
+		return nil, dryRunError()
+	}
+
+	var changes []types.InstanceStateChange
+	for _, id := range instanceIDs {
+		instance := e.findInstance(id)
+		changes = append(changes, types.InstanceStateChange{
+			InstanceId:    aws.String(id),
+			PreviousState: instanceState(instance.State),
+			CurrentState:  instanceState(state),
+		})
+		instance.State = state
+	}
+
+	if err := e.cloud.save(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// checkInstanceIDs fails the whole call if any ID is unknown, as EC2 does
+func (e *EC2) checkInstanceIDs(instanceIDs []string) error {
+	for _, id := range instanceIDs {
+		if e.findInstance(id) == nil {
+			return apiError("InvalidInstanceID.NotFound", "The instance ID '%s' does not exist", id)
+		}
+	}
+	return nil
+}
+
+func (e *EC2) findInstance(id string) *Instance {
+	for i := range e.cloud.seed.Instances {
+		instance := &e.cloud.seed.Instances[i]
+		if instance.InstanceID == id && e.cloud.regionOf(instance.Region) == e.region {
+			return instance
+		}
+	}
+	return nil
+}
+
+func (e *EC2) findVolume(id string) *Volume {
+	for i := range e.cloud.seed.Volumes {
+		volume := &e.cloud.seed.Volumes[i]
+		if volume.VolumeID == id && e.cloud.regionOf(volume.Region) == e.region {
+			return volume
+		}
+	}
+	return nil
+}
+
+// matchesEC2Filters applies EC2 server-side filters. Values within a filter
+// are ORed and filters are ANDed; tag-key and tag:<key> work on tags.
+func matchesEC2Filters(
+	filters []types.Filter,
+	fields map[string]string,
+	tags map[string]string,
+) (bool, error) {
+	for _, filter := range filters {
+		name := aws.ToString(filter.Name)
+
+		switch {
+		case name == "tag-key":
+			found := false
+			for _, key := range filter.Values {
+				if _, exists := tags[key]; exists {
+					found = true
+				}
+			}
+			if !found {
+				return false, nil
+			}
+
+		case strings.HasPrefix(name, "tag:"):
+			value, exists := tags[strings.TrimPrefix(name, "tag:")]
+			if !exists || !containsString(filter.Values, value) {
+				return false, nil
+			}
+
+		default:
+			value, known := fields[name]
+			if !known {
+				return false, apiError("InvalidParameterValue", "The filter '%s' is invalid", name)
+			}
+			if !containsString(filter.Values, value) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func instanceState(name string) *types.InstanceState {
+	return &types.InstanceState{
+		Code: aws.Int32(instanceStateCodes[name]),
+		Name: types.InstanceStateName(name),
+	}
+}
+
+func toSDKInstance(instance Instance) types.Instance {
+	sdkInstance := types.Instance{
+		InstanceId:   aws.String(instance.InstanceID),
+		InstanceType: types.InstanceType(instance.InstanceType),
+		State:        instanceState(instance.State),
+		LaunchTime:   aws.Time(instance.LaunchTime),
+		Platform:     types.PlatformValues(instance.Platform),
+		Tags:         toSDKTags(instance.Tags),
+	}
+
+	if instance.PublicIP != "" {
+		sdkInstance.PublicIpAddress = aws.String(instance.PublicIP)
+	}
+	if instance.PrivateIP != "" {
+		sdkInstance.PrivateIpAddress = aws.String(instance.PrivateIP)
+	}
+	if instance.VpcID != "" {
+		sdkInstance.VpcId = aws.String(instance.VpcID)
+	}
+	if instance.SubnetID != "" {
+		sdkInstance.SubnetId = aws.String(instance.SubnetID)
+	}
+	for _, group := range instance.SecurityGroups {
+		sdkInstance.SecurityGroups = append(sdkInstance.SecurityGroups, types.GroupIdentifier{
+			GroupId: aws.String(group),
+		})
+	}
+
+	return sdkInstance
+}
+
+func toSDKVolume(volume Volume) types.Volume {
+	sdkVolume := types.Volume{
+		VolumeId:         aws.String(volume.VolumeID),
+		VolumeType:       types.VolumeType(volume.VolumeType),
+		State:            types.VolumeState(volume.State),
+		Size:             aws.Int32(volume.SizeGB),
+		Encrypted:        aws.Bool(volume.Encrypted),
+		AvailabilityZone: aws.String(volume.AvailabilityZone),
+		CreateTime:       aws.Time(volume.CreateTime),
+		Tags:             toSDKTags(volume.Tags),
+	}
+
+	if volume.IOPS > 0 {
+		sdkVolume.Iops = aws.Int32(volume.IOPS)
+	}
+	if volume.KMSKeyID != "" {
+		sdkVolume.KmsKeyId = aws.String(volume.KMSKeyID)
+	}
+	if volume.SnapshotID != "" {
+		sdkVolume.SnapshotId = aws.String(volume.SnapshotID)
+	}
+	for _, instanceID := range volume.AttachedTo {
+		sdkVolume.Attachments = append(sdkVolume.Attachments, types.VolumeAttachment{
+			InstanceId: aws.String(instanceID),
+			VolumeId:   aws.String(volume.VolumeID),
+			State:      types.VolumeAttachmentState("attached"),
+		})
+	}
+
+	return sdkVolume
+}
+
+func toSDKTags(tags map[string]string) []types.Tag {
+	var sdkTags []types.Tag
+	for _, key := range sortedKeys(tags) {
+		sdkTags = append(sdkTags, types.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return sdkTags
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
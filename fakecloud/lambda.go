@@ -0,0 +1,79 @@
+package fakecloud
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// lambdaTimeLayout is how Lambda formats LastModified
+const lambdaTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// Lambda is a fake Lambda client for one region
+type Lambda struct {
+	cloud  *Cloud
+	region string
+}
+
+// ListFunctions returns the region's functions in a single page
+func (l *Lambda) ListFunctions(
+	ctx context.Context,
+	params *lambda.ListFunctionsInput,
+	optFns ...func(*lambda.Options),
+) (*lambda.ListFunctionsOutput, error) {
+	l.cloud.mu.Lock()
+	defer l.cloud.mu.Unlock()
+
+	output := &lambda.ListFunctionsOutput{}
+	for _, function := range l.cloud.seed.Functions {
+		if l.cloud.regionOf(function.Region) != l.region {
+			continue
+		}
+
+		configuration := types.FunctionConfiguration{
+			FunctionName: aws.String(function.FunctionName),
+			FunctionArn:  aws.String(l.arn(function.FunctionName)),
+			Runtime:      types.Runtime(function.Runtime),
+			Handler:      aws.String(function.Handler),
+			Description:  aws.String(function.Description),
+			MemorySize:   aws.Int32(function.MemorySizeMB),
+			Timeout:      aws.Int32(function.TimeoutSeconds),
+			CodeSize:     function.CodeSizeBytes,
+			Role:         aws.String(function.Role),
+			LastModified: aws.String(function.LastModified.Format(lambdaTimeLayout)),
+		}
+		if len(function.Environment) > 0 {
+			configuration.Environment = &types.EnvironmentResponse{Variables: copyTags(function.Environment)}
+		}
+
+		output.Functions = append(output.Functions, configuration)
+	}
+
+	return output, nil
+}
+
+// ListTags returns a function's tags by ARN
+func (l *Lambda) ListTags(
+	ctx context.Context,
+	params *lambda.ListTagsInput,
+	optFns ...func(*lambda.Options),
+) (*lambda.ListTagsOutput, error) {
+	l.cloud.mu.Lock()
+	defer l.cloud.mu.Unlock()
+
+	arn := aws.ToString(params.Resource)
+	for _, function := range l.cloud.seed.Functions {
+		if l.cloud.regionOf(function.Region) == l.region && l.arn(function.FunctionName) == arn {
+			return &lambda.ListTagsOutput{Tags: copyTags(function.Tags)}, nil
+		}
+	}
+
+	return nil, apiError("ResourceNotFoundException", "Function not found: %s", arn)
+}
+
+func (l *Lambda) arn(name string) string {
+	return fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", l.region, l.cloud.seed.AccountID, name)
+}
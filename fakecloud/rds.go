@@ -0,0 +1,79 @@
+package fakecloud
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+// RDS is a fake RDS client for one region
+type RDS struct {
+	cloud  *Cloud
+	region string
+}
+
+// DescribeDBInstances returns the region's DB instances in a single page
+func (r *RDS) DescribeDBInstances(
+	ctx context.Context,
+	params *rds.DescribeDBInstancesInput,
+	optFns ...func(*rds.Options),
+) (*rds.DescribeDBInstancesOutput, error) {
+	if params == nil {
+		params = &rds.DescribeDBInstancesInput{}
+	}
+
+	r.cloud.mu.Lock()
+	defer r.cloud.mu.Unlock()
+
+	id := aws.ToString(params.DBInstanceIdentifier)
+	output := &rds.DescribeDBInstancesOutput{}
+	for _, database := range r.cloud.seed.Databases {
+		if r.cloud.regionOf(database.Region) != r.region {
+			continue
+		}
+		if id != "" && database.DBInstanceID != id {
+			continue
+		}
+		output.DBInstances = append(output.DBInstances, r.toSDKInstance(database))
+	}
+
+	if id != "" && len(output.DBInstances) == 0 {
+		return nil, apiError("DBInstanceNotFound", "DBInstance %s not found.", id)
+	}
+	return output, nil
+}
+
+func (r *RDS) toSDKInstance(database DBInstance) types.DBInstance {
+	instance := types.DBInstance{
+		DBInstanceIdentifier: aws.String(database.DBInstanceID),
+		DBInstanceArn: aws.String(fmt.Sprintf(
+			"arn:aws:rds:%s:%s:db:%s", r.region, r.cloud.seed.AccountID, database.DBInstanceID,
+		)),
+		DBInstanceClass:       aws.String(database.InstanceClass),
+		DBInstanceStatus:      aws.String(database.Status),
+		Engine:                aws.String(database.Engine),
+		EngineVersion:         aws.String(database.EngineVersion),
+		AllocatedStorage:      aws.Int32(database.AllocatedStorageGB),
+		BackupRetentionPeriod: aws.Int32(database.BackupRetentionPeriod),
+		MultiAZ:               aws.Bool(database.MultiAZ),
+		StorageEncrypted:      aws.Bool(database.Encrypted),
+		PubliclyAccessible:    aws.Bool(database.PubliclyAccessible),
+		InstanceCreateTime:    aws.Time(database.CreatedAt),
+		AvailabilityZone:      aws.String(database.AvailabilityZone),
+	}
+
+	if database.Endpoint != "" {
+		instance.Endpoint = &types.Endpoint{Address: aws.String(database.Endpoint)}
+	}
+	for _, key := range sortedKeys(database.Tags) {
+		instance.TagList = append(instance.TagList, types.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(database.Tags[key]),
+		})
+	}
+
+	return instance
+}
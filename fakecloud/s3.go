@@ -0,0 +1,414 @@
+package fakecloud
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const allUsersURI = "http://acs.amazonaws.com/groups/global/AllUsers"
+
+// S3 is a fake S3 client. Buckets are global, so it isn't tied to a region.
+type S3 struct {
+	cloud *Cloud
+}
+
+// ListBuckets lists every bucket, sorted by name as S3 does
+func (f *S3) ListBuckets(
+	ctx context.Context,
+	params *s3.ListBucketsInput,
+	optFns ...func(*s3.Options),
+) (*s3.ListBucketsOutput, error) {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	output := &s3.ListBucketsOutput{
+		Owner: &types.Owner{ID: aws.String(f.cloud.seed.AccountID)},
+	}
+	for _, bucket := range f.cloud.seed.Buckets {
+		output.Buckets = append(output.Buckets, types.Bucket{
+			Name:         aws.String(bucket.Name),
+			CreationDate: aws.Time(bucket.CreationDate),
+			BucketRegion: aws.String(f.cloud.regionOf(bucket.Region)),
+		})
+	}
+	sort.Slice(output.Buckets, func(i, j int) bool {
+		return aws.ToString(output.Buckets[i].Name) < aws.ToString(output.Buckets[j].Name)
+	})
+
+	return output, nil
+}
+
+// GetBucketLocation returns the bucket's region; us-east-1 is reported as empty
+func (f *S3) GetBucketLocation(
+	ctx context.Context,
+	params *s3.GetBucketLocationInput,
+	optFns ...func(*s3.Options),
+) (*s3.GetBucketLocationOutput, error) {
+	output := &s3.GetBucketLocationOutput{}
+	err := f.withBucket(params.Bucket, func(bucket *Bucket) error {
+		if region := f.cloud.regionOf(bucket.Region); region != "us-east-1" {
+			output.LocationConstraint = types.BucketLocationConstraint(region)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// GetBucketTagging returns the bucket's tags
+func (f *S3) GetBucketTagging(
+	ctx context.Context,
+	params *s3.GetBucketTaggingInput,
+	optFns ...func(*s3.Options),
+) (*s3.GetBucketTaggingOutput, error) {
+	output := &s3.GetBucketTaggingOutput{}
+	err := f.withBucket(params.Bucket, func(bucket *Bucket) error {
+		if len(bucket.Tags) == 0 {
+			return apiError("NoSuchTagSet", "The TagSet does not exist")
+		}
+		for _, key := range sortedKeys(bucket.Tags) {
+			output.TagSet = append(output.TagSet, types.Tag{
+				Key:   aws.String(key),
+				Value: aws.String(bucket.Tags[key]),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// GetBucketAcl returns the owner's grant plus any public grants
+func (f *S3) GetBucketAcl(
+	ctx context.Context,
+	params *s3.GetBucketAclInput,
+	optFns ...func(*s3.Options),
+) (*s3.GetBucketAclOutput, error) {
+	owner := &types.Owner{ID: aws.String(f.cloud.seed.AccountID)}
+	output := &s3.GetBucketAclOutput{
+		Owner: owner,
+		Grants: []types.Grant{{
+			Grantee:    &types.Grantee{Type: types.TypeCanonicalUser, ID: owner.ID},
+			Permission: types.PermissionFullControl,
+		}},
+	}
+
+	err := f.withBucket(params.Bucket, func(bucket *Bucket) error {
+		if bucket.PublicReadACL {
+			output.Grants = append(output.Grants, publicGrant(types.PermissionRead))
+		}
+		if bucket.PublicWriteACL {
+			output.Grants = append(output.Grants, publicGrant(types.PermissionWrite))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// GetPublicAccessBlock returns the bucket's public access block, if it has one
+func (f *S3) GetPublicAccessBlock(
+	ctx context.Context,
+	params *s3.GetPublicAccessBlockInput,
+	optFns ...func(*s3.Options),
+) (*s3.GetPublicAccessBlockOutput, error) {
+	output := &s3.GetPublicAccessBlockOutput{}
+	err := f.withBucket(params.Bucket, func(bucket *Bucket) error {
+		block := bucket.PublicAccessBlock
+		if block == nil {
+			return apiError("NoSuchPublicAccessBlockConfiguration",
+				"The public access block configuration was not found")
+		}
+		output.PublicAccessBlockConfiguration = &types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(block.BlockPublicACLs),
+			BlockPublicPolicy:     aws.Bool(block.BlockPublicPolicy),
+			IgnorePublicAcls:      aws.Bool(block.IgnorePublicACLs),
+			RestrictPublicBuckets: aws.Bool(block.RestrictPublicBuckets),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// GetBucketVersioning returns the versioning status; empty if never enabled
+func (f *S3) GetBucketVersioning(
+	ctx context.Context,
+	params *s3.GetBucketVersioningInput,
+	optFns ...func(*s3.Options),
+) (*s3.GetBucketVersioningOutput, error) {
+	output := &s3.GetBucketVersioningOutput{}
+	err := f.withBucket(params.Bucket, func(bucket *Bucket) error {
+		output.Status = types.BucketVersioningStatus(bucket.Versioning)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// GetBucketEncryption returns the bucket's default encryption
+func (f *S3) GetBucketEncryption(
+	ctx context.Context,
+	params *s3.GetBucketEncryptionInput,
+	optFns ...func(*s3.Options),
+) (*s3.GetBucketEncryptionOutput, error) {
+	output := &s3.GetBucketEncryptionOutput{}
+	err := f.withBucket(params.Bucket, func(bucket *Bucket) error {
+		if bucket.Encryption == "" {
+			return apiError("ServerSideEncryptionConfigurationNotFoundError",
+				"The server side encryption configuration was not found")
+		}
+		byDefault := &types.ServerSideEncryptionByDefault{
+			SSEAlgorithm: types.ServerSideEncryption(bucket.Encryption),
+		}
+		if bucket.KMSKeyID != "" {
+			byDefault.KMSMasterKeyID = aws.String(bucket.KMSKeyID)
+		}
+		output.ServerSideEncryptionConfiguration = &types.ServerSideEncryptionConfiguration{
+			Rules: []types.ServerSideEncryptionRule{{ApplyServerSideEncryptionByDefault: byDefault}},
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// PutPublicAccessBlock replaces the bucket's public access block
+func (f *S3) PutPublicAccessBlock(
+	ctx context.Context,
+	params *s3.PutPublicAccessBlockInput,
+	optFns ...func(*s3.Options),
+) (*s3.PutPublicAccessBlockOutput, error) {
+	config := params.PublicAccessBlockConfiguration
+	if config == nil {
+		return nil, apiError("MissingRequestBodyError", "Request Body is empty")
+	}
+
+	err := f.updateBucket(params.Bucket, func(bucket *Bucket) error {
+		bucket.PublicAccessBlock = &PublicAccessBlock{
+			BlockPublicACLs:       aws.ToBool(config.BlockPublicAcls),
+			BlockPublicPolicy:     aws.ToBool(config.BlockPublicPolicy),
+			IgnorePublicACLs:      aws.ToBool(config.IgnorePublicAcls),
+			RestrictPublicBuckets: aws.ToBool(config.RestrictPublicBuckets),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutPublicAccessBlockOutput{}, nil
+}
+
+// PutBucketEncryption sets the bucket's default encryption from the first rule
+func (f *S3) PutBucketEncryption(
+	ctx context.Context,
+	params *s3.PutBucketEncryptionInput,
+	optFns ...func(*s3.Options),
+) (*s3.PutBucketEncryptionOutput, error) {
+	config := params.ServerSideEncryptionConfiguration
+	if config == nil || len(config.Rules) == 0 || config.Rules[0].ApplyServerSideEncryptionByDefault == nil {
+		return nil, apiError("MalformedXML", "The XML you provided was not well-formed")
+	}
+	byDefault := config.Rules[0].ApplyServerSideEncryptionByDefault
+
+	err := f.updateBucket(params.Bucket, func(bucket *Bucket) error {
+		bucket.Encryption = string(byDefault.SSEAlgorithm)
+		bucket.KMSKeyID = aws.ToString(byDefault.KMSMasterKeyID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutBucketEncryptionOutput{}, nil
+}
+
+// PutBucketVersioning sets the versioning status
+func (f *S3) PutBucketVersioning(
+	ctx context.Context,
+	params *s3.PutBucketVersioningInput,
+	optFns ...func(*s3.Options),
+) (*s3.PutBucketVersioningOutput, error) {
+	if params.VersioningConfiguration == nil {
+		return nil, apiError("MalformedXML", "The XML you provided was not well-formed")
+	}
+
+	err := f.updateBucket(params.Bucket, func(bucket *Bucket) error {
+		bucket.Versioning = string(params.VersioningConfiguration.Status)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutBucketVersioningOutput{}, nil
+}
+
+// PutBucketTagging replaces the bucket's whole tag set, as S3 does
+func (f *S3) PutBucketTagging(
+	ctx context.Context,
+	params *s3.PutBucketTaggingInput,
+	optFns ...func(*s3.Options),
+) (*s3.PutBucketTaggingOutput, error) {
+	if params.Tagging == nil {
+		return nil, apiError("MalformedXML", "The XML you provided was not well-formed")
+	}
+
+	err := f.updateBucket(params.Bucket, func(bucket *Bucket) error {
+		bucket.Tags = make(map[string]string, len(params.Tagging.TagSet))
+		for _, tag := range params.Tagging.TagSet {
+			bucket.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutBucketTaggingOutput{}, nil
+}
+
+// DeleteBucketTagging removes a bucket's tag set
+func (f *S3) DeleteBucketTagging(
+	ctx context.Context,
+	params *s3.DeleteBucketTaggingInput,
+	optFns ...func(*s3.Options),
+) (*s3.DeleteBucketTaggingOutput, error) {
+	err := f.updateBucket(params.Bucket, func(bucket *Bucket) error {
+		bucket.Tags = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.DeleteBucketTaggingOutput{}, nil
+}
+
+// DeleteBucket removes an empty bucket
+func (f *S3) DeleteBucket(
+	ctx context.Context,
+	params *s3.DeleteBucketInput,
+	optFns ...func(*s3.Options),
+) (*s3.DeleteBucketOutput, error) {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	name := aws.ToString(params.Bucket)
+	for i, bucket := range f.cloud.seed.Buckets {
+		if bucket.Name != name {
+			continue
+		}
+		if len(bucket.Objects) > 0 {
+			return nil, apiError("BucketNotEmpty", "The bucket you tried to delete is not empty")
+		}
+		f.cloud.seed.Buckets = append(f.cloud.seed.Buckets[:i], f.cloud.seed.Buckets[i+1:]...)
+		if err := f.cloud.save(); err != nil {
+			return nil, err
+		}
+		return &s3.DeleteBucketOutput{}, nil
+	}
+
+	return nil, noSuchBucket(name)
+}
+
+// ListObjectsV2 lists the bucket's objects in a single page
+func (f *S3) ListObjectsV2(
+	ctx context.Context,
+	params *s3.ListObjectsV2Input,
+	optFns ...func(*s3.Options),
+) (*s3.ListObjectsV2Output, error) {
+	output := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}
+	err := f.withBucket(params.Bucket, func(bucket *Bucket) error {
+		for _, object := range bucket.Objects {
+			output.Contents = append(output.Contents, types.Object{
+				Key:          aws.String(object.Key),
+				Size:         aws.Int64(object.Size),
+				LastModified: aws.Time(object.LastModified),
+			})
+		}
+		output.KeyCount = aws.Int32(int32(len(output.Contents)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// DeleteObjects removes objects by key; missing keys are not an error
+func (f *S3) DeleteObjects(
+	ctx context.Context,
+	params *s3.DeleteObjectsInput,
+	optFns ...func(*s3.Options),
+) (*s3.DeleteObjectsOutput, error) {
+	if params.Delete == nil {
+		return nil, apiError("MalformedXML", "The XML you provided was not well-formed")
+	}
+
+	deleted := make(map[string]bool)
+	for _, object := range params.Delete.Objects {
+		deleted[aws.ToString(object.Key)] = true
+	}
+
+	err := f.updateBucket(params.Bucket, func(bucket *Bucket) error {
+		var kept []Object
+		for _, object := range bucket.Objects {
+			if !deleted[object.Key] {
+				kept = append(kept, object)
+			}
+		}
+		bucket.Objects = kept
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.DeleteObjectsOutput{}, nil
+}
+
+// withBucket runs fn against a bucket under the lock
+func (f *S3) withBucket(name *string, fn func(*Bucket) error) error {
+	f.cloud.mu.Lock()
+	defer f.cloud.mu.Unlock()
+
+	for i := range f.cloud.seed.Buckets {
+		if bucket := &f.cloud.seed.Buckets[i]; bucket.Name == aws.ToString(name) {
+			return fn(bucket)
+		}
+	}
+	return noSuchBucket(aws.ToString(name))
+}
+
+// updateBucket changes a bucket and saves the result
+func (f *S3) updateBucket(name *string, fn func(*Bucket) error) error {
+	return f.withBucket(name, func(bucket *Bucket) error {
+		if err := fn(bucket); err != nil {
+			return err
+		}
+		return f.cloud.save()
+	})
+}
+
+func noSuchBucket(name string) error {
+	return apiError("NoSuchBucket", "The specified bucket '%s' does not exist", name)
+}
+
+func publicGrant(permission types.Permission) types.Grant {
+	return types.Grant{
+		Grantee:    &types.Grantee{Type: types.TypeGroup, URI: aws.String(allUsersURI)},
+		Permission: permission,
+	}
+}
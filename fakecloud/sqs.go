@@ -0,0 +1,119 @@
+package fakecloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// SQS is a fake SQS client. Queues are shared by every region.
+//
+// Received messages aren't hidden: a message comes back on every receive
+// until it is deleted, as if its visibility timeout were zero.
+type SQS struct {
+	cloud *Cloud
+}
+
+// ReceiveMessage returns the oldest messages on a queue without waiting.
+// The receipt handle is the message ID.
+func (q *SQS) ReceiveMessage(
+	ctx context.Context,
+	params *sqs.ReceiveMessageInput,
+	optFns ...func(*sqs.Options),
+) (*sqs.ReceiveMessageOutput, error) {
+	q.cloud.mu.Lock()
+	defer q.cloud.mu.Unlock()
+
+	queue, err := q.findQueue(aws.ToString(params.QueueUrl))
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(params.MaxNumberOfMessages)
+	if limit <= 0 {
+		limit = 1
+	}
+
+	output := &sqs.ReceiveMessageOutput{}
+	generated := false
+	for i := range queue.Messages {
+		if len(output.Messages) == limit {
+			break
+		}
+		message := &queue.Messages[i]
+		if message.MessageID == "" {
+			message.MessageID = fmt.Sprintf("%s-%d", queue.Name, i+1)
+			generated = true
+		}
+		output.Messages = append(output.Messages, types.Message{
+			MessageId:     aws.String(message.MessageID),
+			ReceiptHandle: aws.String(message.MessageID),
+			Body:          aws.String(message.Body),
+		})
+	}
+
+	if generated {
+		if err := q.cloud.save(); err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+// DeleteMessageBatch removes messages by receipt handle
+func (q *SQS) DeleteMessageBatch(
+	ctx context.Context,
+	params *sqs.DeleteMessageBatchInput,
+	optFns ...func(*sqs.Options),
+) (*sqs.DeleteMessageBatchOutput, error) {
+	q.cloud.mu.Lock()
+	defer q.cloud.mu.Unlock()
+
+	queue, err := q.findQueue(aws.ToString(params.QueueUrl))
+	if err != nil {
+		return nil, err
+	}
+
+	output := &sqs.DeleteMessageBatchOutput{}
+	for _, entry := range params.Entries {
+		handle := aws.ToString(entry.ReceiptHandle)
+		found := false
+		for i, message := range queue.Messages {
+			if message.MessageID == handle {
+				queue.Messages = append(queue.Messages[:i], queue.Messages[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			output.Failed = append(output.Failed, types.BatchResultErrorEntry{
+				Id:          entry.Id,
+				Code:        aws.String("ReceiptHandleIsInvalid"),
+				Message:     aws.String(fmt.Sprintf("no message with receipt handle %s", handle)),
+				SenderFault: true,
+			})
+			continue
+		}
+		output.Successful = append(output.Successful, types.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+
+	if err := q.cloud.save(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// findQueue looks a queue up by URL or name; callers hold the lock
+func (q *SQS) findQueue(queueURL string) (*Queue, error) {
+	name := queueURL[strings.LastIndex(queueURL, "/")+1:]
+	for i := range q.cloud.seed.Queues {
+		if q.cloud.seed.Queues[i].Name == name {
+			return &q.cloud.seed.Queues[i], nil
+		}
+	}
+	return nil, apiError("AWS.SimpleQueueService.NonExistentQueue", "The specified queue %s does not exist.", queueURL)
+}
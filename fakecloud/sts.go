@@ -0,0 +1,34 @@
+package fakecloud
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// fakeUser is the identity every fake cloud caller has
+const fakeUser = "fakecloud"
+
+// STS is a fake STS client
+type STS struct {
+	cloud *Cloud
+}
+
+// GetCallerIdentity returns a fixed user in the seed's account
+func (s *STS) GetCallerIdentity(
+	ctx context.Context,
+	params *sts.GetCallerIdentityInput,
+	optFns ...func(*sts.Options),
+) (*sts.GetCallerIdentityOutput, error) {
+	s.cloud.mu.Lock()
+	defer s.cloud.mu.Unlock()
+
+	account := s.cloud.seed.AccountID
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String(account),
+		Arn:     aws.String(fmt.Sprintf("arn:aws:iam::%s:user/%s", account, fakeUser)),
+		UserId:  aws.String("AIDAFAKECLOUD" + account),
+	}, nil
+}
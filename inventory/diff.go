@@ -0,0 +1,331 @@
+package inventory
+
+import (
+	"context"
+	"custodian-killer/scanner"
+	"custodian-killer/storage"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// volatileFields change on their own as time passes, so they aren't drift
+var volatileFields = map[string]bool{
+	"cpu_utilization":        true,
+	"running_days":           true,
+	"age_days":               true,
+	"days_since_update":      true,
+	"estimated_monthly_cost": true,
+	"security_score":         true, // derived from the flags we do compare
+}
+
+// volatileByType are fields that are estimates for one resource type only
+var volatileByType = map[string]map[string]bool{
+	"s3": {"size": true}, // estimated from the bucket's age
+}
+
+// SnapshotInfo identifies one side of a diff
+type SnapshotInfo struct {
+	Path        string    `json:"path,omitempty"`
+	CollectedAt time.Time `json:"collected_at"`
+	Region      string    `json:"region"`
+}
+
+// ResourceRef identifies a resource in a diff
+type ResourceRef struct {
+	ResourceType string `json:"resource_type"`
+	ID           string `json:"id"`
+	Name         string `json:"name,omitempty"`
+	Region       string `json:"region,omitempty"`
+}
+
+// FieldChange is one field that differs between the snapshots
+type FieldChange struct {
+	Field  string      `json:"field"` // state, tags.<key>, or a property name
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ResourceChange is a resource present in both snapshots that changed
+type ResourceChange struct {
+	ResourceRef
+	Changes        []FieldChange `json:"changes"`
+	NewIssues      []string      `json:"new_issues,omitempty"`
+	ResolvedIssues []string      `json:"resolved_issues,omitempty"`
+}
+
+// AddedResource is a resource only in the newer snapshot
+type AddedResource struct {
+	ResourceRef
+	Issues []string `json:"issues,omitempty"`
+}
+
+// ViolationChange is a resource that started or stopped matching a policy
+type ViolationChange struct {
+	Policy   string      `json:"policy"`
+	Resource ResourceRef `json:"resource"`
+	Status   string      `json:"status"` // new, resolved
+	Added    bool        `json:"added"`  // the resource itself is new
+}
+
+// Diff is what changed between two snapshots
+type Diff struct {
+	From       SnapshotInfo      `json:"from"`
+	To         SnapshotInfo      `json:"to"`
+	Added      []AddedResource   `json:"added"`
+	Removed    []ResourceRef     `json:"removed"`
+	Changed    []ResourceChange  `json:"changed"`
+	Violations []ViolationChange `json:"violations"`
+	Skipped    []string          `json:"skipped,omitempty"` // types only one snapshot collected
+	Errors     []string          `json:"errors,omitempty"`  // policies that couldn't be compared
+}
+
+// HasChanges reports whether anything was added, removed or changed
+func (d *Diff) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
+
+// NewViolations returns the violations introduced since the older snapshot
+func (d *Diff) NewViolations() []ViolationChange {
+	var introduced []ViolationChange
+	for _, violation := range d.Violations {
+		if violation.Status == "new" {
+			introduced = append(introduced, violation)
+		}
+	}
+	return introduced
+}
+
+// Compare reports the resources added, removed and changed between two
+// snapshots, for the types both of them collected
+func Compare(from, to *Snapshot) *Diff {
+	diff := &Diff{
+		From:       SnapshotInfo{CollectedAt: from.CollectedAt, Region: from.Region},
+		To:         SnapshotInfo{CollectedAt: to.CollectedAt, Region: to.Region},
+		Added:      []AddedResource{},
+		Removed:    []ResourceRef{},
+		Changed:    []ResourceChange{},
+		Violations: []ViolationChange{},
+	}
+
+	for _, resourceType := range commonTypes(from, to, diff) {
+		before := indexResources(from.Resources[resourceType])
+		after := indexResources(to.Resources[resourceType])
+
+		for _, key := range sortedResourceKeys(after) {
+			resource := after[key]
+			old, existed := before[key]
+			if !existed {
+				diff.Added = append(diff.Added, AddedResource{
+					ResourceRef: refOf(resourceType, resource),
+					Issues:      resource.Compliance.Issues,
+				})
+				continue
+			}
+
+			if change := compareResource(resourceType, old, resource); change != nil {
+				diff.Changed = append(diff.Changed, *change)
+			}
+		}
+
+		for _, key := range sortedResourceKeys(before) {
+			if _, exists := after[key]; !exists {
+				diff.Removed = append(diff.Removed, refOf(resourceType, before[key]))
+			}
+		}
+	}
+
+	return diff
+}
+
+// CompareViolations scans both snapshots with each policy and records which
+// resources started or stopped matching
+func CompareViolations(diff *Diff, policies []storage.StoredPolicy, from, to *Snapshot) {
+	for i := range policies {
+		policy := &policies[i]
+		_, inFrom := from.Resources[policy.ResourceType]
+		_, inTo := to.Resources[policy.ResourceType]
+		if !inFrom || !inTo {
+			continue
+		}
+
+		before, err := matchedBy(policy, from)
+		if err != nil {
+			diff.Errors = append(diff.Errors, fmt.Sprintf("%s: %v", policy.Name, err))
+			continue
+		}
+		after, err := matchedBy(policy, to)
+		if err != nil {
+			diff.Errors = append(diff.Errors, fmt.Sprintf("%s: %v", policy.Name, err))
+			continue
+		}
+
+		existedBefore := indexResources(from.Resources[policy.ResourceType])
+		for _, key := range sortedResourceKeys(after) {
+			if _, matched := before[key]; !matched {
+				_, existed := existedBefore[key]
+				diff.Violations = append(diff.Violations, ViolationChange{
+					Policy:   policy.Name,
+					Resource: refOf(policy.ResourceType, after[key]),
+					Status:   "new",
+					Added:    !existed,
+				})
+			}
+		}
+		for _, key := range sortedResourceKeys(before) {
+			if _, matched := after[key]; !matched {
+				diff.Violations = append(diff.Violations, ViolationChange{
+					Policy:   policy.Name,
+					Resource: refOf(policy.ResourceType, before[key]),
+					Status:   "resolved",
+				})
+			}
+		}
+	}
+}
+
+// matchedBy returns the resources a policy matches in a snapshot
+func matchedBy(policy *storage.StoredPolicy, snapshot *Snapshot) (map[string]scanner.MatchedResource, error) {
+	resources := snapshot.Resources[policy.ResourceType]
+	policyScanner := scanner.NewPolicyScanner(nil, snapshot, scanner.ScannerConfig{
+		Quiet:        true,
+		MaxResources: len(resources) + 1,
+	})
+
+	result, err := policyScanner.ScanStoredPolicy(context.Background(), policy)
+	if err != nil {
+		return nil, err
+	}
+	return indexResources(result.MatchedResources), nil
+}
+
+// compareResource lists the fields that differ, or returns nil if none do
+func compareResource(resourceType string, before, after scanner.MatchedResource) *ResourceChange {
+	var changes []FieldChange
+
+	if before.State != after.State {
+		changes = append(changes, FieldChange{Field: "state", Before: before.State, After: after.State})
+	}
+
+	for _, key := range unionKeys(before.Tags, after.Tags) {
+		old, hadTag := before.Tags[key]
+		current, hasTag := after.Tags[key]
+		if hadTag == hasTag && old == current {
+			continue
+		}
+		change := FieldChange{Field: "tags." + key}
+		if hadTag {
+			change.Before = old
+		}
+		if hasTag {
+			change.After = current
+		}
+		changes = append(changes, change)
+	}
+
+	for _, key := range unionKeys(before.Properties, after.Properties) {
+		if volatileFields[key] || volatileByType[resourceType][key] {
+			continue
+		}
+		if !sameValue(before.Properties[key], after.Properties[key]) {
+			changes = append(changes, FieldChange{
+				Field:  key,
+				Before: before.Properties[key],
+				After:  after.Properties[key],
+			})
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return &ResourceChange{
+		ResourceRef:    refOf(resourceType, after),
+		Changes:        changes,
+		NewIssues:      missingFrom(after.Compliance.Issues, before.Compliance.Issues),
+		ResolvedIssues: missingFrom(before.Compliance.Issues, after.Compliance.Issues),
+	}
+}
+
+// commonTypes returns the types both snapshots collected, noting the rest
+func commonTypes(from, to *Snapshot, diff *Diff) []string {
+	var types []string
+	for resourceType := range from.Resources {
+		if _, collected := to.Resources[resourceType]; collected {
+			types = append(types, resourceType)
+		} else {
+			diff.Skipped = append(diff.Skipped, resourceType)
+		}
+	}
+	for resourceType := range to.Resources {
+		if _, collected := from.Resources[resourceType]; !collected {
+			diff.Skipped = append(diff.Skipped, resourceType)
+		}
+	}
+	sort.Strings(types)
+	sort.Strings(diff.Skipped)
+	return types
+}
+
+// indexResources keys resources by region and ID
+func indexResources(resources []scanner.MatchedResource) map[string]scanner.MatchedResource {
+	index := make(map[string]scanner.MatchedResource, len(resources))
+	for _, resource := range resources {
+		index[resource.Region+"/"+resource.ID] = resource
+	}
+	return index
+}
+
+func sortedResourceKeys(index map[string]scanner.MatchedResource) []string {
+	keys := make([]string, 0, len(index))
+	for key := range index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func refOf(resourceType string, resource scanner.MatchedResource) ResourceRef {
+	return ResourceRef{ResourceType: resourceType, ID: resource.ID, Name: resource.Name, Region: resource.Region}
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool)
+	for key := range a {
+		seen[key] = true
+	}
+	for key := range b {
+		seen[key] = true
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sameValue compares values by their JSON form, so a freshly collected int
+// equals the float64 it becomes once a snapshot is saved and loaded
+func sameValue(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+// missingFrom returns the items of list that aren't in other
+func missingFrom(list, other []string) []string {
+	present := make(map[string]bool, len(other))
+	for _, item := range other {
+		present[item] = true
+	}
+	var missing []string
+	for _, item := range list {
+		if !present[item] {
+			missing = append(missing, item)
+		}
+	}
+	return missing
+}
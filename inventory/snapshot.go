@@ -0,0 +1,152 @@
+// Package inventory collects point-in-time snapshots of an account's resources,
+// so policies can be scanned against them without calling AWS.
+package inventory
+
+import (
+	"context"
+	"custodian-killer/scanner"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotTimeLayout names snapshot files so they sort by collection time
+const snapshotTimeLayout = "20060102-150405"
+
+// Snapshot is every resource of the collected types at one point in time
+type Snapshot struct {
+	CollectedAt time.Time                            `json:"collected_at"`
+	Region      string                               `json:"region"`
+	Profile     string                               `json:"profile,omitempty"`
+	Types       []string                             `json:"types"`
+	Resources   map[string][]scanner.MatchedResource `json:"resources"`
+	Errors      map[string]string                    `json:"errors,omitempty"` // types that failed to collect
+}
+
+// DefaultDir is where snapshots are kept, ~/.custodian-killer/inventory
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	return filepath.Join(homeDir, ".custodian-killer", "inventory"), nil
+}
+
+// Collect lists every resource of the given types. A type that fails is
+// recorded in Errors and the rest are still collected.
+func Collect(ctx context.Context, provider scanner.ResourceProvider, resourceTypes []string, region string) *Snapshot {
+	snapshot := &Snapshot{
+		CollectedAt: time.Now().UTC(),
+		Region:      region,
+		Resources:   make(map[string][]scanner.MatchedResource),
+		Errors:      make(map[string]string),
+	}
+
+	for _, resourceType := range resourceTypes {
+		resources, err := provider.ListResources(ctx, resourceType)
+		if err != nil {
+			snapshot.Errors[resourceType] = err.Error()
+			continue
+		}
+		if resources == nil {
+			resources = []scanner.MatchedResource{}
+		}
+		snapshot.Types = append(snapshot.Types, resourceType)
+		snapshot.Resources[resourceType] = resources
+	}
+
+	return snapshot
+}
+
+// ListResources serves the snapshot's resources, so a Snapshot can stand in
+// for AWS as the scanner's provider
+func (s *Snapshot) ListResources(ctx context.Context, resourceType string) ([]scanner.MatchedResource, error) {
+	resources, collected := s.Resources[resourceType]
+	if !collected {
+		if reason, failed := s.Errors[resourceType]; failed {
+			return nil, fmt.Errorf("%s failed to collect in this snapshot: %s", resourceType, reason)
+		}
+		return nil, fmt.Errorf("snapshot has no %s resources (collected: %s)", resourceType, strings.Join(s.Types, ", "))
+	}
+	return append([]scanner.MatchedResource(nil), resources...), nil
+}
+
+// Count returns the number of resources across all types
+func (s *Snapshot) Count() int {
+	count := 0
+	for _, resources := range s.Resources {
+		count += len(resources)
+	}
+	return count
+}
+
+// Save writes the snapshot to a timestamped file in dir and returns its path
+func (s *Snapshot) Save(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create inventory directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+
+	path := filepath.Join(dir, "snapshot-"+s.CollectedAt.Format(snapshotTimeLayout)+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	return path, nil
+}
+
+// Load reads a snapshot file
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %v", path, err)
+	}
+	if snapshot.Resources == nil {
+		return nil, fmt.Errorf("%s is not an inventory snapshot", path)
+	}
+	return &snapshot, nil
+}
+
+// List returns the snapshot files in dir, oldest first
+func List(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "snapshot-*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %v", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Resolve turns "latest" into the newest snapshot in dir, and a bare file
+// name from 'inventory list' into its path in dir; other paths pass through
+func Resolve(path, dir string) (string, error) {
+	if path != "latest" {
+		if _, err := os.Stat(path); os.IsNotExist(err) && filepath.Base(path) == path {
+			if _, err := os.Stat(filepath.Join(dir, path)); err == nil {
+				return filepath.Join(dir, path), nil
+			}
+		}
+		return path, nil
+	}
+
+	files, err := List(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no snapshots in %s; run 'inventory collect' first", dir)
+	}
+	return files[len(files)-1], nil
+}
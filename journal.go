@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/rand"
+	"custodian-killer/aws"
+	"custodian-killer/storage"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunRecord is one policy execution as kept in the run journal
+type RunRecord struct {
+	ID            string           `json:"id"`
+	PolicyName    string           `json:"policy_name"`
+	PolicyVersion int              `json:"policy_version"`
+	Region        string           `json:"region"`
+	Profile       string           `json:"profile,omitempty"`
+	Caller        *aws.CallerInfo  `json:"caller,omitempty"`
+	CallerError   string           `json:"caller_error,omitempty"` // why the caller is unknown
+	RollbackOf    string           `json:"rollback_of,omitempty"`  // the run this one undid
+	Result        *ExecutionResult `json:"result"`
+}
+
+// RunJournal keeps every execution as a JSON file, one per run
+type RunJournal struct {
+	dir string
+}
+
+// NewRunJournal opens the journal in dir, creating it if needed
+func NewRunJournal(dir string) (*RunJournal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create runs directory: %v", err)
+	}
+	return &RunJournal{dir: dir}, nil
+}
+
+// openRunJournal opens the journal kept alongside the policies
+func openRunJournal(policyStorage storage.PolicyStorage) (*RunJournal, error) {
+	fileStorage, ok := policyStorage.(*storage.FileStorage)
+	if !ok {
+		return nil, fmt.Errorf("run journal needs file storage")
+	}
+	return NewRunJournal(filepath.Join(fileStorage.BaseDir(), "runs"))
+}
+
+// newRunID returns an ID that sorts by start time, e.g. 20250102-150405-a1b2c3
+func newRunID(start time.Time) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return start.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// Save writes a run to the journal
+func (j *RunJournal) Save(record *RunRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run: %v", err)
+	}
+
+	path := filepath.Join(j.dir, record.ID+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run: %v", err)
+	}
+	return nil
+}
+
+// Get reads a run by ID, or by a prefix that matches exactly one run
+func (j *RunJournal) Get(id string) (*RunRecord, error) {
+	if record, err := j.load(filepath.Join(j.dir, id+".json")); err == nil {
+		return record, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(j.dir, id+"*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %v", err)
+	}
+	switch len(files) {
+	case 0:
+		return nil, fmt.Errorf("run '%s' not found", id)
+	case 1:
+		return j.load(files[0])
+	default:
+		return nil, fmt.Errorf("run '%s' is ambiguous: %d runs match", id, len(files))
+	}
+}
+
+// List returns every run, newest first
+func (j *RunJournal) List() ([]RunRecord, error) {
+	files, err := filepath.Glob(filepath.Join(j.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %v", err)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+
+	var records []RunRecord
+	for _, file := range files {
+		record, err := j.load(file)
+		if err != nil {
+			fmt.Printf("⚠️  Skipping %s: %v\n", filepath.Base(file), err)
+			continue
+		}
+		records = append(records, *record)
+	}
+	return records, nil
+}
+
+func (j *RunJournal) load(path string) (*RunRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run: %v", err)
+	}
+
+	var record RunRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse run %s: %v", filepath.Base(path), err)
+	}
+	if record.Result == nil {
+		return nil, fmt.Errorf("%s is not a run record", filepath.Base(path))
+	}
+	return &record, nil
+}
+
+// filterRuns keeps the runs of one policy (when set) started at or after since
+func filterRuns(records []RunRecord, policyName string, since time.Time) []RunRecord {
+	kept := []RunRecord{}
+	for _, record := range records {
+		if policyName != "" && record.PolicyName != policyName {
+			continue
+		}
+		if record.Result.StartTime.Before(since) {
+			continue
+		}
+		kept = append(kept, record)
+	}
+	return kept
+}
+
+// writeRunsCSV writes one row per action, or one row for a run without
+// actions, so every run appears in the export
+func writeRunsCSV(w io.Writer, records []RunRecord) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{
+		"Run ID", "Policy", "Policy Version", "Account", "Caller ARN", "Region",
+		"Start Time", "Dry Run", "Run Success", "Stopped", "Approvers", "Action", "Resource ID",
+		"Resource Type", "Action Success", "Message", "Errors",
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %v", err)
+	}
+
+	for _, record := range records {
+		account, arn := "", ""
+		if record.Caller != nil {
+			account, arn = record.Caller.Account, record.Caller.Arn
+		}
+		run := []string{
+			record.ID,
+			record.PolicyName,
+			strconv.Itoa(record.PolicyVersion),
+			account,
+			arn,
+			record.Region,
+			record.Result.StartTime.UTC().Format(time.RFC3339),
+			strconv.FormatBool(record.Result.DryRun),
+			strconv.FormatBool(record.Result.Success),
+			record.Result.Stopped,
+			strings.Join(record.Result.Approvers, ";"),
+		}
+		errors := strings.Join(record.Result.Errors, "; ")
+
+		if len(record.Result.ActionResults) == 0 {
+			if err := writer.Write(append(run, "", "", "", "", "", errors)); err != nil {
+				return fmt.Errorf("failed to write CSV row: %v", err)
+			}
+			continue
+		}
+
+		for _, action := range record.Result.ActionResults {
+			row := append(append([]string(nil), run...),
+				action.Action,
+				action.ResourceID,
+				action.ResourceType,
+				strconv.FormatBool(action.Success),
+				action.Message,
+				errors,
+			)
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("failed to write CSV row: %v", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
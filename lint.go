@@ -0,0 +1,183 @@
+package main
+
+import (
+	"custodian-killer/storage"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lint severities, lowest first
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+var severityRank = map[string]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// destructiveActions are the actions that stop or remove resources
+var destructiveActions = []string{"terminate", "delete", "stop"}
+
+// placeholderPattern matches template variables such as {{.retention_days}}
+var placeholderPattern = regexp.MustCompile(`\{\{\s*\.?[A-Za-z0-9_.]*\s*\}\}`)
+
+// LintFinding is one best-practice problem found in a policy
+type LintFinding struct {
+	Policy   string `json:"policy"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"` // info, warning, error
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+// LintPolicy checks a policy for risky patterns on top of schema validation
+func LintPolicy(policy *storage.StoredPolicy) []LintFinding {
+	var findings []LintFinding
+	add := func(rule, severity, field, format string, args ...interface{}) {
+		findings = append(findings, LintFinding{
+			Policy:   policy.Name,
+			Rule:     rule,
+			Severity: severity,
+			Field:    field,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	snapshotTaken := false
+	for i, action := range policy.Actions {
+		field := fmt.Sprintf("actions[%d]", i)
+
+		if isDestructive(action.Type) && len(policy.Filters) == 0 {
+			add("destructive-without-filters", SeverityError, field+".type",
+				"'%s' runs against every %s resource because the policy has no filters",
+				action.Type, policy.ResourceType)
+		}
+
+		switch action.Type {
+		case "create-snapshot":
+			snapshotTaken = true
+		case "terminate":
+			if !snapshotTaken {
+				add("terminate-without-snapshot", SeverityWarning, field+".type",
+					"terminate is not preceded by a create-snapshot action")
+			}
+		}
+
+		if policy.Status == "draft" && !action.DryRun {
+			add("live-action-in-draft", SeverityWarning, field+".dry_run",
+				"draft policy has '%s' with dry_run disabled", action.Type)
+		}
+	}
+
+	lintFilters(policy.Filters, "filters", add)
+
+	// Template variables left behind by a partial substitution
+	checkPlaceholders("name", policy.Name, add)
+	checkPlaceholders("description", policy.Description, add)
+	lintFilterPlaceholders(policy.Filters, "filters", add)
+	for i, action := range policy.Actions {
+		checkPlaceholders(fmt.Sprintf("actions[%d].settings", i), action.Settings, add)
+	}
+	checkPlaceholders("mode.schedule", policy.Mode.Schedule, add)
+
+	// Schema errors are reported too, unless a lint rule already covers the field
+	reported := make(map[string]bool)
+	for _, finding := range findings {
+		reported[finding.Field] = true
+	}
+	for _, err := range ValidateStoredPolicy(policy) {
+		if !reported[err.Field] {
+			add("invalid", SeverityError, err.Field, "%s", err.Message)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank[findings[i].Severity] > severityRank[findings[j].Severity]
+	})
+
+	return findings
+}
+
+// lintFilters flags filters that can never do what their author intended
+func lintFilters(
+	filters []storage.StoredFilter,
+	path string,
+	add func(rule, severity, field, format string, args ...interface{}),
+) {
+	for i, filter := range filters {
+		field := fmt.Sprintf("%s[%d]", path, i)
+
+		if filter.Type == "tag-missing" && strings.TrimSpace(filter.Key) == "" {
+			add("tag-missing-empty-key", SeverityError, field+".key",
+				"tag-missing filter has no tag key, so it matches nothing")
+		}
+
+		lintFilters(filter.Filters, field+".filters", add)
+	}
+}
+
+// lintFilterPlaceholders checks filter keys and values for template variables
+func lintFilterPlaceholders(
+	filters []storage.StoredFilter,
+	path string,
+	add func(rule, severity, field, format string, args ...interface{}),
+) {
+	for i, filter := range filters {
+		field := fmt.Sprintf("%s[%d]", path, i)
+		checkPlaceholders(field+".key", filter.Key, add)
+		checkPlaceholders(field+".value", filter.Value, add)
+		lintFilterPlaceholders(filter.Filters, field+".filters", add)
+	}
+}
+
+// checkPlaceholders reports unsubstituted template variables anywhere inside a value
+func checkPlaceholders(
+	field string,
+	value interface{},
+	add func(rule, severity, field, format string, args ...interface{}),
+) {
+	switch v := value.(type) {
+	case string:
+		if match := placeholderPattern.FindString(v); match != "" {
+			add("unsubstituted-placeholder", SeverityError, field,
+				"template placeholder %s was never substituted", match)
+		}
+	case []interface{}:
+		for i, item := range v {
+			checkPlaceholders(fmt.Sprintf("%s[%d]", field, i), item, add)
+		}
+	case []string:
+		for i, item := range v {
+			checkPlaceholders(fmt.Sprintf("%s[%d]", field, i), item, add)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			checkPlaceholders(field+"."+key, v[key], add)
+		}
+	}
+}
+
+// isDestructive reports whether an action stops or removes resources
+func isDestructive(actionType string) bool {
+	return containsString(destructiveActions, actionType)
+}
+
+// severityAtLeast reports whether severity meets the threshold; "none" never does
+func severityAtLeast(severity, threshold string) bool {
+	minimum, exists := severityRank[threshold]
+	if !exists {
+		return false
+	}
+	return severityRank[severity] >= minimum
+}
@@ -1,14 +1,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
 
 var version = "1.0.0"
 
+// Global flags used when the AWS client is created
+var (
+	recordDir   string
+	replayDir   string
+	maxAttempts int
+	apiRates    []string
+
+	accountsFileFlag string
+)
+
 func main() {
 	rootCmd := &cobra.Command{
 		Use:   "custodian-killer",
@@ -22,16 +35,28 @@ Run without arguments to enter interactive mode, or use subcommands for direct e
 		},
 	}
 
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "Record AWS API requests and responses to this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "Serve AWS API responses from a recording instead of AWS")
+	rootCmd.PersistentFlags().IntVar(&maxAttempts, "max-attempts", 8, "Tries per AWS call before a throttled or failing call gives up")
+	rootCmd.PersistentFlags().StringSliceVar(&apiRates, "rate", nil, "AWS calls per second for a service, e.g. s3=20 (repeatable, 0 for no limit)")
+	rootCmd.PersistentFlags().StringVar(&accountsFileFlag, "accounts-file", "", "Accounts file for --accounts (default: accounts.yaml in the storage directory)")
+
 	// Add subcommands
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(policyCmd)
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(executeCmd)
 	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(inventoryCmd)
+	rootCmd.AddCommand(runsCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(approveCmd)
+	rootCmd.AddCommand(approversCmd)
+	rootCmd.AddCommand(daemonCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(interactiveCmd)
 
-	if err := rootCmd.Execute(); err != nil {
+	if err := rootCmd.ExecuteContext(interruptContext()); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -54,7 +79,27 @@ var interactiveCmd = &cobra.Command{
 	},
 }
 
+// interruptContext returns a context cancelled by the first SIGINT or SIGTERM,
+// so commands can stop cleanly and journal what they finished. A second
+// signal kills the process as usual.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		signal.Stop(signals)
+		fmt.Printf("\n🛑 Received %s: stopping after in-flight requests (send it again to quit now)\n", sig)
+		cancel()
+	}()
+	return ctx
+}
+
 func startInteractiveMode() {
+	// Ctrl-C quits the shell outright, as it always has
+	signal.Reset(os.Interrupt, syscall.SIGTERM)
+
 	// Show the epic ASCII logo first!
 	fmt.Print(`
  ██████╗██╗   ██╗███████╗████████╗ ██████╗ ██████╗ ██╗ █████╗ ███╗   ██╗    
@@ -99,7 +144,7 @@ func startInteractiveMode() {
 		case "scan":
 			runScan()
 		case "execute":
-			executePolicy()
+			executePolicy(context.Background())
 		case "report":
 			generateReport()
 		case "config":
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"custodian-killer/scanner"
+	"custodian-killer/storage"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// planFormatVersion is bumped when ExecutionPlan changes incompatibly
+const planFormatVersion = 1
+
+// ExecutionPlan is a reviewed scan saved by 'scan --out'. 'execute --plan'
+// applies the policy's actions to exactly its resources.
+type ExecutionPlan struct {
+	FormatVersion int                 `json:"format_version"`
+	CreatedAt     time.Time           `json:"created_at"`
+	Region        string              `json:"region"`
+	Account       string              `json:"account,omitempty"`
+	PolicyName    string              `json:"policy_name"`
+	PolicyVersion int                 `json:"policy_version"`
+	PolicyHash    string              `json:"policy_hash"`
+	Scan          *scanner.ScanResult `json:"scan"`
+	Approvals     []PlanApproval      `json:"approvals,omitempty"`
+}
+
+// PolicyHash fingerprints the parts of a policy that decide what it matches
+// and does. Run statistics and the version, which every run bumps, are left out.
+func PolicyHash(policy *storage.StoredPolicy) string {
+	data, _ := json.Marshal(struct {
+		ResourceType        string                 `json:"resource_type"`
+		Filters             []storage.StoredFilter `json:"filters"`
+		Actions             []storage.StoredAction `json:"actions"`
+		AllowUnknownFilters bool                   `json:"allow_unknown_filters"`
+	}{policy.ResourceType, policy.Filters, policy.Actions, policy.AllowUnknownFilters})
+
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// SavePlan writes a plan file
+func SavePlan(plan *ExecutionPlan, path string) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write plan: %v", err)
+	}
+	return nil
+}
+
+// LoadPlan reads a plan file
+func LoadPlan(path string) (*ExecutionPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %v", err)
+	}
+
+	var plan ExecutionPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %v", path, err)
+	}
+	if plan.Scan == nil || plan.PolicyHash == "" {
+		return nil, fmt.Errorf("%s is not a plan file", path)
+	}
+	if plan.FormatVersion != planFormatVersion {
+		return nil, fmt.Errorf("plan %s has format version %d, expected %d", path, plan.FormatVersion, planFormatVersion)
+	}
+	return &plan, nil
+}
+
+// plannedResourceIDs lists the plan's resources. Only the IDs are taken
+// from the plan; what runs on them always comes from the policy's actions.
+func plannedResourceIDs(resources []scanner.MatchedResource) []string {
+	ids := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		ids = append(ids, resource.ID)
+	}
+	return ids
+}
+
+// checkPlannedActions refuses a plan whose actions for any resource aren't
+// exactly the ones the policy plans for it, so an edited plan file can't
+// change what runs or how risky it looks
+func checkPlannedActions(plan *ExecutionPlan, policy *storage.StoredPolicy) error {
+	for _, resource := range plan.Scan.MatchedResources {
+		var expected []scanner.PlannedAction
+		for _, action := range policy.Actions {
+			expected = append(expected, scanner.PlanAction(action, resource.ID))
+		}
+
+		want, err := json.Marshal(expected)
+		if err != nil {
+			return fmt.Errorf("failed to encode planned actions: %v", err)
+		}
+		got, err := json.Marshal(resource.Actions)
+		if err != nil {
+			return fmt.Errorf("failed to encode planned actions: %v", err)
+		}
+		if !bytes.Equal(want, got) {
+			return fmt.Errorf("plan's actions for %s aren't the policy's actions; run 'scan --out' again", resource.ID)
+		}
+	}
+	return nil
+}
+
+// planDrift explains why a planned resource can't be acted on any more, or
+// returns "" when its current state still matches the plan
+func planDrift(planned scanner.MatchedResource, current *scanner.MatchedResource, filters []storage.StoredFilter) string {
+	if current == nil {
+		return fmt.Sprintf("%s no longer exists", planned.ID)
+	}
+	if current.State != planned.State {
+		return fmt.Sprintf("%s is %s, the plan expected %s", planned.ID, current.State, planned.State)
+	}
+	if !scanner.MatchesFilters(filters, *current) {
+		return fmt.Sprintf("%s no longer matches the policy filters", planned.ID)
+	}
+	return ""
+}
@@ -19,6 +19,9 @@ type Policy struct {
 	CreatedAt    time.Time              `json:"created_at"`
 	UpdatedAt    time.Time              `json:"updated_at"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+
+	// AllowUnknownFilters lets experimental filter types through strict validation
+	AllowUnknownFilters bool `json:"allow_unknown_filters,omitempty"`
 }
 
 // PolicyMode defines how the policy should run
@@ -30,12 +33,13 @@ type PolicyMode struct {
 
 // Filter defines resource filtering criteria
 type Filter struct {
-	Type     string      `json:"type"`
+	Type     string      `json:"type"` // filter name, or and / or / not to group Filters
 	Key      string      `json:"key,omitempty"`
 	Value    interface{} `json:"value,omitempty"`
-	Op       string      `json:"op,omitempty"`       // eq, ne, in, not-in, gt, lt, gte, lte, contains, etc.
+	Op       string      `json:"op,omitempty"`       // eq, ne, in, not-in, gt, lt, gte, lte, contains, exists, missing
 	Required bool        `json:"required,omitempty"` // whether this filter is required
 	Negate   bool        `json:"negate,omitempty"`   // negate the filter result
+	Filters  []Filter    `json:"filters,omitempty"`  // child filters for and / or / not
 }
 
 // Action defines what to do with filtered resources
@@ -72,13 +76,21 @@ var SupportedResources = map[string]ResourceType{
 		Description: "EC2 Instances",
 		Filters: []string{
 			"instance-state",
+			"state",
 			"tag",
+			"tag-missing",
 			"instance-type",
 			"launch-time",
 			"vpc-id",
 			"subnet-id",
+			"platform",
+			"cpu-utilization",
+			"cpu-utilization-avg",
+			"running-days",
+			"marked-for-op",
+			"value",
 		},
-		Actions: []string{"stop", "terminate", "tag", "detach-volume", "create-snapshot"},
+		Actions: []string{"stop", "start", "terminate", "tag", "mark-for-op", "detach-volume", "create-snapshot"},
 	},
 	"s3": {
 		Name:        "s3",
@@ -87,15 +99,25 @@ var SupportedResources = map[string]ResourceType{
 		Filters: []string{
 			"bucket-name",
 			"tag",
+			"tag-missing",
 			"creation-date",
 			"encryption",
+			"encrypted",
 			"public-access",
+			"public-read",
+			"public-write",
 			"versioning",
+			"security-score",
+			"size",
+			"marked-for-op",
+			"value",
 		},
 		Actions: []string{
 			"delete",
 			"tag",
+			"mark-for-op",
 			"encrypt",
+			"enable-encryption",
 			"block-public-access",
 			"enable-versioning",
 		},
@@ -106,14 +128,22 @@ var SupportedResources = map[string]ResourceType{
 		Description: "RDS Instances",
 		Filters: []string{
 			"engine",
+			"engine-version",
 			"instance-class",
+			"state",
 			"tag",
+			"tag-missing",
 			"backup-retention",
+			"backup-retention-period",
 			"multi-az",
+			"publicly-accessible",
 			"encryption",
+			"encrypted",
+			"value",
 		},
 		Actions: []string{
 			"stop",
+			"start",
 			"delete",
 			"tag",
 			"create-snapshot",
@@ -127,10 +157,15 @@ var SupportedResources = map[string]ResourceType{
 		Filters: []string{
 			"runtime",
 			"last-modified",
+			"days-since-update",
 			"tag",
+			"tag-missing",
 			"memory-size",
 			"timeout",
+			"code-size",
 			"environment",
+			"role",
+			"value",
 		},
 		Actions: []string{"delete", "tag", "update-configuration", "update-environment"},
 	},
@@ -156,9 +191,16 @@ var SupportedResources = map[string]ResourceType{
 			"volume-type",
 			"state",
 			"tag",
+			"tag-missing",
 			"creation-time",
+			"age",
+			"age-days",
+			"size",
+			"snapshot-id",
 			"attachment-state",
+			"encryption",
 			"encrypted",
+			"value",
 		},
 		Actions: []string{"delete", "tag", "create-snapshot", "detach", "encrypt"},
 	},
@@ -278,33 +320,10 @@ func (pe *PolicyEngine) ListPolicies() []Policy {
 
 // ValidatePolicy validates a policy structure
 func (pe *PolicyEngine) ValidatePolicy(policy Policy) error {
-	if policy.Name == "" {
-		return fmt.Errorf("policy name cannot be empty")
-	}
-
-	if policy.ResourceType == "" {
-		return fmt.Errorf("resource type cannot be empty")
+	storedPolicy := convertPolicyToStored(policy)
+	if errs := ValidateStoredPolicy(&storedPolicy); len(errs) > 0 {
+		return errs
 	}
-
-	// Check if resource type is supported
-	if _, exists := SupportedResources[policy.ResourceType]; !exists {
-		return fmt.Errorf("unsupported resource type: %s", policy.ResourceType)
-	}
-
-	// Validate filters
-	for _, filter := range policy.Filters {
-		if filter.Type == "" {
-			return fmt.Errorf("filter type cannot be empty")
-		}
-	}
-
-	// Validate actions
-	for _, action := range policy.Actions {
-		if action.Type == "" {
-			return fmt.Errorf("action type cannot be empty")
-		}
-	}
-
 	return nil
 }
 
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"custodian-killer/scanner"
+	"custodian-killer/storage"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PolicyTestSuite is a file of test cases for one policy, e.g. old-volumes.test.yaml
+type PolicyTestSuite struct {
+	Policy     string           `json:"policy,omitempty"`      // stored policy name
+	PolicyFile string           `json:"policy_file,omitempty"` // policy file, relative to the suite
+	Cases      []PolicyTestCase `json:"cases"`
+}
+
+// PolicyTestCase runs a policy against a set of resources and checks the outcome
+type PolicyTestCase struct {
+	Name      string            `json:"name"`
+	Fixtures  []string          `json:"fixtures,omitempty"`  // resource files, relative to the suite
+	Kind      string            `json:"kind,omitempty"`      // shape of the inline resources, defaults to the policy's resource type
+	Region    string            `json:"region,omitempty"`    // region given to resources that don't have one
+	Resources []json.RawMessage `json:"resources,omitempty"` // inline resources
+	Expect    PolicyTestExpect  `json:"expect"`
+}
+
+// PolicyTestExpect is the expected outcome of a test case
+type PolicyTestExpect struct {
+	Matched []string            `json:"matched"`           // IDs of every resource that should match
+	Actions map[string][]string `json:"actions,omitempty"` // planned action types by resource ID
+}
+
+// resourceFixture is a fixture file: a list of resources, or a list with its kind
+type resourceFixture struct {
+	Kind      string            `json:"kind"`
+	Region    string            `json:"region"`
+	Resources []json.RawMessage `json:"resources"`
+}
+
+// PolicyTestResult is the outcome of one test case
+type PolicyTestResult struct {
+	Suite    string   `json:"suite"`
+	Policy   string   `json:"policy"`
+	Case     string   `json:"case"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// testSuffixes are the file names policy test discovers in directories
+var testSuffixes = []string{".test.yaml", ".test.yml", ".test.json"}
+
+// FindPolicyTests expands directories to the test suites inside them
+func FindPolicyTests(paths []string) ([]string, error) {
+	var suites []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		if !info.IsDir() {
+			suites = append(suites, path)
+			continue
+		}
+
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && isPolicyTest(file) {
+				suites = append(suites, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %s: %v", path, err)
+		}
+	}
+
+	sort.Strings(suites)
+	return suites, nil
+}
+
+// RunPolicyTestSuite runs every case in a suite file
+func RunPolicyTestSuite(suitePath string) ([]PolicyTestResult, error) {
+	var suite PolicyTestSuite
+	if err := readDocument(suitePath, &suite); err != nil {
+		return nil, fmt.Errorf("failed to load test suite: %v", err)
+	}
+	if len(suite.Cases) == 0 {
+		return nil, fmt.Errorf("test suite %s has no cases", suitePath)
+	}
+
+	policy, err := loadSuitePolicy(suitePath, suite)
+	if err != nil {
+		return nil, err
+	}
+
+	// A policy that fails validation can't pass any case
+	var invalid []string
+	for _, validationErr := range ValidateStoredPolicy(policy) {
+		invalid = append(invalid, "invalid policy: "+validationErr.Error())
+	}
+
+	var results []PolicyTestResult
+	for i, testCase := range suite.Cases {
+		result := PolicyTestResult{Suite: suitePath, Policy: policy.Name, Case: testCase.Name}
+		if result.Case == "" {
+			result.Case = fmt.Sprintf("case %d", i+1)
+		}
+
+		if len(invalid) > 0 {
+			result.Failures = invalid
+		} else {
+			result.Failures = runPolicyTestCase(suitePath, policy, testCase)
+		}
+		result.Passed = len(result.Failures) == 0
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// runPolicyTestCase evaluates the policy offline and returns what didn't match expectations
+func runPolicyTestCase(suitePath string, policy *storage.StoredPolicy, testCase PolicyTestCase) []string {
+	resources, err := loadCaseResources(suitePath, policy.ResourceType, testCase)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	// StrictFilters makes filter problems fail the case instead of warning
+	provider := scanner.NewStaticProvider(map[string][]scanner.MatchedResource{policy.ResourceType: resources})
+	policyScanner := scanner.NewPolicyScanner(nil, provider, scanner.ScannerConfig{
+		StrictFilters: true,
+		Quiet:         true,
+		MaxResources:  len(resources) + 1,
+	})
+
+	scan, err := policyScanner.ScanStoredPolicy(context.Background(), policy)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var failures []string
+	matched := make(map[string]scanner.MatchedResource)
+	for _, resource := range scan.MatchedResources {
+		matched[resource.ID] = resource
+	}
+
+	expected := make(map[string]bool)
+	for _, id := range testCase.Expect.Matched {
+		expected[id] = true
+		if _, ok := matched[id]; !ok {
+			failures = append(failures, fmt.Sprintf("expected %s to match, but it didn't", id))
+		}
+	}
+	for _, resource := range scan.MatchedResources {
+		if !expected[resource.ID] {
+			failures = append(failures, fmt.Sprintf("%s matched, but wasn't expected to", resource.ID))
+		}
+	}
+
+	ids := make([]string, 0, len(testCase.Expect.Actions))
+	for id := range testCase.Expect.Actions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		resource, ok := matched[id]
+		if !ok {
+			continue // already reported above
+		}
+		var planned []string
+		for _, action := range resource.Actions {
+			planned = append(planned, action.Type)
+		}
+		want := testCase.Expect.Actions[id]
+		if strings.Join(planned, ",") != strings.Join(want, ",") {
+			failures = append(failures, fmt.Sprintf(
+				"%s: expected actions [%s], planned [%s]",
+				id, strings.Join(want, ", "), strings.Join(planned, ", "),
+			))
+		}
+	}
+
+	return failures
+}
+
+// loadSuitePolicy finds the policy a suite tests: policy_file, a stored policy,
+// or a policy file next to the suite with the same base name
+func loadSuitePolicy(suitePath string, suite PolicyTestSuite) (*storage.StoredPolicy, error) {
+	dir := filepath.Dir(suitePath)
+
+	if suite.PolicyFile != "" {
+		return storage.LoadPolicyFile(filepath.Join(dir, suite.PolicyFile))
+	}
+
+	if suite.Policy != "" {
+		if policyStorage == nil {
+			return nil, fmt.Errorf("storage not initialized")
+		}
+		return policyStorage.GetPolicy(suite.Policy)
+	}
+
+	base := filepath.Base(suitePath)
+	for _, suffix := range testSuffixes {
+		base = strings.TrimSuffix(base, suffix)
+	}
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		candidate := filepath.Join(dir, base+ext)
+		if _, err := os.Stat(candidate); err == nil {
+			return storage.LoadPolicyFile(candidate)
+		}
+	}
+
+	return nil, fmt.Errorf("%s names no policy or policy_file, and no %s.yaml or %s.json sits next to it", suitePath, base, base)
+}
+
+// loadCaseResources decodes a case's fixture files and inline resources
+func loadCaseResources(
+	suitePath string,
+	resourceType string,
+	testCase PolicyTestCase,
+) ([]scanner.MatchedResource, error) {
+	var resources []scanner.MatchedResource
+
+	for _, fixturePath := range testCase.Fixtures {
+		path := filepath.Join(filepath.Dir(suitePath), fixturePath)
+		fixture, err := loadFixture(path)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %v", fixturePath, err)
+		}
+
+		kind := firstNonEmpty(fixture.Kind, testCase.Kind, resourceType)
+		region := firstNonEmpty(fixture.Region, testCase.Region, "us-east-1")
+		decoded, err := decodeResources(kind, region, fixture.Resources)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %v", fixturePath, err)
+		}
+		resources = append(resources, decoded...)
+	}
+
+	kind := firstNonEmpty(testCase.Kind, resourceType)
+	region := firstNonEmpty(testCase.Region, "us-east-1")
+	decoded, err := decodeResources(kind, region, testCase.Resources)
+	if err != nil {
+		return nil, fmt.Errorf("inline resources: %v", err)
+	}
+
+	return append(resources, decoded...), nil
+}
+
+// loadFixture reads a fixture file holding either a list of resources or
+// {kind, region, resources}
+func loadFixture(path string) (*resourceFixture, error) {
+	var document interface{}
+	if err := readDocument(path, &document); err != nil {
+		return nil, err
+	}
+
+	// Re-encode so either shape decodes into the fixture type
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture resourceFixture
+	if _, isList := document.([]interface{}); isList {
+		err = json.Unmarshal(data, &fixture.Resources)
+	} else {
+		err = json.Unmarshal(data, &fixture)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &fixture, nil
+}
+
+func decodeResources(kind, region string, raw []json.RawMessage) ([]scanner.MatchedResource, error) {
+	var resources []scanner.MatchedResource
+	for i, data := range raw {
+		resource, err := scanner.ResourceFromJSON(kind, data, region)
+		if err != nil {
+			return nil, fmt.Errorf("resource %d: %v", i, err)
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// readDocument decodes a JSON or YAML file, chosen by extension
+func readDocument(path string, value interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if storage.FormatForPath(path) == storage.FormatYAML {
+		return storage.UnmarshalYAML(data, value)
+	}
+	return json.Unmarshal(data, value)
+}
+
+func isPolicyTest(path string) bool {
+	for _, suffix := range testSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const stopDevPolicy = `name: stop-dev
+resource_type: ec2
+filters:
+  - type: tag
+    key: Env
+    value: dev
+  - type: instance-state
+    value: running
+actions:
+  - type: stop
+  - type: tag
+    settings:
+      Stopped: "true"
+mode:
+  type: pull
+`
+
+const devInstances = `
+    resources:
+      - {instance_id: i-dev-running, state: running, tags: {Env: dev}}
+      - {instance_id: i-prod-running, state: running, tags: {Env: prod}}
+      - {instance_id: i-dev-stopped, state: stopped, tags: {Env: dev}}
+`
+
+// writeFiles writes files, named relative to dir, creating directories as needed
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunPolicyTestSuite(t *testing.T) {
+	type caseOutcome struct {
+		passed   bool
+		failures []string // substrings, one per failure
+	}
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		suite   string // file to run
+		want    []caseOutcome
+		wantErr string
+	}{
+		{
+			name: "policy next to the suite, inline resources",
+			files: map[string]string{
+				"stop-dev.yaml": stopDevPolicy,
+				"stop-dev.test.yaml": `cases:
+  - name: only running dev instances
+` + devInstances + `
+    expect:
+      matched: [i-dev-running]
+      actions:
+        i-dev-running: [stop, tag]
+`,
+			},
+			suite: "stop-dev.test.yaml",
+			want:  []caseOutcome{{passed: true}},
+		},
+		{
+			name: "unexpected and missing matches",
+			files: map[string]string{
+				"stop-dev.yaml": stopDevPolicy,
+				"stop-dev.test.yaml": `cases:
+  - name: wrong expectation
+` + devInstances + `
+    expect:
+      matched: [i-prod-running]
+`,
+			},
+			suite: "stop-dev.test.yaml",
+			want: []caseOutcome{{failures: []string{
+				"expected i-prod-running to match, but it didn't",
+				"i-dev-running matched, but wasn't expected to",
+			}}},
+		},
+		{
+			name: "wrong planned actions",
+			files: map[string]string{
+				"stop-dev.yaml": stopDevPolicy,
+				"stop-dev.test.yaml": `cases:
+` + strings.Replace(devInstances, "    resources:", "  - resources:", 1) + `
+    expect:
+      matched: [i-dev-running]
+      actions:
+        i-dev-running: [terminate]
+`,
+			},
+			suite: "stop-dev.test.yaml",
+			want: []caseOutcome{{failures: []string{
+				"i-dev-running: expected actions [terminate], planned [stop, tag]",
+			}}},
+		},
+		{
+			name: "policy_file and both fixture shapes",
+			files: map[string]string{
+				"policies/stop.yaml": stopDevPolicy,
+				"fixtures/list.json": `[{"instance_id": "i-a", "state": "running", "tags": {"Env": "dev"}}]`,
+				"fixtures/kind.yaml": `kind: resource
+region: eu-west-1
+resources:
+  - {id: i-b, type: ec2, state: running, tags: {Env: dev}}
+  - {id: i-c, type: ec2, state: running, tags: {Env: test}}
+`,
+				"tests/stop.test.json": `{
+  "policy_file": "../policies/stop.yaml",
+  "cases": [
+    {"name": "fixtures", "fixtures": ["../fixtures/list.json", "../fixtures/kind.yaml"], "expect": {"matched": ["i-a", "i-b"]}},
+    {"name": "nothing matches", "expect": {"matched": []}}
+  ]
+}`,
+			},
+			suite: "tests/stop.test.json",
+			want:  []caseOutcome{{passed: true}, {passed: true}},
+		},
+		{
+			name: "invalid policy fails every case",
+			files: map[string]string{
+				"bad.yaml": strings.Replace(stopDevPolicy, "type: instance-state", "type: instance-mood", 1),
+				"bad.test.yaml": `cases:
+  - expect: {matched: []}
+  - expect: {matched: []}
+`,
+			},
+			suite: "bad.test.yaml",
+			want: []caseOutcome{
+				{failures: []string{"invalid policy: filters[1].type: unknown filter type 'instance-mood'"}},
+				{failures: []string{"invalid policy: filters[1].type: unknown filter type 'instance-mood'"}},
+			},
+		},
+		{
+			name: "bad fixture fails its case",
+			files: map[string]string{
+				"stop-dev.yaml": stopDevPolicy,
+				"stop-dev.test.yaml": `cases:
+  - fixtures: [missing.json]
+    expect: {matched: []}
+  - kind: submarine
+    resources: [{id: x}]
+    expect: {matched: []}
+`,
+			},
+			suite: "stop-dev.test.yaml",
+			want: []caseOutcome{
+				{failures: []string{"fixture missing.json"}},
+				{failures: []string{"unknown resource kind 'submarine'"}},
+			},
+		},
+		{
+			name: "suite without cases",
+			files: map[string]string{
+				"stop-dev.yaml":      stopDevPolicy,
+				"stop-dev.test.yaml": "cases: []\n",
+			},
+			suite:   "stop-dev.test.yaml",
+			wantErr: "has no cases",
+		},
+		{
+			name: "suite without a policy",
+			files: map[string]string{
+				"orphan.test.yaml": "cases:\n  - expect: {matched: []}\n",
+			},
+			suite:   "orphan.test.yaml",
+			wantErr: "names no policy or policy_file",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, test.files)
+
+			results, err := RunPolicyTestSuite(filepath.Join(dir, test.suite))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunPolicyTestSuite: %v", err)
+			}
+
+			if len(results) != len(test.want) {
+				t.Fatalf("%d results, want %d: %+v", len(results), len(test.want), results)
+			}
+			for i, want := range test.want {
+				result := results[i]
+				if result.Passed != want.passed {
+					t.Errorf("case %q passed = %v, want %v (failures %q)", result.Case, result.Passed, want.passed, result.Failures)
+				}
+				if len(result.Failures) != len(want.failures) {
+					t.Errorf("case %q failures = %q, want %q", result.Case, result.Failures, want.failures)
+					continue
+				}
+				for j, failure := range want.failures {
+					if !strings.Contains(result.Failures[j], failure) {
+						t.Errorf("case %q failure %d = %q, want it to contain %q", result.Case, j, result.Failures[j], failure)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFindPolicyTests(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.test.yaml":        "",
+		"nested/b.test.json": "",
+		"nested/c.test.yml":  "",
+		"policy.yaml":        "",
+		"notes.test.txt":     "",
+	})
+	explicit := filepath.Join(dir, "policy.yaml")
+
+	suites, err := FindPolicyTests([]string{dir, explicit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "a.test.yaml"),
+		filepath.Join(dir, "nested/b.test.json"),
+		filepath.Join(dir, "nested/c.test.yml"),
+		explicit, // named files are taken whatever their name
+	}
+	if !reflect.DeepEqual(suites, want) {
+		t.Errorf("FindPolicyTests = %q, want %q", suites, want)
+	}
+
+	if _, err := FindPolicyTests([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("FindPolicyTests found a missing path")
+	}
+}
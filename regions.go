@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"custodian-killer/aws"
+	"custodian-killer/scanner"
+	"custodian-killer/storage"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// maxRegionWorkers is how many regions scans and reports read at once
+const maxRegionWorkers = 5
+
+// regionsFlag reads --regions, refusing it alongside --region
+func regionsFlag(cmd *cobra.Command) string {
+	regions, _ := cmd.Flags().GetString("regions")
+	region, _ := cmd.Flags().GetString("region")
+	if regions != "" && region != "" {
+		fmt.Println("❌ --region and --regions can't be used together")
+		os.Exit(1)
+	}
+	return regions
+}
+
+// resolveRegions turns a --regions value into region names. "all" is every
+// region enabled for the account; otherwise it's a comma-separated list.
+func resolveRegions(ctx context.Context, client *aws.CustodianClient, spec string) ([]string, error) {
+	if strings.TrimSpace(spec) == "all" {
+		regions, err := client.GetRegions(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list regions: %v", err)
+		}
+		if len(regions) == 0 {
+			return nil, fmt.Errorf("no regions are enabled for this account")
+		}
+		return regions, nil
+	}
+
+	var regions []string
+	seen := make(map[string]bool)
+	for _, region := range strings.Split(spec, ",") {
+		region = strings.TrimSpace(region)
+		if region == "" || seen[region] {
+			continue
+		}
+		if !aws.IsRegionName(region) {
+			return nil, fmt.Errorf("'%s' is not an AWS region name", region)
+		}
+		seen[region] = true
+		regions = append(regions, region)
+	}
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("--regions needs 'all' or a list such as us-east-1,eu-west-1")
+	}
+	return regions, nil
+}
+
+// forEachRegion calls fn for each region, up to maxRegionWorkers at a time,
+// and returns once every call has finished. Regions not started when ctx is
+// cancelled are skipped.
+func forEachRegion(ctx context.Context, regions []string, fn func(region string)) {
+	workers := maxRegionWorkers
+	if workers > len(regions) {
+		workers = len(regions)
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for region := range jobs {
+				fn(region)
+			}
+		}()
+	}
+queue:
+	for _, region := range regions {
+		select {
+		case jobs <- region:
+		case <-ctx.Done():
+			break queue
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// regionalScan is one policy's scan in one region
+type regionalScan struct {
+	Region string
+	Policy string
+	Result *scanner.ScanResult
+	Err    error
+}
+
+// scanRegions scans policies in every region, skipping regions outside a
+// policy's allow-list. With no regions, it scans the client's own region.
+// Scans are grouped by policy, then by region in the order given.
+func scanRegions(
+	ctx context.Context,
+	client *aws.CustodianClient,
+	policies []storage.StoredPolicy,
+	regions []string,
+) []regionalScan {
+	targets := regions
+	if len(targets) == 0 {
+		targets = []string{""}
+	}
+	byRegion := make(map[string][]regionalScan, len(targets))
+	var mu sync.Mutex
+
+	forEachRegion(ctx, targets, func(target string) {
+		regional, region := client, client.Region
+		if target != "" {
+			regional, region = client.ForRegion(target), target
+		}
+		regionalScanner := scanner.NewPolicyScanner(policyStorage,
+			scanner.NewAWSResourceProvider(regional),
+			scanner.ScannerConfig{
+				AWSRegion:     region,
+				AWSAccount:    client.Account,
+				DryRunDefault: true,
+				MaxResources:  1000,
+				Timeout:       300,
+				Quiet:         true,
+			})
+
+		var scans []regionalScan
+		for i := range policies {
+			if !policies[i].AllowsRegion(region) {
+				continue
+			}
+			result, err := regionalScanner.ScanStoredPolicy(ctx, &policies[i])
+			scans = append(scans, regionalScan{Region: region, Policy: policies[i].Name, Result: result, Err: err})
+		}
+
+		mu.Lock()
+		byRegion[target] = scans
+		mu.Unlock()
+	})
+
+	var scans []regionalScan
+	for _, policy := range policies {
+		for _, target := range targets {
+			for _, scan := range byRegion[target] {
+				if scan.Policy == policy.Name {
+					scans = append(scans, scan)
+				}
+			}
+		}
+	}
+	return scans
+}
+
+// runMultiRegionScan scans one policy, or every active policy when
+// policyName is empty, across regions and prints the results
+func runMultiRegionScan(ctx context.Context, policyName string, regionsSpec string, outputFormat string) {
+	jsonOutput := outputFormat == "json"
+	policies := scanPolicies(policyName)
+	if len(policies) == 0 {
+		return
+	}
+
+	// Scans never modify anything, so the client is always in dry run mode
+	awsClient, err := initializeAWSClient(true)
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize AWS client: %v\n", err)
+		os.Exit(1)
+	}
+	defer awsClient.Close()
+
+	regions, err := resolveRegions(ctx, awsClient, regionsSpec)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if !jsonOutput {
+		fmt.Printf("🌍 Scanning %d policies in %d regions: %s\n", len(policies), len(regions), strings.Join(regions, ", "))
+		printRegionSkips(policies, regions)
+	}
+
+	scans := scanRegions(ctx, awsClient, policies, regions)
+	exitIfInterrupted(ctx)
+
+	failed := 0
+	var results []*scanner.ScanResult
+	for _, scan := range scans {
+		if scan.Err != nil {
+			failed++
+			if !jsonOutput {
+				fmt.Printf("❌ Failed to scan %s in %s: %v\n", scan.Policy, scan.Region, scan.Err)
+			}
+			continue
+		}
+		results = append(results, scan.Result)
+	}
+
+	if jsonOutput {
+		printJSON(results)
+	} else {
+		for _, result := range results {
+			displayScanResult(result)
+		}
+		printRegionalScanSummary(scans)
+	}
+
+	if failed > 0 {
+		fmt.Printf("❌ %d of %d scans failed\n", failed, len(scans))
+		os.Exit(1)
+	}
+}
+
+// scanPolicies loads the policy to scan, or every active policy when
+// policyName is empty, exiting if they can't be read
+func scanPolicies(policyName string) []storage.StoredPolicy {
+	if policyStorage == nil {
+		fmt.Println("❌ Storage not initialized!")
+		os.Exit(1)
+	}
+
+	if policyName != "" {
+		policy, err := policyStorage.GetPolicy(policyName)
+		if err != nil {
+			fmt.Printf("❌ Failed to get policy: %v\n", err)
+			os.Exit(1)
+		}
+		return []storage.StoredPolicy{*policy}
+	}
+
+	stored, err := policyStorage.ListPolicies()
+	if err != nil {
+		fmt.Printf("❌ Failed to list policies: %v\n", err)
+		os.Exit(1)
+	}
+	var policies []storage.StoredPolicy
+	for _, policy := range stored {
+		if policy.Status == "active" {
+			policies = append(policies, policy)
+		}
+	}
+	if len(policies) == 0 {
+		fmt.Println("📋 No active policies found!")
+	}
+	return policies
+}
+
+// printRegionSkips notes each policy that won't run in a region because of
+// its allow-list
+func printRegionSkips(policies []storage.StoredPolicy, regions []string) {
+	for _, policy := range policies {
+		for _, region := range regions {
+			if !policy.AllowsRegion(region) {
+				fmt.Printf("⏭️  Skipping %s in %s: limited to %s\n", policy.Name, region, strings.Join(policy.Regions, ", "))
+			}
+		}
+	}
+}
+
+// printRegionalScanSummary lists each scan's matches by policy and region
+func printRegionalScanSummary(scans []regionalScan) {
+	fmt.Println("\n🌍 Scan Summary by Region:")
+	fmt.Println("═══════════════════════════════════════")
+
+	matched := 0
+	for _, scan := range scans {
+		if scan.Err != nil {
+			fmt.Printf("   ❌ %-30s %-16s failed\n", scan.Policy, scan.Region)
+			continue
+		}
+		status := "✅"
+		if len(scan.Result.Errors) > 0 {
+			status = "⚠️ "
+		}
+		fmt.Printf("   %s %-30s %-16s %d/%d matched\n", status, scan.Policy, scan.Region,
+			scan.Result.Summary.MatchedResources, scan.Result.Summary.TotalScanned)
+		matched += scan.Result.Summary.MatchedResources
+	}
+	fmt.Printf("🎯 %d matched resources across %d scans\n", matched, len(scans))
+}
+
+// gatherRegions lists EC2 instances and S3 buckets for a report, from the
+// client's region or, when regions are given, from each of them
+func gatherRegions(
+	ctx context.Context,
+	client *aws.CustodianClient,
+	regions []string,
+) ([]aws.EC2Instance, []aws.S3Bucket) {
+	if len(regions) == 0 {
+		ec2Instances, _ := client.GetEC2Instances(ctx, aws.EC2Filter{})
+		s3Buckets, _ := client.GetS3Buckets(ctx, aws.S3Filter{})
+		return ec2Instances, s3Buckets
+	}
+
+	type regionResources struct {
+		ec2Instances []aws.EC2Instance
+		s3Buckets    []aws.S3Bucket
+	}
+	byRegion := make(map[string]regionResources, len(regions))
+	var mu sync.Mutex
+
+	forEachRegion(ctx, regions, func(region string) {
+		regional := client.ForRegion(region)
+		ec2Instances, err := regional.GetEC2Instances(ctx, aws.EC2Filter{})
+		if err != nil {
+			fmt.Printf("⚠️  Failed to list EC2 instances in %s: %v\n", region, err)
+		}
+		s3Buckets, err := regional.GetS3Buckets(ctx, aws.S3Filter{})
+		if err != nil {
+			fmt.Printf("⚠️  Failed to list S3 buckets in %s: %v\n", region, err)
+		}
+
+		mu.Lock()
+		byRegion[region] = regionResources{ec2Instances: ec2Instances, s3Buckets: s3Buckets}
+		mu.Unlock()
+	})
+
+	var ec2Instances []aws.EC2Instance
+	var s3Buckets []aws.S3Bucket
+	for _, region := range regions {
+		ec2Instances = append(ec2Instances, byRegion[region].ec2Instances...)
+		s3Buckets = append(s3Buckets, byRegion[region].s3Buckets...)
+	}
+	return ec2Instances, s3Buckets
+}
+
+// reportResources gathers a report's resources, across the regions in
+// --regions and the accounts in --accounts when they are set. It returns
+// false if AWS couldn't be reached.
+func reportResources(cmd *cobra.Command, regionsSpec string) ([]aws.EC2Instance, []aws.S3Bucket, bool) {
+	if accounts := accountsFlag(cmd); accounts != nil {
+		ec2Instances, s3Buckets := accountResources(cmd, accounts, regionsSpec)
+		return ec2Instances, s3Buckets, true
+	}
+
+	client, err := initializeAWSClient(true)
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize AWS client: %v\n", err)
+		return nil, nil, false
+	}
+	defer client.Close()
+
+	var regions []string
+	if regionsSpec != "" {
+		regions, err = resolveRegions(cmd.Context(), client, regionsSpec)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("🌍 Gathering resources from %d regions: %s\n", len(regions), strings.Join(regions, ", "))
+	}
+
+	ec2Instances, s3Buckets := gatherRegions(cmd.Context(), client, regions)
+	exitIfInterrupted(cmd.Context())
+	return ec2Instances, s3Buckets, true
+}
@@ -0,0 +1,262 @@
+package replay
+
+import (
+	"context"
+	"custodian-killer/aws"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// wrap puts a tape in front of each service client. When replaying, the
+// clients are nil and never called.
+func wrap(t tape, region string, apis aws.ServiceAPIs) aws.ServiceAPIs {
+	return aws.ServiceAPIs{
+		EC2:    &ec2Client{tape: t, region: region, next: apis.EC2},
+		S3:     &s3Client{tape: t, region: region, next: apis.S3},
+		RDS:    &rdsClient{tape: t, region: region, next: apis.RDS},
+		Lambda: &lambdaClient{tape: t, region: region, next: apis.Lambda},
+		STS:    &stsClient{tape: t, region: region, next: apis.STS},
+		SQS:    &sqsClient{tape: t, region: region, next: apis.SQS},
+	}
+}
+
+// roundTrip sends one call through the tape
+func roundTrip[Out any](
+	t tape,
+	region, service, operation string,
+	input interface{},
+	call func() (*Out, error),
+) (*Out, error) {
+	output := new(Out)
+	err := t.play(region, service, operation, input, output, func() (interface{}, error) {
+		return call()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+type ec2Client struct {
+	tape   tape
+	region string
+	next   aws.EC2API
+}
+
+func (c *ec2Client) DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	return roundTrip(c.tape, c.region, "EC2", "DescribeRegions", params, func() (*ec2.DescribeRegionsOutput, error) {
+		return c.next.DescribeRegions(ctx, params, optFns...)
+	})
+}
+
+func (c *ec2Client) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return roundTrip(c.tape, c.region, "EC2", "DescribeInstances", params, func() (*ec2.DescribeInstancesOutput, error) {
+		return c.next.DescribeInstances(ctx, params, optFns...)
+	})
+}
+
+func (c *ec2Client) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	return roundTrip(c.tape, c.region, "EC2", "DescribeVolumes", params, func() (*ec2.DescribeVolumesOutput, error) {
+		return c.next.DescribeVolumes(ctx, params, optFns...)
+	})
+}
+
+func (c *ec2Client) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	return roundTrip(c.tape, c.region, "EC2", "StopInstances", params, func() (*ec2.StopInstancesOutput, error) {
+		return c.next.StopInstances(ctx, params, optFns...)
+	})
+}
+
+func (c *ec2Client) StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	return roundTrip(c.tape, c.region, "EC2", "StartInstances", params, func() (*ec2.StartInstancesOutput, error) {
+		return c.next.StartInstances(ctx, params, optFns...)
+	})
+}
+
+func (c *ec2Client) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
+	return roundTrip(c.tape, c.region, "EC2", "TerminateInstances", params, func() (*ec2.TerminateInstancesOutput, error) {
+		return c.next.TerminateInstances(ctx, params, optFns...)
+	})
+}
+
+func (c *ec2Client) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	return roundTrip(c.tape, c.region, "EC2", "CreateTags", params, func() (*ec2.CreateTagsOutput, error) {
+		return c.next.CreateTags(ctx, params, optFns...)
+	})
+}
+
+func (c *ec2Client) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	return roundTrip(c.tape, c.region, "EC2", "DeleteTags", params, func() (*ec2.DeleteTagsOutput, error) {
+		return c.next.DeleteTags(ctx, params, optFns...)
+	})
+}
+
+type s3Client struct {
+	tape   tape
+	region string
+	next   aws.S3API
+}
+
+func (c *s3Client) ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "ListBuckets", params, func() (*s3.ListBucketsOutput, error) {
+		return c.next.ListBuckets(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) GetBucketLocation(ctx context.Context, params *s3.GetBucketLocationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "GetBucketLocation", params, func() (*s3.GetBucketLocationOutput, error) {
+		return c.next.GetBucketLocation(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) GetBucketTagging(ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "GetBucketTagging", params, func() (*s3.GetBucketTaggingOutput, error) {
+		return c.next.GetBucketTagging(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) GetBucketAcl(ctx context.Context, params *s3.GetBucketAclInput, optFns ...func(*s3.Options)) (*s3.GetBucketAclOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "GetBucketAcl", params, func() (*s3.GetBucketAclOutput, error) {
+		return c.next.GetBucketAcl(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) GetPublicAccessBlock(ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "GetPublicAccessBlock", params, func() (*s3.GetPublicAccessBlockOutput, error) {
+		return c.next.GetPublicAccessBlock(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) GetBucketVersioning(ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.GetBucketVersioningOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "GetBucketVersioning", params, func() (*s3.GetBucketVersioningOutput, error) {
+		return c.next.GetBucketVersioning(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) GetBucketEncryption(ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.GetBucketEncryptionOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "GetBucketEncryption", params, func() (*s3.GetBucketEncryptionOutput, error) {
+		return c.next.GetBucketEncryption(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) PutPublicAccessBlock(ctx context.Context, params *s3.PutPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.PutPublicAccessBlockOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "PutPublicAccessBlock", params, func() (*s3.PutPublicAccessBlockOutput, error) {
+		return c.next.PutPublicAccessBlock(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) PutBucketEncryption(ctx context.Context, params *s3.PutBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.PutBucketEncryptionOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "PutBucketEncryption", params, func() (*s3.PutBucketEncryptionOutput, error) {
+		return c.next.PutBucketEncryption(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) PutBucketVersioning(ctx context.Context, params *s3.PutBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.PutBucketVersioningOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "PutBucketVersioning", params, func() (*s3.PutBucketVersioningOutput, error) {
+		return c.next.PutBucketVersioning(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) PutBucketTagging(ctx context.Context, params *s3.PutBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.PutBucketTaggingOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "PutBucketTagging", params, func() (*s3.PutBucketTaggingOutput, error) {
+		return c.next.PutBucketTagging(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) DeleteBucketTagging(ctx context.Context, params *s3.DeleteBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketTaggingOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "DeleteBucketTagging", params, func() (*s3.DeleteBucketTaggingOutput, error) {
+		return c.next.DeleteBucketTagging(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) DeleteBucket(ctx context.Context, params *s3.DeleteBucketInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "DeleteBucket", params, func() (*s3.DeleteBucketOutput, error) {
+		return c.next.DeleteBucket(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	return roundTrip(c.tape, c.region, "S3", "ListObjectsV2", params, func() (*s3.ListObjectsV2Output, error) {
+		return c.next.ListObjectsV2(ctx, params, optFns...)
+	})
+}
+
+func (c *s3Client) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	return roundTrip(c.tape, c.region, "S3", "DeleteObjects", params, func() (*s3.DeleteObjectsOutput, error) {
+		return c.next.DeleteObjects(ctx, params, optFns...)
+	})
+}
+
+type rdsClient struct {
+	tape   tape
+	region string
+	next   aws.RDSAPI
+}
+
+func (c *rdsClient) DescribeDBInstances(ctx context.Context, params *rds.DescribeDBInstancesInput, optFns ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	return roundTrip(c.tape, c.region, "RDS", "DescribeDBInstances", params, func() (*rds.DescribeDBInstancesOutput, error) {
+		return c.next.DescribeDBInstances(ctx, params, optFns...)
+	})
+}
+
+type lambdaClient struct {
+	tape   tape
+	region string
+	next   aws.LambdaAPI
+}
+
+func (c *lambdaClient) ListFunctions(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	return roundTrip(c.tape, c.region, "Lambda", "ListFunctions", params, func() (*lambda.ListFunctionsOutput, error) {
+		output, err := c.next.ListFunctions(ctx, params, optFns...)
+		if output != nil {
+			// Environment variables often hold secrets, so their values are never recorded
+			for _, function := range output.Functions {
+				if function.Environment != nil {
+					function.Environment.Variables = aws.RedactValues(function.Environment.Variables)
+				}
+			}
+		}
+		return output, err
+	})
+}
+
+func (c *lambdaClient) ListTags(ctx context.Context, params *lambda.ListTagsInput, optFns ...func(*lambda.Options)) (*lambda.ListTagsOutput, error) {
+	return roundTrip(c.tape, c.region, "Lambda", "ListTags", params, func() (*lambda.ListTagsOutput, error) {
+		return c.next.ListTags(ctx, params, optFns...)
+	})
+}
+
+type stsClient struct {
+	tape   tape
+	region string
+	next   aws.STSAPI
+}
+
+func (c *stsClient) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	return roundTrip(c.tape, c.region, "STS", "GetCallerIdentity", params, func() (*sts.GetCallerIdentityOutput, error) {
+		return c.next.GetCallerIdentity(ctx, params, optFns...)
+	})
+}
+
+type sqsClient struct {
+	tape   tape
+	region string
+	next   aws.SQSAPI
+}
+
+func (c *sqsClient) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	return roundTrip(c.tape, c.region, "SQS", "ReceiveMessage", params, func() (*sqs.ReceiveMessageOutput, error) {
+		return c.next.ReceiveMessage(ctx, params, optFns...)
+	})
+}
+
+func (c *sqsClient) DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	return roundTrip(c.tape, c.region, "SQS", "DeleteMessageBatch", params, func() (*sqs.DeleteMessageBatchOutput, error) {
+		return c.next.DeleteMessageBatch(ctx, params, optFns...)
+	})
+}
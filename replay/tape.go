@@ -0,0 +1,241 @@
+// Package replay records the AWS API traffic of a CustodianClient to fixture
+// files and serves it back later, with no credentials or network.
+package replay
+
+import (
+	"custodian-killer/aws"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Interaction is one recorded API call, stored as one fixture file
+type Interaction struct {
+	Sequence  int             `json:"sequence"`
+	Region    string          `json:"region"`
+	Service   string          `json:"service"`
+	Operation string          `json:"operation"`
+	Input     json.RawMessage `json:"input"`
+	Output    json.RawMessage `json:"output,omitempty"`
+	Error     *RecordedError  `json:"error,omitempty"`
+}
+
+// RecordedError is an error response captured during recording. Replaying
+// returns it as an error with the same message, so checks like
+// strings.Contains(err.Error(), "DryRunOperation") behave the same.
+type RecordedError struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *RecordedError) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns the AWS error code, when the original error had one
+func (e *RecordedError) ErrorCode() string {
+	return e.Code
+}
+
+// tape is where the service wrappers send calls: a Recorder or a Replayer
+type tape interface {
+	play(region, service, operation string, input interface{}, output interface{}, call func() (interface{}, error)) error
+}
+
+// Recorder writes every call made through the clients it wraps to a directory
+type Recorder struct {
+	mu       sync.Mutex
+	dir      string
+	sequence int
+}
+
+// NewRecorder creates a recorder writing fixtures to dir
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %v", err)
+	}
+
+	// Continue numbering after any fixtures already there
+	existing, err := loadInteractions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{dir: dir, sequence: len(existing)}, nil
+}
+
+// Wrap records the calls made through a region's service clients.
+// It matches aws.ClientConfig.WrapAPIs.
+func (r *Recorder) Wrap(region string, apis aws.ServiceAPIs) aws.ServiceAPIs {
+	return wrap(r, region, apis)
+}
+
+func (r *Recorder) play(
+	region, service, operation string,
+	input interface{},
+	output interface{},
+	call func() (interface{}, error),
+) error {
+	result, callErr := call()
+
+	interaction := Interaction{Region: region, Service: service, Operation: operation}
+
+	var err error
+	if interaction.Input, err = json.Marshal(input); err != nil {
+		return fmt.Errorf("failed to record %s.%s input: %v", service, operation, err)
+	}
+	if callErr != nil {
+		interaction.Error = &RecordedError{Message: callErr.Error()}
+		if coded, ok := callErr.(interface{ ErrorCode() string }); ok {
+			interaction.Error.Code = coded.ErrorCode()
+		}
+	} else if interaction.Output, err = json.Marshal(result); err != nil {
+		return fmt.Errorf("failed to record %s.%s output: %v", service, operation, err)
+	}
+
+	if err := r.save(&interaction); err != nil {
+		return err
+	}
+	if callErr != nil {
+		return callErr
+	}
+
+	// Hand the live result to the caller through the same decode replay uses
+	return json.Unmarshal(interaction.Output, output)
+}
+
+func (r *Recorder) save(interaction *Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sequence++
+	interaction.Sequence = r.sequence
+
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %v", err)
+	}
+
+	name := fmt.Sprintf("%04d-%s-%s.json", interaction.Sequence, strings.ToLower(interaction.Service), interaction.Operation)
+	if err := os.WriteFile(filepath.Join(r.dir, name), data, 0600); err != nil {
+		return fmt.Errorf("failed to write recording: %v", err)
+	}
+	return nil
+}
+
+// Replayer serves recorded responses to requests matching the recorded ones
+type Replayer struct {
+	mu        sync.Mutex
+	responses map[string][]Interaction // by request key, in recorded order
+}
+
+// Load reads the fixtures a Recorder wrote to dir
+func Load(dir string) (*Replayer, error) {
+	interactions, err := loadInteractions(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(interactions) == 0 {
+		return nil, fmt.Errorf("no recordings found in %s", dir)
+	}
+
+	replayer := &Replayer{responses: make(map[string][]Interaction)}
+	for _, interaction := range interactions {
+		key := requestKey(interaction.Region, interaction.Service, interaction.Operation, interaction.Input)
+		replayer.responses[key] = append(replayer.responses[key], interaction)
+	}
+	return replayer, nil
+}
+
+// APIs returns service clients that answer from the recording.
+// It matches aws.APIFactory.
+func (p *Replayer) APIs(region string) aws.ServiceAPIs {
+	return wrap(p, region, aws.ServiceAPIs{})
+}
+
+func (p *Replayer) play(
+	region, service, operation string,
+	input interface{},
+	output interface{},
+	call func() (interface{}, error),
+) error {
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s.%s input: %v", service, operation, err)
+	}
+
+	interaction, ok := p.next(requestKey(region, service, operation, encoded))
+	if !ok {
+		return fmt.Errorf("no recorded response for %s.%s in %s with input %s", service, operation, region, encoded)
+	}
+
+	if interaction.Error != nil {
+		return interaction.Error
+	}
+	return json.Unmarshal(interaction.Output, output)
+}
+
+// next returns the responses to a request in recorded order, repeating the
+// last one once they run out
+func (p *Replayer) next(key string) (Interaction, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	queue := p.responses[key]
+	if len(queue) == 0 {
+		return Interaction{}, false
+	}
+	if len(queue) > 1 {
+		p.responses[key] = queue[1:]
+	}
+	return queue[0], true
+}
+
+// requestKey identifies a request by where it went and what it asked for
+func requestKey(region, service, operation string, input []byte) string {
+	return region + "|" + service + "." + operation + "|" + string(compact(input))
+}
+
+// compact normalizes JSON whitespace, so hand-edited fixtures still match
+func compact(data []byte) []byte {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return data
+	}
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return data
+	}
+	return normalized
+}
+
+// loadInteractions reads every fixture in dir, in sequence order
+func loadInteractions(dir string) ([]Interaction, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recordings: %v", err)
+	}
+
+	var interactions []Interaction
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recording %s: %v", file, err)
+		}
+
+		var interaction Interaction
+		if err := json.Unmarshal(data, &interaction); err != nil {
+			return nil, fmt.Errorf("failed to parse recording %s: %v", file, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+
+	sort.SliceStable(interactions, func(i, j int) bool {
+		return interactions[i].Sequence < interactions[j].Sequence
+	})
+	return interactions, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"time"
 )
@@ -48,6 +49,8 @@ func (c *CSVReportGenerator) GenerateEC2Report(instances []aws.EC2Instance, file
 	header := []string{
 		"Instance ID",
 		"Name",
+		"Account",
+		"Region",
 		"Instance Type",
 		"State",
 		"Launch Time",
@@ -115,6 +118,8 @@ func (c *CSVReportGenerator) GenerateEC2Report(instances []aws.EC2Instance, file
 		row := []string{
 			instance.InstanceID,
 			instance.Name,
+			instance.Account,
+			instance.Region,
 			instance.InstanceType,
 			instance.State,
 			instance.LaunchTime.Format("2006-01-02 15:04:05"),
@@ -159,6 +164,7 @@ func (c *CSVReportGenerator) GenerateS3Report(buckets []aws.S3Bucket, filename s
 	// Write header
 	header := []string{
 		"Bucket Name",
+		"Account",
 		"Region",
 		"Creation Date",
 		"Size GB",
@@ -238,6 +244,7 @@ func (c *CSVReportGenerator) GenerateS3Report(buckets []aws.S3Bucket, filename s
 
 		row := []string{
 			bucket.Name,
+			bucket.Account,
 			bucket.Region,
 			bucket.CreationDate.Format("2006-01-02 15:04:05"),
 			fmt.Sprintf("%.2f", sizeGB),
@@ -287,6 +294,8 @@ func (c *CSVReportGenerator) GeneratePolicyExecutionReport(
 	header := []string{
 		"Policy Name",
 		"Resource Type",
+		"Account",
+		"Region",
 		"Execution Time",
 		"Start Time",
 		"End Time",
@@ -313,6 +322,8 @@ func (c *CSVReportGenerator) GeneratePolicyExecutionReport(
 		row := []string{
 			result.PolicyName,
 			result.ResourceType,
+			result.Account,
+			result.Region,
 			result.StartTime.Format("2006-01-02 15:04:05"),
 			result.StartTime.Format("2006-01-02 15:04:05"),
 			result.EndTime.Format("2006-01-02 15:04:05"),
@@ -361,6 +372,8 @@ func (c *CSVReportGenerator) GenerateActionDetailsReport(
 	header := []string{
 		"Policy Name",
 		"Resource Type",
+		"Account",
+		"Region",
 		"Resource ID",
 		"Action Type",
 		"Success",
@@ -391,6 +404,8 @@ func (c *CSVReportGenerator) GenerateActionDetailsReport(
 			row := []string{
 				result.PolicyName,
 				actionResult.ResourceType,
+				result.Account,
+				result.Region,
 				actionResult.ResourceID,
 				actionResult.Action,
 				boolToYesNo(actionResult.Success),
@@ -435,6 +450,8 @@ func (c *CSVReportGenerator) GenerateCostAnalysisReport(
 		"Resource Type",
 		"Resource ID",
 		"Resource Name",
+		"Account",
+		"Region",
 		"Current Monthly Cost $",
 		"Utilization %",
 		"Running Days",
@@ -473,6 +490,8 @@ func (c *CSVReportGenerator) GenerateCostAnalysisReport(
 			"EC2",
 			instance.InstanceID,
 			instance.Name,
+			instance.Account,
+			instance.Region,
 			fmt.Sprintf("%.2f", instance.MonthlyCost),
 			fmt.Sprintf("%.1f", instance.CPUUtilization),
 			strconv.Itoa(instance.RunningDays),
@@ -520,6 +539,8 @@ func (c *CSVReportGenerator) GenerateCostAnalysisReport(
 			"S3",
 			bucket.Name,
 			bucket.Name,
+			bucket.Account,
+			bucket.Region,
 			fmt.Sprintf("%.2f", bucket.MonthlyCostEstimate),
 			"N/A",
 			fmt.Sprintf("%.0f", time.Since(bucket.CreationDate).Hours()/24),
@@ -570,6 +591,8 @@ func (c *CSVReportGenerator) GenerateComplianceSummaryReport(
 		"High Risk Issues",
 		"Medium Risk Issues",
 		"Potential Monthly Savings $",
+		"Regions",
+		"Accounts",
 	}
 
 	if err := writer.Write(header); err != nil {
@@ -644,6 +667,8 @@ func (c *CSVReportGenerator) GenerateComplianceSummaryReport(
 		strconv.Itoa(ec2High),
 		strconv.Itoa(ec2Medium),
 		fmt.Sprintf("%.2f", ec2Savings),
+		joinStrings(regionsOf(ec2Instances, nil), "; "),
+		joinStrings(accountsOf(ec2Instances, nil), "; "),
 	}
 
 	if err := writer.Write(ec2Row); err != nil {
@@ -714,6 +739,8 @@ func (c *CSVReportGenerator) GenerateComplianceSummaryReport(
 		strconv.Itoa(s3High),
 		strconv.Itoa(s3Medium),
 		fmt.Sprintf("%.2f", s3Savings),
+		joinStrings(regionsOf(nil, s3Buckets), "; "),
+		joinStrings(accountsOf(nil, s3Buckets), "; "),
 	}
 
 	if err := writer.Write(s3Row); err != nil {
@@ -739,6 +766,8 @@ func (c *CSVReportGenerator) GenerateComplianceSummaryReport(
 		strconv.Itoa(ec2High + s3High),
 		strconv.Itoa(ec2Medium + s3Medium),
 		fmt.Sprintf("%.2f", ec2Savings+s3Savings),
+		joinStrings(regionsOf(ec2Instances, s3Buckets), "; "),
+		joinStrings(accountsOf(ec2Instances, s3Buckets), "; "),
 	}
 
 	if err := writer.Write(overallRow); err != nil {
@@ -762,6 +791,46 @@ func joinStrings(strs []string, separator string) string {
 	return result
 }
 
+// regionsOf lists the regions resources were found in, sorted
+func regionsOf(ec2Instances []aws.EC2Instance, s3Buckets []aws.S3Bucket) []string {
+	seen := make(map[string]bool)
+	var regions []string
+	add := func(region string) {
+		if region != "" && !seen[region] {
+			seen[region] = true
+			regions = append(regions, region)
+		}
+	}
+	for _, instance := range ec2Instances {
+		add(instance.Region)
+	}
+	for _, bucket := range s3Buckets {
+		add(bucket.Region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// accountsOf lists the accounts resources were found in, sorted
+func accountsOf(ec2Instances []aws.EC2Instance, s3Buckets []aws.S3Bucket) []string {
+	seen := make(map[string]bool)
+	var accounts []string
+	add := func(account string) {
+		if account != "" && !seen[account] {
+			seen[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+	for _, instance := range ec2Instances {
+		add(instance.Account)
+	}
+	for _, bucket := range s3Buckets {
+		add(bucket.Account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
 func boolToYesNo(b bool) string {
 	if b {
 		return "Yes"
@@ -0,0 +1,211 @@
+package reports
+
+import (
+	"custodian-killer/inventory"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// driftReport is what the drift template renders
+type driftReport struct {
+	Title       string
+	GeneratedAt time.Time
+	Diff        *inventory.Diff
+	NewCount    int
+}
+
+// SaveDriftReport saves an inventory diff as HTML
+func (h *HTMLReportGenerator) SaveDriftReport(diff *inventory.Diff, filename string) error {
+	fmt.Printf("💾 Saving HTML drift report: %s\n", filename)
+
+	fullPath := filepath.Join(h.outputDir, filename)
+
+	tmpl := `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 20px; background: #f5f5f5; }
+        .container { max-width: 1200px; margin: 0 auto; background: white; padding: 20px; border-radius: 8px; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
+        .header { text-align: center; border-bottom: 2px solid #007acc; padding-bottom: 20px; margin-bottom: 30px; }
+        .summary { display: grid; grid-template-columns: repeat(auto-fit, minmax(200px, 1fr)); gap: 20px; margin-bottom: 30px; }
+        .summary-card { background: #f8f9fa; padding: 20px; border-radius: 8px; text-align: center; border-left: 4px solid #007acc; }
+        .summary-card h3 { margin: 0 0 10px 0; color: #333; }
+        .summary-card .number { font-size: 2em; font-weight: bold; color: #007acc; }
+        .critical { border-left-color: #dc3545; }
+        .critical .number { color: #dc3545; }
+        .warning { border-left-color: #ffc107; }
+        .warning .number { color: #ffc107; }
+        .success { border-left-color: #28a745; }
+        .success .number { color: #28a745; }
+        .section { margin-bottom: 30px; }
+        .section h2 { color: #333; border-bottom: 1px solid #ddd; padding-bottom: 10px; }
+        .findings { display: grid; gap: 15px; }
+        .finding { background: #fff; border: 1px solid #ddd; border-radius: 8px; padding: 15px; }
+        .finding.critical { border-left: 4px solid #dc3545; }
+        .finding.low { border-left: 4px solid #6c757d; }
+        .finding h4 { margin: 0 0 10px 0; color: #333; }
+        .issues { list-style: none; padding: 0; }
+        .issues li { background: #f8f9fa; margin: 5px 0; padding: 5px 10px; border-radius: 4px; }
+        .cost-table { width: 100%; border-collapse: collapse; margin-top: 15px; }
+        .cost-table th, .cost-table td { border: 1px solid #ddd; padding: 10px; text-align: left; }
+        .cost-table th { background: #f8f9fa; }
+        .footer { text-align: center; margin-top: 40px; padding-top: 20px; border-top: 1px solid #ddd; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🦍 {{.Title}}</h1>
+            <p>{{.Diff.From.CollectedAt.Local.Format "January 2, 2006 at 3:04 PM"}} → {{.Diff.To.CollectedAt.Local.Format "January 2, 2006 at 3:04 PM"}}</p>
+            <p>Generated on {{.GeneratedAt.Format "January 2, 2006 at 3:04 PM"}}</p>
+        </div>
+
+        <div class="summary">
+            <div class="summary-card">
+                <h3>Added</h3>
+                <div class="number">{{len .Diff.Added}}</div>
+            </div>
+            <div class="summary-card">
+                <h3>Removed</h3>
+                <div class="number">{{len .Diff.Removed}}</div>
+            </div>
+            <div class="summary-card {{if .Diff.Changed}}warning{{else}}success{{end}}">
+                <h3>Changed</h3>
+                <div class="number">{{len .Diff.Changed}}</div>
+            </div>
+            <div class="summary-card {{if gt .NewCount 0}}critical{{else}}success{{end}}">
+                <h3>New Violations</h3>
+                <div class="number">{{.NewCount}}</div>
+            </div>
+        </div>
+
+        {{if .Diff.Violations}}
+        <div class="section">
+            <h2>🚨 Policy Violations ({{len .Diff.Violations}})</h2>
+            <table class="cost-table">
+                <tr>
+                    <th>Status</th>
+                    <th>Policy</th>
+                    <th>Resource</th>
+                    <th>Type</th>
+                </tr>
+                {{range .Diff.Violations}}
+                <tr>
+                    <td>{{if eq .Status "new"}}🔴 new{{if .Added}} (added resource){{end}}{{else}}🟢 resolved{{end}}</td>
+                    <td>{{.Policy}}</td>
+                    <td>{{.Resource.Name}} ({{.Resource.ID}})</td>
+                    <td>{{.Resource.ResourceType}}</td>
+                </tr>
+                {{end}}
+            </table>
+        </div>
+        {{end}}
+
+        {{if .Diff.Changed}}
+        <div class="section">
+            <h2>✏️ Changed Resources ({{len .Diff.Changed}})</h2>
+            <div class="findings">
+                {{range .Diff.Changed}}
+                <div class="finding {{if .NewIssues}}critical{{else}}low{{end}}">
+                    <h4>{{.Name}} ({{.ID}}) - {{.ResourceType}}</h4>
+                    <table class="cost-table">
+                        <tr>
+                            <th>Field</th>
+                            <th>Before</th>
+                            <th>After</th>
+                        </tr>
+                        {{range .Changes}}
+                        <tr>
+                            <td>{{.Field}}</td>
+                            <td>{{value .Before}}</td>
+                            <td>{{value .After}}</td>
+                        </tr>
+                        {{end}}
+                    </table>
+                    {{if .NewIssues}}
+                    <ul class="issues">
+                        {{range .NewIssues}}<li>🔴 {{.}}</li>{{end}}
+                    </ul>
+                    {{end}}
+                    {{if .ResolvedIssues}}
+                    <ul class="issues">
+                        {{range .ResolvedIssues}}<li>🟢 {{.}}</li>{{end}}
+                    </ul>
+                    {{end}}
+                </div>
+                {{end}}
+            </div>
+        </div>
+        {{end}}
+
+        {{if .Diff.Added}}
+        <div class="section">
+            <h2>➕ Added Resources ({{len .Diff.Added}})</h2>
+            <div class="findings">
+                {{range .Diff.Added}}
+                <div class="finding {{if .Issues}}critical{{else}}low{{end}}">
+                    <h4>{{.Name}} ({{.ID}}) - {{.ResourceType}}</h4>
+                    {{if .Issues}}
+                    <ul class="issues">
+                        {{range .Issues}}<li>{{.}}</li>{{end}}
+                    </ul>
+                    {{end}}
+                </div>
+                {{end}}
+            </div>
+        </div>
+        {{end}}
+
+        {{if .Diff.Removed}}
+        <div class="section">
+            <h2>➖ Removed Resources ({{len .Diff.Removed}})</h2>
+            <ul class="issues">
+                {{range .Diff.Removed}}<li>{{.Name}} ({{.ID}}) - {{.ResourceType}}</li>{{end}}
+            </ul>
+        </div>
+        {{end}}
+
+        <div class="footer">
+            <p>🦍 Generated by Custodian Killer - Making AWS compliance fun again!</p>
+        </div>
+    </div>
+</body>
+</html>`
+
+	t, err := template.New("drift").Funcs(template.FuncMap{
+		"value": func(v interface{}) string {
+			if v == nil {
+				return "—"
+			}
+			return fmt.Sprint(v)
+		},
+	}).Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %v", err)
+	}
+
+	file, err := os.Create(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	report := driftReport{
+		Title:       "Resource Drift Report",
+		GeneratedAt: time.Now(),
+		Diff:        diff,
+		NewCount:    len(diff.NewViolations()),
+	}
+	if err := t.Execute(file, report); err != nil {
+		return fmt.Errorf("failed to execute template: %v", err)
+	}
+
+	fmt.Printf("✅ HTML report saved: %s\n", fullPath)
+	return nil
+}
@@ -33,6 +33,8 @@ func NewHTMLReportGenerator(outputDir string) *HTMLReportGenerator {
 type ComplianceReport struct {
 	GeneratedAt     time.Time                `json:"generated_at"`
 	Title           string                   `json:"title"`
+	Regions         []string                 `json:"regions"`
+	Accounts        []string                 `json:"accounts,omitempty"`
 	Summary         ComplianceSummary        `json:"summary"`
 	EC2Findings     []EC2ComplianceFinding   `json:"ec2_findings"`
 	S3Findings      []S3ComplianceFinding    `json:"s3_findings"`
@@ -57,6 +59,8 @@ type ComplianceSummary struct {
 type EC2ComplianceFinding struct {
 	InstanceID     string            `json:"instance_id"`
 	Name           string            `json:"name"`
+	Account        string            `json:"account,omitempty"`
+	Region         string            `json:"region"`
 	InstanceType   string            `json:"instance_type"`
 	State          string            `json:"state"`
 	Issues         []string          `json:"issues"`
@@ -70,6 +74,8 @@ type EC2ComplianceFinding struct {
 // S3ComplianceFinding represents S3 compliance issues
 type S3ComplianceFinding struct {
 	BucketName    string   `json:"bucket_name"`
+	Account       string   `json:"account,omitempty"`
+	Region        string   `json:"region"`
 	Issues        []string `json:"issues"`
 	Severity      string   `json:"severity"`
 	PublicAccess  bool     `json:"public_access"`
@@ -106,6 +112,8 @@ type CostImpactSummary struct {
 type CostlyResource struct {
 	ResourceID     string  `json:"resource_id"`
 	ResourceType   string  `json:"resource_type"`
+	Account        string  `json:"account,omitempty"`
+	Region         string  `json:"region"`
 	MonthlyCost    float64 `json:"monthly_cost"`
 	Utilization    string  `json:"utilization"`
 	Recommendation string  `json:"recommendation"`
@@ -121,6 +129,8 @@ func (h *HTMLReportGenerator) GenerateComplianceReport(
 	report := &ComplianceReport{
 		GeneratedAt: time.Now(),
 		Title:       "Custodian Killer Compliance Report",
+		Regions:     regionsOf(ec2Instances, s3Buckets),
+		Accounts:    accountsOf(ec2Instances, s3Buckets),
 		Summary:     ComplianceSummary{},
 		CostImpact: CostImpactSummary{
 			CostByResourceType: make(map[string]float64),
@@ -161,6 +171,8 @@ func (h *HTMLReportGenerator) analyzeEC2Compliance(
 		finding := EC2ComplianceFinding{
 			InstanceID:     instance.InstanceID,
 			Name:           instance.Name,
+			Account:        instance.Account,
+			Region:         instance.Region,
 			InstanceType:   instance.InstanceType,
 			State:          instance.State,
 			Tags:           instance.Tags,
@@ -231,6 +243,8 @@ func (h *HTMLReportGenerator) analyzeS3Compliance(buckets []aws.S3Bucket) []S3Co
 	for _, bucket := range buckets {
 		finding := S3ComplianceFinding{
 			BucketName:    bucket.Name,
+			Account:       bucket.Account,
+			Region:        bucket.Region,
 			PublicAccess:  bucket.PublicReadACL || bucket.PublicWriteACL,
 			Encrypted:     bucket.Encryption.Enabled,
 			Versioning:    bucket.Versioning,
@@ -371,6 +385,8 @@ func (h *HTMLReportGenerator) calculateCostImpact(
 			summary.TopCostlyResources = append(summary.TopCostlyResources, CostlyResource{
 				ResourceID:     instance.InstanceID,
 				ResourceType:   "EC2",
+				Account:        instance.Account,
+				Region:         instance.Region,
 				MonthlyCost:    instance.MonthlyCost,
 				Utilization:    utilization,
 				Recommendation: recommendation,
@@ -542,6 +558,8 @@ func (h *HTMLReportGenerator) SaveHTMLReport(report *ComplianceReport, filename
         <div class="header">
             <h1>🦍 {{.Title}}</h1>
             <p>Generated on {{.GeneratedAt.Format "January 2, 2006 at 3:04 PM"}}</p>
+            {{if .Regions}}<p>Regions: {{join .Regions ", "}}</p>{{end}}
+            {{if .Accounts}}<p>Accounts: {{join .Accounts ", "}}</p>{{end}}
         </div>
 
         <div class="summary">
@@ -549,7 +567,7 @@ func (h *HTMLReportGenerator) SaveHTMLReport(report *ComplianceReport, filename
                 <h3>Critical Issues</h3>
                 <div class="number">{{.Summary.CriticalIssues}}</div>
             </div>
-            <div class="summary-card {{if lt .Summary.CompliancePercentage 80}}warning{{else}}success{{end}}">
+            <div class="summary-card {{if lt .Summary.CompliancePercentage 80.0}}warning{{else}}success{{end}}">
                 <h3>Compliance Score</h3>
                 <div class="number">{{printf "%.0f" .Summary.CompliancePercentage}}%</div>
             </div>
@@ -571,7 +589,7 @@ func (h *HTMLReportGenerator) SaveHTMLReport(report *ComplianceReport, filename
                 {{range .EC2Findings}}
                 <div class="finding {{.Severity}}">
                     <h4>{{.Name}} ({{.InstanceID}})</h4>
-                    <p><strong>Type:</strong> {{.InstanceType}} | <strong>State:</strong> {{.State}} | <strong>Cost:</strong> ${{printf "%.2f" .EstimatedCost}}/month</p>
+                    <p>{{if .Account}}<strong>Account:</strong> {{.Account}} | {{end}}<strong>Region:</strong> {{.Region}} | <strong>Type:</strong> {{.InstanceType}} | <strong>State:</strong> {{.State}} | <strong>Cost:</strong> ${{printf "%.2f" .EstimatedCost}}/month</p>
                     {{if .Issues}}
                     <ul class="issues">
                         {{range .Issues}}<li>{{.}}</li>{{end}}
@@ -590,7 +608,7 @@ func (h *HTMLReportGenerator) SaveHTMLReport(report *ComplianceReport, filename
                 {{range .S3Findings}}
                 <div class="finding {{.Severity}}">
                     <h4>{{.BucketName}}</h4>
-                    <p><strong>Security Score:</strong> {{.SecurityScore}}/100 | <strong>Size:</strong> {{printf "%.1f" .SizeGB}} GB | <strong>Cost:</strong> ${{printf "%.2f" .EstimatedCost}}/month</p>
+                    <p>{{if .Account}}<strong>Account:</strong> {{.Account}} | {{end}}<strong>Region:</strong> {{.Region}} | <strong>Security Score:</strong> {{.SecurityScore}}/100 | <strong>Size:</strong> {{printf "%.1f" .SizeGB}} GB | <strong>Cost:</strong> ${{printf "%.2f" .EstimatedCost}}/month</p>
                     {{if .Issues}}
                     <ul class="issues">
                         {{range .Issues}}<li>{{.}}</li>{{end}}
@@ -647,7 +665,7 @@ func (h *HTMLReportGenerator) SaveHTMLReport(report *ComplianceReport, filename
 </html>`
 
 	// Parse and execute template
-	t, err := template.New("report").Parse(tmpl)
+	t, err := template.New("report").Funcs(template.FuncMap{"join": strings.Join}).Parse(tmpl)
 	if err != nil {
 		return fmt.Errorf("failed to parse template: %v", err)
 	}
@@ -57,6 +57,8 @@ type ExecutionSummaryJSON struct {
 type PolicyExecutionResult struct {
 	PolicyName        string             `json:"policy_name"`
 	ResourceType      string             `json:"resource_type"`
+	Account           string             `json:"account,omitempty"`
+	Region            string             `json:"region,omitempty"`
 	Status            string             `json:"status"` // success, failed, partial
 	ExecutionTime     string             `json:"execution_time"`
 	ResourcesFound    int                `json:"resources_found"`
@@ -88,6 +90,8 @@ type ActionResultJSON struct {
 type ResourceChange struct {
 	ResourceID    string                 `json:"resource_id"`
 	ResourceType  string                 `json:"resource_type"`
+	Account       string                 `json:"account,omitempty"`
+	Region        string                 `json:"region,omitempty"`
 	ChangeType    string                 `json:"change_type"` // created, modified, deleted
 	PolicyName    string                 `json:"policy_name"`
 	Action        string                 `json:"action"`
@@ -169,6 +173,8 @@ func (j *JSONReportGenerator) GenerateExecutionReport(
 		policyResult := PolicyExecutionResult{
 			PolicyName:        result.PolicyName,
 			ResourceType:      result.ResourceType,
+			Account:           result.Account,
+			Region:            result.Region,
 			ExecutionTime:     result.Duration.String(),
 			ResourcesFound:    result.ResourcesFound,
 			ResourcesMatched:  result.ResourcesMatched,
@@ -211,6 +217,8 @@ func (j *JSONReportGenerator) GenerateExecutionReport(
 				change := ResourceChange{
 					ResourceID:   actionResult.ResourceID,
 					ResourceType: actionResult.ResourceType,
+					Account:      result.Account,
+					Region:       result.Region,
 					ChangeType:   "modified",
 					PolicyName:   result.PolicyName,
 					Action:       actionResult.Action,
@@ -289,7 +297,7 @@ func (j *JSONReportGenerator) generateCostImpact(results []ExecutionResult) Deta
 
 	for _, result := range results {
 		savings := result.Summary.EstimatedMonthlySavings
-		impact.CostByPolicy[result.PolicyName] = savings
+		impact.CostByPolicy[result.PolicyName] += savings // a policy run in several regions has several results
 
 		// Breakdown by resource type
 		switch result.ResourceType {
@@ -395,6 +403,8 @@ func (j *JSONReportGenerator) GenerateComplianceReportJSON(
 			"compliance_issues": 0,
 			"security_score":    0,
 			"estimated_savings": 0.0,
+			"regions":           regionsOf(ec2Instances, s3Buckets),
+			"accounts":          accountsOf(ec2Instances, s3Buckets),
 		},
 		"ec2_analysis":    j.analyzeEC2JSON(ec2Instances),
 		"s3_analysis":     j.analyzeS3JSON(s3Buckets),
@@ -423,6 +433,8 @@ func (j *JSONReportGenerator) analyzeEC2JSON(instances []aws.EC2Instance) map[st
 		"issues_found":    0,
 		"by_state":        make(map[string]int),
 		"by_type":         make(map[string]int),
+		"by_region":       make(map[string]int),
+		"by_account":      make(map[string]int),
 		"cost_analysis": map[string]interface{}{
 			"total_monthly_cost": 0.0,
 			"unused_cost":        0.0,
@@ -436,11 +448,17 @@ func (j *JSONReportGenerator) analyzeEC2JSON(instances []aws.EC2Instance) map[st
 
 	stateCount := make(map[string]int)
 	typeCount := make(map[string]int)
+	regionCount := make(map[string]int)
+	accountCount := make(map[string]int)
 
 	for _, instance := range instances {
-		// Count by state and type
+		// Count by state, type, region and account
 		stateCount[instance.State]++
 		typeCount[instance.InstanceType]++
+		regionCount[instance.Region]++
+		if instance.Account != "" {
+			accountCount[instance.Account]++
+		}
 
 		totalCost += instance.MonthlyCost
 
@@ -466,6 +484,8 @@ func (j *JSONReportGenerator) analyzeEC2JSON(instances []aws.EC2Instance) map[st
 			issue := map[string]interface{}{
 				"instance_id":     instance.InstanceID,
 				"name":            instance.Name,
+				"account":         instance.Account,
+				"region":          instance.Region,
 				"instance_type":   instance.InstanceType,
 				"state":           instance.State,
 				"issues":          issues,
@@ -483,6 +503,8 @@ func (j *JSONReportGenerator) analyzeEC2JSON(instances []aws.EC2Instance) map[st
 	analysis["issues_found"] = issuesFound
 	analysis["by_state"] = stateCount
 	analysis["by_type"] = typeCount
+	analysis["by_region"] = regionCount
+	analysis["by_account"] = accountCount
 
 	costAnalysis := analysis["cost_analysis"].(map[string]interface{})
 	costAnalysis["total_monthly_cost"] = totalCost
@@ -496,6 +518,8 @@ func (j *JSONReportGenerator) analyzeS3JSON(buckets []aws.S3Bucket) map[string]i
 	analysis := map[string]interface{}{
 		"total_buckets": len(buckets),
 		"issues_found":  0,
+		"by_region":     make(map[string]int),
+		"by_account":    make(map[string]int),
 		"security_analysis": map[string]interface{}{
 			"public_buckets":         0,
 			"unencrypted_buckets":    0,
@@ -508,8 +532,14 @@ func (j *JSONReportGenerator) analyzeS3JSON(buckets []aws.S3Bucket) map[string]i
 	unencryptedBuckets := 0
 	totalSecurityScore := 0
 	issuesFound := 0
+	regionCount := make(map[string]int)
+	accountCount := make(map[string]int)
 
 	for _, bucket := range buckets {
+		regionCount[bucket.Region]++
+		if bucket.Account != "" {
+			accountCount[bucket.Account]++
+		}
 		issues := []string{}
 		severity := "low"
 
@@ -543,6 +573,8 @@ func (j *JSONReportGenerator) analyzeS3JSON(buckets []aws.S3Bucket) map[string]i
 			issuesFound++
 			issue := map[string]interface{}{
 				"bucket_name":    bucket.Name,
+				"account":        bucket.Account,
+				"region":         bucket.Region,
 				"issues":         issues,
 				"severity":       severity,
 				"security_score": bucket.SecurityScore,
@@ -559,6 +591,8 @@ func (j *JSONReportGenerator) analyzeS3JSON(buckets []aws.S3Bucket) map[string]i
 	}
 
 	analysis["issues_found"] = issuesFound
+	analysis["by_region"] = regionCount
+	analysis["by_account"] = accountCount
 
 	securityAnalysis := analysis["security_analysis"].(map[string]interface{})
 	securityAnalysis["public_buckets"] = publicBuckets
@@ -606,6 +640,8 @@ func (j *JSONReportGenerator) analyzeCostJSON(
 			opportunity := map[string]interface{}{
 				"resource_id":      instance.InstanceID,
 				"resource_type":    "ec2",
+				"account":          instance.Account,
+				"region":           instance.Region,
 				"current_cost":     instance.MonthlyCost,
 				"potential_saving": instance.MonthlyCost,
 				"recommendation":   "Stop or terminate unused instance",
@@ -0,0 +1,194 @@
+package reports
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// OrgReport rolls up scans or executions from many accounts into one
+// organization-wide report
+type OrgReport struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Kind        string         `json:"kind"` // scan or execution
+	Summary     OrgSummary     `json:"summary"`
+	Accounts    []OrgAccount   `json:"accounts"`
+	Results     []OrgPolicyRun `json:"results"`
+}
+
+// OrgSummary adds up every account in an OrgReport
+type OrgSummary struct {
+	Accounts       int `json:"accounts"`
+	AccountsFailed int `json:"accounts_failed"` // accounts that couldn't be reached
+	OrgTotals
+}
+
+// OrgTotals adds up policy runs
+type OrgTotals struct {
+	Runs                    int     `json:"runs"` // one per policy, account and region
+	RunsFailed              int     `json:"runs_failed"`
+	ResourcesFound          int     `json:"resources_found"`
+	ResourcesMatched        int     `json:"resources_matched"`
+	SuccessfulActions       int     `json:"successful_actions"`
+	FailedActions           int     `json:"failed_actions"`
+	EstimatedMonthlySavings float64 `json:"estimated_monthly_savings"`
+}
+
+// OrgAccount is one account's share of an OrgReport
+type OrgAccount struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Regions []string  `json:"regions,omitempty"`
+	Error   string    `json:"error,omitempty"` // why the account couldn't be reached
+	Totals  OrgTotals `json:"totals"`
+}
+
+// OrgPolicyRun is one policy's scan or execution in one account and region
+type OrgPolicyRun struct {
+	Account                 string  `json:"account"`
+	AccountName             string  `json:"account_name"`
+	Region                  string  `json:"region,omitempty"`
+	PolicyName              string  `json:"policy_name"`
+	ResourceType            string  `json:"resource_type"`
+	Success                 bool    `json:"success"`
+	ResourcesFound          int     `json:"resources_found"`
+	ResourcesMatched        int     `json:"resources_matched"`
+	SuccessfulActions       int     `json:"successful_actions"`
+	FailedActions           int     `json:"failed_actions"`
+	EstimatedMonthlySavings float64 `json:"estimated_monthly_savings"`
+	Error                   string  `json:"error,omitempty"`
+}
+
+// NewOrgReport creates an empty report of the given kind
+func NewOrgReport(kind string) *OrgReport {
+	return &OrgReport{GeneratedAt: time.Now(), Kind: kind}
+}
+
+// AddAccount records an account the run covered, with the error that kept it
+// from running when there was one
+func (r *OrgReport) AddAccount(id, name string, regions []string, err error) {
+	account := OrgAccount{ID: id, Name: name, Regions: regions}
+	r.Summary.Accounts++
+	if err != nil {
+		account.Error = err.Error()
+		r.Summary.AccountsFailed++
+	}
+	r.Accounts = append(r.Accounts, account)
+}
+
+// Add records a run and counts it in its account's totals and the report's.
+// The account must have been added first.
+func (r *OrgReport) Add(run OrgPolicyRun) {
+	r.Results = append(r.Results, run)
+	r.Summary.addRun(run)
+	for i := range r.Accounts {
+		if r.Accounts[i].ID == run.Account {
+			r.Accounts[i].Totals.addRun(run)
+		}
+	}
+}
+
+func (t *OrgTotals) addRun(run OrgPolicyRun) {
+	t.Runs++
+	if !run.Success {
+		t.RunsFailed++
+	}
+	t.ResourcesFound += run.ResourcesFound
+	t.ResourcesMatched += run.ResourcesMatched
+	t.SuccessfulActions += run.SuccessfulActions
+	t.FailedActions += run.FailedActions
+	t.EstimatedMonthlySavings += run.EstimatedMonthlySavings
+}
+
+// Sort orders accounts by name and results by account, policy and region
+func (r *OrgReport) Sort() {
+	sort.SliceStable(r.Accounts, func(i, j int) bool {
+		return r.Accounts[i].Name < r.Accounts[j].Name
+	})
+	order := make(map[string]int, len(r.Accounts))
+	for i, account := range r.Accounts {
+		order[account.ID] = i
+	}
+	sort.SliceStable(r.Results, func(i, j int) bool {
+		a, b := r.Results[i], r.Results[j]
+		if order[a.Account] != order[b.Account] {
+			return order[a.Account] < order[b.Account]
+		}
+		if a.PolicyName != b.PolicyName {
+			return a.PolicyName < b.PolicyName
+		}
+		return a.Region < b.Region
+	})
+}
+
+// GenerateOrgReport writes an OrgReport as CSV, one row per policy run plus a
+// row for each account that couldn't be reached
+func (c *CSVReportGenerator) GenerateOrgReport(report *OrgReport, filename string) error {
+	fmt.Printf("📝 Generating organization CSV report: %s\n", filename)
+
+	fullPath := filepath.Join(c.outputDir, filename)
+
+	file, err := os.Create(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{
+		"Account",
+		"Account Name",
+		"Region",
+		"Policy Name",
+		"Resource Type",
+		"Success",
+		"Resources Found",
+		"Resources Matched",
+		"Successful Actions",
+		"Failed Actions",
+		"Estimated Monthly Savings $",
+		"Error",
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %v", err)
+	}
+
+	for _, account := range report.Accounts {
+		if account.Error == "" {
+			continue
+		}
+		row := []string{account.ID, account.Name, "", "", "", "No", "0", "0", "0", "0", "0.00", account.Error}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %v", err)
+		}
+	}
+
+	for _, run := range report.Results {
+		row := []string{
+			run.Account,
+			run.AccountName,
+			run.Region,
+			run.PolicyName,
+			run.ResourceType,
+			boolToYesNo(run.Success),
+			strconv.Itoa(run.ResourcesFound),
+			strconv.Itoa(run.ResourcesMatched),
+			strconv.Itoa(run.SuccessfulActions),
+			strconv.Itoa(run.FailedActions),
+			fmt.Sprintf("%.2f", run.EstimatedMonthlySavings),
+			run.Error,
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %v", err)
+		}
+	}
+
+	fmt.Printf("✅ Organization CSV report generated: %s\n", fullPath)
+	return nil
+}
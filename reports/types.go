@@ -11,6 +11,8 @@ type ExecutionResult struct {
 	EndTime          time.Time        `json:"end_time"`
 	Duration         time.Duration    `json:"duration"`
 	ResourceType     string           `json:"resource_type"`
+	Region           string           `json:"region,omitempty"`
+	Account          string           `json:"account,omitempty"`
 	DryRun           bool             `json:"dry_run"`
 	Success          bool             `json:"success"`
 	ResourcesFound   int              `json:"resources_found"`
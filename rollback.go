@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"custodian-killer/aws"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PriorState is what a reversible action changed on one resource, captured
+// before the action ran so the run can be rolled back
+type PriorState struct {
+	InstanceState     string                 `json:"instance_state,omitempty"`
+	Tags              map[string]string      `json:"tags,omitempty"`       // values to restore
+	AddedTags         []string               `json:"added_tags,omitempty"` // keys the action created
+	SetTags           map[string]string      `json:"set_tags,omitempty"`   // values the action wrote
+	PublicAccessBlock *aws.PublicAccessBlock `json:"public_access_block,omitempty"`
+	Versioning        string                 `json:"versioning,omitempty"`
+}
+
+// captureEC2PriorState records the state an EC2 action is about to change.
+// Actions that can't be undone get no entry.
+func captureEC2PriorState(
+	instances []aws.EC2Instance,
+	actionType string,
+	tags map[string]string,
+) map[string]*PriorState {
+	priors := make(map[string]*PriorState)
+	for _, instance := range instances {
+		switch actionType {
+		case "stop":
+			priors[instance.InstanceID] = &PriorState{InstanceState: instance.State}
+		case "tag", "mark-for-op":
+			// CreateTags merges, so only the keys the action sets need restoring
+			prior := &PriorState{Tags: make(map[string]string), SetTags: copyTags(tags)}
+			for key := range tags {
+				if value, exists := instance.Tags[key]; exists {
+					prior.Tags[key] = value
+				} else {
+					prior.AddedTags = append(prior.AddedTags, key)
+				}
+			}
+			priors[instance.InstanceID] = prior
+		}
+	}
+	return priors
+}
+
+// captureS3PriorState records the state an S3 action is about to change.
+// Actions that can't be undone get no entry.
+func captureS3PriorState(
+	buckets []aws.S3Bucket,
+	actionType string,
+	tags map[string]string,
+) map[string]*PriorState {
+	priors := make(map[string]*PriorState)
+	for _, bucket := range buckets {
+		switch actionType {
+		case "block-public-access":
+			priors[bucket.Name] = &PriorState{PublicAccessBlock: &aws.PublicAccessBlock{
+				BlockPublicACLs:       bucket.BlockPublicACLs,
+				BlockPublicPolicy:     bucket.BlockPublicPolicy,
+				IgnorePublicACLs:      bucket.IgnorePublicACLs,
+				RestrictPublicBuckets: bucket.RestrictPublicBuckets,
+			}}
+		case "enable-versioning":
+			priors[bucket.Name] = &PriorState{Versioning: bucket.Versioning}
+		case "tag", "mark-for-op":
+			// PutBucketTagging replaces the whole tag set, so keep all of it
+			prior := &PriorState{Tags: make(map[string]string), SetTags: copyTags(tags)}
+			for key, value := range bucket.Tags {
+				prior.Tags[key] = value
+			}
+			for key := range tags {
+				if _, exists := bucket.Tags[key]; !exists {
+					prior.AddedTags = append(prior.AddedTags, key)
+				}
+			}
+			priors[bucket.Name] = prior
+		}
+	}
+	return priors
+}
+
+// copyTags copies the tags an action is about to write
+func copyTags(tags map[string]string) map[string]string {
+	copied := make(map[string]string, len(tags))
+	for key, value := range tags {
+		copied[key] = value
+	}
+	return copied
+}
+
+// RollbackRun undoes a run's reversible actions, newest first, and reports
+// each step as an action result. Actions without captured prior state, like
+// terminate or delete, and resources changed since the run are listed as
+// errors. Cancelling ctx stops before the next action.
+func RollbackRun(ctx context.Context, client *aws.CustodianClient, record *RunRecord) *ExecutionResult {
+	startTime := time.Now()
+	result := &ExecutionResult{
+		RunID:         newRunID(startTime),
+		PolicyName:    record.PolicyName,
+		StartTime:     startTime,
+		ResourceType:  record.Result.ResourceType,
+		Region:        client.Region,
+		Account:       client.Account,
+		DryRun:        client.DryRun,
+		ActionResults: make([]ActionResult, 0),
+		Errors:        make([]string, 0),
+		CostImpact:    CostImpact{Currency: "USD"},
+	}
+
+	actions := record.Result.ActionResults
+	for i := len(actions) - 1; i >= 0; i-- {
+		action := actions[i]
+		if !action.Success || action.DryRun {
+			continue
+		}
+		if noteStop(ctx, result) {
+			break
+		}
+		if action.Prior == nil {
+			result.Errors = append(result.Errors, fmt.Sprintf(
+				"%s on %s can't be rolled back: no prior state was recorded", action.Action, action.ResourceID,
+			))
+			continue
+		}
+
+		result.ResourcesFound++
+		drift, err := rollbackDrift(ctx, client, action)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf(
+				"%s on %s: failed to check it before rolling back: %v", action.Action, action.ResourceID, err,
+			))
+			continue
+		}
+		if drift != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf(
+				"%s on %s wasn't rolled back: %s since the run", action.Action, action.ResourceID, drift,
+			))
+			continue
+		}
+
+		result.ResourcesMatched++
+		actionStart := time.Now()
+		message, err := rollbackAction(ctx, client, action)
+
+		rollback := ActionResult{
+			Action:        "rollback-" + action.Action,
+			ResourceID:    action.ResourceID,
+			ResourceType:  action.ResourceType,
+			Success:       err == nil,
+			DryRun:        client.DryRun,
+			Message:       message,
+			Timestamp:     time.Now(),
+			ExecutionTime: time.Since(actionStart),
+		}
+		if err != nil {
+			rollback.Message = fmt.Sprintf("Failed: %v", err)
+			result.Errors = append(result.Errors, fmt.Sprintf("%s on %s: %v", rollback.Action, action.ResourceID, err))
+		}
+		result.ActionResults = append(result.ActionResults, rollback)
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	result.Success = len(result.Errors) == 0
+	for _, action := range result.ActionResults {
+		result.Summary.TotalActions++
+		if action.Success {
+			result.Summary.SuccessfulActions++
+			if !action.DryRun {
+				result.Summary.ResourcesModified++
+			}
+		} else {
+			result.Summary.FailedActions++
+		}
+	}
+	result.ActionsExecuted = result.Summary.TotalActions
+
+	return result
+}
+
+// rollbackDrift describes how a resource changed after the action ran, or
+// returns "" while it is still as the action left it. Rolling back a changed
+// resource would also undo whoever changed it.
+func rollbackDrift(ctx context.Context, client *aws.CustodianClient, action ActionResult) (string, error) {
+	id := action.ResourceID
+
+	switch action.ResourceType {
+	case "ec2":
+		instances, err := client.GetEC2Instances(ctx, aws.EC2Filter{InstanceIDs: []string{id}})
+		if err != nil {
+			return "", err
+		}
+		if len(instances) == 0 {
+			return "it no longer exists", nil
+		}
+		instance := instances[0]
+
+		switch action.Action {
+		case "stop":
+			if instance.State != "stopped" && instance.State != "stopping" {
+				return fmt.Sprintf("it became %s", instance.State), nil
+			}
+		case "tag", "mark-for-op":
+			return tagDrift(instance.Tags, action.Prior.SetTags), nil
+		}
+
+	case "s3":
+		buckets, err := client.GetS3Buckets(ctx, aws.S3Filter{BucketNames: []string{id}})
+		if err != nil {
+			return "", err
+		}
+		if len(buckets) == 0 {
+			return "it no longer exists", nil
+		}
+		bucket := buckets[0]
+
+		switch action.Action {
+		case "block-public-access":
+			if !bucket.BlockPublicACLs || !bucket.BlockPublicPolicy ||
+				!bucket.IgnorePublicACLs || !bucket.RestrictPublicBuckets {
+				return "its public access block was loosened", nil
+			}
+		case "enable-versioning":
+			if bucket.Versioning != "Enabled" {
+				return fmt.Sprintf("its versioning became %s", bucket.Versioning), nil
+			}
+		case "tag", "mark-for-op":
+			return tagDrift(bucket.Tags, action.Prior.SetTags), nil
+		}
+	}
+
+	return "", nil
+}
+
+// tagDrift describes the tags that no longer have the values an action wrote.
+// Runs journaled before the values were recorded can't be checked.
+func tagDrift(current, set map[string]string) string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var changed []string
+	for _, key := range keys {
+		value, exists := current[key]
+		switch {
+		case !exists:
+			changed = append(changed, fmt.Sprintf("tag %s was removed", key))
+		case value != set[key]:
+			changed = append(changed, fmt.Sprintf("tag %s became '%s'", key, value))
+		}
+	}
+	return strings.Join(changed, ", ")
+}
+
+// rollbackAction restores one resource to its prior state
+func rollbackAction(ctx context.Context, client *aws.CustodianClient, action ActionResult) (string, error) {
+	prior := action.Prior
+	id := action.ResourceID
+
+	switch action.ResourceType + ":" + action.Action {
+	case "ec2:stop":
+		if prior.InstanceState != "running" {
+			return fmt.Sprintf("left %s: it was %s before the run", id, prior.InstanceState), nil
+		}
+		if _, err := client.StartInstances(ctx, []string{id}); err != nil {
+			return "", err
+		}
+		return describeRollback(client.DryRun, "started instance again"), nil
+
+	case "ec2:tag", "ec2:mark-for-op":
+		if _, err := client.TagInstances(ctx, []string{id}, prior.Tags); err != nil {
+			return "", err
+		}
+		if _, err := client.UntagInstances(ctx, []string{id}, prior.AddedTags); err != nil {
+			return "", err
+		}
+		return describeRollback(client.DryRun, tagRollbackSummary(prior)), nil
+
+	case "s3:block-public-access":
+		if prior.PublicAccessBlock == nil {
+			return "", fmt.Errorf("no prior public access block recorded")
+		}
+		return s3Rollback(client.DryRun, id, func() (*aws.S3ActionResult, error) {
+			return client.SetPublicAccessBlock(ctx, id, *prior.PublicAccessBlock)
+		}, "restored public access block")
+
+	case "s3:enable-versioning":
+		if prior.Versioning == "Enabled" {
+			return "left versioning enabled: it was enabled before the run", nil
+		}
+		return s3Rollback(client.DryRun, id, func() (*aws.S3ActionResult, error) {
+			return client.SuspendVersioning(ctx, []string{id})
+		}, "suspended versioning")
+
+	case "s3:tag", "s3:mark-for-op":
+		return s3Rollback(client.DryRun, id, func() (*aws.S3ActionResult, error) {
+			return client.RestoreBucketTags(ctx, id, prior.Tags, prior.AddedTags)
+		}, tagRollbackSummary(prior))
+
+	default:
+		return "", fmt.Errorf("don't know how to roll back %s on %s", action.Action, action.ResourceType)
+	}
+}
+
+// s3Rollback runs a bucket call, turning a failed bucket result into an error
+func s3Rollback(
+	dryRun bool,
+	bucketName string,
+	call func() (*aws.S3ActionResult, error),
+	done string,
+) (string, error) {
+	awsResult, err := call()
+	if err != nil {
+		return "", err
+	}
+	if message := awsResult.Results[bucketName]; strings.HasPrefix(message, "failed") {
+		return "", fmt.Errorf("%s", message)
+	}
+	return describeRollback(dryRun, done), nil
+}
+
+func describeRollback(dryRun bool, done string) string {
+	if dryRun {
+		return "Would roll back: " + done
+	}
+	return strings.ToUpper(done[:1]) + done[1:]
+}
+
+func tagRollbackSummary(prior *PriorState) string {
+	var parts []string
+	if len(prior.Tags) > 0 {
+		parts = append(parts, fmt.Sprintf("restored %d tags", len(prior.Tags)))
+	}
+	if len(prior.AddedTags) > 0 {
+		parts = append(parts, fmt.Sprintf("removed %s", strings.Join(prior.AddedTags, ", ")))
+	}
+	if len(parts) == 0 {
+		return "left tags unchanged"
+	}
+	return strings.Join(parts, " and ")
+}
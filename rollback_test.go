@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"custodian-killer/aws"
+	"custodian-killer/fakecloud"
+	"custodian-killer/storage"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// failingEC2 fails StartInstances for some instances, as a partial outage would
+type failingEC2 struct {
+	aws.EC2API
+	fail map[string]bool
+}
+
+func (f failingEC2) StartInstances(
+	ctx context.Context,
+	params *ec2.StartInstancesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.StartInstancesOutput, error) {
+	for _, id := range params.InstanceIds {
+		if f.fail[id] {
+			return nil, errors.New("instance limit exceeded")
+		}
+	}
+	return f.EC2API.StartInstances(ctx, params, optFns...)
+}
+
+// rollbackClient returns a client for cloud whose starts fail for failStart
+func rollbackClient(t *testing.T, cloud *fakecloud.Cloud, dryRun bool, failStart ...string) *aws.CustodianClient {
+	t.Helper()
+	fail := make(map[string]bool)
+	for _, id := range failStart {
+		fail[id] = true
+	}
+	factory := func(region string) aws.ServiceAPIs {
+		apis := cloud.APIs(region)
+		apis.EC2 = failingEC2{EC2API: apis.EC2, fail: fail}
+		return apis
+	}
+	client, err := aws.NewCustodianClientWithAPIs(factory, aws.ClientConfig{Region: "us-east-1", DryRun: dryRun})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func done(action, resourceType, id string, prior *PriorState) ActionResult {
+	return ActionResult{Action: action, ResourceType: resourceType, ResourceID: id, Success: true, Prior: prior}
+}
+
+func TestRollbackRun(t *testing.T) {
+	instance := func(id, state string, tags map[string]string) fakecloud.Instance {
+		return fakecloud.Instance{InstanceID: id, InstanceType: "t3.micro", State: state, Tags: tags}
+	}
+	blocked := &fakecloud.PublicAccessBlock{
+		BlockPublicACLs: true, BlockPublicPolicy: true, IgnorePublicACLs: true, RestrictPublicBuckets: true,
+	}
+
+	tests := []struct {
+		name          string
+		seed          fakecloud.Seed
+		actions       []ActionResult // the run's actions, oldest first
+		dryRun        bool
+		failStart     []string
+		wantResults   []string // "action resource ok|failed", newest first
+		wantErrors    []string // substrings, one per error
+		wantInstances map[string]fakecloud.Instance
+		wantBuckets   map[string]fakecloud.Bucket
+	}{
+		{
+			name: "instance state and tags",
+			seed: fakecloud.Seed{Instances: []fakecloud.Instance{
+				instance("i-1", "stopped", map[string]string{"Env": "dev", "Owner": "cleanup", "Marked": "yes"}),
+				instance("i-2", "stopped", nil),
+			}},
+			actions: []ActionResult{
+				done("stop", "ec2", "i-1", &PriorState{InstanceState: "running"}),
+				done("stop", "ec2", "i-2", &PriorState{InstanceState: "stopped"}),
+				done("tag", "ec2", "i-1", &PriorState{
+					Tags:      map[string]string{"Owner": "alice"},
+					AddedTags: []string{"Marked"},
+					SetTags:   map[string]string{"Owner": "cleanup", "Marked": "yes"},
+				}),
+			},
+			wantResults: []string{"rollback-tag i-1 ok", "rollback-stop i-2 ok", "rollback-stop i-1 ok"},
+			wantInstances: map[string]fakecloud.Instance{
+				"i-1": instance("i-1", "running", map[string]string{"Env": "dev", "Owner": "alice"}),
+				"i-2": instance("i-2", "stopped", nil),
+			},
+		},
+		{
+			name: "bucket settings and tags",
+			seed: fakecloud.Seed{Buckets: []fakecloud.Bucket{{
+				Name: "logs", PublicAccessBlock: blocked, Versioning: "Enabled",
+				Tags: map[string]string{"Team": "platform", "Marked": "yes"},
+			}}},
+			actions: []ActionResult{
+				done("block-public-access", "s3", "logs", &PriorState{PublicAccessBlock: &aws.PublicAccessBlock{BlockPublicPolicy: true}}),
+				done("enable-versioning", "s3", "logs", &PriorState{Versioning: "Suspended"}),
+				done("tag", "s3", "logs", &PriorState{
+					Tags:      map[string]string{"Team": "data"},
+					AddedTags: []string{"Marked"},
+					SetTags:   map[string]string{"Team": "platform", "Marked": "yes"},
+				}),
+			},
+			wantResults: []string{
+				"rollback-tag logs ok", "rollback-enable-versioning logs ok", "rollback-block-public-access logs ok",
+			},
+			wantBuckets: map[string]fakecloud.Bucket{"logs": {
+				Name: "logs", PublicAccessBlock: &fakecloud.PublicAccessBlock{BlockPublicPolicy: true},
+				Versioning: "Suspended", Tags: map[string]string{"Team": "data"},
+			}},
+		},
+		{
+			name: "dry run changes nothing",
+			seed: fakecloud.Seed{Instances: []fakecloud.Instance{
+				instance("i-1", "stopped", map[string]string{"Owner": "cleanup"}),
+			}},
+			actions: []ActionResult{
+				done("stop", "ec2", "i-1", &PriorState{InstanceState: "running"}),
+				done("tag", "ec2", "i-1", &PriorState{
+					Tags: map[string]string{"Owner": "alice"}, SetTags: map[string]string{"Owner": "cleanup"},
+				}),
+			},
+			dryRun:      true,
+			wantResults: []string{"rollback-tag i-1 ok", "rollback-stop i-1 ok"},
+			wantInstances: map[string]fakecloud.Instance{
+				"i-1": instance("i-1", "stopped", map[string]string{"Owner": "cleanup"}),
+			},
+		},
+		{
+			name: "partial failure",
+			seed: fakecloud.Seed{Instances: []fakecloud.Instance{
+				instance("i-1", "stopped", nil),
+				instance("i-2", "stopped", nil),
+				instance("i-3", "stopped", nil),
+			}},
+			actions: []ActionResult{
+				done("stop", "ec2", "i-1", &PriorState{InstanceState: "running"}),
+				done("stop", "ec2", "i-2", &PriorState{InstanceState: "running"}),
+				done("stop", "ec2", "i-3", &PriorState{InstanceState: "running"}),
+			},
+			failStart:   []string{"i-2"},
+			wantResults: []string{"rollback-stop i-3 ok", "rollback-stop i-2 failed", "rollback-stop i-1 ok"},
+			wantErrors:  []string{"rollback-stop on i-2: failed to start instances: instance limit exceeded"},
+			wantInstances: map[string]fakecloud.Instance{
+				"i-1": instance("i-1", "running", nil),
+				"i-2": instance("i-2", "stopped", nil),
+				"i-3": instance("i-3", "running", nil),
+			},
+		},
+		{
+			name: "actions that can't be rolled back",
+			seed: fakecloud.Seed{Instances: []fakecloud.Instance{
+				instance("i-1", "terminated", nil),
+				instance("i-2", "stopped", nil),
+			}},
+			actions: []ActionResult{
+				done("terminate", "ec2", "i-1", nil),
+				{Action: "stop", ResourceType: "ec2", ResourceID: "i-2", Success: true, DryRun: true,
+					Prior: &PriorState{InstanceState: "running"}},
+				{Action: "stop", ResourceType: "ec2", ResourceID: "i-2", Success: false},
+			},
+			wantErrors: []string{"terminate on i-1 can't be rolled back: no prior state was recorded"},
+			wantInstances: map[string]fakecloud.Instance{
+				"i-1": instance("i-1", "terminated", nil),
+				"i-2": instance("i-2", "stopped", nil),
+			},
+		},
+		{
+			name: "drifted resources are left alone",
+			seed: fakecloud.Seed{
+				Instances: []fakecloud.Instance{
+					instance("i-restarted", "running", nil),
+					instance("i-retagged", "stopped", map[string]string{"Owner": "bob"}),
+					instance("i-untagged", "stopped", nil),
+					instance("i-terminated", "terminated", nil),
+					instance("i-unchanged", "stopped", map[string]string{"Owner": "cleanup"}),
+				},
+				Buckets: []fakecloud.Bucket{
+					{Name: "loosened", PublicAccessBlock: &fakecloud.PublicAccessBlock{BlockPublicACLs: true}},
+					{Name: "suspended", Versioning: "Suspended"},
+				},
+			},
+			actions: []ActionResult{
+				done("stop", "ec2", "i-restarted", &PriorState{InstanceState: "running"}),
+				done("tag", "ec2", "i-retagged", &PriorState{
+					Tags: map[string]string{"Owner": "alice"}, SetTags: map[string]string{"Owner": "cleanup"},
+				}),
+				done("tag", "ec2", "i-untagged", &PriorState{
+					AddedTags: []string{"Owner"}, SetTags: map[string]string{"Owner": "cleanup"},
+				}),
+				done("stop", "ec2", "i-terminated", &PriorState{InstanceState: "running"}),
+				done("block-public-access", "s3", "loosened", &PriorState{PublicAccessBlock: &aws.PublicAccessBlock{}}),
+				done("enable-versioning", "s3", "suspended", &PriorState{Versioning: ""}),
+				done("tag", "ec2", "i-unchanged", &PriorState{
+					Tags: map[string]string{"Owner": "alice"}, SetTags: map[string]string{"Owner": "cleanup"},
+				}),
+			},
+			wantResults: []string{"rollback-tag i-unchanged ok"},
+			wantErrors: []string{
+				"enable-versioning on suspended wasn't rolled back: its versioning became Suspended since the run",
+				"block-public-access on loosened wasn't rolled back: its public access block was loosened since the run",
+				"stop on i-terminated wasn't rolled back: it became terminated since the run",
+				"tag on i-untagged wasn't rolled back: tag Owner was removed since the run",
+				"tag on i-retagged wasn't rolled back: tag Owner became 'bob' since the run",
+				"stop on i-restarted wasn't rolled back: it became running since the run",
+			},
+			wantInstances: map[string]fakecloud.Instance{
+				"i-restarted":  instance("i-restarted", "running", nil),
+				"i-retagged":   instance("i-retagged", "stopped", map[string]string{"Owner": "bob"}),
+				"i-untagged":   instance("i-untagged", "stopped", nil),
+				"i-terminated": instance("i-terminated", "terminated", nil),
+				"i-unchanged":  instance("i-unchanged", "stopped", map[string]string{"Owner": "alice"}),
+			},
+			wantBuckets: map[string]fakecloud.Bucket{
+				"loosened":  {Name: "loosened", PublicAccessBlock: &fakecloud.PublicAccessBlock{BlockPublicACLs: true}},
+				"suspended": {Name: "suspended", Versioning: "Suspended"},
+			},
+		},
+		{
+			name: "runs recorded without the values they wrote",
+			seed: fakecloud.Seed{Instances: []fakecloud.Instance{
+				instance("i-1", "stopped", map[string]string{"Owner": "bob"}),
+			}},
+			actions: []ActionResult{
+				done("tag", "ec2", "i-1", &PriorState{Tags: map[string]string{"Owner": "alice"}}),
+			},
+			wantResults: []string{"rollback-tag i-1 ok"},
+			wantInstances: map[string]fakecloud.Instance{
+				"i-1": instance("i-1", "stopped", map[string]string{"Owner": "alice"}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cloud := fakecloud.New(test.seed)
+			client := rollbackClient(t, cloud, test.dryRun, test.failStart...)
+			record := &RunRecord{ID: "run-1", PolicyName: "p", Result: &ExecutionResult{ActionResults: test.actions}}
+
+			result := RollbackRun(context.Background(), client, record)
+
+			var results []string
+			for _, action := range result.ActionResults {
+				outcome := "ok"
+				if !action.Success {
+					outcome = "failed"
+				}
+				results = append(results, action.Action+" "+action.ResourceID+" "+outcome)
+				if action.Success && action.DryRun != test.dryRun {
+					t.Errorf("%s on %s: dry run = %v", action.Action, action.ResourceID, action.DryRun)
+				}
+			}
+			if !reflect.DeepEqual(results, test.wantResults) {
+				t.Errorf("results = %q, want %q", results, test.wantResults)
+			}
+
+			if len(result.Errors) != len(test.wantErrors) {
+				t.Errorf("errors = %q, want %q", result.Errors, test.wantErrors)
+			} else {
+				for i, want := range test.wantErrors {
+					if !strings.Contains(result.Errors[i], want) {
+						t.Errorf("error %d = %q, want %q", i, result.Errors[i], want)
+					}
+				}
+			}
+			if result.Success != (len(test.wantErrors) == 0) {
+				t.Errorf("success = %v with errors %q", result.Success, result.Errors)
+			}
+			if result.Summary.TotalActions != len(test.wantResults) || result.ActionsExecuted != len(test.wantResults) {
+				t.Errorf("summary = %+v, executed %d", result.Summary, result.ActionsExecuted)
+			}
+
+			state := cloud.State()
+			for _, got := range state.Instances {
+				want := test.wantInstances[got.InstanceID]
+				if got.State != want.State || !sameTags(got.Tags, want.Tags) {
+					t.Errorf("%s = %s %v, want %s %v", got.InstanceID, got.State, got.Tags, want.State, want.Tags)
+				}
+			}
+			for _, got := range state.Buckets {
+				want, checked := test.wantBuckets[got.Name]
+				if !checked {
+					continue
+				}
+				if !reflect.DeepEqual(got.PublicAccessBlock, want.PublicAccessBlock) ||
+					got.Versioning != want.Versioning || !sameTags(got.Tags, want.Tags) {
+					t.Errorf("%s = %+v %s %v, want %+v %s %v", got.Name,
+						got.PublicAccessBlock, got.Versioning, got.Tags,
+						want.PublicAccessBlock, want.Versioning, want.Tags)
+				}
+			}
+		})
+	}
+}
+
+// sameTags compares tag sets, treating nil and empty as the same
+func sameTags(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func TestRollbackExecutedRun(t *testing.T) {
+	pe, cloud, fileStorage := testExecutor(t, fakecloud.Seed{Instances: []fakecloud.Instance{
+		{InstanceID: "i-dev", InstanceType: "t3.micro", State: "running", Tags: map[string]string{"Env": "dev", "Owner": "alice"}},
+		{InstanceID: "i-prod", InstanceType: "t3.micro", State: "running", Tags: map[string]string{"Env": "prod"}},
+	}})
+	policy := storage.StoredPolicy{
+		Name: "park-dev", ResourceType: "ec2", Status: "active",
+		Filters: []storage.StoredFilter{{Type: "tag", Key: "Env", Value: "dev"}},
+		Actions: []storage.StoredAction{
+			{Type: "tag", Settings: map[string]interface{}{"Owner": "cleanup", "Parked": "yes"}},
+			{Type: "stop"},
+		},
+	}
+	if err := fileStorage.SavePolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := pe.ExecutePolicy(context.Background(), policy.Name)
+	if err != nil || !result.Success {
+		t.Fatalf("run failed: %v %v", err, result.Errors)
+	}
+	for _, action := range result.ActionResults {
+		if action.Prior == nil {
+			t.Errorf("%s on %s recorded no prior state", action.Action, action.ResourceID)
+		}
+	}
+	if tag := result.ActionResults[0].Prior; !reflect.DeepEqual(tag.SetTags, map[string]string{"Owner": "cleanup", "Parked": "yes"}) {
+		t.Errorf("tag action recorded set tags %v", tag.SetTags)
+	}
+
+	rollback := RollbackRun(context.Background(), rollbackClient(t, cloud, false), &RunRecord{ID: result.RunID, Result: result})
+	if !rollback.Success {
+		t.Fatalf("rollback failed: %v", rollback.Errors)
+	}
+
+	for _, instance := range cloud.State().Instances {
+		if instance.InstanceID != "i-dev" {
+			continue
+		}
+		if want := map[string]string{"Env": "dev", "Owner": "alice"}; instance.State != "running" || !reflect.DeepEqual(instance.Tags, want) {
+			t.Errorf("i-dev = %s %v, want running %v", instance.State, instance.Tags, want)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Compare applies a filter operator to a resource value and the value from the policy.
+// Supported operators: eq, ne, in, not-in, gt, lt, gte, lte, contains, exists, missing.
+func Compare(actual interface{}, op string, expected interface{}) (bool, error) {
+	actual = normalizeValue(actual)
+	expected = normalizeValue(expected)
+
+	switch op {
+	case "", "eq", "equal":
+		return valuesEqual(actual, expected), nil
+	case "ne", "not-equal":
+		return !valuesEqual(actual, expected), nil
+	case "in", "not-in":
+		list, ok := toList(expected)
+		if !ok {
+			return false, fmt.Errorf("operator '%s' needs a list value, got %T", op, expected)
+		}
+		found := valueInList(actual, list)
+		if op == "not-in" {
+			return !found, nil
+		}
+		return found, nil
+	case "gt", "lt", "gte", "lte":
+		if actual == nil || expected == nil {
+			return false, nil
+		}
+		cmp, err := orderValues(actual, expected)
+		if err != nil {
+			return false, err
+		}
+		switch op {
+		case "gt":
+			return cmp > 0, nil
+		case "lt":
+			return cmp < 0, nil
+		case "gte":
+			return cmp >= 0, nil
+		default:
+			return cmp <= 0, nil
+		}
+	case "contains":
+		return valueContains(actual, expected), nil
+	case "exists", "present":
+		return !isEmptyValue(actual), nil
+	case "missing", "absent":
+		return isEmptyValue(actual), nil
+	default:
+		return false, fmt.Errorf("unknown operator '%s'", op)
+	}
+}
+
+// ToNumber converts JSON numbers, Go numeric types and numeric strings to float64
+func ToNumber(value interface{}) (float64, bool) {
+	switch v := normalizeValue(value).(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// ToTime converts timestamps, RFC 3339 / date strings and "N days ago" to time.Time
+func ToTime(value interface{}) (time.Time, bool) {
+	switch v := normalizeValue(value).(type) {
+	case time.Time:
+		return v, true
+	case string:
+		v = strings.TrimSpace(v)
+		for _, layout := range []string{time.RFC3339Nano, time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+			if parsed, err := time.Parse(layout, v); err == nil {
+				return parsed, true
+			}
+		}
+
+		// Relative dates like "30 days ago"
+		var amount int
+		var unit string
+		if n, _ := fmt.Sscanf(v, "%d %s ago", &amount, &unit); n == 2 {
+			switch strings.TrimSuffix(unit, "s") {
+			case "hour":
+				return time.Now().Add(-time.Duration(amount) * time.Hour), true
+			case "day":
+				return time.Now().AddDate(0, 0, -amount), true
+			case "week":
+				return time.Now().AddDate(0, 0, -7*amount), true
+			case "month":
+				return time.Now().AddDate(0, -amount, 0), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// normalizeValue folds the many Go representations of a value into a few canonical ones:
+// float64 for numbers, []interface{} for lists and map[string]interface{} for maps
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, float64, time.Time, []interface{}, map[string]interface{}:
+		return v
+	case json.Number:
+		if number, err := v.Float64(); err == nil {
+			return number
+		}
+		return v.String()
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = normalizeValue(rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Map:
+		m := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			m[fmt.Sprint(key.Interface())] = normalizeValue(rv.MapIndex(key).Interface())
+		}
+		return m
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalizeValue(rv.Elem().Interface())
+	}
+
+	return value
+}
+
+// valuesEqual compares two normalized values, coercing across types where it makes sense
+func valuesEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	_, aNumber := a.(float64)
+	_, bNumber := b.(float64)
+	if aNumber || bNumber {
+		x, okA := ToNumber(a)
+		y, okB := ToNumber(b)
+		return okA && okB && x == y
+	}
+
+	_, aBool := a.(bool)
+	_, bBool := b.(bool)
+	if aBool || bBool {
+		x, okA := toBool(a)
+		y, okB := toBool(b)
+		return okA && okB && x == y
+	}
+
+	_, aTime := a.(time.Time)
+	_, bTime := b.(time.Time)
+	if aTime || bTime {
+		x, okA := ToTime(a)
+		y, okB := ToTime(b)
+		return okA && okB && x.Equal(y)
+	}
+
+	aList, aIsList := a.([]interface{})
+	bList, bIsList := b.([]interface{})
+	if aIsList || bIsList {
+		if !aIsList || !bIsList || len(aList) != len(bList) {
+			return false
+		}
+		for i := range aList {
+			if !valuesEqual(aList[i], bList[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// orderValues returns -1, 0 or 1 comparing two normalized values
+func orderValues(a, b interface{}) (int, error) {
+	if x, ok := ToNumber(a); ok {
+		if y, ok := ToNumber(b); ok {
+			switch {
+			case x < y:
+				return -1, nil
+			case x > y:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+
+	if x, ok := ToTime(a); ok {
+		if y, ok := ToTime(b); ok {
+			return x.Compare(y), nil
+		}
+	}
+
+	x, okA := a.(string)
+	y, okB := b.(string)
+	if okA && okB {
+		return strings.Compare(x, y), nil
+	}
+
+	return 0, fmt.Errorf("cannot order %T against %T", a, b)
+}
+
+// valueInList reports whether a value (or any element of a list value) is in the list
+func valueInList(value interface{}, list []interface{}) bool {
+	if values, ok := value.([]interface{}); ok {
+		for _, v := range values {
+			if valueInList(v, list) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, item := range list {
+		if valuesEqual(value, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueContains checks list membership, substring matches and map keys
+func valueContains(container, item interface{}) bool {
+	switch c := container.(type) {
+	case []interface{}:
+		for _, element := range c {
+			if valuesEqual(element, item) {
+				return true
+			}
+		}
+	case string:
+		if s, ok := item.(string); ok {
+			return strings.Contains(c, s)
+		}
+	case map[string]interface{}:
+		if key, ok := item.(string); ok {
+			_, exists := c[key]
+			return exists
+		}
+	}
+	return false
+}
+
+// toList accepts lists or comma separated strings
+func toList(value interface{}) ([]interface{}, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, true
+	case string:
+		var list []interface{}
+		for _, part := range strings.Split(v, ",") {
+			list = append(list, strings.TrimSpace(part))
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// toBool accepts booleans and "true"/"false" style strings
+func toBool(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		return b, err == nil
+	}
+	return false, false
+}
+
+// isEmptyValue treats nil, empty strings, lists and maps as missing
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
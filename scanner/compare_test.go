@@ -0,0 +1,137 @@
+package scanner
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	launched := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var noTime *time.Time
+	three := int64(3)
+
+	tests := []struct {
+		name     string
+		actual   interface{}
+		op       string
+		expected interface{}
+		want     bool
+		wantErr  bool
+	}{
+		// Numbers compare by value whatever their Go type
+		{name: "int32 eq float64", actual: int32(5), op: "eq", expected: 5.0, want: true},
+		{name: "uint eq int", actual: uint(5), op: "eq", expected: 5, want: true},
+		{name: "pointer is dereferenced", actual: &three, op: "eq", expected: 3, want: true},
+		{name: "numeric string eq number", actual: "5", op: "eq", expected: 5, want: true},
+		{name: "padded numeric string", actual: " 5 ", op: "equal", expected: 5.0, want: true},
+		{name: "json.Number gt int", actual: json.Number("7"), op: "gt", expected: 3, want: true},
+		{name: "non-numeric string ne number", actual: "abc", op: "eq", expected: 5, want: false},
+		{name: "numeric strings order as numbers", actual: "10", op: "gt", expected: "9", want: true},
+		{name: "gte equal numbers", actual: 7, op: "gte", expected: "7", want: true},
+		{name: "lte", actual: 2.5, op: "lte", expected: 2, want: false},
+
+		// Booleans accept true/false strings
+		{name: "bool eq bool string", actual: true, op: "eq", expected: "true", want: true},
+		{name: "bool string eq bool", actual: "False", op: "eq", expected: false, want: true},
+		{name: "yes is not a bool", actual: "yes", op: "eq", expected: true, want: false},
+		{name: "bool ne", actual: false, op: "ne", expected: true, want: true},
+
+		// Times compare with timestamps and date strings
+		{name: "time eq RFC 3339", actual: launched, op: "eq", expected: "2024-03-01T12:00:00Z", want: true},
+		{name: "time lt date", actual: launched, op: "lt", expected: "2024-06-01", want: true},
+		{name: "time gt date", actual: launched, op: "gt", expected: "2024-06-01", want: false},
+		{name: "time lt relative date", actual: launched, op: "lt", expected: "30 days ago", want: true},
+		{name: "nil time pointer is missing", actual: noTime, op: "missing", want: true},
+
+		// Strings
+		{name: "string eq", actual: "running", op: "eq", expected: "running", want: true},
+		{name: "string eq is case sensitive", actual: "Running", op: "eq", expected: "running", want: false},
+		{name: "strings order lexically", actual: "b", op: "gt", expected: "a", want: true},
+
+		// Missing values
+		{name: "nil eq nil", actual: nil, op: "eq", expected: nil, want: true},
+		{name: "nil ne value", actual: nil, op: "ne", expected: "x", want: true},
+		{name: "nil never orders", actual: nil, op: "gt", expected: 1, want: false},
+		{name: "order needs comparable kinds", actual: "abc", op: "gt", expected: true, wantErr: true},
+
+		// Lists
+		{name: "lists equal element-wise", actual: []int{1, 2}, op: "eq", expected: []interface{}{1.0, "2"}, want: true},
+		{name: "lists of different length", actual: []int{1, 2}, op: "eq", expected: []int{1}, want: false},
+		{name: "list ne scalar", actual: []string{"a"}, op: "eq", expected: "a", want: false},
+
+		// in / not-in
+		{name: "in list", actual: "m5.large", op: "in", expected: []string{"t3.micro", "m5.large"}, want: true},
+		{name: "in comma string", actual: "m5.large", op: "in", expected: "t3.micro, m5.large", want: true},
+		{name: "in coerces numbers", actual: 443, op: "in", expected: []string{"80", "443"}, want: true},
+		{name: "any list element in", actual: []string{"a", "b"}, op: "in", expected: []string{"b"}, want: true},
+		{name: "not-in", actual: "c", op: "not-in", expected: []string{"a", "b"}, want: true},
+		{name: "in needs a list", actual: 1, op: "in", expected: 5, wantErr: true},
+
+		// contains
+		{name: "list contains", actual: []string{"a", "b"}, op: "contains", expected: "b", want: true},
+		{name: "list contains number", actual: []int{80, 443}, op: "contains", expected: "443", want: true},
+		{name: "substring", actual: "prod-web-01", op: "contains", expected: "web", want: true},
+		{name: "map key", actual: map[string]string{"Owner": "me"}, op: "contains", expected: "Owner", want: true},
+		{name: "map doesn't contain values", actual: map[string]string{"Owner": "me"}, op: "contains", expected: "me", want: false},
+		{name: "number contains nothing", actual: 12, op: "contains", expected: "1", want: false},
+
+		// exists / missing
+		{name: "empty string is missing", actual: "", op: "missing", want: true},
+		{name: "empty list is absent", actual: []string{}, op: "absent", want: true},
+		{name: "empty map is missing", actual: map[string]int{}, op: "missing", want: true},
+		{name: "zero exists", actual: 0, op: "exists", want: true},
+		{name: "false is present", actual: false, op: "present", want: true},
+
+		{name: "empty op is eq", actual: "x", op: "", expected: "x", want: true},
+		{name: "unknown operator", actual: "x", op: "like", expected: "x", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Compare(test.actual, test.op, test.expected)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Compare(%v, %q, %v) = %v, want an error", test.actual, test.op, test.expected, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compare(%v, %q, %v): %v", test.actual, test.op, test.expected, err)
+			}
+			if got != test.want {
+				t.Errorf("Compare(%v, %q, %v) = %v, want %v", test.actual, test.op, test.expected, got, test.want)
+			}
+		})
+	}
+}
+
+func TestToTime(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		value  interface{}
+		want   time.Time
+		wantOK bool
+	}{
+		{value: "2024-03-01", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), wantOK: true},
+		{value: "2024-03-01 08:30:00", want: time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC), wantOK: true},
+		{value: "2024-03-01T08:30:00.5Z", want: time.Date(2024, 3, 1, 8, 30, 0, 5e8, time.UTC), wantOK: true},
+		{value: "2 days ago", want: now.AddDate(0, 0, -2), wantOK: true},
+		{value: "1 week ago", want: now.AddDate(0, 0, -7), wantOK: true},
+		{value: "3 months ago", want: now.AddDate(0, -3, 0), wantOK: true},
+		{value: "5 fortnights ago"},
+		{value: "yesterday"},
+		{value: 1709280000},
+	}
+
+	for _, test := range tests {
+		got, ok := ToTime(test.value)
+		if ok != test.wantOK {
+			t.Errorf("ToTime(%v) ok = %v, want %v", test.value, ok, test.wantOK)
+			continue
+		}
+		if diff := got.Sub(test.want); diff < -time.Minute || diff > time.Minute {
+			t.Errorf("ToTime(%v) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package scanner
 
 import (
+	"context"
+	"custodian-killer/aws"
 	"custodian-killer/storage"
 	"fmt"
 	"strings"
@@ -9,14 +11,17 @@ import (
 
 // ScanResult represents the result of scanning a policy
 type ScanResult struct {
-	PolicyName       string            `json:"policy_name"`
-	ResourceType     string            `json:"resource_type"`
-	ScanTime         time.Time         `json:"scan_time"`
-	MatchedResources []MatchedResource `json:"matched_resources"`
-	Summary          ScanSummary       `json:"summary"`
-	Errors           []string          `json:"errors,omitempty"`
-	DryRun           bool              `json:"dry_run"`
-	EstimatedCost    *CostEstimate     `json:"estimated_cost,omitempty"`
+	PolicyName       string                      `json:"policy_name"`
+	ResourceType     string                      `json:"resource_type"`
+	Region           string                      `json:"region,omitempty"`
+	Account          string                      `json:"account,omitempty"`
+	ScanTime         time.Time                   `json:"scan_time"`
+	MatchedResources []MatchedResource           `json:"matched_resources"`
+	Summary          ScanSummary                 `json:"summary"`
+	Errors           []string                    `json:"errors,omitempty"`
+	DryRun           bool                        `json:"dry_run"`
+	EstimatedCost    *CostEstimate               `json:"estimated_cost,omitempty"`
+	APICalls         map[string]aws.ServiceStats `json:"api_calls,omitempty"` // AWS calls by service, with retries and throttling
 }
 
 // MatchedResource represents a resource that matched the policy filters
@@ -28,6 +33,7 @@ type MatchedResource struct {
 	State      string                 `json:"state,omitempty"`
 	Tags       map[string]string      `json:"tags,omitempty"`
 	Properties map[string]interface{} `json:"properties,omitempty"`
+	Raw        map[string]interface{} `json:"raw,omitempty"` // source AWS data, for value filters
 	Actions    []PlannedAction        `json:"planned_actions"`
 	RiskLevel  string                 `json:"risk_level"` // low, medium, high
 	Compliance ComplianceStatus       `json:"compliance"`
@@ -68,21 +74,29 @@ type CostEstimate struct {
 
 // PolicyScanner handles policy scanning operations
 type PolicyScanner struct {
-	storage storage.PolicyStorage
-	config  ScannerConfig
+	storage  storage.PolicyStorage
+	provider ResourceProvider
+	config   ScannerConfig
 }
 
 // ScannerConfig holds scanner configuration
 type ScannerConfig struct {
 	AWSRegion     string `json:"aws_region"`
+	AWSAccount    string `json:"aws_account,omitempty"`
 	AWSProfile    string `json:"aws_profile"`
 	DryRunDefault bool   `json:"dry_run_default"`
+	StrictFilters bool   `json:"strict_filters"` // fail scans on filter validation errors
+	Quiet         bool   `json:"quiet"`          // don't print scan progress
 	MaxResources  int    `json:"max_resources"`
 	Timeout       int    `json:"timeout_seconds"`
 }
 
 // NewPolicyScanner creates a new policy scanner
-func NewPolicyScanner(storage storage.PolicyStorage, config ScannerConfig) *PolicyScanner {
+func NewPolicyScanner(
+	storage storage.PolicyStorage,
+	provider ResourceProvider,
+	config ScannerConfig,
+) *PolicyScanner {
 	if config.MaxResources == 0 {
 		config.MaxResources = 1000
 	}
@@ -94,60 +108,88 @@ func NewPolicyScanner(storage storage.PolicyStorage, config ScannerConfig) *Poli
 	}
 
 	return &PolicyScanner{
-		storage: storage,
-		config:  config,
+		storage:  storage,
+		provider: provider,
+		config:   config,
 	}
 }
 
 // ScanPolicy scans a specific policy and returns results
-func (ps *PolicyScanner) ScanPolicy(policyName string) (*ScanResult, error) {
-	fmt.Printf("🔍 Scanning policy: %s\n", policyName)
+func (ps *PolicyScanner) ScanPolicy(ctx context.Context, policyName string) (*ScanResult, error) {
+	ps.logf("🔍 Scanning policy: %s\n", policyName)
 
 	// Get policy from storage
 	policy, err := ps.storage.GetPolicy(policyName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get policy: %v", err)
 	}
+	if !policy.AllowsRegion(ps.config.AWSRegion) {
+		return nil, fmt.Errorf("policy '%s' is limited to %s and does not run in %s",
+			policy.Name, strings.Join(policy.Regions, ", "), ps.config.AWSRegion)
+	}
+
+	return ps.ScanStoredPolicy(ctx, policy)
+}
+
+// ScanStoredPolicy evaluates a policy that isn't necessarily in storage. The
+// scan stops when ctx is done or the configured timeout passes.
+func (ps *PolicyScanner) ScanStoredPolicy(ctx context.Context, policy *storage.StoredPolicy) (*ScanResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(ps.config.Timeout)*time.Second)
+	defer cancel()
+	calls := aws.NewCallStats()
+	ctx = aws.WithCallStats(ctx, calls)
 
 	result := &ScanResult{
 		PolicyName:   policy.Name,
 		ResourceType: policy.ResourceType,
+		Region:       ps.config.AWSRegion,
+		Account:      ps.config.AWSAccount,
 		ScanTime:     time.Now(),
 		DryRun:       true, // Always dry run for scan
 		Summary:      ScanSummary{},
 	}
 
-	fmt.Printf("📊 Resource Type: %s\n", strings.ToUpper(policy.ResourceType))
-	fmt.Printf("🎯 Filters: %d | Actions: %d\n", len(policy.Filters), len(policy.Actions))
-
-	// Mock scanning based on resource type
-	switch policy.ResourceType {
-	case "ec2":
-		err = ps.scanEC2Resources(policy, result)
-	case "s3":
-		err = ps.scanS3Resources(policy, result)
-	case "rds":
-		err = ps.scanRDSResources(policy, result)
-	case "lambda":
-		err = ps.scanLambdaResources(policy, result)
-	case "ebs":
-		err = ps.scanEBSResources(policy, result)
-	default:
-		err = ps.scanGenericResources(policy, result)
+	ps.logf("📊 Resource Type: %s\n", strings.ToUpper(policy.ResourceType))
+	ps.logf("🎯 Filters: %d | Actions: %d\n", len(policy.Filters), len(policy.Actions))
+
+	// Check filters before touching AWS
+	issues := BlockingIssues(ValidateFilters(policy.ResourceType, policy.Filters), policy.AllowUnknownFilters)
+	if len(issues) > 0 {
+		if ps.config.StrictFilters {
+			return nil, fmt.Errorf("policy '%s' has invalid filters: %v", policy.Name, issues)
+		}
+		for _, issue := range issues {
+			ps.logf("⚠️  %s\n", issue.Error())
+			result.Errors = append(result.Errors, issue.Error())
+		}
 	}
 
-	if err != nil {
+	if err := ps.scanResources(ctx, policy, result); err != nil {
+		// A partial resource list would under-report matches, so don't return it
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("scan of '%s' stopped: %v", policy.Name, ctx.Err())
+		}
 		result.Errors = append(result.Errors, err.Error())
 	}
 
 	// Calculate summary
+	totalScanned := result.Summary.TotalScanned
 	result.Summary = ps.calculateSummary(result)
+	result.Summary.TotalScanned = totalScanned
+	result.APICalls = calls.Snapshot()
 
 	return result, nil
 }
 
+// logf prints scan progress unless the scanner is quiet
+func (ps *PolicyScanner) logf(format string, args ...interface{}) {
+	if !ps.config.Quiet {
+		fmt.Printf(format, args...)
+	}
+}
+
 // ScanAllPolicies scans all stored policies
-func (ps *PolicyScanner) ScanAllPolicies() ([]ScanResult, error) {
+func (ps *PolicyScanner) ScanAllPolicies(ctx context.Context) ([]ScanResult, error) {
 	policies, err := ps.storage.ListPolicies()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list policies: %v", err)
@@ -158,8 +200,15 @@ func (ps *PolicyScanner) ScanAllPolicies() ([]ScanResult, error) {
 		if policy.Status != "active" {
 			continue // Skip inactive policies
 		}
+		if !policy.AllowsRegion(ps.config.AWSRegion) {
+			continue // Limited to other regions
+		}
+
+		if ctx.Err() != nil {
+			return results, fmt.Errorf("scan stopped: %v", ctx.Err())
+		}
 
-		result, err := ps.ScanPolicy(policy.Name)
+		result, err := ps.ScanPolicy(ctx, policy.Name)
 		if err != nil {
 			fmt.Printf("⚠️  Failed to scan policy '%s': %v\n", policy.Name, err)
 			continue
@@ -171,249 +220,182 @@ func (ps *PolicyScanner) ScanAllPolicies() ([]ScanResult, error) {
 	return results, nil
 }
 
-// Mock resource scanning functions (replace with real AWS SDK calls later)
-func (ps *PolicyScanner) scanEC2Resources(policy *storage.StoredPolicy, result *ScanResult) error {
-	fmt.Println("🖥️  Scanning EC2 instances...")
-
-	// Mock EC2 instances
-	mockInstances := []MatchedResource{
-		{
-			ID:     "i-1234567890abcdef0",
-			Name:   "web-server-1",
-			Type:   "t3.micro",
-			Region: ps.config.AWSRegion,
-			State:  "running",
-			Tags: map[string]string{
-				"Environment": "dev",
-				"Owner":       "john@company.com",
-			},
-			Properties: map[string]interface{}{
-				"instance_type":   "t3.micro",
-				"launch_time":     "2024-01-15T10:30:00Z",
-				"cpu_utilization": 2.5,
-				"running_days":    15,
-			},
-			RiskLevel: "medium",
-			Compliance: ComplianceStatus{
-				Compliant: false,
-				Issues:    []string{"CPU utilization below threshold"},
-				Severity:  "medium",
-			},
-		},
-		{
-			ID:     "i-0987654321fedcba0",
-			Name:   "database-server",
-			Type:   "t3.small",
-			Region: ps.config.AWSRegion,
-			State:  "running",
-			Tags: map[string]string{
-				"Environment": "prod",
-				"Owner":       "alice@company.com",
-			},
-			Properties: map[string]interface{}{
-				"instance_type":   "t3.small",
-				"launch_time":     "2024-01-10T08:15:00Z",
-				"cpu_utilization": 1.2,
-				"running_days":    20,
-			},
-			RiskLevel: "high",
-			Compliance: ComplianceStatus{
-				Compliant: false,
-				Issues:    []string{"Very low CPU utilization", "Long running unused instance"},
-				Severity:  "high",
-			},
-		},
+// scanResources lists resources from the provider and evaluates the policy against them
+func (ps *PolicyScanner) scanResources(
+	ctx context.Context,
+	policy *storage.StoredPolicy,
+	result *ScanResult,
+) error {
+	resources, err := ps.provider.ListResources(ctx, policy.ResourceType)
+	if err != nil {
+		return fmt.Errorf("failed to list %s resources: %v", policy.ResourceType, err)
+	}
+
+	if len(resources) > ps.config.MaxResources {
+		ps.logf("⚠️  Found %d resources, only the first %d will be evaluated\n",
+			len(resources), ps.config.MaxResources)
+		resources = resources[:ps.config.MaxResources]
 	}
 
 	// Apply filters and determine matches
-	for _, instance := range mockInstances {
-		matched := ps.applyFilters(policy.Filters, instance)
-		if matched {
+	for _, resource := range resources {
+		if ps.applyFilters(policy.Filters, resource) {
 			// Add planned actions
-			instance.Actions = ps.planActions(policy.Actions, instance)
-			result.MatchedResources = append(result.MatchedResources, instance)
+			resource.Actions = ps.planActions(policy.Actions, resource)
+			result.MatchedResources = append(result.MatchedResources, resource)
 		}
 	}
 
-	result.Summary.TotalScanned = len(mockInstances)
+	result.Summary.TotalScanned = len(resources)
 
-	fmt.Printf("✅ Scanned %d EC2 instances, found %d matches\n",
-		len(mockInstances), len(result.MatchedResources))
+	ps.logf("✅ Scanned %d %s resources, found %d matches\n",
+		len(resources), policy.ResourceType, len(result.MatchedResources))
 
 	return nil
 }
 
-func (ps *PolicyScanner) scanS3Resources(policy *storage.StoredPolicy, result *ScanResult) error {
-	fmt.Println("🪣 Scanning S3 buckets...")
-
-	// Mock S3 buckets
-	mockBuckets := []MatchedResource{
-		{
-			ID:     "my-public-bucket",
-			Name:   "my-public-bucket",
-			Type:   "s3-bucket",
-			Region: ps.config.AWSRegion,
-			State:  "active",
-			Properties: map[string]interface{}{
-				"public_read":   true,
-				"public_write":  false,
-				"versioning":    false,
-				"encryption":    false,
-				"creation_date": "2024-01-01T00:00:00Z",
-			},
-			RiskLevel: "high",
-			Compliance: ComplianceStatus{
-				Compliant: false,
-				Issues:    []string{"Bucket allows public read access", "No encryption enabled"},
-				Severity:  "high",
-			},
-		},
-	}
-
-	for _, bucket := range mockBuckets {
-		matched := ps.applyFilters(policy.Filters, bucket)
-		if matched {
-			bucket.Actions = ps.planActions(policy.Actions, bucket)
-			result.MatchedResources = append(result.MatchedResources, bucket)
-		}
-	}
-
-	result.Summary.TotalScanned = len(mockBuckets)
-
-	fmt.Printf("✅ Scanned %d S3 buckets, found %d matches\n",
-		len(mockBuckets), len(result.MatchedResources))
+// applyFilters checks if a resource matches the policy filters
+func (ps *PolicyScanner) applyFilters(
+	filters []storage.StoredFilter,
+	resource MatchedResource,
+) bool {
+	return MatchesFilters(filters, resource)
+}
 
-	return nil
+// MatchesFilters checks if a resource matches every filter in the list
+func MatchesFilters(filters []storage.StoredFilter, resource MatchedResource) bool {
+	matched, ok := matchAll(filters, resource)
+	return matched && ok
 }
 
-func (ps *PolicyScanner) scanRDSResources(policy *storage.StoredPolicy, result *ScanResult) error {
-	fmt.Println("🗄️  Scanning RDS instances...")
-
-	// Mock RDS instances
-	mockRDS := []MatchedResource{
-		{
-			ID:     "db-instance-1",
-			Name:   "production-db",
-			Type:   "db.t3.micro",
-			Region: ps.config.AWSRegion,
-			State:  "available",
-			Properties: map[string]interface{}{
-				"engine":                  "mysql",
-				"backup_retention_period": 3,
-				"multi_az":                false,
-				"encrypted":               false,
-			},
-			RiskLevel: "medium",
-			Compliance: ComplianceStatus{
-				Compliant: false,
-				Issues:    []string{"Backup retention below policy minimum"},
-				Severity:  "medium",
-			},
-		},
+// WalkFilters calls fn for every filter in the tree, including and / or / not groups
+func WalkFilters(filters []storage.StoredFilter, fn func(filter storage.StoredFilter)) {
+	for _, filter := range filters {
+		fn(filter)
+		WalkFilters(filter.Filters, fn)
 	}
+}
 
-	for _, rds := range mockRDS {
-		matched := ps.applyFilters(policy.Filters, rds)
-		if matched {
-			rds.Actions = ps.planActions(policy.Actions, rds)
-			result.MatchedResources = append(result.MatchedResources, rds)
+// EvaluateFilter evaluates a single filter against a resource, honoring Op and
+// Negate. A filter that can't be evaluated never matches, not even under a
+// not group or Negate.
+func EvaluateFilter(filter storage.StoredFilter, resource MatchedResource) bool {
+	matched, ok := evaluateFilter(filter, resource)
+	return matched && ok
+}
+
+// matchAll ands a list of filters. ok is false when the outcome hangs on a
+// filter that couldn't be evaluated; a filter that is surely false decides it.
+func matchAll(filters []storage.StoredFilter, resource MatchedResource) (matched, ok bool) {
+	ok = true
+	for _, filter := range filters {
+		childMatched, childOK := evaluateFilter(filter, resource)
+		if childOK && !childMatched {
+			return false, true
 		}
+		ok = ok && childOK
 	}
-
-	result.Summary.TotalScanned = len(mockRDS)
-
-	fmt.Printf("✅ Scanned %d RDS instances, found %d matches\n",
-		len(mockRDS), len(result.MatchedResources))
-
-	return nil
+	return ok, ok
 }
 
-func (ps *PolicyScanner) scanLambdaResources(
-	policy *storage.StoredPolicy,
-	result *ScanResult,
-) error {
-	fmt.Println("⚡ Scanning Lambda functions...")
-	// Mock Lambda scanning
-	result.Summary.TotalScanned = 5
-	fmt.Println("✅ Scanned 5 Lambda functions, found 0 matches")
-	return nil
-}
+// evaluateFilter evaluates a filter, with ok false when it couldn't be
+func evaluateFilter(filter storage.StoredFilter, resource MatchedResource) (matched, ok bool) {
+	switch filter.Type {
+	case "and":
+		matched, ok = matchAll(filter.Filters, resource)
+		return matched != filter.Negate, ok
+	case "or":
+		ok = true
+		for _, child := range filter.Filters {
+			childMatched, childOK := evaluateFilter(child, resource)
+			if childOK && childMatched {
+				return !filter.Negate, true
+			}
+			ok = ok && childOK
+		}
+		return filter.Negate, ok
+	case "not":
+		matched, ok = matchAll(filter.Filters, resource)
+		return !matched != filter.Negate, ok
+	}
 
-func (ps *PolicyScanner) scanEBSResources(policy *storage.StoredPolicy, result *ScanResult) error {
-	fmt.Println("💾 Scanning EBS volumes...")
-	// Mock EBS scanning
-	result.Summary.TotalScanned = 8
-	fmt.Println("✅ Scanned 8 EBS volumes, found 0 matches")
-	return nil
-}
+	actual, op, expected, known := resolveFilter(filter, resource)
+	if !known {
+		// Unknown filters never match; ValidateFilters reports them before a run
+		return false, false
+	}
 
-func (ps *PolicyScanner) scanGenericResources(
-	policy *storage.StoredPolicy,
-	result *ScanResult,
-) error {
-	fmt.Printf("🔍 Scanning %s resources...\n", policy.ResourceType)
-	result.Summary.TotalScanned = 3
-	fmt.Printf("✅ Scanned 3 %s resources, found 0 matches\n", policy.ResourceType)
-	return nil
-}
+	if filter.Op != "" {
+		op = filter.Op
+	}
+	if filter.Value != nil {
+		expected = filter.Value
+	}
 
-// applyFilters checks if a resource matches the policy filters
-func (ps *PolicyScanner) applyFilters(
-	filters []storage.StoredFilter,
-	resource MatchedResource,
-) bool {
-	for _, filter := range filters {
-		if !ps.evaluateFilter(filter, resource) {
-			return false
-		}
+	matched, err := Compare(actual, op, expected)
+	if err != nil {
+		fmt.Printf("⚠️  Filter '%s' on %s: %v\n", filter.Type, resource.ID, err)
+		return false, false
 	}
-	return true
+	return matched != filter.Negate, true
 }
 
-// evaluateFilter evaluates a single filter against a resource
-func (ps *PolicyScanner) evaluateFilter(
+// resolveFilter finds the resource value a filter looks at, along with the
+// operator and value used when the policy leaves them out
+func resolveFilter(
 	filter storage.StoredFilter,
 	resource MatchedResource,
-) bool {
+) (actual interface{}, op string, expected interface{}, known bool) {
+	spec, registered := FilterRegistry[filter.Type]
+
 	switch filter.Type {
 	case "instance-state", "state":
-		return resource.State == filter.Value
-	case "cpu-utilization", "cpu-utilization-avg":
-		if cpuUtil, ok := resource.Properties["cpu_utilization"].(float64); ok {
-			threshold := 5.0
-			if thresholdVal, ok := filter.Value.(float64); ok {
-				threshold = thresholdVal
-			}
-			return cpuUtil < threshold
+		return resource.State, spec.DefaultOp, nil, true
+	case "encryption", "encrypted":
+		if value, exists := resource.Properties["encryption"]; exists {
+			return value, spec.DefaultOp, spec.Default, true
 		}
-	case "running-days":
-		if days, ok := resource.Properties["running_days"].(int); ok {
-			threshold := 7
-			if thresholdVal, ok := filter.Value.(int); ok {
-				threshold = thresholdVal
-			}
-			return days >= threshold
+		return resource.Properties["encrypted"], spec.DefaultOp, spec.Default, true
+	case "tag", "tag-missing":
+		if filter.Key == "" {
+			return nil, "", nil, false
 		}
-	case "public-read", "public-access":
-		if publicRead, ok := resource.Properties["public_read"].(bool); ok {
-			return publicRead == filter.Value
+		if value, exists := resource.Tags[filter.Key]; exists {
+			actual = value
 		}
-	case "backup-retention-period":
-		if retention, ok := resource.Properties["backup_retention_period"].(int); ok {
-			threshold := 7
-			if thresholdVal, ok := filter.Value.(int); ok {
-				threshold = thresholdVal
-			}
-			return retention < threshold
+		if filter.Type == "tag" && (filter.Value == nil || filter.Value == "") {
+			return actual, "exists", nil, true
+		}
+		return actual, spec.DefaultOp, nil, true
+	case "value":
+		if filter.Key == "" {
+			return nil, "", nil, false
 		}
-	case "tag-missing":
-		if filter.Key != "" {
-			_, exists := resource.Tags[filter.Key]
-			return !exists
+		actual, _ = lookupResourcePath(resource, filter.Key)
+		return actual, spec.DefaultOp, nil, true
+	case "marked-for-op":
+		// Key names the mark tag, Value optionally the operation it must schedule
+		if op, due := dueMark(resource, filter.Key, time.Now()); due {
+			actual = op
 		}
+		if filter.Value == nil || filter.Value == "" {
+			return actual, "exists", nil, true
+		}
+		return actual, spec.DefaultOp, nil, true
+	}
+
+	if registered && spec.Path != "" {
+		actual, _ = lookupResourcePath(resource, spec.Path)
+		return actual, spec.DefaultOp, spec.Default, true
 	}
 
-	return true // Default to match if filter not implemented
+	// Experimental filters fall back to a field with the same name,
+	// e.g. root-device-type -> root_device_type
+	if value, exists := lookupResourcePath(resource, strings.ReplaceAll(filter.Type, "-", "_")); exists {
+		return value, "eq", nil, true
+	}
+
+	return nil, "", nil, false
 }
 
 // planActions determines what actions would be taken on a resource
@@ -424,43 +406,73 @@ func (ps *PolicyScanner) planActions(
 	var plannedActions []PlannedAction
 
 	for _, action := range actions {
-		planned := PlannedAction{
-			Type:     action.Type,
-			DryRun:   action.DryRun,
-			Settings: action.Settings,
-		}
+		plannedActions = append(plannedActions, PlanAction(action, resource.ID))
+	}
 
-		switch action.Type {
-		case "stop":
-			planned.Description = fmt.Sprintf("Stop EC2 instance %s", resource.ID)
-			planned.Impact = "medium"
-			planned.Reversible = true
-		case "terminate":
-			planned.Description = fmt.Sprintf("Terminate EC2 instance %s", resource.ID)
-			planned.Impact = "high"
-			planned.Reversible = false
-		case "tag":
-			planned.Description = fmt.Sprintf("Add tags to resource %s", resource.ID)
-			planned.Impact = "low"
-			planned.Reversible = true
-		case "block-public-access":
-			planned.Description = fmt.Sprintf("Block public access on S3 bucket %s", resource.ID)
-			planned.Impact = "high"
-			planned.Reversible = true
-		case "modify-backup-retention":
-			planned.Description = fmt.Sprintf("Modify backup retention for RDS %s", resource.ID)
-			planned.Impact = "medium"
-			planned.Reversible = true
-		default:
-			planned.Description = fmt.Sprintf("Execute %s on resource %s", action.Type, resource.ID)
-			planned.Impact = "medium"
-			planned.Reversible = true
-		}
+	return plannedActions
+}
 
-		plannedActions = append(plannedActions, planned)
+// PlanAction describes an action on one resource, with its impact
+func PlanAction(action storage.StoredAction, resourceID string) PlannedAction {
+	planned := PlannedAction{
+		Type:     action.Type,
+		DryRun:   action.DryRun,
+		Settings: action.Settings,
 	}
 
-	return plannedActions
+	switch action.Type {
+	case "stop":
+		planned.Description = fmt.Sprintf("Stop EC2 instance %s", resourceID)
+		planned.Impact = "medium"
+		planned.Reversible = true
+	case "start":
+		planned.Description = fmt.Sprintf("Start resource %s", resourceID)
+		planned.Impact = "low"
+		planned.Reversible = true
+	case "create-snapshot":
+		planned.Description = fmt.Sprintf("Snapshot resource %s", resourceID)
+		planned.Impact = "low"
+		planned.Reversible = true
+	case "encrypt", "enable-encryption":
+		planned.Description = fmt.Sprintf("Enable encryption on resource %s", resourceID)
+		planned.Impact = "medium"
+		planned.Reversible = true
+	case "enable-versioning":
+		planned.Description = fmt.Sprintf("Enable versioning on S3 bucket %s", resourceID)
+		planned.Impact = "low"
+		planned.Reversible = true
+	case "terminate":
+		planned.Description = fmt.Sprintf("Terminate EC2 instance %s", resourceID)
+		planned.Impact = "high"
+		planned.Reversible = false
+	case "tag":
+		planned.Description = fmt.Sprintf("Add tags to resource %s", resourceID)
+		planned.Impact = "low"
+		planned.Reversible = true
+	case "mark-for-op":
+		planned.Description = fmt.Sprintf("Mark resource %s for %v", resourceID, action.Settings["op"])
+		planned.Impact = "low"
+		planned.Reversible = true
+	case "block-public-access":
+		planned.Description = fmt.Sprintf("Block public access on S3 bucket %s", resourceID)
+		planned.Impact = "high"
+		planned.Reversible = true
+	case "delete":
+		planned.Description = fmt.Sprintf("Delete resource %s", resourceID)
+		planned.Impact = "high"
+		planned.Reversible = false
+	case "modify-backup-retention":
+		planned.Description = fmt.Sprintf("Modify backup retention for RDS %s", resourceID)
+		planned.Impact = "medium"
+		planned.Reversible = true
+	default:
+		// Unknown actions are treated as the riskiest kind, so they never skip approvals
+		planned.Description = fmt.Sprintf("Execute %s on resource %s", action.Type, resourceID)
+		planned.Impact = "high"
+		planned.Reversible = false
+	}
+
+	return planned
 }
 
 // calculateSummary calculates summary statistics for the scan result
@@ -478,13 +490,23 @@ func (ps *PolicyScanner) calculateSummary(result *ScanResult) ScanSummary {
 			}
 		}
 
-		// Mock cost savings calculation
-		if resource.Type == "t3.micro" {
-			summary.CostSavings += 8.76 // ~$8.76/month for t3.micro
-		} else if resource.Type == "t3.small" {
-			summary.CostSavings += 17.52 // ~$17.52/month for t3.small
+		// Only count savings when a planned action actually stops paying for the resource
+		if cost, ok := resource.Properties["estimated_monthly_cost"].(float64); ok &&
+			ps.removesCost(resource.Actions) {
+			summary.CostSavings += cost
 		}
 	}
 
 	return summary
 }
+
+// removesCost reports whether any planned action stops or deletes the resource
+func (ps *PolicyScanner) removesCost(actions []PlannedAction) bool {
+	for _, action := range actions {
+		switch action.Type {
+		case "stop", "terminate", "delete":
+			return true
+		}
+	}
+	return false
+}
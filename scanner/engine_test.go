@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"custodian-killer/storage"
+	"testing"
+)
+
+func TestMatchesFiltersTree(t *testing.T) {
+	resource := MatchedResource{
+		ID:    "i-0abc",
+		Type:  "ec2",
+		State: "running",
+		Tags:  map[string]string{"Env": "prod", "Owner": "alice"},
+		Properties: map[string]interface{}{
+			"instance_type":   "m5.large",
+			"cpu_utilization": 2.0,
+		},
+	}
+
+	prod := storage.StoredFilter{Type: "tag", Key: "Env", Value: "prod"}
+	dev := storage.StoredFilter{Type: "tag", Key: "Env", Value: "dev"}
+	large := storage.StoredFilter{Type: "instance-type", Value: "m5.large"}
+	micro := storage.StoredFilter{Type: "instance-type", Value: "t3.micro"}
+	unknown := storage.StoredFilter{Type: "no-such-filter", Value: "x"}
+	broken := storage.StoredFilter{Type: "value", Key: "instance_type", Op: "in", Value: 5}
+	group := func(kind string, negate bool, children ...storage.StoredFilter) storage.StoredFilter {
+		return storage.StoredFilter{Type: kind, Negate: negate, Filters: children}
+	}
+
+	tests := []struct {
+		name    string
+		filters []storage.StoredFilter
+		want    bool
+	}{
+		{name: "no filters match everything", want: true},
+		{name: "top level is an and", filters: []storage.StoredFilter{prod, large}, want: true},
+		{name: "top level fails on one false", filters: []storage.StoredFilter{prod, micro}, want: false},
+
+		{name: "and all true", filters: []storage.StoredFilter{group("and", false, prod, large)}, want: true},
+		{name: "and one false", filters: []storage.StoredFilter{group("and", false, prod, micro)}, want: false},
+		{name: "negated and", filters: []storage.StoredFilter{group("and", true, prod, micro)}, want: true},
+
+		{name: "or one true", filters: []storage.StoredFilter{group("or", false, dev, prod)}, want: true},
+		{name: "or none true", filters: []storage.StoredFilter{group("or", false, dev, micro)}, want: false},
+		{name: "negated or", filters: []storage.StoredFilter{group("or", true, dev, micro)}, want: true},
+
+		{name: "not of a true filter", filters: []storage.StoredFilter{group("not", false, prod)}, want: false},
+		{name: "not of a false filter", filters: []storage.StoredFilter{group("not", false, dev)}, want: true},
+		{name: "not ands its children", filters: []storage.StoredFilter{group("not", false, prod, micro)}, want: true},
+		{name: "negated not", filters: []storage.StoredFilter{group("not", true, dev)}, want: false},
+
+		{
+			name: "nested groups",
+			filters: []storage.StoredFilter{group("and", false,
+				group("or", false, dev, prod),
+				group("not", false, micro),
+			)},
+			want: true,
+		},
+		{
+			name: "leaf negate inside a group",
+			filters: []storage.StoredFilter{group("or", false,
+				storage.StoredFilter{Type: "tag", Key: "Env", Value: "prod", Negate: true},
+				micro,
+			)},
+			want: false,
+		},
+
+		// Filters that can't be evaluated never make a resource match
+		{name: "unknown filter", filters: []storage.StoredFilter{unknown}, want: false},
+		{name: "negated unknown filter", filters: []storage.StoredFilter{{Type: "no-such-filter", Negate: true}}, want: false},
+		{name: "not of an unknown filter", filters: []storage.StoredFilter{group("not", false, unknown)}, want: false},
+		{name: "not of a failing comparison", filters: []storage.StoredFilter{group("not", false, broken)}, want: false},
+		{name: "negated and of an unknown filter", filters: []storage.StoredFilter{group("and", true, unknown)}, want: false},
+		{name: "negated or of an unknown filter", filters: []storage.StoredFilter{group("or", true, unknown)}, want: false},
+		{name: "or decided by a true child", filters: []storage.StoredFilter{group("or", false, unknown, prod)}, want: true},
+		{name: "not decided by a false child", filters: []storage.StoredFilter{group("not", false, unknown, dev)}, want: true},
+		{name: "not over an unknown and a true child", filters: []storage.StoredFilter{group("not", false, unknown, prod)}, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := MatchesFilters(test.filters, resource); got != test.want {
+				t.Errorf("MatchesFilters = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package scanner
+
+import (
+	"custodian-killer/storage"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValueKind describes the kind of value a filter compares against
+type ValueKind string
+
+const (
+	KindAny    ValueKind = "any"
+	KindString ValueKind = "string"
+	KindNumber ValueKind = "number"
+	KindBool   ValueKind = "bool"
+	KindTime   ValueKind = "time"
+	KindNone   ValueKind = "none"  // the filter takes no value
+	KindGroup  ValueKind = "group" // and / or / not
+)
+
+// FilterSpec describes a filter type the scanner knows how to evaluate
+type FilterSpec struct {
+	Path      string      // where the value lives in the resource document
+	Kind      ValueKind   // kind of value the filter compares against
+	DefaultOp string      // operator used when the policy leaves Op empty
+	Default   interface{} // value used when the policy leaves Value empty
+	NeedsKey  bool        // the filter's Key field is required
+	Resources []string    // resource types the filter applies to, empty for all
+}
+
+// FilterRegistry holds every filter type the scanner evaluates
+var FilterRegistry = map[string]FilterSpec{
+	// Boolean groups
+	"and": {Kind: KindGroup},
+	"or":  {Kind: KindGroup},
+	"not": {Kind: KindGroup},
+
+	// Generic filters
+	"value":       {Kind: KindAny, DefaultOp: "eq", NeedsKey: true},
+	"tag":         {Kind: KindString, DefaultOp: "eq", NeedsKey: true},
+	"tag-missing": {Kind: KindNone, DefaultOp: "missing", NeedsKey: true},
+	"state":       {Kind: KindString, DefaultOp: "eq"},
+
+	// Resources tagged by mark-for-op whose date has come
+	"marked-for-op": {Kind: KindString, DefaultOp: "eq"},
+
+	// EC2
+	"instance-state":      {Kind: KindString, DefaultOp: "eq", Resources: []string{"ec2"}},
+	"instance-type":       {Path: "instance_type", Kind: KindString, DefaultOp: "eq", Resources: []string{"ec2"}},
+	"launch-time":         {Path: "launch_time", Kind: KindTime, DefaultOp: "lt", Resources: []string{"ec2"}},
+	"vpc-id":              {Path: "vpc_id", Kind: KindString, DefaultOp: "eq", Resources: []string{"ec2"}},
+	"subnet-id":           {Path: "subnet_id", Kind: KindString, DefaultOp: "eq", Resources: []string{"ec2"}},
+	"platform":            {Path: "platform", Kind: KindString, DefaultOp: "eq", Resources: []string{"ec2"}},
+	"cpu-utilization":     {Path: "cpu_utilization", Kind: KindNumber, DefaultOp: "lt", Default: 5.0, Resources: []string{"ec2"}},
+	"cpu-utilization-avg": {Path: "cpu_utilization", Kind: KindNumber, DefaultOp: "lt", Default: 5.0, Resources: []string{"ec2"}},
+	"running-days":        {Path: "running_days", Kind: KindNumber, DefaultOp: "gte", Default: 7, Resources: []string{"ec2"}},
+
+	// S3
+	"bucket-name":    {Path: "name", Kind: KindString, DefaultOp: "eq", Resources: []string{"s3"}},
+	"creation-date":  {Path: "creation_date", Kind: KindTime, DefaultOp: "lt", Resources: []string{"s3"}},
+	"public-read":    {Path: "public_read", Kind: KindBool, DefaultOp: "eq", Default: true, Resources: []string{"s3"}},
+	"public-access":  {Path: "public_read", Kind: KindBool, DefaultOp: "eq", Default: true, Resources: []string{"s3"}},
+	"public-write":   {Path: "public_write", Kind: KindBool, DefaultOp: "eq", Default: true, Resources: []string{"s3"}},
+	"versioning":     {Path: "versioning", Kind: KindBool, DefaultOp: "eq", Default: true, Resources: []string{"s3"}},
+	"security-score": {Path: "security_score", Kind: KindNumber, DefaultOp: "gte", Resources: []string{"s3"}},
+	"size":           {Path: "size", Kind: KindNumber, DefaultOp: "gte", Resources: []string{"s3", "ebs"}},
+	"encryption":     {Kind: KindBool, DefaultOp: "eq", Default: true, Resources: []string{"s3", "rds", "ebs"}},
+	"encrypted":      {Kind: KindBool, DefaultOp: "eq", Default: true, Resources: []string{"s3", "rds", "ebs"}},
+
+	// RDS
+	"engine":                  {Path: "engine", Kind: KindString, DefaultOp: "eq", Resources: []string{"rds"}},
+	"engine-version":          {Path: "engine_version", Kind: KindString, DefaultOp: "eq", Resources: []string{"rds"}},
+	"instance-class":          {Path: "instance_class", Kind: KindString, DefaultOp: "eq", Resources: []string{"rds"}},
+	"backup-retention":        {Path: "backup_retention_period", Kind: KindNumber, DefaultOp: "lt", Default: 7, Resources: []string{"rds"}},
+	"backup-retention-period": {Path: "backup_retention_period", Kind: KindNumber, DefaultOp: "lt", Default: 7, Resources: []string{"rds"}},
+	"multi-az":                {Path: "multi_az", Kind: KindBool, DefaultOp: "eq", Default: true, Resources: []string{"rds"}},
+	"publicly-accessible":     {Path: "publicly_accessible", Kind: KindBool, DefaultOp: "eq", Default: true, Resources: []string{"rds"}},
+
+	// Lambda
+	"runtime":           {Path: "runtime", Kind: KindString, DefaultOp: "eq", Resources: []string{"lambda"}},
+	"last-modified":     {Path: "last_modified", Kind: KindTime, DefaultOp: "lt", Resources: []string{"lambda"}},
+	"memory-size":       {Path: "memory_size", Kind: KindNumber, DefaultOp: "eq", Resources: []string{"lambda"}},
+	"timeout":           {Path: "timeout", Kind: KindNumber, DefaultOp: "eq", Resources: []string{"lambda"}},
+	"code-size":         {Path: "code_size", Kind: KindNumber, DefaultOp: "gte", Resources: []string{"lambda"}},
+	"days-since-update": {Path: "days_since_update", Kind: KindNumber, DefaultOp: "gte", Resources: []string{"lambda"}},
+	"environment":       {Path: "environment", Kind: KindAny, DefaultOp: "contains", Resources: []string{"lambda"}},
+	"role":              {Path: "role", Kind: KindString, DefaultOp: "eq", Resources: []string{"lambda"}},
+
+	// EBS
+	"volume-type":      {Path: "volume_type", Kind: KindString, DefaultOp: "eq", Resources: []string{"ebs"}},
+	"attachment-state": {Path: "attachment_state", Kind: KindString, DefaultOp: "eq", Resources: []string{"ebs"}},
+	"creation-time":    {Path: "create_time", Kind: KindTime, DefaultOp: "lt", Resources: []string{"ebs"}},
+	"age-days":         {Path: "age_days", Kind: KindNumber, DefaultOp: "gte", Resources: []string{"ebs"}},
+	"age":              {Path: "age_days", Kind: KindNumber, DefaultOp: "gt", Resources: []string{"ebs", "ebs-snapshot"}},
+	"snapshot-id":      {Path: "snapshot_id", Kind: KindString, DefaultOp: "eq", Resources: []string{"ebs"}},
+}
+
+// operators lists every operator Compare understands
+var operators = []string{
+	"eq", "equal", "ne", "not-equal", "in", "not-in", "gt", "lt", "gte", "lte",
+	"contains", "exists", "present", "missing", "absent",
+}
+
+// redactedFields are the resource fields whose values are replaced with
+// aws.RedactedValue when read, such as Lambda environment variables. Filters
+// can check which keys they hold, never the values.
+var redactedFields = map[string]string{"lambda": "environment"}
+
+// existenceOperators don't look at the value they test
+var existenceOperators = []string{"exists", "present", "missing", "absent"}
+
+// FilterIssue describes a problem found while validating a policy's filters
+type FilterIssue struct {
+	Field   string // e.g. filters[1].filters[0].value
+	Message string
+	Unknown bool // the filter type isn't registered; allowed by AllowUnknownFilters
+}
+
+// Error implements the error interface
+func (i FilterIssue) Error() string {
+	return fmt.Sprintf("%s: %s", i.Field, i.Message)
+}
+
+// ValidateFilters checks a filter tree against the registry before anything runs
+func ValidateFilters(resourceType string, filters []storage.StoredFilter) []FilterIssue {
+	return validateFilterList(resourceType, filters, "filters")
+}
+
+// BlockingIssues drops unknown-filter issues when the policy allows experimental filters
+func BlockingIssues(issues []FilterIssue, allowUnknown bool) []FilterIssue {
+	if !allowUnknown {
+		return issues
+	}
+
+	var blocking []FilterIssue
+	for _, issue := range issues {
+		if !issue.Unknown {
+			blocking = append(blocking, issue)
+		}
+	}
+	return blocking
+}
+
+func validateFilterList(
+	resourceType string,
+	filters []storage.StoredFilter,
+	prefix string,
+) []FilterIssue {
+	var issues []FilterIssue
+
+	for i, filter := range filters {
+		field := fmt.Sprintf("%s[%d]", prefix, i)
+
+		if filter.Type == "" {
+			issues = append(issues, FilterIssue{Field: field + ".type", Message: "filter type cannot be empty"})
+			continue
+		}
+
+		spec, known := FilterRegistry[filter.Type]
+		if !known {
+			message := fmt.Sprintf("unknown filter type '%s'", filter.Type)
+			if suggestion := suggestFilter(filter.Type); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+			}
+			issues = append(issues, FilterIssue{Field: field + ".type", Message: message, Unknown: true})
+			continue
+		}
+
+		if len(spec.Resources) > 0 && resourceType != "" && !containsString(spec.Resources, resourceType) {
+			issues = append(issues, FilterIssue{
+				Field:   field + ".type",
+				Message: fmt.Sprintf("filter '%s' does not apply to %s resources", filter.Type, resourceType),
+			})
+			continue
+		}
+
+		if spec.Kind == KindGroup {
+			if len(filter.Filters) == 0 {
+				issues = append(issues, FilterIssue{
+					Field:   field + ".filters",
+					Message: fmt.Sprintf("'%s' filter needs at least one child filter", filter.Type),
+				})
+			}
+			issues = append(issues, validateFilterList(resourceType, filter.Filters, field+".filters")...)
+			continue
+		}
+
+		if len(filter.Filters) > 0 {
+			issues = append(issues, FilterIssue{
+				Field:   field + ".filters",
+				Message: fmt.Sprintf("only and / or / not filters can have child filters, not '%s'", filter.Type),
+			})
+		}
+
+		if spec.NeedsKey && filter.Key == "" {
+			issues = append(issues, FilterIssue{Field: field + ".key", Message: "key is required"})
+		}
+		if filter.Type == "value" && filter.Key != "" {
+			if err := ValidatePath(filter.Key); err != nil {
+				issues = append(issues, FilterIssue{Field: field + ".key", Message: err.Error()})
+			}
+		}
+
+		op := filter.Op
+		if op == "" {
+			op = spec.DefaultOp
+		}
+		if !containsString(operators, op) {
+			issues = append(issues, FilterIssue{
+				Field:   field + ".op",
+				Message: fmt.Sprintf("unknown operator '%s'", filter.Op),
+			})
+			continue
+		}
+
+		if message := checkRedactedFilter(resourceType, filter, op); message != "" {
+			issues = append(issues, FilterIssue{Field: field + ".op", Message: message})
+			continue
+		}
+
+		if message := checkFilterValue(spec.Kind, op, filter.Value); message != "" {
+			issues = append(issues, FilterIssue{Field: field + ".value", Message: message})
+		}
+	}
+
+	return issues
+}
+
+// checkRedactedFilter returns a message if a filter would compare against a
+// redacted field's values: the whole field can only be tested for the keys it
+// contains, and one of its keys only for whether it exists
+func checkRedactedFilter(resourceType string, filter storage.StoredFilter, op string) string {
+	redacted, ok := redactedFields[resourceType]
+	if !ok {
+		return ""
+	}
+
+	path := []string{filter.Type}
+	if filter.Type == "value" {
+		segments, err := parsePath(filter.Key)
+		if err != nil {
+			return ""
+		}
+		path = segments
+	}
+	if path[0] != redacted {
+		return ""
+	}
+
+	allowed := existenceOperators
+	if len(path) == 1 {
+		allowed = append([]string{"contains"}, existenceOperators...)
+	}
+	if containsString(allowed, op) {
+		return ""
+	}
+	name := redacted
+	if filter.Type == "value" {
+		name = filter.Key
+	}
+	return fmt.Sprintf("%s values are redacted when read, so '%s' can only use %s",
+		redacted, name, strings.Join(allowed, ", "))
+}
+
+// checkFilterValue returns a message if the value doesn't fit the filter's kind and operator
+func checkFilterValue(kind ValueKind, op string, value interface{}) string {
+	switch op {
+	case "exists", "present", "missing", "absent":
+		return ""
+	case "gt", "lt", "gte", "lte":
+		if kind == KindBool {
+			return fmt.Sprintf("operator '%s' can't be used on a true/false filter", op)
+		}
+	}
+
+	if value == nil || kind == KindAny || kind == KindNone {
+		return ""
+	}
+
+	if op == "in" || op == "not-in" {
+		list, ok := toList(normalizeValue(value))
+		if !ok {
+			return fmt.Sprintf("operator '%s' needs a list value, got %T", op, value)
+		}
+		for _, item := range list {
+			if message := checkValueKind(kind, item); message != "" {
+				return message
+			}
+		}
+		return ""
+	}
+
+	return checkValueKind(kind, value)
+}
+
+// checkValueKind returns a message if a single value can't be coerced to kind
+func checkValueKind(kind ValueKind, value interface{}) string {
+	switch kind {
+	case KindNumber:
+		if _, ok := ToNumber(value); !ok {
+			return fmt.Sprintf("expected a number, got %s", describeValue(value))
+		}
+	case KindBool:
+		if _, ok := toBool(normalizeValue(value)); !ok {
+			return fmt.Sprintf("expected true or false, got %s", describeValue(value))
+		}
+	case KindTime:
+		if _, ok := ToTime(value); !ok {
+			return fmt.Sprintf("expected a date or timestamp, got %s", describeValue(value))
+		}
+	case KindString:
+		switch normalizeValue(value).(type) {
+		case []interface{}, map[string]interface{}:
+			return fmt.Sprintf("expected a single value, got %s", describeValue(value))
+		}
+	}
+	return ""
+}
+
+func describeValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%T %v", value, value)
+}
+
+// suggestFilter returns the registered filter name closest to a misspelled one
+func suggestFilter(name string) string {
+	names := make([]string, 0, len(FilterRegistry))
+	for known := range FilterRegistry {
+		names = append(names, known)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", 4 // ignore anything more than 3 edits away
+	for _, known := range names {
+		if distance := editDistance(strings.ToLower(name), known); distance < bestDistance {
+			best, bestDistance = known, distance
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
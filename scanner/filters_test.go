@@ -0,0 +1,97 @@
+package scanner
+
+import (
+	"custodian-killer/aws"
+	"custodian-killer/storage"
+	"strings"
+	"testing"
+)
+
+func TestValidateFiltersRedactedFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourceType string
+		filter       storage.StoredFilter
+		wantErr      string // substring of the issue, "" for none
+	}{
+		{name: "variable names", resourceType: "lambda", filter: storage.StoredFilter{Type: "environment", Value: "DB_HOST"}},
+		{name: "environment missing", resourceType: "lambda", filter: storage.StoredFilter{Type: "environment", Op: "missing"}},
+		{name: "value filter on the whole map", resourceType: "lambda", filter: storage.StoredFilter{Type: "value", Key: "environment", Op: "contains", Value: "DB_HOST"}},
+		{name: "one variable exists", resourceType: "lambda", filter: storage.StoredFilter{Type: "value", Key: "environment.DB_HOST", Op: "exists"}},
+		{name: "quoted variable missing", resourceType: "lambda", filter: storage.StoredFilter{Type: "value", Key: `environment["DB_HOST"]`, Op: "absent"}},
+		{name: "other lambda fields", resourceType: "lambda", filter: storage.StoredFilter{Type: "value", Key: "runtime", Value: "go1.x"}},
+		{name: "other resource types", resourceType: "ec2", filter: storage.StoredFilter{Type: "value", Key: "environment.stage", Value: "prod"}},
+
+		{
+			name:         "variable value",
+			resourceType: "lambda",
+			filter:       storage.StoredFilter{Type: "value", Key: "environment.STAGE", Value: "prod"},
+			wantErr:      "'environment.STAGE' can only use exists, present, missing, absent",
+		},
+		{
+			name:         "variable substring",
+			resourceType: "lambda",
+			filter:       storage.StoredFilter{Type: "value", Key: `environment["STAGE"]`, Op: "contains", Value: "prod"},
+			wantErr:      "environment values are redacted",
+		},
+		{
+			name:         "environment map equality",
+			resourceType: "lambda",
+			filter:       storage.StoredFilter{Type: "environment", Op: "eq", Value: map[string]interface{}{"STAGE": "prod"}},
+			wantErr:      "'environment' can only use contains, exists",
+		},
+		{
+			name:         "inside a group",
+			resourceType: "lambda",
+			filter: storage.StoredFilter{Type: "not", Filters: []storage.StoredFilter{
+				{Type: "value", Key: "environment.STAGE", Op: "in", Value: []interface{}{"prod"}},
+			}},
+			wantErr: "filters[0].filters[0].op",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issues := ValidateFilters(test.resourceType, []storage.StoredFilter{test.filter})
+			if test.wantErr == "" {
+				if len(issues) > 0 {
+					t.Fatalf("ValidateFilters: %v", issues)
+				}
+				return
+			}
+			if len(issues) != 1 || !strings.Contains(issues[0].Error(), test.wantErr) {
+				t.Fatalf("ValidateFilters = %v, want one issue containing %q", issues, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedactedEnvironmentFilters(t *testing.T) {
+	function := aws.LambdaFunction{
+		FunctionName: "billing",
+		Runtime:      "go1.x",
+		Environment:  aws.RedactValues(map[string]string{"DB_HOST": "db.internal", "STAGE": "prod"}),
+	}
+	resource := LambdaFunctionToResource(function, "us-east-1")
+
+	tests := []struct {
+		name   string
+		filter storage.StoredFilter
+		want   bool
+	}{
+		{name: "has variable", filter: storage.StoredFilter{Type: "environment", Value: "DB_HOST"}, want: true},
+		{name: "lacks variable", filter: storage.StoredFilter{Type: "environment", Value: "API_KEY"}, want: false},
+		{name: "variable exists", filter: storage.StoredFilter{Type: "value", Key: "environment.STAGE", Op: "exists"}, want: true},
+		{name: "variable missing", filter: storage.StoredFilter{Type: "value", Key: "environment.API_KEY", Op: "missing"}, want: true},
+		// What validation guards against: the value read is never the real one
+		{name: "values are redacted", filter: storage.StoredFilter{Type: "value", Key: "environment.STAGE", Value: aws.RedactedValue}, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := MatchesFilters([]storage.StoredFilter{test.filter}, resource); got != test.want {
+				t.Errorf("MatchesFilters = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultMarkTag is the tag mark-for-op writes to, the same one Cloud Custodian uses
+const DefaultMarkTag = "maid_status"
+
+// markDateLayout is the date format inside a mark, e.g. stop@2025/01/31
+const markDateLayout = "2006/01/02"
+
+// FormatMark builds the tag value that schedules op on a resource for the given day
+func FormatMark(op string, due time.Time) string {
+	return fmt.Sprintf("Resource does not meet policy: %s@%s", op, due.Format(markDateLayout))
+}
+
+// ParseMark reads the operation and due date out of a mark tag value
+func ParseMark(value string) (op string, due time.Time, ok bool) {
+	// Anything before the last ':' is a free-form message
+	if colon := strings.LastIndex(value, ":"); colon >= 0 {
+		value = value[colon+1:]
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(value), "@", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", time.Time{}, false
+	}
+
+	due, err := time.ParseInLocation(markDateLayout, parts[1], time.UTC)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return parts[0], due, true
+}
+
+// dueMark returns the marked operation when the resource's mark has come due
+func dueMark(resource MatchedResource, tag string, now time.Time) (string, bool) {
+	if tag == "" {
+		tag = DefaultMarkTag
+	}
+	op, due, ok := ParseMark(resource.Tags[tag])
+	if !ok || now.Before(due) {
+		return "", false
+	}
+	return op, true
+}
@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LookupPath resolves a path such as "encryption.algorithm", "tags.Owner",
+// "security_groups[0]" or `tags["Cost Center"]` inside a value. Structs are
+// looked up by their JSON field names.
+func LookupPath(value interface{}, path string) (interface{}, bool) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, false
+	}
+
+	current := toDocument(value)
+	for _, segment := range segments {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, exists := node[segment]
+			if !exists {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return nil, false
+			}
+			if index < 0 {
+				index += len(node) // negative indexes count from the end
+			}
+			if index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// ValidatePath reports whether a path expression is well formed
+func ValidatePath(path string) error {
+	_, err := parsePath(path)
+	return err
+}
+
+// ResourceDocument returns the lookup documents for a resource in priority order:
+// the scanner properties, the raw AWS data and finally the common resource fields
+func ResourceDocument(resource MatchedResource) []interface{} {
+	common := map[string]interface{}{
+		"id":     resource.ID,
+		"name":   resource.Name,
+		"type":   resource.Type,
+		"region": resource.Region,
+		"state":  resource.State,
+		"tags":   toDocument(resource.Tags),
+	}
+
+	var documents []interface{}
+	if resource.Properties != nil {
+		documents = append(documents, toDocument(resource.Properties))
+	}
+	if resource.Raw != nil {
+		documents = append(documents, resource.Raw)
+	}
+	return append(documents, common)
+}
+
+// lookupResourcePath resolves a path against every document of a resource
+func lookupResourcePath(resource MatchedResource, path string) (interface{}, bool) {
+	for _, document := range ResourceDocument(resource) {
+		if value, found := LookupPath(document, path); found {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// toDocument converts structs and typed maps/slices to plain JSON values
+func toDocument(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, float64, map[string]interface{}, []interface{}:
+		return v
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil
+	}
+	return document
+}
+
+// parsePath splits a path expression into map keys and list indexes
+func parsePath(path string) ([]string, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("path is empty")
+	}
+
+	var segments []string
+	var current strings.Builder
+	expectSegment := true
+
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '.':
+			if current.Len() == 0 && expectSegment {
+				return nil, fmt.Errorf("empty segment at position %d in '%s'", i, path)
+			}
+			if current.Len() > 0 {
+				segments = append(segments, current.String())
+				current.Reset()
+			}
+			expectSegment = true
+		case '[':
+			if current.Len() > 0 {
+				segments = append(segments, current.String())
+				current.Reset()
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '[' at position %d in '%s'", i, path)
+			}
+			inner := strings.TrimSpace(path[i+1 : i+end])
+			if unquoted, err := strconv.Unquote(inner); err == nil {
+				segments = append(segments, unquoted)
+			} else if _, err := strconv.Atoi(inner); err == nil {
+				segments = append(segments, inner)
+			} else {
+				return nil, fmt.Errorf("invalid index '[%s]' in '%s'", inner, path)
+			}
+			i += end
+			expectSegment = false
+		default:
+			current.WriteByte(c)
+			expectSegment = false
+		}
+	}
+
+	if current.Len() > 0 {
+		segments = append(segments, current.String())
+	} else if expectSegment {
+		return nil, fmt.Errorf("path '%s' ends with '.'", path)
+	}
+
+	return segments, nil
+}
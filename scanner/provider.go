@@ -0,0 +1,356 @@
+package scanner
+
+import (
+	"context"
+	"custodian-killer/aws"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ResourceProvider lists the resources a policy is evaluated against
+type ResourceProvider interface {
+	ListResources(ctx context.Context, resourceType string) ([]MatchedResource, error)
+}
+
+// ScannableTypes are the resource types AWSResourceProvider can list
+var ScannableTypes = []string{"ec2", "s3", "rds", "lambda", "ebs"}
+
+// AWSResourceProvider lists live resources through the AWS client
+type AWSResourceProvider struct {
+	client *aws.CustodianClient
+}
+
+// NewAWSResourceProvider creates a resource provider backed by AWS
+func NewAWSResourceProvider(client *aws.CustodianClient) *AWSResourceProvider {
+	return &AWSResourceProvider{client: client}
+}
+
+// Region is the region the provider lists resources in
+func (p *AWSResourceProvider) Region() string {
+	return p.client.Region
+}
+
+// ListResources fetches every resource of the given type in the client's region
+func (p *AWSResourceProvider) ListResources(ctx context.Context, resourceType string) ([]MatchedResource, error) {
+	var resources []MatchedResource
+
+	switch resourceType {
+	case "ec2":
+		instances, err := p.client.GetEC2Instances(ctx, aws.EC2Filter{IncludeMetrics: true})
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range instances {
+			resources = append(resources, EC2InstanceToResource(instance, p.client.Region))
+		}
+	case "s3":
+		buckets, err := p.client.GetS3Buckets(ctx, aws.S3Filter{})
+		if err != nil {
+			return nil, err
+		}
+		for _, bucket := range buckets {
+			resources = append(resources, S3BucketToResource(bucket))
+		}
+	case "rds":
+		instances, err := p.client.GetRDSInstances(ctx, aws.RDSFilter{})
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range instances {
+			resources = append(resources, RDSInstanceToResource(instance, p.client.Region))
+		}
+	case "lambda":
+		functions, err := p.client.GetLambdaFunctions(ctx, aws.LambdaFilter{})
+		if err != nil {
+			return nil, err
+		}
+		for _, function := range functions {
+			resources = append(resources, LambdaFunctionToResource(function, p.client.Region))
+		}
+	case "ebs":
+		volumes, err := p.client.GetEBSVolumes(ctx, aws.EBSFilter{})
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range volumes {
+			resources = append(resources, EBSVolumeToResource(volume, p.client.Region))
+		}
+	default:
+		return nil, fmt.Errorf("resource type '%s' is not supported by the scanner yet", resourceType)
+	}
+
+	return resources, nil
+}
+
+// StaticProvider serves a fixed set of resources, such as test fixtures
+type StaticProvider struct {
+	resources map[string][]MatchedResource
+}
+
+// NewStaticProvider creates a provider that returns the given resources by type
+func NewStaticProvider(resources map[string][]MatchedResource) *StaticProvider {
+	return &StaticProvider{resources: resources}
+}
+
+// ListResources returns a copy of the resources of the given type
+func (p *StaticProvider) ListResources(ctx context.Context, resourceType string) ([]MatchedResource, error) {
+	return append([]MatchedResource(nil), p.resources[resourceType]...), nil
+}
+
+// ResourceFromJSON decodes one resource shaped like the given kind: an AWS type
+// (ec2, s3, rds, lambda, ebs) or "resource" for a MatchedResource as-is
+func ResourceFromJSON(kind string, data []byte, region string) (MatchedResource, error) {
+	var err error
+	var resource MatchedResource
+
+	switch kind {
+	case "ec2":
+		var instance aws.EC2Instance
+		if err = json.Unmarshal(data, &instance); err == nil {
+			resource = EC2InstanceToResource(instance, region)
+		}
+	case "s3":
+		var bucket aws.S3Bucket
+		if err = json.Unmarshal(data, &bucket); err == nil {
+			if bucket.Region == "" {
+				bucket.Region = region
+			}
+			resource = S3BucketToResource(bucket)
+		}
+	case "rds":
+		var instance aws.RDSInstance
+		if err = json.Unmarshal(data, &instance); err == nil {
+			resource = RDSInstanceToResource(instance, region)
+		}
+	case "lambda":
+		var function aws.LambdaFunction
+		if err = json.Unmarshal(data, &function); err == nil {
+			resource = LambdaFunctionToResource(function, region)
+		}
+	case "ebs":
+		var volume aws.EBSVolume
+		if err = json.Unmarshal(data, &volume); err == nil {
+			resource = EBSVolumeToResource(volume, region)
+		}
+	case "resource":
+		if err = json.Unmarshal(data, &resource); err == nil && resource.Region == "" {
+			resource.Region = region
+		}
+	default:
+		return resource, fmt.Errorf("unknown resource kind '%s'", kind)
+	}
+
+	if err != nil {
+		return resource, fmt.Errorf("invalid %s resource: %v", kind, err)
+	}
+	if resource.ID == "" {
+		return resource, fmt.Errorf("%s resource has no id", kind)
+	}
+	return resource, nil
+}
+
+// EC2InstanceToResource converts an EC2 instance to a scanner resource
+func EC2InstanceToResource(instance aws.EC2Instance, region string) MatchedResource {
+	resource := MatchedResource{
+		ID:     instance.InstanceID,
+		Name:   instance.Name,
+		Type:   instance.InstanceType,
+		Region: region,
+		State:  instance.State,
+		Tags:   instance.Tags,
+		Properties: map[string]interface{}{
+			"instance_type":          instance.InstanceType,
+			"launch_time":            instance.LaunchTime.Format(time.RFC3339),
+			"cpu_utilization":        instance.CPUUtilization,
+			"running_days":           instance.RunningDays,
+			"vpc_id":                 instance.VpcID,
+			"subnet_id":              instance.SubnetID,
+			"public_ip":              instance.PublicIP,
+			"private_ip":             instance.PrivateIP,
+			"security_groups":        instance.SecurityGroups,
+			"platform":               instance.Platform,
+			"estimated_monthly_cost": instance.MonthlyCost,
+		},
+		Raw:        rawDocument(instance),
+		RiskLevel:  "low",
+		Compliance: ComplianceStatus{Compliant: true},
+	}
+
+	if instance.State == "running" && instance.CPUUtilization > 0 && instance.CPUUtilization < 5.0 {
+		resource.addIssue("CPU utilization below threshold", "medium")
+	}
+	if instance.State == "running" && instance.RunningDays > 30 {
+		resource.addIssue("Long running instance", "medium")
+	}
+	if len(instance.Tags) == 0 {
+		resource.addIssue("Instance has no tags", "low")
+	}
+	if instance.PublicIP != "" {
+		resource.addIssue("Instance has a public IP address", "medium")
+	}
+
+	return resource
+}
+
+// S3BucketToResource converts an S3 bucket to a scanner resource
+func S3BucketToResource(bucket aws.S3Bucket) MatchedResource {
+	resource := MatchedResource{
+		ID:     bucket.Name,
+		Name:   bucket.Name,
+		Type:   "s3-bucket",
+		Region: bucket.Region,
+		State:  "active",
+		Tags:   bucket.Tags,
+		Properties: map[string]interface{}{
+			"public_read":            bucket.PublicReadACL || bucket.PublicReadPolicy,
+			"public_write":           bucket.PublicWriteACL || bucket.PublicWritePolicy,
+			"versioning":             bucket.Versioning == "Enabled",
+			"encryption":             bucket.Encryption.Enabled,
+			"encryption_algorithm":   bucket.Encryption.Algorithm,
+			"block_public_acls":      bucket.BlockPublicACLs,
+			"block_public_policy":    bucket.BlockPublicPolicy,
+			"security_score":         bucket.SecurityScore,
+			"size":                   bucket.SizeBytes,
+			"creation_date":          bucket.CreationDate.Format(time.RFC3339),
+			"estimated_monthly_cost": bucket.MonthlyCostEstimate,
+		},
+		Raw:        rawDocument(bucket),
+		RiskLevel:  "low",
+		Compliance: ComplianceStatus{Compliant: true},
+	}
+
+	for _, issue := range bucket.ComplianceIssues {
+		severity := "medium"
+		if bucket.SecurityScore < 50 {
+			severity = "high"
+		}
+		resource.addIssue(issue, severity)
+	}
+
+	return resource
+}
+
+// RDSInstanceToResource converts an RDS instance to a scanner resource
+func RDSInstanceToResource(instance aws.RDSInstance, region string) MatchedResource {
+	resource := MatchedResource{
+		ID:     instance.DBInstanceID,
+		Name:   instance.DBInstanceID,
+		Type:   instance.InstanceClass,
+		Region: region,
+		State:  instance.Status,
+		Tags:   instance.Tags,
+		Properties: map[string]interface{}{
+			"engine":                  instance.Engine,
+			"engine_version":          instance.EngineVersion,
+			"instance_class":          instance.InstanceClass,
+			"backup_retention_period": instance.BackupRetentionPeriod,
+			"multi_az":                instance.MultiAZ,
+			"encrypted":               instance.Encrypted,
+			"publicly_accessible":     instance.PubliclyAccessible,
+			"allocated_storage_gb":    instance.AllocatedStorageGB,
+			"estimated_monthly_cost":  instance.MonthlyCost,
+		},
+		Raw:        rawDocument(instance),
+		RiskLevel:  "low",
+		Compliance: ComplianceStatus{Compliant: true},
+	}
+
+	if instance.BackupRetentionPeriod < 7 {
+		resource.addIssue("Backup retention below policy minimum", "medium")
+	}
+	if !instance.Encrypted {
+		resource.addIssue("Storage encryption disabled", "high")
+	}
+	if instance.PubliclyAccessible {
+		resource.addIssue("Database is publicly accessible", "high")
+	}
+
+	return resource
+}
+
+// LambdaFunctionToResource converts a Lambda function to a scanner resource
+func LambdaFunctionToResource(function aws.LambdaFunction, region string) MatchedResource {
+	resource := MatchedResource{
+		ID:     function.FunctionName,
+		Name:   function.FunctionName,
+		Type:   "lambda-function",
+		Region: region,
+		State:  "active",
+		Tags:   function.Tags,
+		Properties: map[string]interface{}{
+			"runtime":           function.Runtime,
+			"memory_size":       function.MemorySizeMB,
+			"timeout":           function.TimeoutSeconds,
+			"code_size":         function.CodeSizeBytes,
+			"last_modified":     function.LastModified.Format(time.RFC3339),
+			"days_since_update": function.DaysSinceUpdate,
+			"role":              function.Role,
+		},
+		Raw:        rawDocument(function),
+		RiskLevel:  "low",
+		Compliance: ComplianceStatus{Compliant: true},
+	}
+
+	if function.DaysSinceUpdate > 365 {
+		resource.addIssue("Function not updated in over a year", "low")
+	}
+	if len(function.Tags) == 0 {
+		resource.addIssue("Function has no tags", "low")
+	}
+
+	return resource
+}
+
+// EBSVolumeToResource converts an EBS volume to a scanner resource
+func EBSVolumeToResource(volume aws.EBSVolume, region string) MatchedResource {
+	resource := MatchedResource{
+		ID:     volume.VolumeID,
+		Name:   volume.Name,
+		Type:   volume.VolumeType,
+		Region: region,
+		State:  volume.State,
+		Tags:   volume.Tags,
+		Properties: map[string]interface{}{
+			"volume_type":            volume.VolumeType,
+			"size":                   volume.SizeGB,
+			"encrypted":              volume.Encrypted,
+			"attachment_state":       volume.AttachmentState,
+			"age_days":               volume.AgeDays,
+			"snapshot_id":            volume.SnapshotID,
+			"estimated_monthly_cost": volume.MonthlyCost,
+		},
+		Raw:        rawDocument(volume),
+		RiskLevel:  "low",
+		Compliance: ComplianceStatus{Compliant: true},
+	}
+
+	if volume.AttachmentState == "detached" {
+		resource.addIssue("Volume is not attached to any instance", "medium")
+	}
+	if !volume.Encrypted {
+		resource.addIssue("Volume is not encrypted", "medium")
+	}
+
+	return resource
+}
+
+// rawDocument converts an AWS struct to a JSON map for path lookups
+func rawDocument(value interface{}) map[string]interface{} {
+	document, _ := toDocument(value).(map[string]interface{})
+	return document
+}
+
+// addIssue records a compliance issue and raises the risk level if needed
+func (r *MatchedResource) addIssue(issue, severity string) {
+	r.Compliance.Compliant = false
+	r.Compliance.Issues = append(r.Compliance.Issues, issue)
+
+	rank := map[string]int{"": 0, "low": 1, "medium": 2, "high": 3}
+	if rank[severity] > rank[r.Compliance.Severity] {
+		r.Compliance.Severity = severity
+	}
+	if rank[severity] > rank[r.RiskLevel] {
+		r.RiskLevel = severity
+	}
+}
@@ -0,0 +1,273 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	Expression string
+
+	minutes  uint64 // bit n set = minute n matches
+	hours    uint64
+	days     uint64 // day of month, 1-31
+	months   uint64 // 1-12
+	weekdays uint64 // 0-6, Sunday = 0
+
+	daysRestricted     bool // day of month field doesn't start with '*'
+	weekdaysRestricted bool // day of week field doesn't start with '*'
+
+	every  time.Duration // set for "@every <duration>"
+	fields []string      // the five fields, with descriptors expanded
+}
+
+// field describes one of the five cron fields
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField  = field{name: "minute", min: 0, max: 59}
+	hourField    = field{name: "hour", min: 0, max: 23}
+	dayField     = field{name: "day of month", min: 1, max: 31}
+	monthField   = field{name: "month", min: 1, max: 12, names: monthNames}
+	weekdayField = field{name: "day of week", min: 0, max: 6, names: weekdayNames}
+)
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// descriptors maps the @shortcuts to their five-field equivalent
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a standard five-field cron expression (minute hour day-of-month
+// month day-of-week), a descriptor like @daily, or "@every 15m"
+func Parse(expression string) (*Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+
+	if strings.HasPrefix(expression, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expression, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every interval: %v", err)
+		}
+		if interval < time.Minute {
+			return nil, fmt.Errorf("@every interval must be at least 1m, got %s", interval)
+		}
+		return &Schedule{Expression: expression, every: interval}, nil
+	}
+
+	fields := expression
+	if strings.HasPrefix(expression, "@") {
+		descriptor, exists := descriptors[strings.ToLower(expression)]
+		if !exists {
+			return nil, fmt.Errorf("unknown descriptor '%s'", expression)
+		}
+		fields = descriptor
+	}
+
+	parts := strings.Fields(fields)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf(
+			"expected 5 fields (minute hour day-of-month month day-of-week), got %d",
+			len(parts),
+		)
+	}
+
+	s := &Schedule{Expression: expression, fields: parts}
+	var err error
+	if s.minutes, err = parseField(parts[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hours, err = parseField(parts[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.days, err = parseField(parts[2], dayField); err != nil {
+		return nil, err
+	}
+	if s.months, err = parseField(parts[3], monthField); err != nil {
+		return nil, err
+	}
+	// 7 is accepted as Sunday, like most cron implementations
+	weekdays := weekdayField
+	weekdays.max = 7
+	if s.weekdays, err = parseField(parts[4], weekdays); err != nil {
+		return nil, err
+	}
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1
+	}
+
+	// As in Vixie cron, a day field starting with '*' (including */N) doesn't
+	// count as restricted when deciding how the two day fields combine
+	s.daysRestricted = !strings.HasPrefix(parts[2], "*")
+	s.weekdaysRestricted = !strings.HasPrefix(parts[4], "*")
+
+	// Without a day of week to fall back on, the day of month must exist in
+	// one of the months, or the schedule would never run
+	if !s.weekdaysRestricted && !s.daysFitMonths() {
+		return nil, fmt.Errorf("day of month '%s' never falls in month '%s'", parts[2], parts[3])
+	}
+
+	return s, nil
+}
+
+// monthLengths holds the longest each month gets, counting leap years
+var monthLengths = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// daysFitMonths reports whether any chosen day of month exists in a chosen month
+func (s *Schedule) daysFitMonths() bool {
+	for month := 1; month <= 12; month++ {
+		if s.months&(1<<uint(month)) == 0 {
+			continue
+		}
+		for day := 1; day <= monthLengths[month]; day++ {
+			if s.days&(1<<uint(day)) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Fields returns the five cron fields with descriptors like @daily expanded,
+// or nil for an @every schedule
+func (s *Schedule) Fields() []string {
+	return append([]string(nil), s.fields...)
+}
+
+// Every returns the interval of an @every schedule, or zero
+func (s *Schedule) Every() time.Duration {
+	return s.every
+}
+
+// Next returns the first time after t that matches the schedule
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every).Truncate(time.Second)
+	}
+
+	// Start at the next whole minute
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Cron expressions repeat at least every few years; give up after that
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches applies cron's rule: when both day fields are restricted, either may match
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dayMatch := s.days&(1<<uint(t.Day())) != 0
+	weekdayMatch := s.weekdays&(1<<uint(t.Weekday())) != 0
+
+	if s.daysRestricted && s.weekdaysRestricted {
+		return dayMatch || weekdayMatch
+	}
+	return dayMatch && weekdayMatch
+}
+
+// parseField parses one comma separated cron field into a bit set
+func parseField(text string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(text, ",") {
+		rangePart, step := part, 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			rangePart = part[:slash]
+			var err error
+			step, err = strconv.Atoi(part[slash+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step '%s' in %s field", part[slash+1:], f.name)
+			}
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = parseValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range '%s' in %s field", rangePart, f.name)
+			}
+		default:
+			value, err := parseValue(rangePart, f)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			if step == 1 {
+				high = value
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+// parseValue parses a single number or name within a field's bounds
+func parseValue(text string, f field) (int, error) {
+	if value, exists := f.names[strings.ToLower(text)]; exists {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s' in %s field", text, f.name)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("%s value %d out of range %d-%d", f.name, value, f.min, f.max)
+	}
+	return value, nil
+}
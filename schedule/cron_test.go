@@ -0,0 +1,107 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantErr    string // substring of the error, "" for none
+	}{
+		{expression: "*/15 * * * *"},
+		{expression: "0 9-17 * * mon-fri"},
+		{expression: "0 0 1,15 jan,jul *"},
+		{expression: "0 0 * * 7"},
+		{expression: "0 0 29 2 *"},
+		{expression: "0 0 31 2 1"}, // the day of week still fires
+		{expression: "@daily"},
+		{expression: "@every 90m"},
+
+		{expression: "", wantErr: "empty"},
+		{expression: "* * * *", wantErr: "expected 5 fields"},
+		{expression: "60 * * * *", wantErr: "out of range"},
+		{expression: "* 24 * * *", wantErr: "out of range"},
+		{expression: "* * 0 * *", wantErr: "out of range"},
+		{expression: "* * * 13 *", wantErr: "out of range"},
+		{expression: "* * * * 8", wantErr: "out of range"},
+		{expression: "*/0 * * * *", wantErr: "invalid step"},
+		{expression: "5-1 * * * *", wantErr: "invalid range"},
+		{expression: "* * * foo *", wantErr: "invalid value"},
+		{expression: "@sometimes", wantErr: "unknown descriptor"},
+		{expression: "@every 10s", wantErr: "at least 1m"},
+		{expression: "@every soon", wantErr: "invalid @every"},
+		{expression: "0 0 31 2 *", wantErr: "never falls"},
+		{expression: "0 0 30,31 feb *", wantErr: "never falls"},
+		{expression: "0 0 31 4,6,9,11 *", wantErr: "never falls"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			_, err := Parse(test.expression)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse(%q): %v", test.expression, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("Parse(%q) error = %v, want one containing %q", test.expression, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	// 2025-01-01 is a Wednesday
+	tests := []struct {
+		name       string
+		expression string
+		from       string
+		want       string
+	}{
+		{name: "every minute", expression: "* * * * *", from: "2025-01-01 10:00", want: "2025-01-01 10:01"},
+		{name: "step minutes", expression: "*/15 * * * *", from: "2025-01-01 10:01", want: "2025-01-01 10:15"},
+		{name: "next day", expression: "30 2 * * *", from: "2025-01-01 03:00", want: "2025-01-02 02:30"},
+		{name: "weekday range", expression: "0 9 * * mon-fri", from: "2025-01-03 10:00", want: "2025-01-06 09:00"},
+		{name: "7 is Sunday", expression: "0 0 * * 7", from: "2025-01-01 00:00", want: "2025-01-05 00:00"},
+		{name: "month rollover", expression: "0 0 1 * *", from: "2025-01-15 00:00", want: "2025-02-01 00:00"},
+		{name: "year rollover", expression: "@yearly", from: "2025-06-01 00:00", want: "2026-01-01 00:00"},
+		{name: "31st skips short months", expression: "0 0 31 * *", from: "2025-04-01 00:00", want: "2025-05-31 00:00"},
+		{name: "leap day", expression: "0 0 29 2 *", from: "2025-01-01 00:00", want: "2028-02-29 00:00"},
+
+		// Both day fields restricted: either may match
+		{name: "day of month or day of week", expression: "0 0 15 * fri", from: "2025-01-01 00:00", want: "2025-01-03 00:00"},
+		{name: "impossible date falls back on weekday", expression: "0 0 31 2 mon", from: "2025-01-31 12:00", want: "2025-02-03 00:00"},
+
+		// A day field starting with * doesn't count as restricted, so both must match
+		{name: "step day of month with weekday", expression: "0 0 */2 * mon", from: "2025-01-01 00:00", want: "2025-01-13 00:00"},
+		{name: "step day of week with day of month", expression: "0 0 10 * */2", from: "2025-01-01 00:00", want: "2025-04-10 00:00"},
+		{name: "step day of month alone", expression: "0 0 */10 * *", from: "2025-01-02 00:00", want: "2025-01-11 00:00"},
+
+		{name: "every interval", expression: "@every 90m", from: "2025-01-01 10:00", want: "2025-01-01 11:30"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedule, err := Parse(test.expression)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", test.expression, err)
+			}
+			got := schedule.Next(at(test.from))
+			if want := at(test.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", test.from, got.Format("2006-01-02 15:04 Mon"), test.want)
+			}
+		})
+	}
+}
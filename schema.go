@@ -0,0 +1,485 @@
+package main
+
+import (
+	"custodian-killer/aws"
+	"custodian-killer/scanner"
+	"custodian-killer/schedule"
+	"custodian-killer/storage"
+	"custodian-killer/templates"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationError points at the exact policy field that failed validation
+type ValidationError struct {
+	Field   string `json:"field"` // e.g. filters[1].value, actions[0].settings.days
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors collects every problem found in a policy
+type ValidationErrors []ValidationError
+
+// Error implements the error interface
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ParamSpec declares one action setting
+type ParamSpec struct {
+	Kind     scanner.ValueKind
+	Required bool
+	Min, Max float64 // numeric bounds, checked when Max > Min
+	Enum     []string
+}
+
+// ActionSchema declares which resources an action applies to and the settings it takes
+type ActionSchema struct {
+	Resources []string
+	Settings  map[string]ParamSpec
+	Tags      bool // settings are tag key/values instead of declared params
+}
+
+// ActionSchemas holds the parameter schema for every action
+var ActionSchemas = map[string]ActionSchema{
+	"stop": {
+		Resources: []string{"ec2", "rds"},
+		Settings:  map[string]ParamSpec{"force": {Kind: scanner.KindBool}},
+	},
+	"start":     {Resources: []string{"ec2", "rds"}},
+	"terminate": {Resources: []string{"ec2"}},
+	"delete": {
+		Resources: []string{"s3", "rds", "lambda", "ebs", "ebs-snapshot", "iam", "vpc", "elb"},
+		Settings: map[string]ParamSpec{
+			"force":               {Kind: scanner.KindBool},
+			"skip_final_snapshot": {Kind: scanner.KindBool},
+		},
+	},
+	"tag": {Tags: true},
+	"mark-for-op": {
+		Resources: []string{"ec2", "s3"},
+		Settings: map[string]ParamSpec{
+			"op":   {Kind: scanner.KindString, Required: true},
+			"days": {Kind: scanner.KindNumber, Min: 0, Max: 365},
+			"tag":  {Kind: scanner.KindString},
+		},
+	},
+	"create-snapshot": {
+		Resources: []string{"ec2", "rds", "ebs"},
+		Settings:  map[string]ParamSpec{"description": {Kind: scanner.KindString}},
+	},
+	"detach-volume": {Resources: []string{"ec2"}},
+	"detach":        {Resources: []string{"ebs"}},
+	"block-public-access": {
+		Resources: []string{"s3"},
+	},
+	"encrypt": {
+		Resources: []string{"s3", "ebs"},
+		Settings:  map[string]ParamSpec{"kms_key_id": {Kind: scanner.KindString}},
+	},
+	"enable-encryption": {
+		Resources: []string{"s3"},
+		Settings:  map[string]ParamSpec{"kms_key_id": {Kind: scanner.KindString}},
+	},
+	"enable-versioning": {Resources: []string{"s3"}},
+	"modify-backup-retention": {
+		Resources: []string{"rds"},
+		Settings: map[string]ParamSpec{
+			"backup_retention_period": {Kind: scanner.KindNumber, Required: true, Min: 1, Max: 35},
+		},
+	},
+	"modify-security-group": {
+		Resources: []string{"ec2"},
+		Settings:  map[string]ParamSpec{"group_id": {Kind: scanner.KindString}},
+	},
+	"update-configuration": {
+		Resources: []string{"lambda"},
+		Settings: map[string]ParamSpec{
+			"memory_size": {Kind: scanner.KindNumber, Min: 128, Max: 10240},
+			"timeout":     {Kind: scanner.KindNumber, Min: 1, Max: 900},
+		},
+	},
+	"update-environment": {Resources: []string{"lambda"}, Tags: true},
+	"detach-policy": {
+		Resources: []string{"iam"},
+		Settings:  map[string]ParamSpec{"policy_arn": {Kind: scanner.KindString}},
+	},
+	"add-to-group": {
+		Resources: []string{"iam"},
+		Settings:  map[string]ParamSpec{"group": {Kind: scanner.KindString, Required: true}},
+	},
+	"modify-attribute":  {Resources: []string{"vpc"}},
+	"modify-attributes": {Resources: []string{"elb"}},
+}
+
+// policyModes lists the supported execution modes
+var policyModes = []string{"pull", "push", "periodic", "event"}
+
+// ValidateStoredPolicy checks a policy against the filter and action schemas
+func ValidateStoredPolicy(policy *storage.StoredPolicy) ValidationErrors {
+	var errs ValidationErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if policy.Name == "" {
+		add("name", "policy name cannot be empty")
+	}
+
+	resourceInfo, supported := SupportedResources[policy.ResourceType]
+	if policy.ResourceType == "" {
+		add("resource_type", "resource type cannot be empty")
+	} else if !supported {
+		add("resource_type", "unsupported resource type: %s", policy.ResourceType)
+	}
+
+	// Filters are checked against the scanner's registry, then against the
+	// filters the resource type offers
+	issues := scanner.ValidateFilters(policy.ResourceType, policy.Filters)
+	for _, issue := range scanner.BlockingIssues(issues, policy.AllowUnknownFilters) {
+		errs = append(errs, ValidationError{Field: issue.Field, Message: issue.Message})
+	}
+	if supported {
+		errs = append(errs, validateResourceFilters("filters", resourceInfo, policy.Filters)...)
+	}
+
+	for i, action := range policy.Actions {
+		field := fmt.Sprintf("actions[%d]", i)
+
+		if action.Type == "" {
+			add(field+".type", "action type cannot be empty")
+			continue
+		}
+
+		schema, known := ActionSchemas[action.Type]
+		if !known {
+			add(field+".type", "unknown action type '%s'", action.Type)
+			continue
+		}
+
+		// The resource type must offer the action and the action must apply to it
+		if supported && (!containsString(resourceInfo.Actions, action.Type) ||
+			len(schema.Resources) > 0 && !containsString(schema.Resources, policy.ResourceType)) {
+			add(field+".type", "action '%s' is not available for %s resources", action.Type, policy.ResourceType)
+			continue
+		}
+
+		errs = append(errs, validateActionSettings(field+".settings", schema, action.Settings)...)
+	}
+
+	errs = append(errs, validateMode(policy.Mode)...)
+
+	for i, region := range policy.Regions {
+		if !aws.IsRegionName(region) {
+			add(fmt.Sprintf("regions[%d]", i), "'%s' is not an AWS region name", region)
+		}
+	}
+
+	return errs
+}
+
+// ValidateTemplate checks the policies a template makes: once with every
+// variable at its default, then once per option of each enum-like variable.
+// Variables the template never refers to are reported too.
+func ValidateTemplate(manager *templates.TemplateManager, template templates.PolicyTemplate) ValidationErrors {
+	instantiate := func(name string, value interface{}) ValidationErrors {
+		variables := map[string]interface{}{"policy_name": template.ID}
+		for _, variable := range template.Variables {
+			variables[variable.Name] = variable.DefaultValue
+		}
+		if name != "" {
+			variables[name] = value
+		}
+
+		definition, err := manager.InstantiateTemplate(template.ID, variables)
+		if err != nil {
+			return ValidationErrors{{Field: "template", Message: err.Error()}}
+		}
+		policy := convertPolicyToStored(convertTemplatePolicyToPolicy(definition))
+		return ValidateStoredPolicy(&policy)
+	}
+
+	errs := instantiate("", nil)
+	for _, variable := range template.Variables {
+		field := "variables." + variable.Name
+		for _, option := range variable.Options {
+			if fmt.Sprint(variable.DefaultValue) == option {
+				continue
+			}
+			// Only what the option breaks, not what the defaults already did
+			for _, optionErr := range instantiate(variable.Name, option) {
+				if !containsValidationError(errs, optionErr) {
+					errs = append(errs, ValidationError{
+						Field:   field,
+						Message: fmt.Sprintf("option '%s' makes an invalid policy: %v", option, optionErr),
+					})
+				}
+			}
+		}
+	}
+	for _, name := range template.UnusedVariables() {
+		errs = append(errs, ValidationError{Field: "variables." + name, Message: "the template never uses this variable"})
+	}
+	return errs
+}
+
+func containsValidationError(errs ValidationErrors, target ValidationError) bool {
+	for _, err := range errs {
+		if err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// validateResourceFilters reports filters the scanner knows but the resource
+// type doesn't offer, looking inside and / or / not groups
+func validateResourceFilters(
+	prefix string,
+	resourceInfo ResourceType,
+	filters []storage.StoredFilter,
+) ValidationErrors {
+	var errs ValidationErrors
+	for i, filter := range filters {
+		field := fmt.Sprintf("%s[%d]", prefix, i)
+		spec, known := scanner.FilterRegistry[filter.Type]
+		switch {
+		case !known, len(spec.Resources) > 0 && !containsString(spec.Resources, resourceInfo.Name):
+			// scanner.ValidateFilters already reported it
+		case spec.Kind == scanner.KindGroup:
+			errs = append(errs, validateResourceFilters(field+".filters", resourceInfo, filter.Filters)...)
+		case !containsString(resourceInfo.Filters, filter.Type):
+			errs = append(errs, ValidationError{
+				Field:   field + ".type",
+				Message: fmt.Sprintf("filter '%s' is not available for %s resources", filter.Type, resourceInfo.Name),
+			})
+		}
+	}
+	return errs
+}
+
+// validateActionSettings checks action settings against the action's schema
+func validateActionSettings(
+	field string,
+	schema ActionSchema,
+	settings map[string]interface{},
+) ValidationErrors {
+	var errs ValidationErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if schema.Tags {
+		tags, err := TagsFromSettings(settings)
+		if err != nil {
+			add(field, "%v", err)
+			return errs
+		}
+		if len(tags) == 0 {
+			add(field, "at least one tag key/value is required")
+		}
+		for key, value := range tags {
+			switch {
+			case key == "":
+				add(field, "tag key cannot be empty")
+			case len(key) > 128:
+				add(field+"."+key, "tag key is longer than 128 characters")
+			case strings.HasPrefix(strings.ToLower(key), "aws:"):
+				add(field+"."+key, "tag keys starting with 'aws:' are reserved")
+			}
+			if len(value) > 256 {
+				add(field+"."+key, "tag value is longer than 256 characters")
+			}
+		}
+		return errs
+	}
+
+	// Report in a stable order
+	names := make([]string, 0, len(schema.Settings))
+	for name := range schema.Settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		spec := schema.Settings[name]
+		value, exists := settings[name]
+		if !exists || value == nil {
+			if spec.Required {
+				add(field+"."+name, "setting is required")
+			}
+			continue
+		}
+		if message := checkParam(spec, value); message != "" {
+			add(field+"."+name, "%s", message)
+		}
+	}
+
+	var unknown []string
+	for name := range settings {
+		if _, declared := schema.Settings[name]; !declared {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		add(field+"."+name, "unknown setting")
+	}
+
+	return errs
+}
+
+// checkParam returns a message if a setting value doesn't fit its spec
+func checkParam(spec ParamSpec, value interface{}) string {
+	switch spec.Kind {
+	case scanner.KindNumber:
+		number, ok := scanner.ToNumber(value)
+		if !ok {
+			return fmt.Sprintf("expected a number, got %v", value)
+		}
+		if spec.Max > spec.Min && (number < spec.Min || number > spec.Max) {
+			return fmt.Sprintf("must be between %g and %g, got %g", spec.Min, spec.Max, number)
+		}
+	case scanner.KindBool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Sprintf("expected true or false, got %v", value)
+		}
+	case scanner.KindString:
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Sprintf("expected a string, got %v", value)
+		}
+		if len(spec.Enum) > 0 && !containsString(spec.Enum, text) {
+			return fmt.Sprintf("must be one of %s", strings.Join(spec.Enum, ", "))
+		}
+	}
+	return ""
+}
+
+// validateMode checks the execution mode, its cron schedule, catch-up setting
+// and event names
+func validateMode(mode storage.StoredPolicyMode) ValidationErrors {
+	var errs ValidationErrors
+
+	if mode.Type != "" && !containsString(policyModes, mode.Type) {
+		errs = append(errs, ValidationError{
+			Field:   "mode.type",
+			Message: fmt.Sprintf("unknown mode '%s' (expected %s)", mode.Type, strings.Join(policyModes, ", ")),
+		})
+	}
+
+	switch {
+	case mode.Type == "periodic" && mode.Schedule == "":
+		errs = append(errs, ValidationError{Field: "mode.schedule", Message: "periodic mode needs a schedule"})
+	case mode.Schedule != "":
+		if _, err := schedule.Parse(mode.Schedule); err != nil {
+			errs = append(errs, ValidationError{Field: "mode.schedule", Message: err.Error()})
+		}
+	}
+
+	if catchUp, set := mode.Settings["catch_up"]; set && !containsString(catchUpModes, catchUp) {
+		errs = append(errs, ValidationError{
+			Field:   "mode.settings.catch_up",
+			Message: fmt.Sprintf("unknown catch-up '%s' (expected %s)", catchUp, strings.Join(catchUpModes, ", ")),
+		})
+	}
+
+	for _, name := range eventNames(mode) {
+		if !isEventName(name) {
+			errs = append(errs, ValidationError{
+				Field:   "mode.settings.events",
+				Message: fmt.Sprintf("'%s' isn't a CloudTrail event name like RunInstances", name),
+			})
+		}
+	}
+
+	return errs
+}
+
+// isEventName reports whether a name looks like a CloudTrail eventName
+func isEventName(name string) bool {
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// TagsFromSettings reads tag settings, accepting either a plain key/value map or
+// the wizard's {"key": ..., "value": ...} form
+func TagsFromSettings(settings map[string]interface{}) (map[string]string, error) {
+	tags := make(map[string]string)
+
+	if key, exists := settings["key"]; exists {
+		keyStr, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("tag key must be a string, got %v", key)
+		}
+		value := settings["value"]
+		valueStr, ok := value.(string)
+		if value != nil && !ok {
+			return nil, fmt.Errorf("tag value must be a string, got %v", value)
+		}
+		tags[keyStr] = valueStr
+		return tags, nil
+	}
+
+	for key, value := range settings {
+		valueStr, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value for tag '%s' must be a string, got %v", key, value)
+		}
+		tags[key] = valueStr
+	}
+
+	return tags, nil
+}
+
+// MarkFromSettings builds the mark-for-op tag: the operation and the day it
+// comes due, in the format the marked-for-op filter reads
+func MarkFromSettings(settings map[string]interface{}, now time.Time) (map[string]string, error) {
+	op, _ := settings["op"].(string)
+	if op == "" {
+		return nil, fmt.Errorf("mark-for-op needs an 'op' setting")
+	}
+
+	days := 4.0 // Cloud Custodian's default
+	if value, exists := settings["days"]; exists {
+		number, ok := scanner.ToNumber(value)
+		if !ok {
+			return nil, fmt.Errorf("mark-for-op days must be a number, got %v", value)
+		}
+		days = number
+	}
+
+	tag, _ := settings["tag"].(string)
+	if tag == "" {
+		tag = scanner.DefaultMarkTag
+	}
+
+	due := now.UTC().AddDate(0, 0, int(days))
+	return map[string]string{tag: scanner.FormatMark(op, due)}, nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"custodian-killer/storage"
+	"custodian-killer/templates"
+	"strings"
+	"testing"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	// Fields each built-in template is expected to have problems with
+	want := map[string][]string{
+		"unused-ec2-killer":         nil,
+		"untagged-resources-tagger": nil,
+		"public-s3-buckets-locker": {
+			"variables.action_type", // tag-only
+			"variables.action_type", // notify-only
+			"variables.notification_email",
+		},
+		"old-ebs-snapshots-cleaner": {"resource_type"},
+		"rds-backup-enforcer":       {"variables.require_multi_az"},
+	}
+
+	manager := templates.NewTemplateManager()
+	for _, template := range manager.GetAllTemplates() {
+		t.Run(template.ID, func(t *testing.T) {
+			wantFields, listed := want[template.ID]
+			if !listed {
+				t.Fatalf("no expectation for template %s", template.ID)
+			}
+
+			errs := ValidateTemplate(manager, template)
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(wantFields, ",") {
+				t.Errorf("problems = %v, want them on %v", errs, wantFields)
+			}
+
+			// Validating instantiates every option; the template keeps its placeholders
+			if again := ValidateTemplate(manager, template); len(again) != len(errs) {
+				t.Errorf("second validation found %v, first found %v", again, errs)
+			}
+		})
+	}
+}
+
+func TestValidateTemplateOptions(t *testing.T) {
+	template := templates.PolicyTemplate{
+		ID:           "custom",
+		Name:         "Custom",
+		ResourceType: "ec2",
+		Variables: []templates.TemplateVar{
+			{Name: "action", DefaultValue: "stop", Options: []string{"stop", "terminate", "delete", "reboot"}},
+			{Name: "owner", DefaultValue: "ops"},
+		},
+		Template: templates.PolicyDefinition{
+			Name:         "{{.policy_name}}",
+			ResourceType: "ec2",
+			Actions:      []templates.ActionDefinition{{Type: "{{.action}}"}},
+			Mode:         templates.PolicyModeDefinition{Type: "pull"},
+		},
+	}
+	manager := templates.NewTemplateManager()
+	if err := manager.AddCustomTemplate(template); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := ValidateTemplate(manager, template)
+	want := []string{
+		"variables.action: option 'delete' makes an invalid policy: actions[0].type: action 'delete' is not available for ec2 resources",
+		"variables.action: option 'reboot' makes an invalid policy: actions[0].type: unknown action type 'reboot'",
+		"variables.owner: the template never uses this variable",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("problems = %v, want %d", errs, len(want))
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("problem %d = %q, want %q", i, err.Error(), want[i])
+		}
+	}
+}
+
+func TestValidateStoredPolicyResourceTables(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  storage.StoredPolicy
+		wantErr string // field of the expected error, "" for none
+	}{
+		{
+			name: "listed filter and action",
+			policy: storage.StoredPolicy{
+				ResourceType: "ec2",
+				Filters:      []storage.StoredFilter{{Type: "running-days", Value: 7}},
+				Actions:      []storage.StoredAction{{Type: "stop"}},
+			},
+		},
+		{
+			name: "generic filter the resource doesn't offer",
+			policy: storage.StoredPolicy{
+				ResourceType: "lambda",
+				Filters:      []storage.StoredFilter{{Type: "marked-for-op", Value: "stop"}},
+			},
+			wantErr: "filters[0].type",
+		},
+		{
+			name: "unlisted filter inside a group",
+			policy: storage.StoredPolicy{
+				ResourceType: "iam",
+				Filters: []storage.StoredFilter{{
+					Type: "or",
+					Filters: []storage.StoredFilter{
+						{Type: "tag", Key: "Owner", Value: "me"},
+						{Type: "value", Key: "path", Value: "/"},
+					},
+				}},
+			},
+			wantErr: "filters[0].filters[1].type",
+		},
+		{
+			name: "filter for another resource type",
+			policy: storage.StoredPolicy{
+				ResourceType: "s3",
+				Filters:      []storage.StoredFilter{{Type: "running-days", Value: 7}},
+			},
+			wantErr: "filters[0].type",
+		},
+		{
+			name: "action the schema allows but the resource doesn't offer",
+			policy: storage.StoredPolicy{
+				ResourceType: "ec2",
+				Actions:      []storage.StoredAction{{Type: "modify-security-group"}},
+			},
+			wantErr: "actions[0].type",
+		},
+		{
+			name: "action the resource offers but the schema doesn't allow",
+			policy: storage.StoredPolicy{
+				ResourceType: "lambda",
+				Actions:      []storage.StoredAction{{Type: "stop"}},
+			},
+			wantErr: "actions[0].type",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.policy.Name = "test"
+			test.policy.Mode = storage.StoredPolicyMode{Type: "pull"}
+			errs := ValidateStoredPolicy(&test.policy)
+
+			if test.wantErr == "" {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != test.wantErr {
+				t.Fatalf("got %v, want one error on %s", errs, test.wantErr)
+			}
+			if !strings.Contains(errs[0].Message, "not available") && !strings.Contains(errs[0].Message, "does not apply") {
+				t.Errorf("unexpected message: %s", errs[0].Message)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -37,15 +39,33 @@ type StoredPolicy struct {
 	RunCount     int                    `json:"run_count"`
 	Source       string                 `json:"source"` // template, manual, import
 	TemplateID   string                 `json:"template_id,omitempty"`
+	Regions      []string               `json:"regions,omitempty"` // where the policy may run; empty means anywhere
+
+	// AllowUnknownFilters lets experimental filter types through strict validation
+	AllowUnknownFilters bool `json:"allow_unknown_filters,omitempty"`
+}
+
+// AllowsRegion reports whether the policy may run in region
+func (p *StoredPolicy) AllowsRegion(region string) bool {
+	if len(p.Regions) == 0 {
+		return true
+	}
+	for _, allowed := range p.Regions {
+		if allowed == region {
+			return true
+		}
+	}
+	return false
 }
 
 type StoredFilter struct {
-	Type     string      `json:"type"`
-	Key      string      `json:"key,omitempty"`
-	Value    interface{} `json:"value,omitempty"`
-	Op       string      `json:"op,omitempty"`
-	Required bool        `json:"required,omitempty"`
-	Negate   bool        `json:"negate,omitempty"`
+	Type     string         `json:"type"`
+	Key      string         `json:"key,omitempty"`
+	Value    interface{}    `json:"value,omitempty"`
+	Op       string         `json:"op,omitempty"`
+	Required bool           `json:"required,omitempty"`
+	Negate   bool           `json:"negate,omitempty"`
+	Filters  []StoredFilter `json:"filters,omitempty"` // children of and / or / not filters
 }
 
 type StoredAction struct {
@@ -63,6 +83,7 @@ type StoredPolicyMode struct {
 // FileStorage implements PolicyStorage using local filesystem
 type FileStorage struct {
 	baseDir string
+	mu      sync.Mutex // saves run one at a time, so versions are numbered in order
 }
 
 // NewFileStorage creates a new file-based storage system
@@ -95,8 +116,22 @@ func NewFileStorage(baseDir string) (*FileStorage, error) {
 	return &FileStorage{baseDir: baseDir}, nil
 }
 
-// SavePolicy saves a policy to the filesystem
+// SavePolicy saves a policy to the filesystem, keeping the format (and YAML
+// comments) of an existing policy file
 func (fs *FileStorage) SavePolicy(policy StoredPolicy) error {
+	format, original := FormatJSON, []byte(nil)
+	if path, exists := fs.policyFile(policy.Name); exists {
+		format = FormatForPath(path)
+		original, _ = os.ReadFile(path)
+	}
+	return fs.storePolicy(policy, format, original)
+}
+
+// storePolicy versions a policy and writes it in the given format
+func (fs *FileStorage) storePolicy(policy StoredPolicy, format string, original []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
 	// Set timestamps
 	if policy.CreatedAt.IsZero() {
 		policy.CreatedAt = time.Now()
@@ -123,40 +158,77 @@ func (fs *FileStorage) SavePolicy(policy StoredPolicy) error {
 		policy.Version = 1
 	}
 
-	// Convert to JSON
-	data, err := json.MarshalIndent(policy, "", "  ")
+	data, err := EncodePolicy(policy, format, original)
 	if err != nil {
 		return fmt.Errorf("failed to marshal policy: %v", err)
 	}
 
-	// Save to file
-	filename := filepath.Join(fs.baseDir, "policies", fmt.Sprintf("%s.json", policy.Name))
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	// Save to file, dropping a copy in the other format
+	previous, _ := fs.policyFile(policy.Name)
+	filename := filepath.Join(fs.baseDir, "policies", fmt.Sprintf("%s.%s", policy.Name, format))
+	if err := writeFileAtomic(filename, data); err != nil {
 		return fmt.Errorf("failed to write policy file: %v", err)
 	}
+	if previous != filename {
+		os.Remove(previous)
+	}
 
 	fmt.Printf("💾 Policy '%s' saved to: %s\n", policy.Name, filename)
 	return nil
 }
 
+// writeFileAtomic replaces a file in one step, so a policy being read while
+// another run saves it is never seen half written
+func writeFileAtomic(filename string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
 // GetPolicy retrieves a policy by name
 func (fs *FileStorage) GetPolicy(name string) (*StoredPolicy, error) {
-	filename := filepath.Join(fs.baseDir, "policies", fmt.Sprintf("%s.json", name))
+	filename, exists := fs.policyFile(name)
+	if !exists {
+		return nil, fmt.Errorf("policy '%s' not found", name)
+	}
 
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("policy '%s' not found", name)
-		}
 		return nil, fmt.Errorf("failed to read policy file: %v", err)
 	}
 
-	var policy StoredPolicy
-	if err := json.Unmarshal(data, &policy); err != nil {
+	policy, err := DecodePolicy(data, FormatForPath(filename))
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse policy file: %v", err)
 	}
 
-	return &policy, nil
+	return policy, nil
+}
+
+// policyFile finds the file a policy is stored in, whatever its format
+func (fs *FileStorage) policyFile(name string) (string, bool) {
+	for _, ext := range policyExtensions {
+		filename := filepath.Join(fs.baseDir, "policies", name+ext)
+		if _, err := os.Stat(filename); err == nil {
+			return filename, true
+		}
+	}
+	return filepath.Join(fs.baseDir, "policies", name+".json"), false
 }
 
 // ListPolicies returns all stored policies
@@ -169,9 +241,16 @@ func (fs *FileStorage) ListPolicies() ([]StoredPolicy, error) {
 	}
 
 	var policies []StoredPolicy
+	seen := make(map[string]bool)
 	for _, file := range files {
-		if filepath.Ext(file.Name()) == ".json" {
-			policyName := file.Name()[:len(file.Name())-5] // Remove .json extension
+		ext := filepath.Ext(file.Name())
+		if containsExtension(ext) {
+			policyName := strings.TrimSuffix(file.Name(), ext)
+			if seen[policyName] {
+				continue
+			}
+			seen[policyName] = true
+
 			policy, err := fs.GetPolicy(policyName)
 			if err != nil {
 				fmt.Printf("⚠️  Warning: Failed to load policy '%s': %v\n", policyName, err)
@@ -186,10 +265,9 @@ func (fs *FileStorage) ListPolicies() ([]StoredPolicy, error) {
 
 // DeletePolicy removes a policy
 func (fs *FileStorage) DeletePolicy(name string) error {
-	filename := filepath.Join(fs.baseDir, "policies", fmt.Sprintf("%s.json", name))
-
 	// Check if policy exists
-	if !fs.PolicyExists(name) {
+	filename, exists := fs.policyFile(name)
+	if !exists {
 		return fmt.Errorf("policy '%s' not found", name)
 	}
 
@@ -211,9 +289,8 @@ func (fs *FileStorage) DeletePolicy(name string) error {
 
 // PolicyExists checks if a policy exists
 func (fs *FileStorage) PolicyExists(name string) bool {
-	filename := filepath.Join(fs.baseDir, "policies", fmt.Sprintf("%s.json", name))
-	_, err := os.Stat(filename)
-	return !os.IsNotExist(err)
+	_, exists := fs.policyFile(name)
+	return exists
 }
 
 // GetPolicyHistory returns the version history of a policy
@@ -268,6 +345,11 @@ func (fs *FileStorage) saveToHistory(policy StoredPolicy) error {
 	return os.WriteFile(filename, data, 0644)
 }
 
+// BaseDir returns the directory everything is stored under
+func (fs *FileStorage) BaseDir() string {
+	return fs.baseDir
+}
+
 // GetStorageInfo returns information about the storage system
 func (fs *FileStorage) GetStorageInfo() (map[string]interface{}, error) {
 	policies, err := fs.ListPolicies()
@@ -297,14 +379,20 @@ func (fs *FileStorage) GetStorageInfo() (map[string]interface{}, error) {
 	return info, nil
 }
 
-// ExportPolicy exports a policy to a specific file
+// ExportPolicy exports a policy to a specific file, in YAML or JSON by extension
 func (fs *FileStorage) ExportPolicy(name, outputPath string) error {
 	policy, err := fs.GetPolicy(name)
 	if err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(policy, "", "  ")
+	// Carry comments over from a stored YAML policy
+	var original []byte
+	if path, _ := fs.policyFile(name); FormatForPath(path) == FormatYAML {
+		original, _ = os.ReadFile(path)
+	}
+
+	data, err := EncodePolicy(*policy, FormatForPath(outputPath), original)
 	if err != nil {
 		return fmt.Errorf("failed to marshal policy: %v", err)
 	}
@@ -317,27 +405,53 @@ func (fs *FileStorage) ExportPolicy(name, outputPath string) error {
 	return nil
 }
 
-// ImportPolicy imports a policy from a file
-func (fs *FileStorage) ImportPolicy(inputPath string) error {
+// LoadPolicyFile reads a YAML or JSON policy file without saving it
+func LoadPolicyFile(inputPath string) (*StoredPolicy, error) {
 	data, err := os.ReadFile(inputPath)
 	if err != nil {
-		return fmt.Errorf("failed to read import file: %v", err)
+		return nil, fmt.Errorf("failed to read import file: %v", err)
 	}
 
-	var policy StoredPolicy
-	if err := json.Unmarshal(data, &policy); err != nil {
-		return fmt.Errorf("failed to parse import file: %v", err)
+	policy, err := DecodePolicy(data, FormatForPath(inputPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse import file: %v", err)
+	}
+
+	return policy, nil
+}
+
+// ImportPolicy imports a policy from a file
+func (fs *FileStorage) ImportPolicy(inputPath string) error {
+	loaded, err := LoadPolicyFile(inputPath)
+	if err != nil {
+		return err
 	}
+	policy := *loaded
 
 	// Mark as imported
 	policy.Source = "import"
 	policy.CreatedAt = time.Now()
 	policy.UpdatedAt = time.Now()
 
-	if err := fs.SavePolicy(policy); err != nil {
+	// YAML policies are stored as YAML so their comments survive later edits
+	format := FormatForPath(inputPath)
+	var original []byte
+	if format == FormatYAML {
+		original, _ = os.ReadFile(inputPath)
+	}
+	if err := fs.storePolicy(policy, format, original); err != nil {
 		return fmt.Errorf("failed to save imported policy: %v", err)
 	}
 
 	fmt.Printf("📥 Policy '%s' imported from: %s\n", policy.Name, inputPath)
 	return nil
 }
+
+func containsExtension(ext string) bool {
+	for _, candidate := range policyExtensions {
+		if candidate == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy file formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// policyExtensions lists the file extensions policies are read from
+var policyExtensions = []string{".json", ".yaml", ".yml"}
+
+// FormatForPath picks the policy format from a file extension, defaulting to JSON
+func FormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatJSON
+	}
+}
+
+// EncodePolicy serializes a policy. For YAML, comments and key order from
+// original (a previous YAML version of the same policy) are carried over to
+// the keys that still exist.
+func EncodePolicy(policy StoredPolicy, format string, original []byte) ([]byte, error) {
+	if format != FormatYAML {
+		return json.MarshalIndent(policy, "", "  ")
+	}
+
+	document, err := yamlDocument(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(original) > 0 {
+		var previous yaml.Node
+		if err := yaml.Unmarshal(original, &previous); err == nil {
+			copyLayout(document, &previous)
+		}
+	}
+
+	return encodeYAML(document)
+}
+
+// DecodePolicy parses a policy in the given format
+func DecodePolicy(data []byte, format string) (*StoredPolicy, error) {
+	var policy StoredPolicy
+	var err error
+	if format == FormatYAML {
+		err = UnmarshalYAML(data, &policy)
+	} else {
+		err = json.Unmarshal(data, &policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// UnmarshalYAML decodes YAML into a value using its JSON field names
+func UnmarshalYAML(data []byte, value interface{}) error {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	// Round trip through JSON so the json struct tags apply
+	jsonData, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("unsupported YAML content: %v", err)
+	}
+	return json.Unmarshal(jsonData, value)
+}
+
+// yamlDocument builds a YAML node tree from a value's JSON form. JSON is valid
+// YAML, so parsing it keeps the struct field order.
+func yamlDocument(value interface{}) (*yaml.Node, error) {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(jsonData, &document); err != nil {
+		return nil, err
+	}
+	clearStyle(&document)
+	return &document, nil
+}
+
+// encodeYAML writes a node tree with two-space indentation
+func encodeYAML(document *yaml.Node) ([]byte, error) {
+	var builder strings.Builder
+	encoder := yaml.NewEncoder(&builder)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(builder.String()), nil
+}
+
+// clearStyle switches JSON's flow style and quoting to block style YAML
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// copyLayout copies comments from src onto the matching nodes of dst and puts
+// mapping keys in src's order, with keys src doesn't have after them. Mapping
+// entries are matched by key and sequence items by position.
+func copyLayout(dst, src *yaml.Node) {
+	if dst.HeadComment == "" {
+		dst.HeadComment = src.HeadComment
+	}
+	if dst.LineComment == "" {
+		dst.LineComment = src.LineComment
+	}
+	if dst.FootComment == "" {
+		dst.FootComment = src.FootComment
+	}
+
+	if dst.Kind != src.Kind {
+		return
+	}
+
+	switch dst.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for i := 0; i < len(dst.Content) && i < len(src.Content); i++ {
+			copyLayout(dst.Content[i], src.Content[i])
+		}
+	case yaml.MappingNode:
+		pairs := make(map[string][]*yaml.Node)
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			pairs[dst.Content[i].Value] = dst.Content[i : i+2]
+		}
+
+		ordered := make([]*yaml.Node, 0, len(dst.Content))
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			pair, exists := pairs[src.Content[i].Value]
+			if !exists {
+				continue
+			}
+			copyLayout(pair[0], src.Content[i])
+			copyLayout(pair[1], src.Content[i+1])
+			ordered = append(ordered, pair...)
+			delete(pairs, src.Content[i].Value)
+		}
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			if _, added := pairs[dst.Content[i].Value]; added {
+				ordered = append(ordered, dst.Content[i], dst.Content[i+1])
+			}
+		}
+		dst.Content = ordered
+	}
+}
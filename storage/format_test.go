@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// originalYAML is a hand-written policy, with its keys in the author's order
+const originalYAML = `# Parks dev instances overnight
+name: park-dev
+resource_type: ec2 # EC2 only for now
+description: Stop dev instances
+status: active
+version: 1
+# What to look for
+filters:
+  # Dev boxes
+  - type: tag
+    key: Env
+    value: dev
+  - type: instance-state # only running ones
+    value: running
+actions:
+  - type: tag
+    settings:
+      Parked: "yes" # so people know why
+      Owner: cleanup
+  - type: stop
+mode:
+  type: periodic
+  schedule: 0 19 * * 1-5 # weekday evenings
+`
+
+func TestYAMLRoundTrip(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(fs.BaseDir(), "policies", "park-dev.yaml")
+	if err := os.WriteFile(path, []byte(originalYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Load, edit and save, as 'policy edit' does
+	policy, err := fs.GetPolicy("park-dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy.Description = "Stop dev instances at night"
+	policy.Filters[1].Value = "pending"
+	policy.Regions = []string{"eu-west-1"}
+	if err := fs.SavePolicy(*policy); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("policy wasn't saved as YAML: %v", err)
+	}
+	saved := string(data)
+
+	for _, comment := range []string{
+		"# Parks dev instances overnight",
+		"resource_type: ec2 # EC2 only for now",
+		"# What to look for",
+		"# Dev boxes",
+		"type: instance-state # only running ones",
+		"Parked: yes # so people know why",
+		"schedule: 0 19 * * 1-5 # weekday evenings",
+	} {
+		if !strings.Contains(saved, comment) {
+			t.Errorf("saved policy lost %q:\n%s", comment, saved)
+		}
+	}
+
+	// The author's keys keep their order; new keys follow them
+	for _, order := range [][]string{
+		{"name:", "resource_type:", "description:", "status:", "version:", "filters:", "actions:", "mode:", "created_at:", "regions:"},
+		{"type: tag", "key: Env", "value: dev"},
+		{"Parked:", "Owner:"},
+		{"type: periodic", "schedule:"},
+	} {
+		if got := keyOrder(saved, order); !reflect.DeepEqual(got, order) {
+			t.Errorf("keys saved in order %q, want %q:\n%s", got, order, saved)
+		}
+	}
+
+	// Loading again gives back the edited policy
+	reloaded, err := fs.GetPolicy("park-dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Description != policy.Description || reloaded.Version != 2 ||
+		reloaded.Filters[1].Value != "pending" || !reflect.DeepEqual(reloaded.Regions, policy.Regions) ||
+		!reflect.DeepEqual(reloaded.Actions, policy.Actions) || reloaded.Mode.Schedule != policy.Mode.Schedule {
+		t.Errorf("reloaded policy = %+v, want %+v", reloaded, policy)
+	}
+
+	// A second save with no changes keeps the file as it is, apart from the bookkeeping
+	if err := fs.SavePolicy(*reloaded); err != nil {
+		t.Fatal(err)
+	}
+	again, _ := os.ReadFile(path)
+	if stripBookkeeping(string(again)) != stripBookkeeping(saved) {
+		t.Errorf("second save changed the file:\n%s\nwas:\n%s", again, saved)
+	}
+}
+
+// keyOrder returns the given lines' prefixes in the order they appear in text,
+// leaving out any that are missing
+func keyOrder(text string, keys []string) []string {
+	var found []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimLeft(strings.TrimSpace(line), "- ")
+		for _, key := range keys {
+			if strings.HasPrefix(line, key) && !contains(found, key) {
+				found = append(found, key)
+			}
+		}
+	}
+	return found
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// stripBookkeeping drops the fields every save updates
+func stripBookkeeping(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, "version:") && !strings.HasPrefix(line, "updated_at:") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestEncodePolicyFormats(t *testing.T) {
+	policy := StoredPolicy{Name: "p", ResourceType: "s3", Actions: []StoredAction{{Type: "enable-versioning"}}}
+
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		data, err := EncodePolicy(policy, format, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		decoded, err := DecodePolicy(data, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(*decoded, policy) {
+			t.Errorf("%s round trip = %+v, want %+v", format, *decoded, policy)
+		}
+	}
+
+	if got := FormatForPath("a/p.YML"); got != FormatYAML {
+		t.Errorf("FormatForPath(p.YML) = %s", got)
+	}
+	if got := FormatForPath("p.txt"); got != FormatJSON {
+		t.Errorf("FormatForPath(p.txt) = %s", got)
+	}
+}
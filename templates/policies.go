@@ -1,6 +1,7 @@
 package templates
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -46,12 +47,13 @@ type PolicyDefinition struct {
 }
 
 type FilterDefinition struct {
-	Type     string      `json:"type"`
-	Key      string      `json:"key,omitempty"`
-	Value    interface{} `json:"value,omitempty"`
-	Op       string      `json:"op,omitempty"`
-	Required bool        `json:"required,omitempty"`
-	Negate   bool        `json:"negate,omitempty"`
+	Type     string             `json:"type"`
+	Key      string             `json:"key,omitempty"`
+	Value    interface{}        `json:"value,omitempty"`
+	Op       string             `json:"op,omitempty"`
+	Required bool               `json:"required,omitempty"`
+	Negate   bool               `json:"negate,omitempty"`
+	Filters  []FilterDefinition `json:"filters,omitempty"` // children of and / or / not filters
 }
 
 type ActionDefinition struct {
@@ -96,7 +98,7 @@ var BuiltInTemplates = []PolicyTemplate{
 				Description:  "What to do with unused instances",
 				Type:         "string",
 				DefaultValue: "stop",
-				Options:      []string{"stop", "terminate", "tag-only"},
+				Options:      []string{"stop", "terminate"},
 				Required:     true,
 			},
 		},
@@ -445,29 +447,42 @@ func (tm *TemplateManager) InstantiateTemplate(
 	policy.Description = substituteVariables(policy.Description, variables)
 
 	// Substitute variables in filters
-	for i, filter := range policy.Filters {
-		if filter.Value != nil {
-			if valueStr, ok := filter.Value.(string); ok {
-				policy.Filters[i].Value = substituteVariables(valueStr, variables)
-			}
-		}
-	}
-
-	// Substitute variables in actions
-	for i, action := range policy.Actions {
-		for key, value := range action.Settings {
-			if valueStr, ok := value.(string); ok {
-				policy.Actions[i].Settings[key] = substituteVariables(valueStr, variables)
+	policy.Filters = substituteFilterVariables(policy.Filters, variables)
+
+	// Substitute variables in actions, on copies so the template keeps its placeholders
+	policy.Actions = make([]ActionDefinition, len(template.Template.Actions))
+	for i, action := range template.Template.Actions {
+		if action.Settings != nil {
+			settings := make(map[string]interface{}, len(action.Settings))
+			for key, value := range action.Settings {
+				if valueStr, ok := value.(string); ok {
+					value = substituteVariables(valueStr, variables)
+				}
+				settings[key] = value
 			}
+			action.Settings = settings
 		}
 
 		// Substitute action type
-		policy.Actions[i].Type = substituteVariables(action.Type, variables)
+		action.Type = substituteVariables(action.Type, variables)
+		policy.Actions[i] = action
 	}
 
 	return policy, nil
 }
 
+// UnusedVariables lists the template's variables that none of its fields refer to
+func (t PolicyTemplate) UnusedVariables() []string {
+	data, _ := json.Marshal(t.Template)
+	var unused []string
+	for _, variable := range t.Variables {
+		if !strings.Contains(string(data), fmt.Sprintf("{{.%s}}", variable.Name)) {
+			unused = append(unused, variable.Name)
+		}
+	}
+	return unused
+}
+
 // ValidateTemplateVariables checks if all required variables are provided
 func (tm *TemplateManager) ValidateTemplateVariables(
 	templateID string,
@@ -489,6 +504,27 @@ func (tm *TemplateManager) ValidateTemplateVariables(
 	return nil
 }
 
+// substituteFilterVariables copies a filter tree, substituting variables in string values
+func substituteFilterVariables(
+	filters []FilterDefinition,
+	variables map[string]interface{},
+) []FilterDefinition {
+	if filters == nil {
+		return nil
+	}
+
+	result := make([]FilterDefinition, len(filters))
+	for i, filter := range filters {
+		if valueStr, ok := filter.Value.(string); ok {
+			filter.Value = substituteVariables(valueStr, variables)
+		}
+		filter.Filters = substituteFilterVariables(filter.Filters, variables)
+		result[i] = filter
+	}
+
+	return result
+}
+
 // Simple variable substitution (in a real implementation, you'd use a proper template engine)
 func substituteVariables(text string, variables map[string]interface{}) string {
 	result := text
@@ -2,13 +2,19 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"custodian-killer/aws"
+	"custodian-killer/fakecloud"
+	"custodian-killer/inventory"
+	"custodian-killer/replay"
 	"custodian-killer/reports"
 	"custodian-killer/scanner"
+	"custodian-killer/schedule"
 	"custodian-killer/storage"
 	"custodian-killer/templates"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -145,6 +151,14 @@ func createPolicyFromTemplate(reader *bufio.Reader) {
 	fmt.Printf("🎯 Resource Type: %s\n", selectedTemplate.ResourceType)
 	fmt.Printf("⚠️  Impact: %s\n", selectedTemplate.Impact)
 
+	// Templates that make invalid policies are still offered, with what's wrong
+	if errs := ValidateTemplate(templateManager, selectedTemplate); len(errs) > 0 {
+		fmt.Printf("\n⚠️  This template has %d problem(s); policies made from it may not save:\n", len(errs))
+		for _, err := range errs {
+			fmt.Printf("   • %s: %s\n", err.Field, err.Message)
+		}
+	}
+
 	// Show examples
 	if len(selectedTemplate.Examples) > 0 {
 		fmt.Println("\n💡 Examples of what this template does:")
@@ -182,6 +196,9 @@ func createPolicyFromTemplate(reader *bufio.Reader) {
 			// Use default if empty and not required
 			if input == "" && !variable.Required && variable.DefaultValue != nil {
 				variables[variable.Name] = variable.DefaultValue
+			} else if input != "" && len(variable.Options) > 0 && !containsString(variable.Options, input) {
+				fmt.Printf("   ⚠️  Not one of the options, using default: %v\n", variable.DefaultValue)
+				variables[variable.Name] = variable.DefaultValue
 			} else if input != "" {
 				// Type conversion based on variable type
 				switch variable.Type {
@@ -841,6 +858,11 @@ func explainPolicyInDetail(policy Policy, aiExplanation string) {
 func createFilters(reader *bufio.Reader, resourceType string) []Filter {
 	fmt.Println("\n🔍 Let's add some filters to target the right resources...")
 
+	return chooseFilters(reader, resourceType, true)
+}
+
+// chooseFilters lets the user pick filters, optionally including OR groups
+func chooseFilters(reader *bufio.Reader, resourceType string, allowGroups bool) []Filter {
 	resourceInfo := SupportedResources[resourceType]
 	var filters []Filter
 
@@ -849,14 +871,35 @@ func createFilters(reader *bufio.Reader, resourceType string) []Filter {
 		for i, filterType := range resourceInfo.Filters {
 			fmt.Printf("%d. %s\n", i+1, filterType)
 		}
-		fmt.Printf("%d. ✅ Done adding filters\n", len(resourceInfo.Filters)+1)
+		groupChoice := 0
+		if allowGroups {
+			groupChoice = len(resourceInfo.Filters) + 1
+			fmt.Printf("%d. 🔀 OR group (match ANY of several filters)\n", groupChoice)
+		}
+		doneChoice := len(resourceInfo.Filters) + 1
+		if allowGroups {
+			doneChoice++
+		}
+		fmt.Printf("%d. ✅ Done adding filters\n", doneChoice)
 
-		choice := getChoice(reader, 1, len(resourceInfo.Filters)+1, "Choose filter to add: ")
+		choice := getChoice(reader, 1, doneChoice, "Choose filter to add: ")
 
-		if choice == len(resourceInfo.Filters)+1 {
+		if choice == doneChoice {
 			break
 		}
 
+		if choice == groupChoice {
+			fmt.Println("\n🔀 Add the filters for this group - a resource matching ANY of them passes")
+			children := chooseFilters(reader, resourceType, false)
+			if len(children) == 0 {
+				fmt.Println("⚠️  Empty group skipped")
+				continue
+			}
+			filters = append(filters, Filter{Type: "or", Filters: children})
+			fmt.Printf("✅ Added OR group with %d filters\n", len(children))
+			continue
+		}
+
 		filterType := resourceInfo.Filters[choice-1]
 		filter := createSingleFilter(reader, filterType)
 		filters = append(filters, filter)
@@ -884,6 +927,25 @@ func createSingleFilter(reader *bufio.Reader, filterType string) Filter {
 		fmt.Println("Common states: running, stopped, terminated, pending")
 		filter.Value = getInput(reader, "State: ")
 		filter.Op = "eq"
+	case "value":
+		fmt.Println("Path examples: encryption.algorithm, tags.Owner, security_groups[0]")
+		filter.Key = getInput(reader, "Path: ")
+		fmt.Println("Operators: eq, ne, in, not-in, gt, lt, gte, lte, contains, exists, missing")
+		filter.Op = getInput(reader, "Operator (default eq): ")
+		if filter.Op == "" {
+			filter.Op = "eq"
+		}
+		if filter.Op != "exists" && filter.Op != "missing" {
+			filter.Value = getInput(reader, "Value: ")
+		}
+	case "marked-for-op":
+		filter.Key = getInput(reader, fmt.Sprintf("Mark tag (default %s): ", scanner.DefaultMarkTag))
+		filter.Value = getInput(reader, "Marked operation, e.g. stop (or leave empty for any): ")
+		if filter.Value == "" {
+			filter.Op = "exists"
+		} else {
+			filter.Op = "eq"
+		}
 	case "creation-date", "launch-time":
 		fmt.Println("Examples: '30 days ago', '2024-01-01', 'last week'")
 		filter.Value = getInput(reader, "Date/time: ")
@@ -951,6 +1013,12 @@ func createSingleAction(reader *bufio.Reader, actionType string) Action {
 		tagValue := getInput(reader, "Tag value: ")
 		action.Settings["key"] = tagKey
 		action.Settings["value"] = tagValue
+	case "mark-for-op":
+		action.Settings["op"] = getInput(reader, "Operation to schedule (e.g. stop, terminate, delete): ")
+		days := getInput(reader, "Days until it's due (default 4): ")
+		if parsed, err := strconv.Atoi(days); err == nil {
+			action.Settings["days"] = parsed
+		}
 	case "stop":
 		fmt.Println("Should instances be force-stopped if graceful stop fails?")
 		force := getChoice(reader, 1, 2, "1. Graceful only  2. Force if needed: ") == 2
@@ -974,13 +1042,49 @@ func createPolicyMode(reader *bufio.Reader) PolicyMode {
 		mode.Type = "pull"
 	case 2:
 		mode.Type = "periodic"
-		mode.Schedule = getInput(
-			reader,
-			"Schedule (cron format, e.g., '0 2 * * *' for daily at 2am): ",
-		)
+		for {
+			mode.Schedule = getInput(
+				reader,
+				"Schedule (cron format, e.g., '0 2 * * *' for daily at 2am): ",
+			)
+			if _, err := schedule.Parse(mode.Schedule); err != nil {
+				fmt.Printf("❌ Invalid schedule: %v\n", err)
+				continue
+			}
+			break
+		}
+
+		fmt.Println("\nIf the daemon was down when runs came due:")
+		fmt.Println("1. ⏭️  Skip them")
+		fmt.Println("2. ▶️  Run once to catch up")
+		fmt.Println("3. ⏪ Run every missed run")
+		catchUp := catchUpModes[getChoice(reader, 1, 3, "Choose catch-up behavior: ")-1]
+		mode.Settings = map[string]string{"catch_up": catchUp}
 	case 3:
 		mode.Type = "event"
-		// TODO: Add event configuration
+		fmt.Println("\nWhich CloudTrail events should run this policy?")
+		fmt.Println("e.g. RunInstances, CreateTags for EC2 or CreateBucket, PutBucketAcl for S3")
+	events:
+		for {
+			var names []string
+			for _, name := range strings.Split(getInput(reader, "Event names (comma-separated): "), ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					names = append(names, name)
+				}
+			}
+			if len(names) == 0 {
+				fmt.Println("❌ Name at least one event")
+				continue
+			}
+			for _, name := range names {
+				if !isEventName(name) {
+					fmt.Printf("❌ '%s' isn't a CloudTrail event name like RunInstances\n", name)
+					continue events
+				}
+			}
+			mode.Settings = map[string]string{"events": strings.Join(names, ",")}
+			break
+		}
 	}
 
 	return mode
@@ -988,7 +1092,17 @@ func createPolicyMode(reader *bufio.Reader) PolicyMode {
 
 // Initialize AWS client with configuration
 func initializeAWSClient(forceDryRun bool) (*aws.CustodianClient, error) {
-	fmt.Println("🔧 Initializing AWS connection...")
+	return newAWSClient(forceDryRun, nil)
+}
+
+// newAWSClient creates an AWS client, assuming the account's role when an
+// account is given. Recordings and fake clouds are kept per account.
+func newAWSClient(forceDryRun bool, account *Account) (*aws.CustodianClient, error) {
+	if account != nil {
+		fmt.Printf("🔧 Initializing AWS connection to %s...\n", account)
+	} else {
+		fmt.Println("🔧 Initializing AWS connection...")
+	}
 
 	// Get AWS configuration from environment or use defaults
 	config := aws.ClientConfig{
@@ -1009,6 +1123,63 @@ func initializeAWSClient(forceDryRun bool) (*aws.CustodianClient, error) {
 		config.Profile = "default"
 	}
 
+	if recordDir != "" && replayDir != "" {
+		return nil, fmt.Errorf("--record and --replay can't be used together")
+	}
+
+	rates, err := aws.ParseRates(apiRates)
+	if err != nil {
+		return nil, err
+	}
+	config.Retry = aws.RetryConfig{MaxAttempts: maxAttempts, Rates: rates}
+
+	replaying, recording := replayDir, recordDir
+	seedFile := os.Getenv("CUSTODIAN_FAKE_CLOUD")
+	if account != nil {
+		config.AssumeRoleARN = account.RoleARN
+		config.ExternalID = account.ExternalID
+		if replaying != "" {
+			replaying = filepath.Join(replaying, account.ID)
+		}
+		if recording != "" {
+			recording = filepath.Join(recording, account.ID)
+		}
+		if seedFile != "" {
+			seedFile = filepath.Join(filepath.Dir(seedFile), account.ID+".json")
+		}
+	}
+
+	// Replaying needs no credentials or network
+	if replaying != "" {
+		replayer, err := replay.Load(replaying)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("📼 Replaying AWS responses from %s\n", replaying)
+		config.Retry.Disabled = true // recorded responses already went through retries
+		return aws.NewCustodianClientWithAPIs(replayer.APIs, config)
+	}
+
+	if recording != "" {
+		recorder, err := replay.NewRecorder(recording)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("🔴 Recording AWS traffic to %s\n", recording)
+		config.WrapAPIs = recorder.Wrap
+	}
+
+	// CUSTODIAN_FAKE_CLOUD points at a seed file to run against an in-memory
+	// cloud; each account reads <account id>.json next to it
+	if seedFile != "" {
+		cloud, err := fakecloud.Load(seedFile)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("🧸 Using fake cloud from %s\n", seedFile)
+		return aws.NewCustodianClientWithAPIs(cloud.APIs, config)
+	}
+
 	return aws.NewCustodianClient(config)
 }
 
@@ -1023,6 +1194,9 @@ func displayExecutionResult(result *ExecutionResult) {
 	} else {
 		fmt.Println("💥 LIVE MODE - Changes were applied")
 	}
+	if result.Stopped != "" {
+		fmt.Printf("⏹️  Stopped early (%s): later actions never ran\n", result.Stopped)
+	}
 
 	// Summary
 	fmt.Println("\n📈 Summary:")
@@ -1030,6 +1204,9 @@ func displayExecutionResult(result *ExecutionResult) {
 	fmt.Printf("   • Resources Matched: %d\n", result.ResourcesMatched)
 	fmt.Printf("   • Actions Executed: %d\n", result.Summary.TotalActions)
 	fmt.Printf("   • Successful Actions: %d\n", result.Summary.SuccessfulActions)
+	if len(result.APICalls) > 0 {
+		fmt.Printf("   • 📡 AWS Calls: %s\n", describeAPICalls(result.APICalls))
+	}
 
 	if result.Summary.FailedActions > 0 {
 		fmt.Printf("   • ❌ Failed Actions: %d\n", result.Summary.FailedActions)
@@ -1137,6 +1314,12 @@ func displayExecutionResult(result *ExecutionResult) {
 }
 
 func runScan() {
+	runScanFrom(context.Background(), "")
+}
+
+// runScanFrom runs the interactive scan against AWS, or against an inventory
+// snapshot when snapshotFile is set
+func runScanFrom(ctx context.Context, snapshotFile string) {
 	if policyStorage == nil {
 		fmt.Println("❌ Storage not initialized!")
 		return
@@ -1145,16 +1328,28 @@ func runScan() {
 	fmt.Println("🔍 Policy Scanner - See what your policies would do!")
 	fmt.Println("═══════════════════════════════════════════════════")
 
+	provider, closeProvider, err := newScanProvider(snapshotFile)
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize resource source: %v\n", err)
+		if snapshotFile == "" {
+			fmt.Println("💡 Make sure your AWS credentials are configured!")
+		}
+		return
+	}
+	defer closeProvider()
+
+	if snapshot, ok := provider.(*inventory.Snapshot); ok {
+		printSnapshotSource(snapshot)
+	}
+
 	// Initialize scanner
-	scannerConfig := scanner.ScannerConfig{
-		AWSRegion:     os.Getenv("AWS_REGION"),
+	policyScanner := scanner.NewPolicyScanner(policyStorage, provider, scanner.ScannerConfig{
+		AWSRegion:     providerRegion(provider),
 		AWSProfile:    os.Getenv("AWS_PROFILE"),
 		DryRunDefault: true,
 		MaxResources:  1000,
 		Timeout:       300,
-	}
-
-	policyScanner := scanner.NewPolicyScanner(policyStorage, scannerConfig)
+	})
 
 	// List available policies
 	policies, err := policyStorage.ListPolicies()
@@ -1196,7 +1391,7 @@ func runScan() {
 		fmt.Println("\n🚀 Scanning ALL active policies...")
 		fmt.Println("═══════════════════════════════════════")
 
-		results, err := policyScanner.ScanAllPolicies()
+		results, err := policyScanner.ScanAllPolicies(ctx)
 		if err != nil {
 			fmt.Printf("❌ Failed to scan policies: %v\n", err)
 			return
@@ -1211,7 +1406,7 @@ func runScan() {
 		fmt.Printf("\n🎯 Scanning policy: %s\n", selectedPolicy.Name)
 		fmt.Println("═══════════════════════════════════════")
 
-		result, err := policyScanner.ScanPolicy(selectedPolicy.Name)
+		result, err := policyScanner.ScanPolicy(ctx, selectedPolicy.Name)
 		if err != nil {
 			fmt.Printf("❌ Failed to scan policy: %v\n", err)
 			return
@@ -1226,9 +1421,56 @@ func runScan() {
 	fmt.Println("   • Modify policies if needed")
 }
 
+// newScanProvider returns where scans read resources from: an inventory
+// snapshot when snapshotFile is set ("latest" picks the newest), otherwise AWS.
+// The returned func releases it.
+func newScanProvider(snapshotFile string) (scanner.ResourceProvider, func(), error) {
+	if snapshotFile != "" {
+		dir, err := inventory.DefaultDir()
+		if err != nil {
+			return nil, nil, err
+		}
+		path, err := inventory.Resolve(snapshotFile, dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		snapshot, err := inventory.Load(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return snapshot, func() {}, nil
+	}
+
+	// Scans never modify anything, so the client is always in dry run mode
+	awsClient, err := initializeAWSClient(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return scanner.NewAWSResourceProvider(awsClient), func() { awsClient.Close() }, nil
+}
+
+// providerRegion is the region a scan provider reads resources from
+func providerRegion(provider scanner.ResourceProvider) string {
+	switch p := provider.(type) {
+	case *inventory.Snapshot:
+		return p.Region
+	case *scanner.AWSResourceProvider:
+		return p.Region()
+	}
+	return ""
+}
+
+func printSnapshotSource(snapshot *inventory.Snapshot) {
+	fmt.Printf("📸 Scanning snapshot from %s (%s, %d resources) - AWS won't be called\n",
+		snapshot.CollectedAt.Local().Format("2006-01-02 15:04:05"), snapshot.Region, snapshot.Count())
+}
+
 func displayScanResult(result *scanner.ScanResult) {
 	fmt.Printf("\n📊 Scan Results for: %s\n", result.PolicyName)
 	fmt.Printf("🎯 Resource Type: %s\n", strings.ToUpper(result.ResourceType))
+	if result.Region != "" {
+		fmt.Printf("🌍 Region: %s\n", result.Region)
+	}
 	fmt.Printf("⏰ Scan Time: %s\n", result.ScanTime.Format("2006-01-02 15:04:05"))
 
 	if result.DryRun {
@@ -1249,6 +1491,10 @@ func displayScanResult(result *scanner.ScanResult) {
 		fmt.Printf("   • 💰 Estimated Savings: $%.2f/month\n", result.Summary.CostSavings)
 	}
 
+	if len(result.APICalls) > 0 {
+		fmt.Printf("   • 📡 AWS Calls: %s\n", describeAPICalls(result.APICalls))
+	}
+
 	// Show matched resources
 	if len(result.MatchedResources) > 0 {
 		fmt.Println("\n🎯 Matched Resources:")
@@ -1308,7 +1554,7 @@ func displayScanResult(result *scanner.ScanResult) {
 	}
 }
 
-func executePolicy() {
+func executePolicy(ctx context.Context) {
 	if policyStorage == nil {
 		fmt.Println("❌ Storage not initialized!")
 		return
@@ -1428,7 +1674,7 @@ func executePolicy() {
 	fmt.Printf("\n🚀 Executing policy: %s\n", selectedPolicy.Name)
 	fmt.Println("═══════════════════════════════════")
 
-	result, err := executor.ExecutePolicy(selectedPolicy.Name)
+	result, err := executor.ExecutePolicy(ctx, selectedPolicy.Name)
 	if err != nil {
 		fmt.Printf("❌ Failed to execute policy: %v\n", err)
 		return
@@ -1464,8 +1710,8 @@ func generateReport() {
 
 	// Get resources
 	fmt.Println("\n🔍 Gathering AWS resource data...")
-	ec2Instances, _ := awsClient.GetEC2Instances(aws.EC2Filter{})
-	s3Buckets, _ := awsClient.GetS3Buckets(aws.S3Filter{})
+	ec2Instances, _ := awsClient.GetEC2Instances(context.Background(), aws.EC2Filter{})
+	s3Buckets, _ := awsClient.GetS3Buckets(context.Background(), aws.S3Filter{})
 
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
 
@@ -1700,9 +1946,7 @@ func showPolicySummary(policy Policy) {
 	fmt.Printf("Resource Type: %s\n", strings.ToUpper(policy.ResourceType))
 
 	fmt.Println("\nFilters:")
-	for _, filter := range policy.Filters {
-		fmt.Printf("  • %s %s %v\n", filter.Type, filter.Op, filter.Value)
-	}
+	printFilterTree(policy.Filters, "  ")
 
 	fmt.Println("\nActions:")
 	for _, action := range policy.Actions {
@@ -1725,7 +1969,27 @@ func savePolicy(policy Policy) {
 		return
 	}
 
-	// Convert to StoredPolicy
+	storedPolicy := convertPolicyToStored(policy)
+
+	// Refuse to save policies that would fail at execution time
+	if errs := ValidateStoredPolicy(&storedPolicy); len(errs) > 0 {
+		printValidationErrors(errs)
+		fmt.Println("❌ Policy not saved - fix the problems above and try again")
+		return
+	}
+
+	// Save to storage
+	if err := policyStorage.SavePolicy(storedPolicy); err != nil {
+		fmt.Printf("❌ Failed to save policy: %v\n", err)
+		return
+	}
+
+	fmt.Printf("✅ Policy '%s' saved successfully!\n", policy.Name)
+	fmt.Printf("📁 You can find it in your policies directory\n")
+}
+
+// convertPolicyToStored converts a wizard policy to its storage form
+func convertPolicyToStored(policy Policy) storage.StoredPolicy {
 	storedPolicy := storage.StoredPolicy{
 		Name:         policy.Name,
 		Description:  policy.Description,
@@ -1738,20 +2002,12 @@ func savePolicy(policy Policy) {
 		Version:      1,
 		Status:       "active",
 		Source:       "wizard",
+
+		AllowUnknownFilters: policy.AllowUnknownFilters,
 	}
 
 	// Convert filters
-	for _, filter := range policy.Filters {
-		storedFilter := storage.StoredFilter{
-			Type:     filter.Type,
-			Key:      filter.Key,
-			Value:    filter.Value,
-			Op:       filter.Op,
-			Required: filter.Required,
-			Negate:   filter.Negate,
-		}
-		storedPolicy.Filters = append(storedPolicy.Filters, storedFilter)
-	}
+	storedPolicy.Filters = convertFiltersToStored(policy.Filters)
 
 	// Convert actions
 	for _, action := range policy.Actions {
@@ -1770,14 +2026,66 @@ func savePolicy(policy Policy) {
 		Settings: policy.Mode.Settings,
 	}
 
-	// Save to storage
-	if err := policyStorage.SavePolicy(storedPolicy); err != nil {
-		fmt.Printf("❌ Failed to save policy: %v\n", err)
-		return
+	return storedPolicy
+}
+
+// printValidationErrors lists validation errors with the field they point at
+func printValidationErrors(errs ValidationErrors) {
+	fmt.Printf("❌ Policy validation failed with %d error(s):\n", len(errs))
+	for _, err := range errs {
+		fmt.Printf("   • %s: %s\n", err.Field, err.Message)
 	}
+}
 
-	fmt.Printf("✅ Policy '%s' saved successfully!\n", policy.Name)
-	fmt.Printf("📁 You can find it in your policies directory\n")
+// convertFiltersToStored converts a filter tree to its storage form
+func convertFiltersToStored(filters []Filter) []storage.StoredFilter {
+	var storedFilters []storage.StoredFilter
+	for _, filter := range filters {
+		storedFilters = append(storedFilters, storage.StoredFilter{
+			Type:     filter.Type,
+			Key:      filter.Key,
+			Value:    filter.Value,
+			Op:       filter.Op,
+			Required: filter.Required,
+			Negate:   filter.Negate,
+			Filters:  convertFiltersToStored(filter.Filters),
+		})
+	}
+	return storedFilters
+}
+
+// convertTemplateFilters appends a template filter tree to filters
+func convertTemplateFilters(filters []Filter, filterDefs []templates.FilterDefinition) []Filter {
+	for _, filterDef := range filterDefs {
+		filters = append(filters, Filter{
+			Type:     filterDef.Type,
+			Key:      filterDef.Key,
+			Value:    filterDef.Value,
+			Op:       filterDef.Op,
+			Required: filterDef.Required,
+			Negate:   filterDef.Negate,
+			Filters:  convertTemplateFilters(nil, filterDef.Filters),
+		})
+	}
+	return filters
+}
+
+// printFilterTree prints filters, indenting the children of and / or / not groups
+func printFilterTree(filters []Filter, indent string) {
+	for _, filter := range filters {
+		negate := ""
+		if filter.Negate {
+			negate = "NOT "
+		}
+
+		switch filter.Type {
+		case "and", "or", "not":
+			fmt.Printf("%s• %s%s:\n", indent, negate, strings.ToUpper(filter.Type))
+			printFilterTree(filter.Filters, indent+"    ")
+		default:
+			fmt.Printf("%s• %s%s %s %v\n", indent, negate, filter.Type, filter.Op, filter.Value)
+		}
+	}
 }
 
 // Convert template policy definition to main Policy struct
@@ -1790,17 +2098,7 @@ func convertTemplatePolicyToPolicy(policyDef templates.PolicyDefinition) Policy
 	policy.Metadata = policyDef.Metadata
 
 	// Convert filters
-	for _, filterDef := range policyDef.Filters {
-		filter := Filter{
-			Type:     filterDef.Type,
-			Key:      filterDef.Key,
-			Value:    filterDef.Value,
-			Op:       filterDef.Op,
-			Required: filterDef.Required,
-			Negate:   filterDef.Negate,
-		}
-		policy.Filters = append(policy.Filters, filter)
-	}
+	policy.Filters = convertTemplateFilters(policy.Filters, policyDef.Filters)
 
 	// Convert actions
 	for _, actionDef := range policyDef.Actions {